@@ -0,0 +1,157 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const (
+	// MaxBatchEvents is the most events a single POST /events:batch creates.
+	MaxBatchEvents = 1000
+
+	// BatchAllOrNothing creates the events only if every one of them is valid and saved.
+	BatchAllOrNothing = "all_or_nothing"
+	// BatchBestEffort creates the valid events and reports the others.
+	BatchBestEffort = "best_effort"
+)
+
+var errBatchAborted = errors.New("not created, another event in the batch failed")
+
+// BatchResult is the outcome of one event of a batch, identified by its position in the request.
+type BatchResult struct {
+	Index  int    `json:"index"`
+	Status int    `json:"status"`
+	Event  *Event `json:"event,omitempty"`
+	Error  *Error `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Mode    string        `json:"mode"`
+	Created int           `json:"created"`
+	Failed  int           `json:"failed"`
+	Results []BatchResult `json:"results"`
+}
+
+func ParseBatchMode(value string) (string, error) {
+	switch value {
+	case "", BatchAllOrNothing:
+		return BatchAllOrNothing, nil
+	case BatchBestEffort:
+		return BatchBestEffort, nil
+	default:
+		return "", fmt.Errorf("mode must be %s or %s", BatchAllOrNothing, BatchBestEffort)
+	}
+}
+
+// CreateEvents decodes and validates each item, then saves the valid events in one statement. In best-effort mode a
+// failed statement is retried one event at a time, so a single row rejected by the database does not fail the rest.
+func CreateEvents(ctx context.Context, repository RepositoryInterface, items []json.RawMessage, mode string) BatchResponse {
+	response := BatchResponse{Mode: mode, Results: make([]BatchResult, len(items))}
+
+	var events []Event
+	var indexes []int
+
+	// Batches usually target a single calendar, look each one up once
+	type lookup struct {
+		calendar *Calendar
+		err      error
+	}
+
+	calendars := make(map[string]lookup)
+	getCalendar := func(ctx context.Context, id string) (*Calendar, error) {
+		l, ok := calendars[id]
+		if !ok {
+			l.calendar, l.err = repository.GetCalendar(ctx, id)
+			calendars[id] = l
+		}
+
+		return l.calendar, l.err
+	}
+
+	for i, item := range items {
+		response.Results[i] = BatchResult{Index: i}
+
+		var event Event
+
+		err := json.Unmarshal(item, &event)
+		if err != nil {
+			response.Results[i].Status, response.Results[i].Error = http.StatusBadRequest, NewError("failed to bind JSON", err)
+			continue
+		}
+
+		err = applyCalendar(ctx, getCalendar, &event)
+		if errors.Is(err, ErrCalendarNotFound) {
+			response.Results[i].Status, response.Results[i].Error = http.StatusNotFound, NewError("calendar not found", err)
+			continue
+		}
+
+		if err != nil {
+			response.Results[i].Status, response.Results[i].Error = http.StatusInternalServerError, NewError("getting calendar failed", err)
+			continue
+		}
+
+		err = ValidateEvent(event)
+		if err != nil {
+			response.Results[i].Status, response.Results[i].Error = http.StatusBadRequest, NewError("event validation failed", err)
+			continue
+		}
+
+		events = append(events, event)
+		indexes = append(indexes, i)
+	}
+
+	if mode == BatchAllOrNothing && len(events) < len(items) {
+		for _, i := range indexes {
+			response.Results[i].Status, response.Results[i].Error = http.StatusFailedDependency, NewError("event not created", errBatchAborted)
+		}
+
+		return response.count()
+	}
+
+	saved, err := repository.SaveEvents(ctx, events)
+
+	for j, i := range indexes {
+		switch {
+		case err == nil:
+			response.Results[i].Status, response.Results[i].Event = http.StatusCreated, &saved[j]
+		case mode == BatchAllOrNothing:
+			response.Results[i].Status, response.Results[i].Error = saveErrorStatus(err), NewError("saving events failed", err)
+		default:
+			savedEvent, err := repository.SaveEvent(ctx, &events[j])
+			if err != nil {
+				response.Results[i].Status, response.Results[i].Error = saveErrorStatus(err), NewError("saving event failed", err)
+				continue
+			}
+
+			response.Results[i].Status, response.Results[i].Event = http.StatusCreated, savedEvent
+		}
+	}
+
+	return response.count()
+}
+
+func saveErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrEventConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrCalendarNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (r BatchResponse) count() BatchResponse {
+	for _, result := range r.Results {
+		if result.Status == http.StatusCreated {
+			r.Created++
+		} else {
+			r.Failed++
+		}
+	}
+
+	return r
+}
@@ -0,0 +1,150 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBatchMode(t *testing.T) {
+	t.Parallel()
+
+	for value, want := range map[string]string{"": BatchAllOrNothing, "all_or_nothing": BatchAllOrNothing, "best_effort": BatchBestEffort} {
+		got, err := ParseBatchMode(value)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := ParseBatchMode("yolo")
+	require.Error(t, err)
+}
+
+func TestCreateEvents(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	first := Event{Title: "Kickoff", StartTime: start, EndTime: start.Add(time.Hour)}
+	second := Event{Title: "Review", StartTime: start.Add(2 * time.Hour), EndTime: start.Add(3 * time.Hour)}
+
+	items := func(values ...any) []json.RawMessage {
+		var raw []json.RawMessage
+		for _, v := range values {
+			data, err := json.Marshal(v)
+			require.NoError(t, err)
+
+			raw = append(raw, data)
+		}
+
+		return raw
+	}
+
+	tests := []struct {
+		name         string
+		items        []json.RawMessage
+		mode         string
+		mockSetup    func(m *MockRepository)
+		wantStatuses []int
+	}{
+		{
+			name:  "all created",
+			items: items(first, second),
+			mode:  BatchAllOrNothing,
+			mockSetup: func(m *MockRepository) {
+				m.On("SaveEvents", mock.Anything, []Event{first, second}).
+					Return([]Event{{Id: "1", Title: "Kickoff"}, {Id: "2", Title: "Review"}}, nil)
+			},
+			wantStatuses: []int{http.StatusCreated, http.StatusCreated},
+		},
+		{
+			name:         "all or nothing with an invalid event",
+			items:        items(first, Event{Title: "", StartTime: start, EndTime: start}, "not an event"),
+			mode:         BatchAllOrNothing,
+			wantStatuses: []int{http.StatusFailedDependency, http.StatusBadRequest, http.StatusBadRequest},
+		},
+		{
+			name:  "best effort with an invalid event",
+			items: items(first, Event{Title: "Backwards", StartTime: start, EndTime: start.Add(-time.Hour)}, second),
+			mode:  BatchBestEffort,
+			mockSetup: func(m *MockRepository) {
+				m.On("SaveEvents", mock.Anything, []Event{first, second}).
+					Return([]Event{{Id: "1"}, {Id: "2"}}, nil)
+			},
+			wantStatuses: []int{http.StatusCreated, http.StatusBadRequest, http.StatusCreated},
+		},
+		{
+			name:  "all or nothing with a database error",
+			items: items(first, second),
+			mode:  BatchAllOrNothing,
+			mockSetup: func(m *MockRepository) {
+				m.On("SaveEvents", mock.Anything, []Event{first, second}).Return(nil, errors.New("db error"))
+			},
+			wantStatuses: []int{http.StatusInternalServerError, http.StatusInternalServerError},
+		},
+		{
+			name:  "best effort retries one by one",
+			items: items(first, second),
+			mode:  BatchBestEffort,
+			mockSetup: func(m *MockRepository) {
+				m.On("SaveEvents", mock.Anything, []Event{first, second}).Return(nil, errors.New("db error"))
+				m.On("SaveEvent", mock.Anything, &first).Return(&Event{Id: "1"}, nil)
+				m.On("SaveEvent", mock.Anything, &second).Return(nil, errors.New("check constraint"))
+			},
+			wantStatuses: []int{http.StatusCreated, http.StatusInternalServerError},
+		},
+		{
+			name:  "best effort reports conflicts",
+			items: items(first, second),
+			mode:  BatchBestEffort,
+			mockSetup: func(m *MockRepository) {
+				m.On("SaveEvents", mock.Anything, []Event{first, second}).Return(nil, ErrEventConflict)
+				m.On("SaveEvent", mock.Anything, &first).Return(&Event{Id: "1"}, nil)
+				m.On("SaveEvent", mock.Anything, &second).Return(nil, &ConflictError{EventIds: []string{"1"}})
+			},
+			wantStatuses: []int{http.StatusCreated, http.StatusConflict},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := new(MockRepository)
+			if tt.mockSetup != nil {
+				tt.mockSetup(repo)
+			}
+
+			got := CreateEvents(context.Background(), repo, tt.items, tt.mode)
+
+			require.Len(t, got.Results, len(tt.wantStatuses))
+
+			created := 0
+			for i, result := range got.Results {
+				assert.Equal(t, i, result.Index)
+				assert.Equal(t, tt.wantStatuses[i], result.Status, "item %d", i)
+
+				if result.Status == http.StatusCreated {
+					created++
+
+					assert.NotNil(t, result.Event)
+					assert.Nil(t, result.Error)
+				} else {
+					assert.Nil(t, result.Event)
+					assert.NotNil(t, result.Error)
+				}
+			}
+
+			assert.Equal(t, tt.mode, got.Mode)
+			assert.Equal(t, created, got.Created)
+			assert.Equal(t, len(tt.wantStatuses)-created, got.Failed)
+
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// claimLoop dispatches work that replicas share through the database, such as reminders and webhook deliveries.
+// Dispatchers claim due items for a lease, during which other replicas leave them alone; the lease must outlast
+// dispatching a whole batch, or another replica could dispatch the same items again.
+type claimLoop[T any] struct {
+	// component, item and items name the dispatcher and what it claims in its logs
+	component string
+	item      string
+	items     string
+	batch     int
+	lease     time.Duration
+	// claim returns up to limit items due at now, leased until leaseEnd
+	claim func(ctx context.Context, now time.Time, leaseEnd time.Time, limit int) ([]T, error)
+	// dispatch handles a claimed item and returns how many things it got done, its failure is left for dispatch to
+	// schedule a retry of
+	dispatch func(ctx context.Context, item T, now time.Time) (int, error)
+	id       func(item T) string
+}
+
+// run claims and dispatches the due items, a batch at a time until none is left, and returns the sum of what
+// dispatch got done. An item that fails is logged without holding up the others.
+func (l claimLoop[T]) run(ctx context.Context) (int, error) {
+	done := 0
+
+	for ctx.Err() == nil {
+		now := time.Now()
+
+		items, err := l.claim(ctx, now, now.Add(l.lease), l.batch)
+		if err != nil {
+			log.Error().Str("component", l.component).Err(err).Msg("failed to claim " + l.items)
+			return done, err
+		}
+
+		for _, item := range items {
+			n, err := l.dispatch(ctx, item, now)
+			done += n
+
+			if err != nil {
+				log.Error().Str("component", l.component).Str(l.item, l.id(item)).Err(err).Msg("failed to dispatch " + l.item)
+			}
+		}
+
+		if len(items) < l.batch {
+			break
+		}
+	}
+
+	log.Info().Str("component", l.component).Int("dispatched", done).Msg(l.items + " dispatched")
+
+	return done, ctx.Err()
+}
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// LoaderWait is how long a loader waits for another load to batch with the previous ones.
+	LoaderWait = 2 * time.Millisecond
+	// LoaderBatch is the most keys a loader fetches at once.
+	LoaderBatch = MaxPageLimit
+)
+
+// Loader batches the loads made at about the same time into a single fetch, so that resolving a field of every event
+// of a page queries the database once rather than once per event. Values are not cached past their batch: a
+// subscription loading them again gets them as they are then.
+type Loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+	wait  time.Duration
+	mu    sync.Mutex
+	batch *loaderBatch[K, V]
+}
+
+type loaderBatch[K comparable, V any] struct {
+	keys   []K
+	timer  *time.Timer
+	done   chan struct{}
+	once   sync.Once
+	values map[K]V
+	err    error
+}
+
+// NewLoader fetches the values of the keys loaded together, those missing from what fetch returns load as the zero
+// value.
+func NewLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error), wait time.Duration) *Loader[K, V] {
+	return &Loader[K, V]{fetch: fetch, wait: wait}
+}
+
+// Load returns the value of the key once its batch is fetched.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+
+	b := l.batch
+	if b == nil {
+		b = &loaderBatch[K, V]{done: make(chan struct{})}
+		l.batch = b
+
+		b.timer = time.AfterFunc(l.wait, func() { l.dispatch(ctx, b) })
+	}
+
+	// Keys are fetched once per batch however many times they are loaded, every new one waits for the next
+	if !slices.Contains(b.keys, key) {
+		b.keys = append(b.keys, key)
+		b.timer.Reset(l.wait)
+	}
+
+	full := len(b.keys) >= LoaderBatch
+
+	l.mu.Unlock()
+
+	if full {
+		l.dispatch(ctx, b)
+	}
+
+	select {
+	case <-b.done:
+		return b.values[key], b.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// dispatch fetches the batch, once it is either full or no new key was loaded for a while.
+func (l *Loader[K, V]) dispatch(ctx context.Context, b *loaderBatch[K, V]) {
+	b.once.Do(func() {
+		l.mu.Lock()
+		if l.batch == b {
+			l.batch = nil
+		}
+		l.mu.Unlock()
+
+		b.values, b.err = l.fetch(ctx, b.keys)
+		close(b.done)
+	})
+}
+
+// Loaders are the loaders of the data related to events, for the lifetime of a GraphQL request.
+type Loaders struct {
+	Calendars *Loader[string, *Calendar]
+	Attendees *Loader[string, []Attendee]
+}
+
+func NewLoaders(repository RepositoryInterface) *Loaders {
+	return &Loaders{
+		Calendars: NewLoader(func(ctx context.Context, ids []string) (map[string]*Calendar, error) {
+			calendars, err := repository.GetCalendars(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			byId := make(map[string]*Calendar, len(calendars))
+			for i := range calendars {
+				byId[calendars[i].Id] = &calendars[i]
+			}
+
+			return byId, nil
+		}, LoaderWait),
+		Attendees: NewLoader(func(ctx context.Context, eventIds []string) (map[string][]Attendee, error) {
+			attendees, err := repository.ListAttendeesByEvents(ctx, eventIds)
+			if err != nil {
+				return nil, err
+			}
+
+			byEvent := make(map[string][]Attendee, len(eventIds))
+			for _, attendee := range attendees {
+				byEvent[attendee.EventId] = append(byEvent[attendee.EventId], attendee)
+			}
+
+			return byEvent, nil
+		}, LoaderWait),
+	}
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoader_Load(t *testing.T) {
+	t.Parallel()
+
+	t.Run("batches the keys loaded together", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			mu      sync.Mutex
+			batches [][]string
+		)
+
+		loader := NewLoader(func(_ context.Context, keys []string) (map[string]string, error) {
+			mu.Lock()
+			batches = append(batches, keys)
+			mu.Unlock()
+
+			values := make(map[string]string, len(keys))
+			for _, key := range keys {
+				if key != "missing" {
+					values[key] = "value-" + key
+				}
+			}
+
+			return values, nil
+		}, 50*time.Millisecond)
+
+		keys := []string{"a", "b", "a", "missing"}
+		values := make([]string, len(keys))
+
+		var wg sync.WaitGroup
+		for i, key := range keys {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				value, err := loader.Load(context.Background(), key)
+				assert.NoError(t, err)
+
+				values[i] = value
+			}()
+		}
+		wg.Wait()
+
+		require.Len(t, batches, 1)
+		assert.ElementsMatch(t, []string{"a", "b", "missing"}, batches[0])
+		assert.Equal(t, []string{"value-a", "value-b", "value-a", ""}, values)
+
+		// Values are not cached past their batch
+		_, err := loader.Load(context.Background(), "a")
+		require.NoError(t, err)
+		assert.Len(t, batches, 2)
+	})
+
+	t.Run("fetches full batches without waiting", func(t *testing.T) {
+		t.Parallel()
+
+		loader := NewLoader(func(_ context.Context, keys []int) (map[int]int, error) {
+			return map[int]int{keys[0]: len(keys)}, nil
+		}, time.Hour)
+
+		results := make(chan int, LoaderBatch)
+
+		for i := range LoaderBatch {
+			go func() {
+				value, _ := loader.Load(context.Background(), i)
+				results <- value
+			}()
+		}
+
+		total := 0
+		for range LoaderBatch {
+			select {
+			case value := <-results:
+				total += value
+			case <-time.After(5 * time.Second):
+				t.Fatal("batch not fetched")
+			}
+		}
+
+		assert.Equal(t, LoaderBatch, total)
+	})
+
+	t.Run("fails every load of the batch", func(t *testing.T) {
+		t.Parallel()
+
+		loader := NewLoader(func(_ context.Context, _ []string) (map[string]string, error) {
+			return nil, errors.New("db error")
+		}, time.Millisecond)
+
+		_, err := loader.Load(context.Background(), "a")
+		assert.EqualError(t, err, "db error")
+	})
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// UnmarshalJSON reads events as encoding/json does, except that all-day events may carry date-only start_time and
+// end_time ("2025-12-24"), read as midnight in the event's time zone. end_time is exclusive, so a one-day event
+// ends at midnight of the next day.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	type event Event
+
+	aux := struct {
+		*event
+
+		StartTime json.RawMessage `json:"start_time"`
+		EndTime   json.RawMessage `json:"end_time"`
+	}{event: (*event)(e)}
+
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	err = e.parseTime("start_time", aux.StartTime, &e.StartTime)
+	if err != nil {
+		return err
+	}
+
+	return e.parseTime("end_time", aux.EndTime, &e.EndTime)
+}
+
+func (e *Event) parseTime(name string, raw json.RawMessage, dst *time.Time) error {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	var value string
+
+	err := json.Unmarshal(raw, &value)
+	if err != nil || len(value) != len(time.DateOnly) {
+		return json.Unmarshal(raw, dst) //nolint:wrapcheck
+	}
+
+	if !e.AllDay {
+		return fmt.Errorf("%s must be an RFC 3339 timestamp, dates are only allowed for all-day events", name)
+	}
+
+	loc, err := Location(e.TimeZone)
+	if err != nil {
+		return err
+	}
+
+	*dst, err = time.ParseInLocation(time.DateOnly, value, loc)
+	if err != nil {
+		return fmt.Errorf("%s must be a date: %w", name, err)
+	}
+
+	return nil
+}
+
+// anchorDates moves the dates of an all-day event read without a time zone, at midnight UTC, to the same days in
+// loc, once the event gets a time zone.
+func anchorDates(event *Event, loc *time.Location) {
+	anchor := func(t time.Time) time.Time {
+		year, month, day := t.UTC().Date()
+		hour, minute, second := t.UTC().Clock()
+
+		return time.Date(year, month, day, hour, minute, second, t.Nanosecond(), loc)
+	}
+
+	event.StartTime, event.EndTime = anchor(event.StartTime), anchor(event.EndTime)
+
+	for i := range event.RDates {
+		event.RDates[i] = anchor(event.RDates[i])
+	}
+
+	for i := range event.ExDates {
+		event.ExDates[i] = anchor(event.ExDates[i])
+	}
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvent_UnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		body      string
+		wantStart time.Time
+		wantEnd   time.Time
+		wantErr   bool
+	}{
+		{
+			name:      "timestamps",
+			body:      `{"title":"Kickoff","start_time":"2025-12-24T10:00:00+01:00","end_time":"2025-12-24T11:00:00+01:00"}`,
+			wantStart: time.Date(2025, 12, 24, 9, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2025, 12, 24, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "all-day dates in the event time zone",
+			body:      `{"title":"Holiday","start_time":"2025-12-24","end_time":"2025-12-26","all_day":true,"time_zone":"Europe/Madrid"}`,
+			wantStart: time.Date(2025, 12, 24, 0, 0, 0, 0, madrid),
+			wantEnd:   time.Date(2025, 12, 26, 0, 0, 0, 0, madrid),
+		},
+		{
+			name:      "all-day dates default to UTC",
+			body:      `{"title":"Holiday","start_time":"2025-12-24","end_time":"2025-12-25","all_day":true}`,
+			wantStart: time.Date(2025, 12, 24, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "missing times",
+			body: `{"title":"Draft","start_time":null}`,
+		},
+		{
+			name:    "dates without all_day",
+			body:    `{"title":"Holiday","start_time":"2025-12-24","end_time":"2025-12-25"}`,
+			wantErr: true,
+		},
+		{
+			name:    "malformed date",
+			body:    `{"title":"Holiday","start_time":"2025-13-24","end_time":"2025-12-25","all_day":true}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown time zone",
+			body:    `{"title":"Holiday","start_time":"2025-12-24","end_time":"2025-12-25","all_day":true,"time_zone":"Nowhere/Special"}`,
+			wantErr: true,
+		},
+		{
+			name:    "malformed timestamp",
+			body:    `{"title":"Kickoff","start_time":"tomorrow"}`,
+			wantErr: true,
+		},
+		{
+			name:    "malformed end timestamp",
+			body:    `{"title":"Kickoff","end_time":42}`,
+			wantErr: true,
+		},
+		{
+			name:    "malformed event",
+			body:    `{"title":42}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var event Event
+
+			err := json.Unmarshal([]byte(tt.body), &event)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, tt.wantStart.Equal(event.StartTime), "start: want %v, got %v", tt.wantStart, event.StartTime)
+			assert.True(t, tt.wantEnd.Equal(event.EndTime), "end: want %v, got %v", tt.wantEnd, event.EndTime)
+		})
+	}
+}
+
+func TestEvent_UnmarshalJSON_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 12, 24, 0, 0, 0, 0, time.UTC)
+	event := Event{
+		Id: "uuid-1", Title: "Holiday", StartTime: start, EndTime: start.AddDate(0, 0, 1),
+		TimeZone: "UTC", AllDay: true, RRule: "FREQ=YEARLY", Version: 2,
+	}
+
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+
+	var got Event
+
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, event, got)
+}
@@ -4,9 +4,43 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 )
 
-var ErrEventNotFound = errors.New("event not found")
+var (
+	ErrEventNotFound   = errors.New("event not found")
+	ErrVersionMismatch = errors.New("event version does not match")
+	ErrEventExists     = errors.New("event already exists")
+	ErrEventConflict   = errors.New("event overlaps with another event of the same scope")
+
+	ErrCalendarNotFound = errors.New("calendar not found")
+	ErrCalendarNotEmpty = errors.New("calendar still has events")
+
+	ErrAttendeeNotFound = errors.New("attendee not found")
+	ErrAttendeeExists   = errors.New("attendee already invited")
+
+	ErrRegistrationNotFound = errors.New("registration not found")
+	ErrRegistrationExists   = errors.New("already registered")
+
+	ErrReminderNotFound = errors.New("reminder not found")
+	ErrReminderExists   = errors.New("reminder already set")
+
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("delivery not found")
+)
+
+// ConflictError lists the events of the same scope an event overlaps with.
+type ConflictError struct {
+	EventIds []string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrEventConflict, strings.Join(e.EventIds, ", "))
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrEventConflict
+}
 
 type Error struct {
 	Message string   `json:"message,omitempty"`
@@ -56,3 +90,21 @@ func (e *Error) Unwrap() error {
 func (e *Error) Messages() []string {
 	return e.Err
 }
+
+// ConflictResponse is the body of 409 responses to writes that would overlap events of the same scope.
+type ConflictResponse struct {
+	*Error
+
+	Conflicts []string `json:"conflicts"`
+}
+
+func NewConflictResponse(message string, err error) *ConflictResponse {
+	var conflictErr *ConflictError
+
+	conflicts := []string{}
+	if errors.As(err, &conflictErr) {
+		conflicts = conflictErr.EventIds
+	}
+
+	return &ConflictResponse{Error: NewError(message, err), Conflicts: conflicts}
+}
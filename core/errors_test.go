@@ -1,7 +1,9 @@
 package core
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -55,3 +57,19 @@ func TestError(t *testing.T) {
 		require.NoError(t, e2.Unwrap())
 	})
 }
+
+func TestConflictError(t *testing.T) {
+	t.Parallel()
+
+	err := fmt.Errorf("failed to save event: %w", &ConflictError{EventIds: []string{"uuid-1", "uuid-2"}})
+
+	require.ErrorIs(t, err, ErrEventConflict)
+	assert.Contains(t, err.Error(), "uuid-1, uuid-2")
+
+	data, jsonErr := json.Marshal(NewConflictResponse("event conflicts with another event", err))
+	require.NoError(t, jsonErr)
+	assert.JSONEq(t, `{"message":"event conflicts with another event","err":["`+err.Error()+`"],"conflicts":["uuid-1","uuid-2"]}`, string(data))
+
+	// Conflicts are always listed, even when unknown
+	assert.Equal(t, []string{}, NewConflictResponse("conflict", ErrEventConflict).Conflicts)
+}
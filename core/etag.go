@@ -0,0 +1,98 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AnyVersion is the expected version for an unconditional update (If-Match: *).
+const AnyVersion = 0
+
+var (
+	ErrPreconditionRequired = errors.New("header 'If-Match' is required")
+	ErrPreconditionFailed   = errors.New("header 'If-Match' does not match the current event version")
+)
+
+func ETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// ParseIfMatch returns the event versions an If-Match header value lists, AnyVersion alone for "*". Entity tags that
+// are not versions never match, a value listing only such tags fails the precondition outright.
+func ParseIfMatch(value string) ([]int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, ErrPreconditionRequired
+	}
+
+	if value == "*" {
+		return []int{AnyVersion}, nil
+	}
+
+	var versions []int
+
+	for tag := range strings.SplitSeq(value, ",") {
+		tag = strings.TrimSpace(tag)
+
+		// Versions are compared byte by byte, so weak entity tags never match (RFC 9110, section 13.1.1)
+		if tag == "" || strings.HasPrefix(tag, "W/") {
+			continue
+		}
+
+		unquoted, err := strconv.Unquote(tag)
+		if err != nil || !strings.HasPrefix(tag, `"`) {
+			return nil, fmt.Errorf("%w: malformed entity tag %s", ErrPreconditionFailed, tag)
+		}
+
+		version, err := strconv.Atoi(unquoted)
+		if err == nil && version > 0 {
+			versions = append(versions, version)
+		}
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: unknown entity tag %s", ErrPreconditionFailed, value)
+	}
+
+	return versions, nil
+}
+
+// MatchesVersion tells whether the versions of an If-Match header value include the version.
+func MatchesVersion(versions []int, version int) bool {
+	return slices.Contains(versions, AnyVersion) || slices.Contains(versions, version)
+}
+
+// ContentETag returns a strong entity tag derived from a representation's bytes.
+func ContentETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return strconv.Quote(hex.EncodeToString(sum[:16]))
+}
+
+// NotModified evaluates If-None-Match, or If-Modified-Since when it is absent (RFC 9110, section 13.2.2),
+// against the current entity tag and modification time of a representation.
+func NotModified(req *http.Request, etag string, lastModified time.Time) bool {
+	if value := req.Header.Get("If-None-Match"); value != "" {
+		for tag := range strings.SplitSeq(value, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	since, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	if err != nil || lastModified.IsZero() {
+		return false
+	}
+
+	return !lastModified.Truncate(time.Second).After(since)
+}
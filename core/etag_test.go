@@ -0,0 +1,109 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestETag(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, `"3"`, ETag(3))
+}
+
+func TestContentETag(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, ContentETag([]byte("a")), ContentETag([]byte("a")))
+	assert.NotEqual(t, ContentETag([]byte("a")), ContentETag([]byte("b")))
+	assert.Len(t, ContentETag([]byte("a")), 34)
+}
+
+func TestParseIfMatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		value   string
+		want    []int
+		wantErr error
+	}{
+		{name: "strong etag", value: ETag(3), want: []int{3}},
+		{name: "any", value: " * ", want: []int{AnyVersion}},
+		{name: "list", value: `"3", "4",W/"5"`, want: []int{3, 4}},
+		{name: "list with unknown tags", value: `"abc", "4"`, want: []int{4}},
+		{name: "missing", value: "", wantErr: ErrPreconditionRequired},
+		{name: "weak etag", value: `W/"3"`, wantErr: ErrPreconditionFailed},
+		{name: "unquoted", value: "3", wantErr: ErrPreconditionFailed},
+		{name: "unquoted in a list", value: `"3", 4`, wantErr: ErrPreconditionFailed},
+		{name: "not a version", value: `"abc"`, wantErr: ErrPreconditionFailed},
+		{name: "zero", value: `"0"`, wantErr: ErrPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseIfMatch(tt.value)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMatchesVersion(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, MatchesVersion([]int{1, 2}, 2))
+	assert.True(t, MatchesVersion([]int{AnyVersion}, 7))
+	assert.False(t, MatchesVersion([]int{1, 3}, 2))
+}
+
+func TestNotModified(t *testing.T) {
+	t.Parallel()
+
+	etag := ContentETag([]byte("BEGIN:VCALENDAR"))
+	lastModified := time.Date(2025, 12, 22, 10, 0, 0, 500, time.UTC)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{name: "unconditional", want: false},
+		{name: "matching etag", headers: map[string]string{"If-None-Match": etag}, want: true},
+		{name: "matching weak etag in a list", headers: map[string]string{"If-None-Match": `"other", W/` + etag}, want: true},
+		{name: "any etag", headers: map[string]string{"If-None-Match": "*"}, want: true},
+		{name: "stale etag", headers: map[string]string{"If-None-Match": `"other"`}, want: false},
+		{
+			name:    "etag takes precedence over date",
+			headers: map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": "Mon, 22 Dec 2025 10:00:00 GMT"},
+			want:    false,
+		},
+		{name: "not modified since", headers: map[string]string{"If-Modified-Since": "Mon, 22 Dec 2025 10:00:00 GMT"}, want: true},
+		{name: "modified since", headers: map[string]string{"If-Modified-Since": "Mon, 22 Dec 2025 09:59:59 GMT"}, want: false},
+		{name: "malformed date", headers: map[string]string{"If-Modified-Since": "yesterday"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/events.ics", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			assert.Equal(t, tt.want, NotModified(req, etag, lastModified))
+		})
+	}
+}
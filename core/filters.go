@@ -0,0 +1,224 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultRadiusKm is the radius_km of a near filter without one.
+	DefaultRadiusKm = 10
+	// MaxRadiusKm is about half the circumference of the Earth, a larger radius would not filter anything.
+	MaxRadiusKm = 20000
+)
+
+func ParseEventFilter(gctx *gin.Context) (EventFilter, error) {
+	var err error
+	var filter EventFilter
+
+	filter.Limit, err = ParseLimit(gctx.Query("limit"))
+	if err != nil {
+		return filter, err
+	}
+
+	if value := gctx.Query("cursor"); value != "" {
+		filter.Cursor, err = DecodeCursor(value)
+		if err != nil {
+			return filter, err
+		}
+	}
+
+	filter.StartsAfter, err = ParseTime("starts_after", gctx.Query("starts_after"))
+	if err != nil {
+		return filter, err
+	}
+
+	filter.EndsBefore, err = ParseTime("ends_before", gctx.Query("ends_before"))
+	if err != nil {
+		return filter, err
+	}
+
+	if value := gctx.Query("overlaps"); value != "" {
+		filter.Overlaps, err = ParseTimeWindow("overlaps", value)
+		if err != nil {
+			return filter, err
+		}
+	}
+
+	filter.TitlePrefix = strings.TrimSpace(gctx.Query("title_prefix"))
+	if utf8.RuneCountInString(filter.TitlePrefix) > 100 {
+		return filter, errors.New("parameter 'title_prefix' is too long (100 characters tops)")
+	}
+
+	filter.CalendarId = gctx.Query("calendar_id")
+	if filter.CalendarId != "" && !IsUUID(filter.CalendarId) {
+		return filter, errors.New("parameter 'calendar_id' must be a UUID")
+	}
+
+	if value := gctx.Query("near"); value != "" {
+		filter.Near, err = ParseGeoPoint("near", value)
+		if err != nil {
+			return filter, err
+		}
+
+		filter.RadiusKm, err = ParseRadius(gctx.Query("radius_km"))
+		if err != nil {
+			return filter, err
+		}
+	} else if gctx.Query("radius_km") != "" {
+		return filter, errors.New("parameter 'radius_km' requires 'near'")
+	}
+
+	if value := gctx.Query("tags"); value != "" {
+		filter.Tags, filter.TagMode, err = ParseTags(value, gctx.Query("tag_mode"))
+		if err != nil {
+			return filter, err
+		}
+	} else if gctx.Query("tag_mode") != "" {
+		return filter, errors.New("parameter 'tag_mode' requires 'tags'")
+	}
+
+	return filter, nil
+}
+
+// ParseGeoPoint parses a "latitude,longitude" pair of decimal degrees.
+func ParseGeoPoint(name string, value string) (*GeoPoint, error) {
+	latitude, longitude, found := strings.Cut(value, ",")
+	if !found {
+		return nil, fmt.Errorf("parameter '%s' must be in the form 'latitude,longitude'", name)
+	}
+
+	var err error
+	var point GeoPoint
+
+	point.Latitude, err = strconv.ParseFloat(strings.TrimSpace(latitude), 64)
+	if err != nil {
+		return nil, fmt.Errorf("parameter '%s' has an invalid latitude: %w", name, err)
+	}
+
+	point.Longitude, err = strconv.ParseFloat(strings.TrimSpace(longitude), 64)
+	if err != nil {
+		return nil, fmt.Errorf("parameter '%s' has an invalid longitude: %w", name, err)
+	}
+
+	err = ValidateCoordinates(point.Latitude, point.Longitude)
+	if err != nil {
+		return nil, fmt.Errorf("parameter '%s' is invalid: %w", name, err)
+	}
+
+	return &point, nil
+}
+
+// ParseTags parses comma-separated tags, normalized as the tags of events are, and their mode (default TagModeAny).
+func ParseTags(value string, mode string) ([]string, string, error) {
+	switch mode {
+	case "":
+		mode = TagModeAny
+	case TagModeAny, TagModeAll:
+	default:
+		return nil, "", fmt.Errorf("parameter 'tag_mode' must be %s or %s", TagModeAny, TagModeAll)
+	}
+
+	tags := NormalizeTags(strings.Split(value, ","))
+	if len(tags) == 0 {
+		return nil, "", errors.New("parameter 'tags' must name at least one tag")
+	}
+
+	err := ValidateTags(tags)
+	if err != nil {
+		return nil, "", fmt.Errorf("parameter 'tags' is invalid: %w", err)
+	}
+
+	return tags, mode, nil
+}
+
+// ParseRadius parses radius_km, in (0, MaxRadiusKm]; an empty value yields DefaultRadiusKm.
+func ParseRadius(value string) (float64, error) {
+	if value == "" {
+		return DefaultRadiusKm, nil
+	}
+
+	radius, err := strconv.ParseFloat(value, 64)
+	if err != nil || !(radius > 0 && radius <= MaxRadiusKm) {
+		return 0, fmt.Errorf("parameter 'radius_km' must be a number of kilometers between 0 and %d", MaxRadiusKm)
+	}
+
+	return radius, nil
+}
+
+// ParseTime parses an RFC 3339 timestamp; an empty value yields the zero time.
+func ParseTime(name string, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parameter '%s' must be an RFC 3339 timestamp: %w", name, err)
+	}
+
+	return t, nil
+}
+
+// ParseTimeWindow parses a "from,to" pair of RFC 3339 timestamps.
+func ParseTimeWindow(name string, value string) (*TimeWindow, error) {
+	from, to, found := strings.Cut(value, ",")
+	if !found || from == "" || to == "" {
+		return nil, fmt.Errorf("parameter '%s' must be in the form 'from,to'", name)
+	}
+
+	var err error
+	var window TimeWindow
+
+	window.From, err = ParseTime(name, from)
+	if err != nil {
+		return nil, err
+	}
+
+	window.To, err = ParseTime(name, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if !window.From.Before(window.To) {
+		return nil, fmt.Errorf("parameter '%s' must have 'from' before 'to'", name)
+	}
+
+	return &window, nil
+}
+
+// ParseOccurrenceWindow reads the mandatory from/to window of GET /events/occurrences.
+func ParseOccurrenceWindow(gctx *gin.Context) (TimeWindow, error) {
+	var err error
+	var window TimeWindow
+
+	if gctx.Query("from") == "" || gctx.Query("to") == "" {
+		return window, errors.New("parameters 'from' and 'to' are required")
+	}
+
+	window.From, err = ParseTime("from", gctx.Query("from"))
+	if err != nil {
+		return window, err
+	}
+
+	window.To, err = ParseTime("to", gctx.Query("to"))
+	if err != nil {
+		return window, err
+	}
+
+	if !window.From.Before(window.To) {
+		return window, errors.New("parameter 'from' must be before 'to'")
+	}
+
+	if window.To.Sub(window.From) > MaxOccurrenceWindow {
+		return window, fmt.Errorf("window is too long (%d days tops)", MaxOccurrenceWindow/(24*time.Hour))
+	}
+
+	return window, nil
+}
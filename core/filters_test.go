@@ -0,0 +1,153 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEventFilter(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	monday9 := time.Date(2025, 12, 22, 9, 0, 0, 0, time.UTC)
+	monday17 := time.Date(2025, 12, 22, 17, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		query   string
+		want    EventFilter
+		wantErr bool
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			want:  EventFilter{Limit: DefaultPageLimit},
+		},
+		{
+			name:  "all filters",
+			query: "?limit=10&starts_after=2025-12-22T09:00:00Z&ends_before=2025-12-22T17:00:00Z&overlaps=2025-12-22T09:00:00Z,2025-12-22T17:00:00Z&title_prefix=%20Stand&calendar_id=4acc05c4-3526-4c09-8739-621c4b57c8e6",
+			want: EventFilter{
+				Limit:       10,
+				StartsAfter: monday9,
+				EndsBefore:  monday17,
+				Overlaps:    &TimeWindow{From: monday9, To: monday17},
+				TitlePrefix: "Stand",
+				CalendarId:  "4acc05c4-3526-4c09-8739-621c4b57c8e6",
+			},
+		},
+		{
+			name:  "near with default radius",
+			query: "?near=40.4169,-3.7035",
+			want:  EventFilter{Limit: DefaultPageLimit, Near: &GeoPoint{Latitude: 40.4169, Longitude: -3.7035}, RadiusKm: DefaultRadiusKm},
+		},
+		{
+			name:  "near with radius",
+			query: "?near=40.4169,%20-3.7035&radius_km=2.5",
+			want:  EventFilter{Limit: DefaultPageLimit, Near: &GeoPoint{Latitude: 40.4169, Longitude: -3.7035}, RadiusKm: 2.5},
+		},
+		{
+			name:  "tags with default mode",
+			query: "?tags=Release,%20oncall,,release",
+			want:  EventFilter{Limit: DefaultPageLimit, Tags: []string{"oncall", "release"}, TagMode: TagModeAny},
+		},
+		{
+			name:  "tags with all mode",
+			query: "?tags=oncall,release&tag_mode=all",
+			want:  EventFilter{Limit: DefaultPageLimit, Tags: []string{"oncall", "release"}, TagMode: TagModeAll},
+		},
+		{name: "invalid limit", query: "?limit=-1", wantErr: true},
+		{name: "calendar_id not a UUID", query: "?calendar_id=uuid-c", wantErr: true},
+		{name: "tags blank", query: "?tags=,%20,", wantErr: true},
+		{name: "tags invalid", query: "?tags=on%20call", wantErr: true},
+		{name: "tag_mode unknown", query: "?tags=oncall&tag_mode=none", wantErr: true},
+		{name: "tag_mode without tags", query: "?tag_mode=all", wantErr: true},
+		{name: "near without comma", query: "?near=40.4169", wantErr: true},
+		{name: "near malformed latitude", query: "?near=north,-3.7035", wantErr: true},
+		{name: "near out of range", query: "?near=40.4169,-183", wantErr: true},
+		{name: "radius_km without near", query: "?radius_km=5", wantErr: true},
+		{name: "radius_km zero", query: "?near=40.4169,-3.7035&radius_km=0", wantErr: true},
+		{name: "radius_km too large", query: "?near=40.4169,-3.7035&radius_km=20001", wantErr: true},
+		{name: "radius_km malformed", query: "?near=40.4169,-3.7035&radius_km=far", wantErr: true},
+		{name: "invalid cursor", query: "?cursor=abc", wantErr: true},
+		{name: "malformed starts_after", query: "?starts_after=monday", wantErr: true},
+		{name: "malformed ends_before", query: "?ends_before=2025-12-22", wantErr: true},
+		{name: "overlaps without comma", query: "?overlaps=2025-12-22T09:00:00Z", wantErr: true},
+		{name: "overlaps malformed from", query: "?overlaps=monday,2025-12-22T17:00:00Z", wantErr: true},
+		{name: "overlaps malformed to", query: "?overlaps=2025-12-22T09:00:00Z,friday", wantErr: true},
+		{name: "overlaps reversed", query: "?overlaps=2025-12-22T17:00:00Z,2025-12-22T09:00:00Z", wantErr: true},
+		{name: "title_prefix too long", query: "?title_prefix=" + string(make([]byte, 101)), wantErr: true},
+		{
+			// Characters are counted, not bytes
+			name:  "title_prefix of 100 letters",
+			query: "?title_prefix=" + url.QueryEscape(strings.Repeat("я", 100)),
+			want:  EventFilter{Limit: DefaultPageLimit, TitlePrefix: strings.Repeat("я", 100)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/events", nil)
+			c.Request.URL.RawQuery = tt.query[min(1, len(tt.query)):]
+
+			got, err := ParseEventFilter(c)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseOccurrenceWindow(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		query   string
+		want    TimeWindow
+		wantErr bool
+	}{
+		{
+			name:  "valid",
+			query: "?from=2025-12-01T00:00:00Z&to=2026-01-01T00:00:00Z",
+			want:  TimeWindow{From: time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{name: "missing from", query: "?to=2026-01-01T00:00:00Z", wantErr: true},
+		{name: "missing to", query: "?from=2025-12-01T00:00:00Z", wantErr: true},
+		{name: "malformed from", query: "?from=monday&to=2026-01-01T00:00:00Z", wantErr: true},
+		{name: "malformed to", query: "?from=2025-12-01T00:00:00Z&to=friday", wantErr: true},
+		{name: "reversed", query: "?from=2026-01-01T00:00:00Z&to=2025-12-01T00:00:00Z", wantErr: true},
+		{name: "too long", query: "?from=2025-01-01T00:00:00Z&to=2026-01-03T00:00:00Z", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/events/occurrences"+tt.query, nil)
+
+			got, err := ParseOccurrenceWindow(c)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"slices"
+	"time"
+)
+
+// MergeBusy coalesces the intervals that overlap or touch, and returns them ordered by start time. Empty intervals
+// are dropped.
+func MergeBusy(intervals []BusyInterval) []BusyInterval {
+	intervals = slices.DeleteFunc(slices.Clone(intervals), func(i BusyInterval) bool { return !i.End.After(i.Start) })
+	slices.SortFunc(intervals, func(a, b BusyInterval) int { return a.Start.Compare(b.Start) })
+
+	merged := make([]BusyInterval, 0, len(intervals))
+
+	for _, interval := range intervals {
+		last := len(merged) - 1
+		if last >= 0 && !interval.Start.After(merged[last].End) {
+			if interval.End.After(merged[last].End) {
+				merged[last].End = interval.End
+			}
+
+			continue
+		}
+
+		merged = append(merged, interval)
+	}
+
+	return merged
+}
+
+// occurrencesBusy returns the intervals of the occurrences, clipped to the window.
+func occurrencesBusy(occurrences []Occurrence, window TimeWindow) []BusyInterval {
+	busy := make([]BusyInterval, 0, len(occurrences))
+
+	for _, o := range occurrences {
+		busy = append(busy, BusyInterval{Start: laterOf(o.StartTime, window.From).UTC(), End: earlierOf(o.EndTime, window.To).UTC()})
+	}
+
+	return busy
+}
+
+func laterOf(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
+
+func earlierOf(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+
+	return b
+}
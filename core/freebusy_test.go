@@ -0,0 +1,55 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeBusy(t *testing.T) {
+	t.Parallel()
+
+	at := func(hour, minute int) time.Time { return time.Date(2025, 3, 3, hour, minute, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name      string
+		intervals []BusyInterval
+		want      []BusyInterval
+	}{
+		{name: "none", want: []BusyInterval{}},
+		{
+			name:      "disjoint",
+			intervals: []BusyInterval{{at(9, 0), at(10, 0)}, {at(11, 0), at(12, 0)}},
+			want:      []BusyInterval{{at(9, 0), at(10, 0)}, {at(11, 0), at(12, 0)}},
+		},
+		{
+			name:      "overlapping and unordered",
+			intervals: []BusyInterval{{at(10, 0), at(11, 0)}, {at(9, 0), at(10, 30)}},
+			want:      []BusyInterval{{at(9, 0), at(11, 0)}},
+		},
+		{
+			name:      "touching",
+			intervals: []BusyInterval{{at(9, 0), at(10, 0)}, {at(10, 0), at(11, 0)}},
+			want:      []BusyInterval{{at(9, 0), at(11, 0)}},
+		},
+		{
+			name:      "contained",
+			intervals: []BusyInterval{{at(9, 0), at(12, 0)}, {at(10, 0), at(11, 0)}, {at(11, 30), at(13, 0)}},
+			want:      []BusyInterval{{at(9, 0), at(13, 0)}},
+		},
+		{
+			name:      "empty intervals are dropped",
+			intervals: []BusyInterval{{at(9, 0), at(9, 0)}, {at(11, 0), at(10, 0)}},
+			want:      []BusyInterval{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, MergeBusy(tt.intervals))
+		})
+	}
+}
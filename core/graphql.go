@@ -0,0 +1,527 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	gqlotel "github.com/graph-gophers/graphql-go/trace/otel"
+	"github.com/rs/zerolog/log"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	// GraphQLMaxDepth is how deep the fields of a query may be nested, introspection aside.
+	GraphQLMaxDepth = 8
+	// GraphQLMaxIntrospectionDepth is how deep the introspection fields of a query may be nested, enough for the
+	// introspection query of GraphiQL.
+	GraphQLMaxIntrospectionDepth = 15
+	// GraphQLMaxComplexity is the most fields a query may resolve: every field counts once, those under a field taking
+	// `first` count once per event of the page.
+	GraphQLMaxComplexity = 2500
+	// GraphQLMaxParallelism is the most fields resolved at once, enough for the calendar and the attendees of every
+	// event of a full page to resolve together and so be loaded in a single batch each.
+	GraphQLMaxParallelism = 2 * MaxPageLimit
+	// GraphQLMaxOperations is the most operations a WebSocket connection runs at once.
+	GraphQLMaxOperations = 50
+	// GraphQLInitWait is how long clients connecting over WebSocket have to send their connection_init.
+	GraphQLInitWait = 10 * time.Second
+)
+
+// Messages of the graphql-transport-ws protocol, see
+// https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md.
+const (
+	gqlConnectionInit = "connection_init"
+	gqlConnectionAck  = "connection_ack"
+	gqlPing           = "ping"
+	gqlPong           = "pong"
+	gqlSubscribe      = "subscribe"
+	gqlNext           = "next"
+	gqlError          = "error"
+	gqlComplete       = "complete"
+
+	gqlSubprotocol = "graphql-transport-ws"
+)
+
+// Close codes of the graphql-transport-ws protocol.
+const (
+	gqlCloseInvalidMessage    = 4400
+	gqlCloseUnauthorized      = 4401
+	gqlCloseNotAcceptable     = 4406
+	gqlCloseInitTimeout       = 4408
+	gqlCloseSubscriberExists  = 4409
+	gqlCloseTooManyInitialise = 4429
+)
+
+// GraphQLRequest is a query, mutation or subscription, along with the values of its variables.
+type GraphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// GraphQL serves GraphQLSchema over HTTP, and over WebSocket with the graphql-transport-ws protocol. The fields related
+// to events are loaded in batches, once per request rather than once per event.
+type GraphQL struct {
+	schema     *graphql.Schema
+	limits     *ast.Schema
+	repository RepositoryInterface
+}
+
+// NewGraphQL subscribes to the changes of events from stream, subscriptions are unavailable without it.
+func NewGraphQL(repository RepositoryInterface, stream *ChangeStream) *GraphQL {
+	resolver := &graphQLResolver{repository: repository, stream: stream}
+
+	return &GraphQL{
+		schema: graphql.MustParseSchema(GraphQLSchema, resolver,
+			graphql.UseStringDescriptions(),
+			// Backs checkLimits up: nothing nests deeper than introspection may
+			graphql.MaxDepth(GraphQLMaxIntrospectionDepth),
+			graphql.MaxParallelism(GraphQLMaxParallelism),
+			graphql.Tracer(&gqlotel.Tracer{Tracer: otel.Tracer("ltk-code-challenge/graphql")}),
+		),
+		limits:     gqlparser.MustLoadSchema(&ast.Source{Name: "schema.graphql", Input: GraphQLSchema}),
+		repository: repository,
+	}
+}
+
+// Serve answers the queries and mutations POSTed as JSON, and upgrades the GETs to WebSocket.
+func (g *GraphQL) Serve(gctx *gin.Context) {
+	if gctx.Request.Method == http.MethodGet {
+		g.serveWebSocket(gctx)
+
+		return
+	}
+
+	var req GraphQLRequest
+
+	// Accepts a JSON payload with query, operationName and variables
+	err := gctx.ShouldBindJSON(&req)
+	if err != nil {
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("failed to bind JSON", err))
+
+		return
+	}
+
+	// Queries too deep or too complex are rejected before resolving any field
+	err = g.checkLimits(req)
+	if err != nil {
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, &graphql.Response{Errors: []*gqlerrors.QueryError{gqlerrors.Errorf("%s", err)}})
+
+		return
+	}
+
+	ctx := withLoaders(gctx.Request.Context(), NewLoaders(g.repository))
+
+	gctx.JSON(http.StatusOK, g.schema.Exec(ctx, req.Query, req.OperationName, req.Variables))
+}
+
+// checkLimits returns why the operation of the request is too deep or too complex. Requests the schema rejects are left
+// for it to report; those it accepts but that cannot be measured are rejected, limits are never skipped.
+func (g *GraphQL) checkLimits(req GraphQLRequest) error {
+	doc, errs := gqlparser.LoadQuery(g.limits, req.Query)
+	if len(errs) > 0 {
+		if len(g.schema.ValidateWithVariables(req.Query, req.Variables)) > 0 {
+			return nil
+		}
+
+		return fmt.Errorf("query cannot be measured: %s", errs[0].Message)
+	}
+
+	op := doc.Operations.ForName(req.OperationName)
+	switch {
+	case op == nil && req.OperationName == "":
+		return errors.New("query has several operations, operationName must name the one to run")
+	case op == nil:
+		return fmt.Errorf("query has no operation named '%s'", req.OperationName)
+	}
+
+	depth, introspectionDepth, complexity := measure(op.SelectionSet, req.Variables, false)
+
+	switch {
+	case depth > GraphQLMaxDepth:
+		return fmt.Errorf("query is too deep: %d levels of fields (%d tops)", depth, GraphQLMaxDepth)
+	case introspectionDepth > GraphQLMaxIntrospectionDepth:
+		return fmt.Errorf("introspection is too deep: %d levels of fields (%d tops)", introspectionDepth, GraphQLMaxIntrospectionDepth)
+	case complexity > GraphQLMaxComplexity:
+		return fmt.Errorf("query is too complex: %d fields to resolve (%d tops)", complexity, GraphQLMaxComplexity)
+	default:
+		return nil
+	}
+}
+
+// measure returns how deep the fields of the selection are nested, apart from the paths through introspection fields,
+// how deep those paths are, and how many fields would be resolved at most. Every field of the selection is an
+// introspection field when introspection is set.
+func measure(selections ast.SelectionSet, variables map[string]any, introspection bool) (int, int, int) {
+	depth, introspectionDepth, complexity := 0, 0, 0
+
+	for _, selection := range selections {
+		var childDepth, childIntrospectionDepth, childComplexity int
+
+		switch s := selection.(type) {
+		case *ast.Field:
+			// GraphiQL queries the schema deeper than any query of events, introspection has a depth of its own
+			inner := introspection || strings.HasPrefix(s.Name, "__")
+
+			childDepth, childIntrospectionDepth, childComplexity = measure(s.SelectionSet, variables, inner)
+
+			if inner || childIntrospectionDepth > 0 {
+				childIntrospectionDepth++
+			}
+
+			if !inner {
+				childDepth++
+			}
+
+			childComplexity = 1 + pageSize(s, variables)*childComplexity
+		case *ast.FragmentSpread:
+			childDepth, childIntrospectionDepth, childComplexity = measure(s.Definition.SelectionSet, variables, introspection)
+		case *ast.InlineFragment:
+			childDepth, childIntrospectionDepth, childComplexity = measure(s.SelectionSet, variables, introspection)
+		}
+
+		depth = max(depth, childDepth)
+		introspectionDepth = max(introspectionDepth, childIntrospectionDepth)
+		complexity += childComplexity
+	}
+
+	return depth, introspectionDepth, complexity
+}
+
+// pageSize returns how many times the selection of the field is resolved: the page size for the fields taking `first`,
+// once otherwise.
+func pageSize(field *ast.Field, variables map[string]any) int {
+	if field.Definition == nil || field.Definition.Arguments.ForName("first") == nil {
+		return 1
+	}
+
+	size := DefaultPageLimit
+
+	if arg := field.Arguments.ForName("first"); arg != nil {
+		value, err := arg.Value.Value(variables)
+		if err != nil {
+			return MaxPageLimit
+		}
+
+		switch v := value.(type) {
+		case int64:
+			size = int(v)
+		case float64:
+			size = int(v)
+		case json.Number:
+			n, _ := v.Int64()
+			size = int(n)
+		}
+	}
+
+	return min(max(size, 1), MaxPageLimit)
+}
+
+var graphQLUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	Subprotocols:    []string{gqlSubprotocol},
+}
+
+// gqlMessage is a message of the graphql-transport-ws protocol, either way.
+type gqlMessage struct {
+	Id      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// gqlSession is a connection of a client to /graphql over WebSocket. The read loop handles its messages, every
+// operation writes its results from its own goroutine.
+type gqlSession struct {
+	graphql    *GraphQL
+	conn       *websocket.Conn
+	ctx        context.Context
+	writeMu    sync.Mutex // guards writes to conn
+	mu         sync.Mutex // guards acked and operations
+	acked      bool
+	operations map[string]context.CancelFunc
+}
+
+func (g *GraphQL) serveWebSocket(gctx *gin.Context) {
+	// WebSocket: GET /graphql, the upgrader answers the requests it cannot upgrade
+	conn, err := graphQLUpgrader.Upgrade(gctx.Writer, gctx.Request, nil)
+	if err != nil {
+		log.Err(err).Msg("websocket upgrade failed")
+
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(gctx.Request.Context()))
+	defer cancel()
+
+	session := &gqlSession{
+		graphql:    g,
+		conn:       conn,
+		ctx:        ctx,
+		operations: make(map[string]context.CancelFunc),
+	}
+
+	if conn.Subprotocol() != gqlSubprotocol {
+		session.close(gqlCloseNotAcceptable, "Subprotocol not acceptable")
+
+		return
+	}
+
+	session.run()
+}
+
+// run reads the messages of the client until the connection closes, pinging it meanwhile.
+func (s *gqlSession) run() {
+	init := time.AfterFunc(GraphQLInitWait, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if !s.acked {
+			s.close(gqlCloseInitTimeout, "Connection initialisation timeout")
+		}
+	})
+	defer init.Stop()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go s.ping(done)
+
+	s.conn.SetReadLimit(WSMaxMessageSize)
+	_ = s.conn.SetReadDeadline(time.Now().Add(WSPongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(WSPongWait))
+	})
+
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		if !s.handle(data) {
+			return
+		}
+	}
+}
+
+func (s *gqlSession) ping(done <-chan struct{}) {
+	ping := time.NewTicker(WSPingPeriod)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ping.C:
+			s.writeMu.Lock()
+			err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(WSWriteWait))
+			s.writeMu.Unlock()
+
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handle handles a message of the client, and returns whether the connection stays open.
+func (s *gqlSession) handle(data []byte) bool {
+	var message gqlMessage
+
+	err := json.Unmarshal(data, &message)
+	if err != nil {
+		return s.close(gqlCloseInvalidMessage, "Invalid message received")
+	}
+
+	switch message.Type {
+	case gqlConnectionInit:
+		s.mu.Lock()
+		acked := s.acked
+		s.acked = true
+		s.mu.Unlock()
+
+		if acked {
+			return s.close(gqlCloseTooManyInitialise, "Too many initialisation requests")
+		}
+
+		return s.send(gqlMessage{Type: gqlConnectionAck}) == nil
+	case gqlPing:
+		return s.send(gqlMessage{Type: gqlPong}) == nil
+	case gqlPong:
+		return true
+	case gqlSubscribe:
+		return s.subscribe(message)
+	case gqlComplete:
+		s.mu.Lock()
+		if cancel, ok := s.operations[message.Id]; ok {
+			cancel()
+			delete(s.operations, message.Id)
+		}
+		s.mu.Unlock()
+
+		return true
+	default:
+		return s.close(gqlCloseInvalidMessage, fmt.Sprintf("Invalid message type %q", message.Type))
+	}
+}
+
+// subscribe starts the operation of the message, its results are sent as they come until it completes.
+func (s *gqlSession) subscribe(message gqlMessage) bool {
+	var req GraphQLRequest
+
+	err := json.Unmarshal(message.Payload, &req)
+	if err != nil || message.Id == "" {
+		return s.close(gqlCloseInvalidMessage, "Invalid subscribe message")
+	}
+
+	s.mu.Lock()
+
+	if !s.acked {
+		s.mu.Unlock()
+		return s.close(gqlCloseUnauthorized, "Unauthorized")
+	}
+
+	if _, ok := s.operations[message.Id]; ok {
+		s.mu.Unlock()
+		return s.close(gqlCloseSubscriberExists, fmt.Sprintf("Subscriber for %s already exists", message.Id))
+	}
+
+	if len(s.operations) >= GraphQLMaxOperations {
+		s.mu.Unlock()
+		return s.fail(message.Id, fmt.Errorf("too many operations (%d tops)", GraphQLMaxOperations)) == nil
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.operations[message.Id] = cancel
+
+	s.mu.Unlock()
+
+	// Operations that cannot run are answered with an error rather than results
+	errs := s.graphql.schema.ValidateWithVariables(req.Query, req.Variables)
+	if len(errs) == 0 {
+		err = s.graphql.checkLimits(req)
+		if err != nil {
+			errs = []*gqlerrors.QueryError{gqlerrors.Errorf("%s", err)}
+		}
+	}
+
+	if len(errs) > 0 {
+		s.end(message.Id)
+		return s.send(gqlMessage{Id: message.Id, Type: gqlError, Payload: marshal(errs)}) == nil
+	}
+
+	results, err := s.graphql.schema.Subscribe(withLoaders(ctx, NewLoaders(s.graphql.repository)), req.Query, req.OperationName, req.Variables)
+	if err != nil {
+		s.end(message.Id)
+		return s.fail(message.Id, err) == nil
+	}
+
+	go func() {
+		for result := range results {
+			err := s.send(gqlMessage{Id: message.Id, Type: gqlNext, Payload: marshal(result)})
+			if err != nil {
+				cancel()
+			}
+		}
+
+		// Operations completed by the client are not completed again
+		if s.end(message.Id) {
+			_ = s.send(gqlMessage{Id: message.Id, Type: gqlComplete})
+		}
+	}()
+
+	return true
+}
+
+// end forgets the operation, and returns whether it was still running.
+func (s *gqlSession) end(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cancel, ok := s.operations[id]
+	if ok {
+		cancel()
+		delete(s.operations, id)
+	}
+
+	return ok
+}
+
+func (s *gqlSession) fail(id string, err error) error {
+	return s.send(gqlMessage{Id: id, Type: gqlError, Payload: marshal([]*gqlerrors.QueryError{gqlerrors.Errorf("%s", err)})})
+}
+
+func (s *gqlSession) send(message gqlMessage) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	_ = s.conn.SetWriteDeadline(time.Now().Add(WSWriteWait))
+
+	return s.conn.WriteJSON(message)
+}
+
+// close closes the connection with the code and the reason of the protocol, and returns false for handle to stop.
+func (s *gqlSession) close(code int, reason string) bool {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	_ = s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(WSWriteWait))
+	_ = s.conn.Close()
+
+	return false
+}
+
+func marshal(v any) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Err(err).Msg("failed to marshal GraphQL result")
+		return json.RawMessage("null")
+	}
+
+	return data
+}
+
+// ServeGraphiQL serves GraphiQL, to try out queries and subscriptions from a browser.
+func (g *GraphQL) ServeGraphiQL(gctx *gin.Context) {
+	gctx.Data(http.StatusOK, "text/html; charset=utf-8", []byte(graphiQLPage))
+}
+
+const graphiQLPage = `<!doctype html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>GraphiQL</title>
+  <style>body { margin: 0; } #graphiql { height: 100vh; }</style>
+  <link rel="stylesheet" href="https://unpkg.com/graphiql@3/graphiql.min.css">
+  <script crossorigin src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/graphql-ws@5/umd/graphql-ws.min.js"></script>
+  <script crossorigin src="https://unpkg.com/graphiql@3/graphiql.min.js"></script>
+</head>
+<body>
+  <div id="graphiql">Loading...</div>
+  <script>
+    const url = new URL('/graphql', window.location.href).href;
+    const fetcher = GraphiQL.createFetcher({
+      url,
+      wsClient: graphqlWs.createClient({ url: url.replace(/^http/, 'ws') }),
+    });
+    ReactDOM.createRoot(document.getElementById('graphiql')).render(React.createElement(GraphiQL, { fetcher }));
+  </script>
+</body>
+</html>
+`
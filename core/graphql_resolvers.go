@@ -0,0 +1,664 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/rs/zerolog/log"
+)
+
+// GraphQLSchema is the schema of /graphql, resolved by graphQLResolver.
+const GraphQLSchema = `
+schema {
+  query: Query
+  mutation: Mutation
+  subscription: Subscription
+}
+
+scalar Time
+
+type Query {
+  event(id: ID!): Event
+  "Events by start time, first (50 by default, 200 tops) at a time. cursor is the next or prev of the previous page."
+  events(filter: EventFilter, first: Int, cursor: String): EventPage!
+  calendars: [Calendar!]!
+}
+
+type Mutation {
+  createEvent(input: EventInput!): Event!
+  "Replaces the event, version is the one last read (0 for any)."
+  updateEvent(id: ID!, version: Int!, input: EventInput!): Event!
+}
+
+type Subscription {
+  "Changes of events as they are committed, of any of the topics (see /ws) or all of them without topics."
+  eventChanged(topics: [String!]): EventChange!
+}
+
+enum TagMode {
+  ANY
+  ALL
+}
+
+input EventFilter {
+  startsAfter: Time
+  endsBefore: Time
+  titlePrefix: String
+  calendarId: ID
+  tags: [String!]
+  tagMode: TagMode
+}
+
+input PlaceInput {
+  name: String
+  address: String
+  latitude: Float
+  longitude: Float
+}
+
+input EventInput {
+  title: String!
+  description: String
+  startTime: Time!
+  endTime: Time!
+  timeZone: String
+  rrule: String
+  allDay: Boolean
+  scope: String
+  calendarId: ID
+  capacity: Int
+  location: PlaceInput
+  tags: [String!]
+}
+
+type EventPage {
+  events: [Event!]!
+  next: String
+  prev: String
+}
+
+type Event {
+  id: ID!
+  title: String!
+  description: String!
+  startTime: Time!
+  endTime: Time!
+  timeZone: String!
+  rrule: String!
+  allDay: Boolean!
+  scope: String!
+  capacity: Int!
+  location: Place
+  tags: [String!]!
+  version: Int!
+  createdAt: Time!
+  updatedAt: Time!
+  calendar: Calendar
+  attendees: [Attendee!]!
+}
+
+type Place {
+  name: String!
+  address: String!
+  latitude: Float
+  longitude: Float
+}
+
+type Calendar {
+  id: ID!
+  name: String!
+  description: String!
+  timeZone: String!
+  color: String!
+}
+
+type Attendee {
+  id: ID!
+  email: String!
+  name: String!
+  status: String!
+  respondedAt: Time
+}
+
+"A change of an event. A change of type stream.reset tells that changes may have been missed, what is shown should be fetched again."
+type EventChange {
+  id: ID!
+  type: String!
+  eventId: ID!
+  title: String!
+  startTime: Time
+  seriesEnd: Time
+  createdAt: Time
+  "The event as it is now, null once deleted."
+  event: Event
+}
+`
+
+// graphQLError is an error of a resolver, with a code telling what went wrong (NOT_FOUND, BAD_USER_INPUT,
+// PRECONDITION_FAILED, CONFLICT, UNAVAILABLE or INTERNAL).
+type graphQLError struct {
+	message string
+	code    string
+}
+
+func (e *graphQLError) Error() string {
+	return e.message
+}
+
+func (e *graphQLError) Extensions() map[string]any {
+	return map[string]any{"code": e.code}
+}
+
+func badUserInput(err error) error {
+	return &graphQLError{message: err.Error(), code: "BAD_USER_INPUT"}
+}
+
+// resolverError maps the errors of the repository to the codes of the errors of the resolvers.
+func resolverError(message string, err error) error {
+	switch {
+	case errors.Is(err, ErrEventNotFound):
+		return &graphQLError{message: "event not found", code: "NOT_FOUND"}
+	case errors.Is(err, ErrCalendarNotFound):
+		return &graphQLError{message: "calendar not found", code: "NOT_FOUND"}
+	case errors.Is(err, ErrVersionMismatch):
+		return &graphQLError{message: err.Error(), code: "PRECONDITION_FAILED"}
+	case errors.Is(err, ErrEventConflict):
+		return &graphQLError{message: err.Error(), code: "CONFLICT"}
+	default:
+		log.Err(err).Msg(message)
+		return &graphQLError{message: message, code: "INTERNAL"}
+	}
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, loaders *Loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, loaders)
+}
+
+// graphQLResolver resolves the queries, mutations and subscriptions of GraphQLSchema.
+type graphQLResolver struct {
+	repository RepositoryInterface
+	stream     *ChangeStream
+}
+
+// loaders returns the loaders of the request, requests get their own so that they batch their loads together.
+func (r *graphQLResolver) loaders(ctx context.Context) *Loaders {
+	if loaders, ok := ctx.Value(loadersKey{}).(*Loaders); ok {
+		return loaders
+	}
+
+	return NewLoaders(r.repository)
+}
+
+func (r *graphQLResolver) Event(ctx context.Context, args struct{ ID graphql.ID }) (*eventResolver, error) {
+	event, err := r.repository.GetEventById(ctx, string(args.ID))
+	if errors.Is(err, ErrEventNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, resolverError("getting event failed", err)
+	}
+
+	return &eventResolver{event: *event, loaders: r.loaders(ctx)}, nil
+}
+
+type eventFilterInput struct {
+	StartsAfter *graphql.Time
+	EndsBefore  *graphql.Time
+	TitlePrefix *string
+	CalendarId  *graphql.ID
+	Tags        *[]string
+	TagMode     *string
+}
+
+func (r *graphQLResolver) Events(ctx context.Context, args struct {
+	Filter *eventFilterInput
+	First  *int32
+	Cursor *string
+},
+) (*eventPageResolver, error) {
+	filter, err := graphQLEventFilter(args.Filter, args.First, args.Cursor)
+	if err != nil {
+		return nil, badUserInput(err)
+	}
+
+	page, err := r.repository.ListEvents(ctx, filter)
+	if err != nil {
+		return nil, resolverError("listing events failed", err)
+	}
+
+	return &eventPageResolver{page: *page, loaders: r.loaders(ctx)}, nil
+}
+
+// graphQLEventFilter checks the arguments like ParseEventFilter checks the query of GET /events.
+func graphQLEventFilter(input *eventFilterInput, first *int32, cursor *string) (EventFilter, error) {
+	var err error
+
+	filter := EventFilter{Limit: DefaultPageLimit}
+
+	if first != nil {
+		if *first <= 0 {
+			return filter, errors.New("first must be a positive integer")
+		}
+
+		filter.Limit = min(int(*first), MaxPageLimit)
+	}
+
+	if cursor != nil && *cursor != "" {
+		filter.Cursor, err = DecodeCursor(*cursor)
+		if err != nil {
+			return filter, err
+		}
+	}
+
+	if input == nil {
+		return filter, nil
+	}
+
+	if input.StartsAfter != nil {
+		filter.StartsAfter = input.StartsAfter.Time
+	}
+
+	if input.EndsBefore != nil {
+		filter.EndsBefore = input.EndsBefore.Time
+	}
+
+	if input.TitlePrefix != nil {
+		filter.TitlePrefix = strings.TrimSpace(*input.TitlePrefix)
+		if utf8.RuneCountInString(filter.TitlePrefix) > 100 {
+			return filter, errors.New("titlePrefix is too long (100 characters tops)")
+		}
+	}
+
+	if input.CalendarId != nil {
+		filter.CalendarId = string(*input.CalendarId)
+		if !IsUUID(filter.CalendarId) {
+			return filter, errors.New("calendarId must be a UUID")
+		}
+	}
+
+	mode := ""
+	if input.TagMode != nil {
+		mode = strings.ToLower(*input.TagMode)
+	}
+
+	if input.Tags != nil {
+		filter.Tags, filter.TagMode, err = ParseTags(strings.Join(*input.Tags, ","), mode)
+		if err != nil {
+			return filter, err
+		}
+	} else if mode != "" {
+		return filter, errors.New("tagMode requires tags")
+	}
+
+	return filter, nil
+}
+
+func (r *graphQLResolver) Calendars(ctx context.Context) ([]*calendarResolver, error) {
+	calendars, err := r.repository.ListCalendars(ctx)
+	if err != nil {
+		return nil, resolverError("listing calendars failed", err)
+	}
+
+	resolvers := make([]*calendarResolver, 0, len(calendars))
+	for _, calendar := range calendars {
+		resolvers = append(resolvers, &calendarResolver{calendar: calendar})
+	}
+
+	return resolvers, nil
+}
+
+type placeInput struct {
+	Name      *string
+	Address   *string
+	Latitude  *float64
+	Longitude *float64
+}
+
+type eventInput struct {
+	Title       string
+	Description *string
+	StartTime   graphql.Time
+	EndTime     graphql.Time
+	TimeZone    *string
+	Rrule       *string
+	AllDay      *bool
+	Scope       *string
+	CalendarId  *graphql.ID
+	Capacity    *int32
+	Location    *placeInput
+	Tags        *[]string
+}
+
+// event returns the event of the input, the fields left out are left empty.
+func (input eventInput) event() Event {
+	event := Event{
+		Title:     input.Title,
+		StartTime: input.StartTime.Time,
+		EndTime:   input.EndTime.Time,
+	}
+
+	event.Description = deref(input.Description)
+	event.TimeZone = deref(input.TimeZone)
+	event.RRule = deref(input.Rrule)
+	event.AllDay = deref(input.AllDay)
+	event.Scope = deref(input.Scope)
+	event.CalendarId = string(deref(input.CalendarId))
+	event.Capacity = int(deref(input.Capacity))
+	event.Tags = deref(input.Tags)
+
+	if input.Location != nil {
+		event.Location = Place{
+			Name:      deref(input.Location.Name),
+			Address:   deref(input.Location.Address),
+			Latitude:  input.Location.Latitude,
+			Longitude: input.Location.Longitude,
+		}
+	}
+
+	return event
+}
+
+func deref[T any](v *T) T {
+	if v == nil {
+		var zero T
+		return zero
+	}
+
+	return *v
+}
+
+func (r *graphQLResolver) CreateEvent(ctx context.Context, args struct{ Input eventInput }) (*eventResolver, error) {
+	event := args.Input.event()
+
+	// Events created in a calendar default to its time zone
+	err := applyCalendar(ctx, r.repository.GetCalendar, &event)
+	if err != nil {
+		return nil, resolverError("getting calendar failed", err)
+	}
+
+	err = ValidateEvent(event)
+	if err != nil {
+		return nil, badUserInput(err)
+	}
+
+	savedEvent, err := r.repository.SaveEvent(ctx, &event)
+	if err != nil {
+		return nil, resolverError("saving event failed", err)
+	}
+
+	return &eventResolver{event: *savedEvent, loaders: r.loaders(ctx)}, nil
+}
+
+func (r *graphQLResolver) UpdateEvent(ctx context.Context, args struct {
+	ID      graphql.ID
+	Version int32
+	Input   eventInput
+},
+) (*eventResolver, error) {
+	event := args.Input.event()
+
+	err := ValidateEvent(event)
+	if err != nil {
+		return nil, badUserInput(err)
+	}
+
+	event.Id = string(args.ID)
+
+	updatedEvent, err := r.repository.UpdateEvent(ctx, &event, int(args.Version))
+	if err != nil {
+		return nil, resolverError("updating event failed", err)
+	}
+
+	return &eventResolver{event: *updatedEvent, loaders: r.loaders(ctx)}, nil
+}
+
+// EventChanged subscribes to the changes of the topics until the client leaves, falls too far behind or the stream is
+// drained; clients subscribe again then.
+func (r *graphQLResolver) EventChanged(ctx context.Context, args struct{ Topics *[]string }) (<-chan *changeResolver, error) {
+	if r.stream == nil {
+		return nil, &graphQLError{message: ErrStreamClosed.Error(), code: "UNAVAILABLE"}
+	}
+
+	topics := make([]Topic, 0)
+
+	for _, name := range deref(args.Topics) {
+		topic, err := ParseTopic(name)
+		if err != nil {
+			return nil, badUserInput(err)
+		}
+
+		topics = append(topics, topic)
+	}
+
+	if len(topics) > MaxTopics {
+		return nil, badUserInput(errors.New("too many topics"))
+	}
+
+	sub, err := r.stream.Subscribe(nil)
+	if err != nil {
+		return nil, &graphQLError{message: err.Error(), code: "UNAVAILABLE"}
+	}
+
+	changes := make(chan *changeResolver)
+
+	go func() {
+		defer close(changes)
+		defer r.stream.Unsubscribe(sub)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case change, ok := <-sub.Changes():
+				if !ok {
+					return
+				}
+
+				if change.Type != StreamReset && !matchesAny(topics, change) {
+					continue
+				}
+
+				select {
+				case changes <- &changeResolver{change: change, resolver: r}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return changes, nil
+}
+
+// matchesAny returns whether the change is of any of the topics, every change is without topics.
+func matchesAny(topics []Topic, change Change) bool {
+	if len(topics) == 0 {
+		return true
+	}
+
+	for _, topic := range topics {
+		if topic.Matches(change) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type eventPageResolver struct {
+	page    EventPage
+	loaders *Loaders
+}
+
+func (r *eventPageResolver) Events() []*eventResolver {
+	resolvers := make([]*eventResolver, 0, len(r.page.Events))
+	for _, event := range r.page.Events {
+		resolvers = append(resolvers, &eventResolver{event: event, loaders: r.loaders})
+	}
+
+	return resolvers
+}
+
+func (r *eventPageResolver) Next() *string {
+	if r.page.Next == "" {
+		return nil
+	}
+
+	return &r.page.Next
+}
+
+func (r *eventPageResolver) Prev() *string {
+	if r.page.Prev == "" {
+		return nil
+	}
+
+	return &r.page.Prev
+}
+
+type eventResolver struct {
+	event   Event
+	loaders *Loaders
+}
+
+func (r *eventResolver) ID() graphql.ID          { return graphql.ID(r.event.Id) }
+func (r *eventResolver) Title() string           { return r.event.Title }
+func (r *eventResolver) Description() string     { return r.event.Description }
+func (r *eventResolver) StartTime() graphql.Time { return graphql.Time{Time: r.event.StartTime} }
+func (r *eventResolver) EndTime() graphql.Time   { return graphql.Time{Time: r.event.EndTime} }
+func (r *eventResolver) TimeZone() string        { return r.event.TimeZone }
+func (r *eventResolver) Rrule() string           { return r.event.RRule }
+func (r *eventResolver) AllDay() bool            { return r.event.AllDay }
+func (r *eventResolver) Scope() string           { return r.event.Scope }
+func (r *eventResolver) Capacity() int32         { return int32(r.event.Capacity) } //nolint:gosec
+func (r *eventResolver) Version() int32          { return int32(r.event.Version) }  //nolint:gosec
+func (r *eventResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.event.CreatedAt} }
+func (r *eventResolver) UpdatedAt() graphql.Time { return graphql.Time{Time: r.event.UpdatedAt} }
+func (r *eventResolver) Tags() []string          { return append([]string{}, r.event.Tags...) }
+
+func (r *eventResolver) Location() *placeResolver {
+	if r.event.Location == (Place{}) {
+		return nil
+	}
+
+	return &placeResolver{place: r.event.Location}
+}
+
+// Calendar is loaded along with those of the other events resolved at the same time.
+func (r *eventResolver) Calendar(ctx context.Context) (*calendarResolver, error) {
+	if r.event.CalendarId == "" {
+		return nil, nil
+	}
+
+	calendar, err := r.loaders.Calendars.Load(ctx, r.event.CalendarId)
+	if err != nil {
+		return nil, resolverError("getting calendar failed", err)
+	}
+
+	if calendar == nil {
+		return nil, nil
+	}
+
+	return &calendarResolver{calendar: *calendar}, nil
+}
+
+// Attendees are loaded along with those of the other events resolved at the same time.
+func (r *eventResolver) Attendees(ctx context.Context) ([]*attendeeResolver, error) {
+	attendees, err := r.loaders.Attendees.Load(ctx, r.event.Id)
+	if err != nil {
+		return nil, resolverError("listing attendees failed", err)
+	}
+
+	resolvers := make([]*attendeeResolver, 0, len(attendees))
+	for _, attendee := range attendees {
+		resolvers = append(resolvers, &attendeeResolver{attendee: attendee})
+	}
+
+	return resolvers, nil
+}
+
+type placeResolver struct {
+	place Place
+}
+
+func (r *placeResolver) Name() string        { return r.place.Name }
+func (r *placeResolver) Address() string     { return r.place.Address }
+func (r *placeResolver) Latitude() *float64  { return r.place.Latitude }
+func (r *placeResolver) Longitude() *float64 { return r.place.Longitude }
+
+type calendarResolver struct {
+	calendar Calendar
+}
+
+func (r *calendarResolver) ID() graphql.ID      { return graphql.ID(r.calendar.Id) }
+func (r *calendarResolver) Name() string        { return r.calendar.Name }
+func (r *calendarResolver) Description() string { return r.calendar.Description }
+func (r *calendarResolver) TimeZone() string    { return r.calendar.TimeZone }
+func (r *calendarResolver) Color() string       { return r.calendar.Color }
+
+type attendeeResolver struct {
+	attendee Attendee
+}
+
+func (r *attendeeResolver) ID() graphql.ID { return graphql.ID(r.attendee.Id) }
+func (r *attendeeResolver) Email() string  { return r.attendee.Email }
+func (r *attendeeResolver) Name() string   { return r.attendee.Name }
+func (r *attendeeResolver) Status() string { return r.attendee.Status }
+
+func (r *attendeeResolver) RespondedAt() *graphql.Time {
+	if r.attendee.RespondedAt == nil {
+		return nil
+	}
+
+	return &graphql.Time{Time: *r.attendee.RespondedAt}
+}
+
+type changeResolver struct {
+	change   Change
+	resolver *graphQLResolver
+}
+
+func (r *changeResolver) ID() graphql.ID      { return graphql.ID(strconv.FormatInt(r.change.Id, 10)) }
+func (r *changeResolver) Type() string        { return r.change.Type }
+func (r *changeResolver) EventId() graphql.ID { return graphql.ID(r.change.EventId) }
+func (r *changeResolver) Title() string       { return r.change.Title }
+func (r *changeResolver) StartTime() *graphql.Time {
+	return optionalTime(r.change.StartTime)
+}
+
+func (r *changeResolver) SeriesEnd() *graphql.Time {
+	if r.change.SeriesEnd == nil {
+		return nil
+	}
+
+	return optionalTime(*r.change.SeriesEnd)
+}
+
+func (r *changeResolver) CreatedAt() *graphql.Time {
+	return optionalTime(r.change.CreatedAt)
+}
+
+// Event fetches the event as it is now, changes only describe it.
+func (r *changeResolver) Event(ctx context.Context) (*eventResolver, error) {
+	if r.change.Type == StreamReset {
+		return nil, nil
+	}
+
+	return r.resolver.Event(ctx, struct{ ID graphql.ID }{ID: graphql.ID(r.change.EventId)})
+}
+
+// optionalTime returns nil for the zero time, a StreamReset describes no event.
+func optionalTime(t time.Time) *graphql.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &graphql.Time{Time: t}
+}
@@ -0,0 +1,546 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// graphQLResult is the response to a GraphQL request, errors by their message and their code.
+type graphQLResult struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func postGraphQL(t *testing.T, repo RepositoryInterface, req GraphQLRequest) (int, graphQLResult) {
+	t.Helper()
+
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewBuffer(body))
+
+	NewGraphQL(repo, nil).Serve(c)
+
+	var result graphQLResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+
+	return w.Code, result
+}
+
+func TestGraphQL_Serve(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 12, 22, 9, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	tests := []struct {
+		name           string
+		req            GraphQLRequest
+		setupMock      func(*MockRepository)
+		expectedStatus int
+		wantData       string
+		wantCode       string
+	}{
+		{
+			name: "event",
+			req:  GraphQLRequest{Query: `{ event(id: "uuid-1") { id title startTime tags } }`},
+			setupMock: func(m *MockRepository) {
+				m.On("GetEventById", mock.Anything, "uuid-1").Return(&Event{Id: "uuid-1", Title: "Standup", StartTime: start, Tags: []string{"team"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			wantData:       `{"event":{"id":"uuid-1","title":"Standup","startTime":"2025-12-22T09:00:00Z","tags":["team"]}}`,
+		},
+		{
+			name: "event not found",
+			req:  GraphQLRequest{Query: `{ event(id: "uuid-2") { id } }`},
+			setupMock: func(m *MockRepository) {
+				m.On("GetEventById", mock.Anything, "uuid-2").Return(nil, ErrEventNotFound)
+			},
+			expectedStatus: http.StatusOK,
+			wantData:       `{"event":null}`,
+		},
+		{
+			name: "events filtered",
+			req: GraphQLRequest{
+				Query:     `query Events($first: Int) { events(filter: {titlePrefix: " stand ", tags: ["Team"], tagMode: ALL}, first: $first) { events { id } next prev } }`,
+				Variables: map[string]any{"first": 500},
+			},
+			setupMock: func(m *MockRepository) {
+				m.On("ListEvents", mock.Anything, EventFilter{
+					Limit:       MaxPageLimit,
+					TitlePrefix: "stand",
+					Tags:        []string{"team"},
+					TagMode:     TagModeAll,
+				}).Return(&EventPage{Events: []Event{{Id: "uuid-1"}}, Next: "next"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			wantData:       `{"events":{"events":[{"id":"uuid-1"}],"next":"next","prev":null}}`,
+		},
+		{
+			name:           "events with invalid cursor",
+			req:            GraphQLRequest{Query: `{ events(cursor: "nope") { next } }`},
+			expectedStatus: http.StatusOK,
+			wantCode:       "BAD_USER_INPUT",
+		},
+		{
+			name: "create event",
+			req: GraphQLRequest{
+				Query: `mutation { createEvent(input: {title: "Standup", startTime: "2025-12-22T09:00:00Z", endTime: "2025-12-22T10:00:00Z"}) { id version } }`,
+			},
+			setupMock: func(m *MockRepository) {
+				m.On("SaveEvent", mock.Anything, mock.MatchedBy(func(event *Event) bool {
+					return event.Title == "Standup" && event.StartTime.Equal(start) && event.EndTime.Equal(end)
+				})).Return(&Event{Id: "uuid-1", Title: "Standup", StartTime: start, EndTime: end, Version: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			wantData:       `{"createEvent":{"id":"uuid-1","version":1}}`,
+		},
+		{
+			name: "create invalid event",
+			req: GraphQLRequest{
+				Query: `mutation { createEvent(input: {title: "", startTime: "2025-12-22T09:00:00Z", endTime: "2025-12-22T10:00:00Z"}) { id } }`,
+			},
+			expectedStatus: http.StatusOK,
+			wantCode:       "BAD_USER_INPUT",
+		},
+		{
+			name: "update event with stale version",
+			req: GraphQLRequest{
+				Query: `mutation { updateEvent(id: "uuid-1", version: 1, input: {title: "Standup", startTime: "2025-12-22T09:00:00Z", endTime: "2025-12-22T10:00:00Z"}) { id } }`,
+			},
+			setupMock: func(m *MockRepository) {
+				m.On("UpdateEvent", mock.Anything, mock.MatchedBy(func(event *Event) bool { return event.Id == "uuid-1" }), 1).Return(nil, ErrVersionMismatch)
+			},
+			expectedStatus: http.StatusOK,
+			wantCode:       "PRECONDITION_FAILED",
+		},
+		{
+			name: "database failure",
+			req:  GraphQLRequest{Query: `{ calendars { id } }`},
+			setupMock: func(m *MockRepository) {
+				m.On("ListCalendars", mock.Anything).Return(nil, errors.New("db error"))
+			},
+			expectedStatus: http.StatusOK,
+			wantCode:       "INTERNAL",
+		},
+		{
+			name:           "too complex",
+			req:            GraphQLRequest{Query: `{ events(first: 200) { events { id title description startTime endTime timeZone rrule allDay scope tags calendar { id name } attendees { id email } } } }`},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "introspection too deep",
+			req:            GraphQLRequest{Query: `{ __schema { types { fields { type { ` + strings.Repeat("ofType { ", 12) + `name` + strings.Repeat(" }", 12) + ` } } } } }`},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid query",
+			req:            GraphQLRequest{Query: `{ nope }`},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "several operations without a name",
+			req:            GraphQLRequest{Query: `query A { calendars { id } } query B { calendars { name } }`},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown operation",
+			req:            GraphQLRequest{Query: `query A { calendars { id } }`, OperationName: "B"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "fragments",
+			req:  GraphQLRequest{Query: `{ ...Page } fragment Page on Query { events { events { ... on Event { id } } } }`},
+			setupMock: func(m *MockRepository) {
+				m.On("ListEvents", mock.Anything, EventFilter{Limit: DefaultPageLimit}).Return(&EventPage{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			wantData:       `{"events":{"events":[]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := new(MockRepository)
+			if tt.setupMock != nil {
+				tt.setupMock(repo)
+			}
+
+			status, result := postGraphQL(t, repo, tt.req)
+			assert.Equal(t, tt.expectedStatus, status)
+
+			if tt.wantData != "" {
+				assert.JSONEq(t, tt.wantData, string(result.Data))
+				assert.Empty(t, result.Errors)
+			}
+
+			if tt.wantCode != "" {
+				require.Len(t, result.Errors, 1)
+				assert.Equal(t, tt.wantCode, result.Errors[0].Extensions["code"])
+			}
+
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGraphQL_Serve_batchesNestedFields(t *testing.T) {
+	t.Parallel()
+
+	events := []Event{{Id: "uuid-1", CalendarId: "cal-1"}, {Id: "uuid-2", CalendarId: "cal-2"}, {Id: "uuid-3", CalendarId: "cal-1"}, {Id: "uuid-4"}}
+
+	repo := new(MockRepository)
+	repo.On("ListEvents", mock.Anything, EventFilter{Limit: DefaultPageLimit}).Return(&EventPage{Events: events}, nil)
+	repo.On("GetCalendars", mock.Anything, mock.MatchedBy(func(ids []string) bool {
+		return assert.ElementsMatch(t, []string{"cal-1", "cal-2"}, ids)
+	})).Return([]Calendar{{Id: "cal-1", Name: "Work"}, {Id: "cal-2", Name: "Home"}}, nil).Once()
+	repo.On("ListAttendeesByEvents", mock.Anything, mock.MatchedBy(func(ids []string) bool {
+		return assert.ElementsMatch(t, []string{"uuid-1", "uuid-2", "uuid-3", "uuid-4"}, ids)
+	})).Return([]Attendee{{Id: "att-1", EventId: "uuid-2", Email: "ada@example.com"}}, nil).Once()
+
+	status, result := postGraphQL(t, repo, GraphQLRequest{Query: `{ events { events { id calendar { name } attendees { email } } } }`})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, result.Errors)
+	assert.JSONEq(t, `{"events":{"events":[
+		{"id":"uuid-1","calendar":{"name":"Work"},"attendees":[]},
+		{"id":"uuid-2","calendar":{"name":"Home"},"attendees":[{"email":"ada@example.com"}]},
+		{"id":"uuid-3","calendar":{"name":"Work"},"attendees":[]},
+		{"id":"uuid-4","calendar":null,"attendees":[]}
+	]}}`, string(result.Data))
+
+	// A single query per field however many events the page has
+	repo.AssertExpectations(t)
+}
+
+func TestGraphQL_Serve_batchesFullPage(t *testing.T) {
+	t.Parallel()
+
+	events := make([]Event, DefaultPageLimit)
+	calendars := make([]Calendar, DefaultPageLimit)
+	calendarIds := make([]string, DefaultPageLimit)
+
+	for i := range events {
+		calendarIds[i] = fmt.Sprintf("cal-%d", i)
+		calendars[i] = Calendar{Id: calendarIds[i]}
+		events[i] = Event{Id: fmt.Sprintf("uuid-%d", i), CalendarId: calendarIds[i]}
+	}
+
+	repo := new(MockRepository)
+	repo.On("ListEvents", mock.Anything, EventFilter{Limit: DefaultPageLimit}).Return(&EventPage{Events: events}, nil)
+	repo.On("GetCalendars", mock.Anything, mock.MatchedBy(func(ids []string) bool {
+		return assert.ElementsMatch(t, calendarIds, ids)
+	})).Return(calendars, nil).Once()
+
+	status, result := postGraphQL(t, repo, GraphQLRequest{Query: `{ events { events { id calendar { id } } } }`})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, result.Errors)
+
+	// The calendars of every event of the page are loaded at once, in a single batch
+	repo.AssertExpectations(t)
+}
+
+func TestGraphQL_checkLimits(t *testing.T) {
+	t.Parallel()
+
+	g := NewGraphQL(new(MockRepository), nil)
+
+	require.NoError(t, g.checkLimits(GraphQLRequest{Query: `{ events { next } }`}))
+	require.ErrorContains(t, g.checkLimits(GraphQLRequest{Query: `{ events(first: 200) { events { id title description startTime endTime timeZone rrule allDay scope tags calendar { id name } attendees { id email } } } }`}), "too complex")
+
+	// Queries the schema runs are measured even when the limits do not know them
+	g.limits = gqlparser.MustLoadSchema(&ast.Source{Input: `type Query { calendars: [String] }`})
+
+	require.ErrorContains(t, g.checkLimits(GraphQLRequest{Query: `{ events { next } }`}), "cannot be measured")
+	require.NoError(t, g.checkLimits(GraphQLRequest{Query: `{ nope }`}))
+}
+
+func TestMeasure(t *testing.T) {
+	t.Parallel()
+
+	// Nodes nest, unlike the types of events, for queries to be as deep as they like
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: `
+		type Query { node: Node }
+		type Node { id: ID, child: Node, children(first: Int): [Node] }
+	`})
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		wantDepth int
+		// wantIntrospectionDepth is how deep the paths through introspection fields are
+		wantIntrospectionDepth int
+		wantComplexity         int
+	}{
+		{
+			name:           "fields",
+			query:          `{ node { id child { id } } }`,
+			wantDepth:      3,
+			wantComplexity: 4,
+		},
+		{
+			name:           "page size",
+			query:          `{ node { children(first: 10) { id child { id } } } }`,
+			wantDepth:      4,
+			wantComplexity: 32,
+		},
+		{
+			name:           "page size of a variable",
+			query:          `query Q($first: Int) { node { children(first: $first) { id } } }`,
+			variables:      map[string]any{"first": float64(1000)},
+			wantDepth:      3,
+			wantComplexity: 2 + MaxPageLimit,
+		},
+		{
+			name:           "default page size",
+			query:          `{ node { children { id } } }`,
+			wantDepth:      3,
+			wantComplexity: 2 + DefaultPageLimit,
+		},
+		{
+			name:           "fragments",
+			query:          `{ node { ...F ... on Node { child { id } } } } fragment F on Node { id }`,
+			wantDepth:      3,
+			wantComplexity: 4,
+		},
+		{
+			name:                   "introspection",
+			query:                  `{ __schema { types { fields { type { ofType { ofType { ofType { name } } } } } } } node { id } }`,
+			wantDepth:              2,
+			wantIntrospectionDepth: 8,
+			wantComplexity:         10,
+		},
+		{
+			name:                   "typename",
+			query:                  `{ node { __typename child { id } } }`,
+			wantDepth:              3,
+			wantIntrospectionDepth: 2,
+			wantComplexity:         4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc, errs := gqlparser.LoadQuery(schema, tt.query)
+			require.Empty(t, errs)
+
+			depth, introspectionDepth, complexity := measure(doc.Operations[0].SelectionSet, tt.variables, false)
+			assert.Equal(t, tt.wantDepth, depth)
+			assert.Equal(t, tt.wantIntrospectionDepth, introspectionDepth)
+			assert.Equal(t, tt.wantComplexity, complexity)
+		})
+	}
+}
+
+// dialGraphQL connects to /graphql over WebSocket with the protocols, and returns the connection.
+func dialGraphQL(t *testing.T, repo RepositoryInterface, stream *ChangeStream, protocols ...string) *websocket.Conn {
+	t.Helper()
+
+	router := gin.New()
+	router.GET("/graphql", NewGraphQL(repo, stream).Serve)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	dialer := websocket.Dialer{Subprotocols: protocols}
+
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/graphql", nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	return conn
+}
+
+func send(t *testing.T, conn *websocket.Conn, message gqlMessage) {
+	t.Helper()
+
+	require.NoError(t, conn.WriteJSON(message))
+}
+
+func receive(t *testing.T, conn *websocket.Conn) gqlMessage {
+	t.Helper()
+
+	var message gqlMessage
+	require.NoError(t, conn.ReadJSON(&message))
+
+	return message
+}
+
+// closeCode reads until the server closes the connection, and returns why it did.
+func closeCode(t *testing.T, conn *websocket.Conn) int {
+	t.Helper()
+
+	for {
+		_, _, err := conn.ReadMessage()
+
+		var closeErr *websocket.CloseError
+		if errors.As(err, &closeErr) {
+			return closeErr.Code
+		}
+
+		require.NoError(t, err)
+	}
+}
+
+func subscribeMessage(id string, query string) gqlMessage {
+	return gqlMessage{Id: id, Type: gqlSubscribe, Payload: marshal(GraphQLRequest{Query: query})}
+}
+
+func TestGraphQL_ServeWebSocket(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sends the changes of the topics subscribed to", func(t *testing.T) {
+		t.Parallel()
+
+		repo := new(MockRepository)
+		repo.On("GetEventById", mock.Anything, "uuid-1").Return(&Event{Id: "uuid-1", Title: "Review"}, nil)
+
+		stream := NewChangeStream(nil, time.Second)
+		conn := dialGraphQL(t, repo, stream, gqlSubprotocol)
+
+		send(t, conn, gqlMessage{Type: gqlConnectionInit})
+		assert.Equal(t, gqlConnectionAck, receive(t, conn).Type)
+
+		send(t, conn, gqlMessage{Type: gqlPing})
+		assert.Equal(t, gqlPong, receive(t, conn).Type)
+
+		send(t, conn, subscribeMessage("1", `subscription { eventChanged(topics: ["event:uuid-1"]) { type eventId event { title } } }`))
+		require.Eventually(t, func() bool { return stream.Subscribers() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+		stream.publish(Change{Id: 1, Type: EventUpdated, EventId: "uuid-2"})
+		stream.publish(Change{Id: 2, Type: EventUpdated, EventId: "uuid-1"})
+
+		message := receive(t, conn)
+		assert.Equal(t, gqlMessage{Id: "1", Type: gqlNext, Payload: message.Payload}, message)
+		assert.JSONEq(t, `{"data":{"eventChanged":{"type":"event.updated","eventId":"uuid-1","event":{"title":"Review"}}}}`, string(message.Payload))
+
+		// Completing the subscription unsubscribes from the stream, the server does not complete it again
+		send(t, conn, gqlMessage{Id: "1", Type: gqlComplete})
+		require.Eventually(t, func() bool { return stream.Subscribers() == 0 }, 5*time.Second, 10*time.Millisecond)
+
+		send(t, conn, gqlMessage{Type: gqlPing})
+		assert.Equal(t, gqlPong, receive(t, conn).Type)
+	})
+
+	t.Run("runs queries", func(t *testing.T) {
+		t.Parallel()
+
+		repo := new(MockRepository)
+		repo.On("ListCalendars", mock.Anything).Return([]Calendar{{Id: "cal-1"}}, nil)
+
+		conn := dialGraphQL(t, repo, nil, gqlSubprotocol)
+
+		send(t, conn, gqlMessage{Type: gqlConnectionInit})
+		assert.Equal(t, gqlConnectionAck, receive(t, conn).Type)
+
+		send(t, conn, subscribeMessage("1", `{ calendars { id } }`))
+
+		message := receive(t, conn)
+		assert.Equal(t, gqlNext, message.Type)
+		assert.JSONEq(t, `{"data":{"calendars":[{"id":"cal-1"}]}}`, string(message.Payload))
+		assert.Equal(t, gqlMessage{Id: "1", Type: gqlComplete}, receive(t, conn))
+
+		// Invalid operations are answered with their errors
+		send(t, conn, subscribeMessage("2", `{ nope }`))
+
+		message = receive(t, conn)
+		assert.Equal(t, gqlError, message.Type)
+		assert.Equal(t, "2", message.Id)
+	})
+
+	t.Run("subscription unavailable", func(t *testing.T) {
+		t.Parallel()
+
+		conn := dialGraphQL(t, new(MockRepository), nil, gqlSubprotocol)
+
+		send(t, conn, gqlMessage{Type: gqlConnectionInit})
+		assert.Equal(t, gqlConnectionAck, receive(t, conn).Type)
+
+		send(t, conn, subscribeMessage("1", `subscription { eventChanged { id } }`))
+
+		message := receive(t, conn)
+		assert.Equal(t, gqlNext, message.Type)
+		assert.JSONEq(t, `{"errors":[{"message":"change stream closed"}]}`, string(message.Payload))
+		assert.Equal(t, gqlComplete, receive(t, conn).Type)
+	})
+
+	tests := []struct {
+		name      string
+		protocols []string
+		messages  []gqlMessage
+		wantCode  int
+	}{
+		{
+			name:     "subprotocol missing",
+			wantCode: gqlCloseNotAcceptable,
+		},
+		{
+			name:      "subscribe before init",
+			protocols: []string{gqlSubprotocol},
+			messages:  []gqlMessage{subscribeMessage("1", `{ calendars { id } }`)},
+			wantCode:  gqlCloseUnauthorized,
+		},
+		{
+			name:      "init twice",
+			protocols: []string{gqlSubprotocol},
+			messages:  []gqlMessage{{Type: gqlConnectionInit}, {Type: gqlConnectionInit}},
+			wantCode:  gqlCloseTooManyInitialise,
+		},
+		{
+			name:      "subscriber exists",
+			protocols: []string{gqlSubprotocol},
+			messages: []gqlMessage{
+				{Type: gqlConnectionInit},
+				subscribeMessage("1", `subscription { eventChanged { id } }`),
+				subscribeMessage("1", `subscription { eventChanged { id } }`),
+			},
+			wantCode: gqlCloseSubscriberExists,
+		},
+		{
+			name:      "invalid message",
+			protocols: []string{gqlSubprotocol},
+			messages:  []gqlMessage{{Type: "nope"}},
+			wantCode:  gqlCloseInvalidMessage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			conn := dialGraphQL(t, new(MockRepository), NewChangeStream(nil, time.Second), tt.protocols...)
+
+			for _, message := range tt.messages {
+				send(t, conn, message)
+			}
+
+			assert.Equal(t, tt.wantCode, closeCode(t, conn))
+		})
+	}
+}
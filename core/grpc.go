@@ -0,0 +1,296 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	eventv1 "ltk-code-challenge/api/event/v1"
+)
+
+// EventServer serves the events over gRPC, see api/event/v1/event.proto. Events are validated and stored as they are
+// through the REST API.
+type EventServer struct {
+	eventv1.UnimplementedEventServiceServer
+
+	repository RepositoryInterface
+	stream     *ChangeStream
+}
+
+// NewEventServer streams the changes of events from stream, StreamEvents is unavailable without it.
+func NewEventServer(repository RepositoryInterface, stream *ChangeStream) *EventServer {
+	return &EventServer{repository: repository, stream: stream}
+}
+
+func (s *EventServer) CreateEvent(ctx context.Context, req *eventv1.CreateEventRequest) (*eventv1.CreateEventResponse, error) {
+	if req.GetEvent() == nil {
+		return nil, status.Error(codes.InvalidArgument, "event is required")
+	}
+
+	event := eventFromProto(req.GetEvent())
+
+	// Events created in a calendar default to its time zone
+	err := applyCalendar(ctx, s.repository.GetCalendar, &event)
+	if errors.Is(err, ErrCalendarNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	if err != nil {
+		log.Err(err).Msg("getting calendar failed")
+		return nil, status.Errorf(codes.Internal, "getting calendar failed: %v", err)
+	}
+
+	err = ValidateEvent(event)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "event validation failed: %v", err)
+	}
+
+	savedEvent, err := s.repository.SaveEvent(ctx, &event)
+	if errors.Is(err, ErrEventConflict) {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	if err != nil {
+		log.Err(err).Msg("saving event failed")
+		return nil, status.Errorf(codes.Internal, "saving event failed: %v", err)
+	}
+
+	return &eventv1.CreateEventResponse{Event: eventToProto(*savedEvent)}, nil
+}
+
+func (s *EventServer) GetEvent(ctx context.Context, req *eventv1.GetEventRequest) (*eventv1.GetEventResponse, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	event, err := s.repository.GetEventById(ctx, req.GetId())
+	if errors.Is(err, ErrEventNotFound) {
+		return nil, status.Error(codes.NotFound, "event not found")
+	}
+
+	if err != nil {
+		log.Err(err).Msg("getting event failed")
+		return nil, status.Errorf(codes.Internal, "getting event failed: %v", err)
+	}
+
+	return &eventv1.GetEventResponse{Event: eventToProto(*event)}, nil
+}
+
+func (s *EventServer) ListEvents(ctx context.Context, req *eventv1.ListEventsRequest) (*eventv1.ListEventsResponse, error) {
+	filter, err := listEventsFilter(req)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+	}
+
+	page, err := s.repository.ListEvents(ctx, filter)
+	if err != nil {
+		log.Err(err).Msg("listing events failed")
+		return nil, status.Errorf(codes.Internal, "listing events failed: %v", err)
+	}
+
+	resp := &eventv1.ListEventsResponse{
+		Events:        make([]*eventv1.Event, 0, len(page.Events)),
+		NextPageToken: page.Next,
+		PrevPageToken: page.Prev,
+	}
+
+	for _, event := range page.Events {
+		resp.Events = append(resp.Events, eventToProto(event))
+	}
+
+	return resp, nil
+}
+
+// listEventsFilter checks the request like ParseEventFilter checks the query of GET /events.
+func listEventsFilter(req *eventv1.ListEventsRequest) (EventFilter, error) {
+	var err error
+
+	filter := EventFilter{Limit: DefaultPageLimit}
+
+	switch {
+	case req.GetPageSize() < 0:
+		return filter, errors.New("page_size must not be negative")
+	case req.GetPageSize() > 0:
+		filter.Limit = min(int(req.GetPageSize()), MaxPageLimit)
+	}
+
+	if req.GetPageToken() != "" {
+		filter.Cursor, err = DecodeCursor(req.GetPageToken())
+		if err != nil {
+			return filter, err
+		}
+	}
+
+	filter.StartsAfter = timeFromProto(req.GetStartsAfter())
+	filter.EndsBefore = timeFromProto(req.GetEndsBefore())
+
+	filter.TitlePrefix = strings.TrimSpace(req.GetTitlePrefix())
+	if utf8.RuneCountInString(filter.TitlePrefix) > 100 {
+		return filter, errors.New("title_prefix is too long (100 characters tops)")
+	}
+
+	filter.CalendarId = req.GetCalendarId()
+	if filter.CalendarId != "" && !IsUUID(filter.CalendarId) {
+		return filter, errors.New("calendar_id must be a UUID")
+	}
+
+	if len(req.GetTags()) > 0 {
+		filter.Tags, filter.TagMode, err = ParseTags(strings.Join(req.GetTags(), ","), req.GetTagMode())
+		if err != nil {
+			return filter, err
+		}
+	} else if req.GetTagMode() != "" {
+		return filter, errors.New("tag_mode requires tags")
+	}
+
+	return filter, nil
+}
+
+// StreamEvents streams the changes until the client leaves or the stream is drained, clients then resume it with the
+// id of the last change they got.
+func (s *EventServer) StreamEvents(req *eventv1.StreamEventsRequest, srv grpc.ServerStreamingServer[eventv1.StreamEventsResponse]) error {
+	if s.stream == nil {
+		return status.Error(codes.Unavailable, ErrStreamClosed.Error())
+	}
+
+	sub, err := s.stream.Subscribe(req.LastId)
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	defer s.stream.Unsubscribe(sub)
+
+	for {
+		select {
+		case <-srv.Context().Done():
+			return nil
+		case change, ok := <-sub.Changes():
+			if !ok {
+				return status.Error(codes.Unavailable, sub.Err().Error())
+			}
+
+			err = srv.Send(changeToProto(change))
+			if err != nil {
+				log.Err(err).Msg("streaming events failed")
+				return err //nolint:wrapcheck
+			}
+		}
+	}
+}
+
+func eventToProto(event Event) *eventv1.Event {
+	return &eventv1.Event{
+		Id:          event.Id,
+		Title:       event.Title,
+		Description: event.Description,
+		StartTime:   timestamppb.New(event.StartTime),
+		EndTime:     timestamppb.New(event.EndTime),
+		TimeZone:    event.TimeZone,
+		Rrule:       event.RRule,
+		Exdates:     timesToProto(event.ExDates),
+		Rdates:      timesToProto(event.RDates),
+		AllDay:      event.AllDay,
+		Uid:         event.Uid,
+		Scope:       event.Scope,
+		CalendarId:  event.CalendarId,
+		Capacity:    int32(event.Capacity), //nolint:gosec
+		Location: &eventv1.Place{
+			Name:      event.Location.Name,
+			Address:   event.Location.Address,
+			Latitude:  event.Location.Latitude,
+			Longitude: event.Location.Longitude,
+		},
+		Tags:       event.Tags,
+		Version:    int32(event.Version), //nolint:gosec
+		CreateTime: timestamppb.New(event.CreatedAt),
+		UpdateTime: timestamppb.New(event.UpdatedAt),
+	}
+}
+
+// eventFromProto returns the event to create, what the server sets is ignored.
+func eventFromProto(event *eventv1.Event) Event {
+	location := Place{Name: event.GetLocation().GetName(), Address: event.GetLocation().GetAddress()}
+	if event.GetLocation() != nil {
+		location.Latitude, location.Longitude = event.GetLocation().Latitude, event.GetLocation().Longitude
+	}
+
+	return Event{
+		Title:       event.GetTitle(),
+		Description: event.GetDescription(),
+		StartTime:   timeFromProto(event.GetStartTime()),
+		EndTime:     timeFromProto(event.GetEndTime()),
+		TimeZone:    event.GetTimeZone(),
+		RRule:       event.GetRrule(),
+		ExDates:     timesFromProto(event.GetExdates()),
+		RDates:      timesFromProto(event.GetRdates()),
+		AllDay:      event.GetAllDay(),
+		Uid:         event.GetUid(),
+		Scope:       event.GetScope(),
+		CalendarId:  event.GetCalendarId(),
+		Capacity:    int(event.GetCapacity()),
+		Location:    location,
+		Tags:        event.GetTags(),
+	}
+}
+
+func changeToProto(change Change) *eventv1.StreamEventsResponse {
+	resp := &eventv1.StreamEventsResponse{
+		Id:      change.Id,
+		Type:    change.Type,
+		EventId: change.EventId,
+		Title:   change.Title,
+	}
+
+	// A StreamReset describes no event
+	if !change.StartTime.IsZero() {
+		resp.StartTime = timestamppb.New(change.StartTime)
+	}
+
+	if change.SeriesEnd != nil {
+		resp.SeriesEnd = timestamppb.New(*change.SeriesEnd)
+	}
+
+	if !change.CreatedAt.IsZero() {
+		resp.CreateTime = timestamppb.New(change.CreatedAt)
+	}
+
+	return resp
+}
+
+// timeFromProto returns the zero time for unset timestamps.
+func timeFromProto(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+
+	return ts.AsTime()
+}
+
+func timesToProto(times []time.Time) []*timestamppb.Timestamp {
+	result := make([]*timestamppb.Timestamp, 0, len(times))
+	for _, t := range times {
+		result = append(result, timestamppb.New(t))
+	}
+
+	return result
+}
+
+func timesFromProto(timestamps []*timestamppb.Timestamp) []time.Time {
+	if len(timestamps) == 0 {
+		return nil
+	}
+
+	result := make([]time.Time, 0, len(timestamps))
+	for _, ts := range timestamps {
+		result = append(result, ts.AsTime())
+	}
+
+	return result
+}
@@ -0,0 +1,319 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	eventv1 "ltk-code-challenge/api/event/v1"
+)
+
+// dialEventServer serves the events from the repository and the stream in memory, and connects to it.
+func dialEventServer(t *testing.T, repo RepositoryInterface, stream *ChangeStream) eventv1.EventServiceClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+
+	server := grpc.NewServer()
+	eventv1.RegisterEventServiceServer(server, NewEventServer(repo, stream))
+
+	go func() { _ = server.Serve(lis) }()
+
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return eventv1.NewEventServiceClient(conn)
+}
+
+func TestEventServer_CreateEvent(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 12, 22, 9, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		event     *eventv1.Event
+		setupMock func(*MockRepository)
+		wantCode  codes.Code
+	}{
+		{
+			name:  "created",
+			event: &eventv1.Event{Title: "Standup", StartTime: timestamppb.New(start), EndTime: timestamppb.New(end), Tags: []string{"team"}},
+			setupMock: func(m *MockRepository) {
+				m.On("SaveEvent", mock.Anything, mock.MatchedBy(func(event *Event) bool {
+					return event.Title == "Standup" && event.StartTime.Equal(start) && event.EndTime.Equal(end)
+				})).Return(&Event{Id: "uuid-1", Title: "Standup", StartTime: start, EndTime: end, Version: 1, Tags: []string{"team"}}, nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "missing event",
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "invalid event",
+			event:    &eventv1.Event{StartTime: timestamppb.New(start), EndTime: timestamppb.New(end)},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:  "calendar not found",
+			event: &eventv1.Event{Title: "Standup", StartTime: timestamppb.New(start), EndTime: timestamppb.New(end), CalendarId: "cal-1"},
+			setupMock: func(m *MockRepository) {
+				m.On("GetCalendar", mock.Anything, "cal-1").Return(nil, ErrCalendarNotFound)
+			},
+			wantCode: codes.NotFound,
+		},
+		{
+			name:  "conflict",
+			event: &eventv1.Event{Title: "Standup", StartTime: timestamppb.New(start), EndTime: timestamppb.New(end), Scope: "room-1"},
+			setupMock: func(m *MockRepository) {
+				m.On("SaveEvent", mock.Anything, mock.Anything).Return(nil, &ConflictError{EventIds: []string{"uuid-2"}})
+			},
+			wantCode: codes.FailedPrecondition,
+		},
+		{
+			name:  "database failure",
+			event: &eventv1.Event{Title: "Standup", StartTime: timestamppb.New(start), EndTime: timestamppb.New(end)},
+			setupMock: func(m *MockRepository) {
+				m.On("SaveEvent", mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+			},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := new(MockRepository)
+			if tt.setupMock != nil {
+				tt.setupMock(repo)
+			}
+
+			client := dialEventServer(t, repo, nil)
+
+			resp, err := client.CreateEvent(context.Background(), &eventv1.CreateEventRequest{Event: tt.event})
+			assert.Equal(t, tt.wantCode, status.Code(err))
+
+			if tt.wantCode == codes.OK {
+				assert.Equal(t, "uuid-1", resp.GetEvent().GetId())
+				assert.Equal(t, int32(1), resp.GetEvent().GetVersion())
+				assert.Equal(t, []string{"team"}, resp.GetEvent().GetTags())
+			}
+
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestEventServer_GetEvent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		id        string
+		setupMock func(*MockRepository)
+		wantCode  codes.Code
+	}{
+		{
+			name: "found",
+			id:   "uuid-1",
+			setupMock: func(m *MockRepository) {
+				m.On("GetEventById", mock.Anything, "uuid-1").Return(&Event{Id: "uuid-1", Title: "Standup"}, nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "missing id",
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "not found",
+			id:   "uuid-2",
+			setupMock: func(m *MockRepository) {
+				m.On("GetEventById", mock.Anything, "uuid-2").Return(nil, ErrEventNotFound)
+			},
+			wantCode: codes.NotFound,
+		},
+		{
+			name: "database failure",
+			id:   "uuid-3",
+			setupMock: func(m *MockRepository) {
+				m.On("GetEventById", mock.Anything, "uuid-3").Return(nil, errors.New("db error"))
+			},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := new(MockRepository)
+			if tt.setupMock != nil {
+				tt.setupMock(repo)
+			}
+
+			resp, err := dialEventServer(t, repo, nil).GetEvent(context.Background(), &eventv1.GetEventRequest{Id: tt.id})
+			assert.Equal(t, tt.wantCode, status.Code(err))
+
+			if tt.wantCode == codes.OK {
+				assert.Equal(t, "Standup", resp.GetEvent().GetTitle())
+			}
+		})
+	}
+}
+
+func TestEventServer_ListEvents(t *testing.T) {
+	t.Parallel()
+
+	after := time.Date(2025, 12, 22, 0, 0, 0, 0, time.UTC)
+	cursor := Cursor{StartTime: after, Id: "uuid-1"}
+
+	tests := []struct {
+		name       string
+		req        *eventv1.ListEventsRequest
+		wantFilter EventFilter
+		wantCode   codes.Code
+	}{
+		{
+			name:       "defaults",
+			req:        &eventv1.ListEventsRequest{},
+			wantFilter: EventFilter{Limit: DefaultPageLimit},
+		},
+		{
+			name: "filters",
+			req: &eventv1.ListEventsRequest{
+				PageSize:    500,
+				PageToken:   EncodeCursor(cursor),
+				StartsAfter: timestamppb.New(after),
+				TitlePrefix: " stand ",
+				CalendarId:  "4acc05c4-3526-4c09-8739-621c4b57c8e6",
+				Tags:        []string{"Team", "ops"},
+				TagMode:     TagModeAll,
+			},
+			wantFilter: EventFilter{
+				Limit:       MaxPageLimit,
+				Cursor:      &cursor,
+				StartsAfter: after,
+				TitlePrefix: "stand",
+				CalendarId:  "4acc05c4-3526-4c09-8739-621c4b57c8e6",
+				Tags:        []string{"ops", "team"},
+				TagMode:     TagModeAll,
+			},
+		},
+		{
+			name:     "negative page size",
+			req:      &eventv1.ListEventsRequest{PageSize: -1},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "invalid page token",
+			req:      &eventv1.ListEventsRequest{PageToken: "nope"},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "tag mode without tags",
+			req:      &eventv1.ListEventsRequest{TagMode: TagModeAny},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "calendar id not a UUID",
+			req:      &eventv1.ListEventsRequest{CalendarId: "cal-1"},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := new(MockRepository)
+			if tt.wantCode == codes.OK {
+				repo.On("ListEvents", mock.Anything, tt.wantFilter).Return(&EventPage{Events: []Event{{Id: "uuid-1"}}, Next: "next"}, nil)
+			}
+
+			resp, err := dialEventServer(t, repo, nil).ListEvents(context.Background(), tt.req)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+
+			if tt.wantCode == codes.OK {
+				require.Len(t, resp.GetEvents(), 1)
+				assert.Equal(t, "next", resp.GetNextPageToken())
+			}
+
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestEventServer_StreamEvents(t *testing.T) {
+	t.Parallel()
+
+	t.Run("streams the changes after the last one", func(t *testing.T) {
+		t.Parallel()
+
+		stream := NewChangeStream(nil, time.Second)
+		stream.publish(Change{Id: 1, Type: EventCreated, EventId: "uuid-1"})
+		stream.publish(Change{Id: 2, Type: EventUpdated, EventId: "uuid-1"})
+
+		client := dialEventServer(t, new(MockRepository), stream)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		events, err := client.StreamEvents(ctx, &eventv1.StreamEventsRequest{LastId: int64Ptr(1)})
+		require.NoError(t, err)
+
+		change, err := events.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), change.GetId())
+		assert.Equal(t, EventUpdated, change.GetType())
+
+		require.Eventually(t, func() bool { return stream.Subscribers() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+		seriesEnd := time.Date(2025, 12, 22, 10, 0, 0, 0, time.UTC)
+		stream.publish(Change{Id: 3, Type: EventDeleted, EventId: "uuid-2", SeriesEnd: &seriesEnd})
+
+		change, err = events.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, "uuid-2", change.GetEventId())
+		assert.Equal(t, seriesEnd, change.GetSeriesEnd().AsTime())
+
+		// Draining the stream ends it, the client resumes it later
+		stream.Close()
+
+		_, err = events.Recv()
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.NotErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("stream unavailable", func(t *testing.T) {
+		t.Parallel()
+
+		events, err := dialEventServer(t, new(MockRepository), nil).StreamEvents(context.Background(), &eventv1.StreamEventsRequest{})
+		require.NoError(t, err)
+
+		_, err = events.Recv()
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+}
@@ -1,10 +1,17 @@
 package core
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
@@ -12,15 +19,63 @@ import (
 
 type RepositoryInterface interface {
 	SaveEvent(ctx context.Context, event *Event) (*Event, error)
+	SaveEvents(ctx context.Context, events []Event) ([]Event, error)
 	GetEventById(ctx context.Context, id string) (*Event, error)
+	UpdateEvent(ctx context.Context, event *Event, expectedVersion int) (*Event, error)
+	DeleteEvent(ctx context.Context, id string) error
+	RestoreEvent(ctx context.Context, id string) (*Event, error)
+	PurgeEvents(ctx context.Context, deletedBefore time.Time) (int64, error)
+	ListEvents(ctx context.Context, filter EventFilter) (*EventPage, error)
+	ListTags(ctx context.Context) ([]Tag, error)
+	SearchEvents(ctx context.Context, query SearchQuery) ([]SearchResult, error)
+	ListSeries(ctx context.Context, window TimeWindow) ([]Series, error)
+	ListOverrides(ctx context.Context, eventIds []string) ([]Override, error)
+	FreeBusy(ctx context.Context, query FreeBusyQuery) ([]BusyInterval, error)
+	SaveOverride(ctx context.Context, override *Override) (*Override, error)
+	FindConflicts(ctx context.Context, event Event) ([]Event, error)
+	SaveCalendar(ctx context.Context, calendar *Calendar) (*Calendar, error)
+	GetCalendar(ctx context.Context, id string) (*Calendar, error)
+	GetCalendars(ctx context.Context, ids []string) ([]Calendar, error)
+	ListCalendars(ctx context.Context) ([]Calendar, error)
+	UpdateCalendar(ctx context.Context, calendar *Calendar) (*Calendar, error)
+	DeleteCalendar(ctx context.Context, id string, cascade bool) error
+	SaveAttendee(ctx context.Context, attendee *Attendee) (*Attendee, error)
+	ListAttendees(ctx context.Context, eventId string) ([]Attendee, error)
+	ListAttendeesByEvents(ctx context.Context, eventIds []string) ([]Attendee, error)
+	UpdateAttendeeStatus(ctx context.Context, attendee *Attendee) (*Attendee, error)
+	DeleteAttendee(ctx context.Context, eventId string, id string) error
+	SaveReminder(ctx context.Context, reminder *Reminder) (*Reminder, error)
+	ListReminders(ctx context.Context, eventId string) ([]Reminder, error)
+	DeleteReminder(ctx context.Context, eventId string, id string) error
+	ClaimReminders(ctx context.Context, now time.Time, until time.Time, limit int) ([]Reminder, error)
+	RescheduleReminder(ctx context.Context, reminder *Reminder) error
+	SaveWebhook(ctx context.Context, webhook *Webhook) (*Webhook, error)
+	GetWebhook(ctx context.Context, id string) (*Webhook, error)
+	ListWebhooks(ctx context.Context) ([]Webhook, error)
+	UpdateWebhook(ctx context.Context, webhook *Webhook) (*Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	ListDeliveries(ctx context.Context, webhookId string, status string) ([]Delivery, error)
+	RedeliverDelivery(ctx context.Context, webhookId string, id string) (*Delivery, error)
+	ClaimDeliveries(ctx context.Context, now time.Time, until time.Time, limit int) ([]PendingDelivery, error)
+	RecordDelivery(ctx context.Context, delivery *Delivery) error
+	DispatchOutbox(ctx context.Context, limit int, publish func(ctx context.Context, message OutboxMessage) error) (int, error)
+	OutboxBacklog(ctx context.Context) (int64, *time.Time, error)
+	PurgeOutbox(ctx context.Context, deliveredBefore time.Time) (int64, error)
+	Register(ctx context.Context, registration *Registration) (*Registration, error)
+	ListRegistrations(ctx context.Context, eventId string) ([]Registration, error)
+	CancelRegistration(ctx context.Context, eventId string, id string) error
+	ImportEvent(ctx context.Context, series *Series) (*Event, error)
 }
 
 type Handlers struct {
 	repository RepositoryInterface
+	stream     *ChangeStream
+	wsMetrics  *WSMetrics
 }
 
-func NewHandlers(repository RepositoryInterface) *Handlers {
-	return &Handlers{repository: repository}
+// NewHandlers serves GET /events/stream and /ws from the stream, they are unavailable without one.
+func NewHandlers(repository RepositoryInterface, stream *ChangeStream) *Handlers {
+	return &Handlers{repository: repository, stream: stream, wsMetrics: NewWSMetrics()}
 }
 
 func (h *Handlers) PostEvents(gctx *gin.Context) {
@@ -35,8 +90,21 @@ func (h *Handlers) PostEvents(gctx *gin.Context) {
 		return
 	}
 
+	h.createEvent(gctx, &event)
+}
+
+func (h *Handlers) createEvent(gctx *gin.Context, event *Event) {
+	ctx := gctx.Request.Context()
+
+	// Events created in a calendar default to its time zone
+	err := applyCalendar(ctx, h.repository.GetCalendar, event)
+	if err != nil {
+		abortWithCalendarError(gctx, err)
+		return
+	}
+
 	// Validates that title is non-empty and <= 100 characters, start_time is before end_time.
-	err = ValidateEvent(event)
+	err = ValidateEvent(*event)
 	if err != nil {
 		log.Err(err).Msg("event validation failed")
 		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("event validation failed", err))
@@ -45,9 +113,14 @@ func (h *Handlers) PostEvents(gctx *gin.Context) {
 	}
 
 	// Inserts the event into a PostgreSQL database, generating a UUID for id and setting created_at to current time.
-	ctx := gctx.Request.Context()
+	savedEvent, err := h.repository.SaveEvent(ctx, event)
+	if errors.Is(err, ErrEventConflict) {
+		log.Info().Err(err).Msg("event conflicts with another event")
+		gctx.AbortWithStatusJSON(http.StatusConflict, NewConflictResponse("event conflicts with another event", err))
+
+		return
+	}
 
-	savedEvent, err := h.repository.SaveEvent(ctx, &event)
 	if err != nil {
 		log.Err(err).Msg("saving event failed")
 		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("saving event failed", err))
@@ -56,10 +129,105 @@ func (h *Handlers) PostEvents(gctx *gin.Context) {
 	}
 
 	// Returns the created event as JSON with HTTP 201 status.
+	gctx.Header("ETag", ETag(savedEvent.Version))
 	gctx.JSON(http.StatusCreated, savedEvent)
 }
 
+// PostEventsAction serves the custom methods of the events collection (POST /events:<action>), which gin cannot
+// register next to POST /events.
+func (h *Handlers) PostEventsAction(gctx *gin.Context) {
+	switch gctx.Param("action") {
+	case ":batch":
+		h.BatchEvents(gctx)
+	case ":conflicts":
+		h.CheckConflicts(gctx)
+	default:
+		gctx.AbortWithStatusJSON(http.StatusNotFound, NewError("unknown action"))
+	}
+}
+
+func (h *Handlers) BatchEvents(gctx *gin.Context) {
+	// Checks the mode, all_or_nothing unless told otherwise
+	mode, err := ParseBatchMode(gctx.Query("mode"))
+	if err != nil {
+		log.Err(err).Msg("invalid query parameters")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("invalid query parameters", err))
+
+		return
+	}
+
+	// Accepts a JSON array of events, each one is decoded on its own so it can fail on its own
+	var items []json.RawMessage
+
+	err = gctx.ShouldBindJSON(&items)
+	if err != nil {
+		log.Err(err).Msg("failed to bind JSON")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("failed to bind JSON", err))
+
+		return
+	}
+
+	if len(items) == 0 || len(items) > MaxBatchEvents {
+		err = fmt.Errorf("a batch holds between 1 and %d events", MaxBatchEvents)
+		log.Err(err).Msg("invalid batch size")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("invalid batch size", err))
+
+		return
+	}
+
+	// Create Events: POST /events:batch, responds with the outcome of every event by its index in the request
+	ctx := gctx.Request.Context()
+
+	response := CreateEvents(ctx, h.repository, items, mode)
+
+	gctx.JSON(http.StatusMultiStatus, response)
+}
+
+func (h *Handlers) CheckConflicts(gctx *gin.Context) {
+	var event Event
+
+	// Accepts the proposed event, as for POST /events; an id excludes that event, as when moving it
+	err := gctx.ShouldBindJSON(&event)
+	if err != nil {
+		log.Err(err).Msg("failed to bind JSON")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("failed to bind JSON", err))
+
+		return
+	}
+
+	err = ValidateEvent(event)
+	if err == nil && event.Id != "" && !IsUUID(event.Id) {
+		err = errors.New("id must be a UUID")
+	}
+
+	if err != nil {
+		log.Err(err).Msg("event validation failed")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("event validation failed", err))
+
+		return
+	}
+
+	// Check Conflicts: POST /events:conflicts, nothing is saved
+	ctx := gctx.Request.Context()
+
+	conflicts, err := h.repository.FindConflicts(ctx, event)
+	if err != nil {
+		log.Err(err).Msg("finding conflicts failed")
+		gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("finding conflicts failed", err))
+
+		return
+	}
+
+	gctx.JSON(http.StatusOK, gin.H{"conflicts": conflicts})
+}
+
 func (h *Handlers) GetEvents(gctx *gin.Context) {
+	// GET /events/:id.ics shares the route, gin cannot register both
+	if strings.HasSuffix(gctx.Param("id"), ".ics") {
+		h.ExportEvent(gctx)
+		return
+	}
+
 	// Ready body
 	body, err := io.ReadAll(gctx.Request.Body)
 	if err != nil {
@@ -106,5 +274,1443 @@ func (h *Handlers) GetEvents(gctx *gin.Context) {
 	}
 
 	// Returns the event with the specified UUID
+	gctx.Header("ETag", ETag(events.Version))
 	gctx.JSON(http.StatusOK, events)
 }
+
+func (h *Handlers) PutEvents(gctx *gin.Context) {
+	// Checks that id param is there
+	id := gctx.Param("id")
+	if len(id) == 0 {
+		log.Info().Msg("parameter 'id' is required")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("parameter 'id' is required"))
+
+		return
+	}
+
+	// Checks that If-Match carries the version the client last read
+	versions, err := ParseIfMatch(gctx.GetHeader("If-Match"))
+	if err != nil {
+		abortWithPreconditionError(gctx, err)
+		return
+	}
+
+	// Accepts the full replacement of the event as a JSON payload
+	var event Event
+
+	err = gctx.ShouldBindJSON(&event)
+	if err != nil {
+		log.Err(err).Msg("failed to bind JSON")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("failed to bind JSON", err))
+
+		return
+	}
+
+	err = ValidateEvent(event)
+	if err != nil {
+		log.Err(err).Msg("event validation failed")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("event validation failed", err))
+
+		return
+	}
+
+	// Lists of versions are checked against the current one, which the update then expects
+	version := versions[0]
+	if len(versions) > 1 {
+		current, err := h.repository.GetEventById(gctx.Request.Context(), id)
+		if err != nil {
+			abortWithUpdateError(gctx, err)
+			return
+		}
+
+		if !MatchesVersion(versions, current.Version) {
+			abortWithUpdateError(gctx, ErrVersionMismatch)
+			return
+		}
+
+		version = current.Version
+	}
+
+	event.Id = id
+	h.updateEvent(gctx, &event, version)
+}
+
+func (h *Handlers) PatchEvents(gctx *gin.Context) {
+	// Checks that id param is there
+	id := gctx.Param("id")
+	if len(id) == 0 {
+		log.Info().Msg("parameter 'id' is required")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("parameter 'id' is required"))
+
+		return
+	}
+
+	// Checks that If-Match carries the version the client last read
+	versions, err := ParseIfMatch(gctx.GetHeader("If-Match"))
+	if err != nil {
+		abortWithPreconditionError(gctx, err)
+		return
+	}
+
+	// Accepts a JSON Merge Patch (RFC 7396) document
+	mediaType, _, _ := mime.ParseMediaType(gctx.ContentType())
+	if mediaType != "application/merge-patch+json" && mediaType != "application/json" {
+		log.Info().Str("content-type", gctx.ContentType()).Msg("unsupported media type")
+		gctx.AbortWithStatusJSON(http.StatusUnsupportedMediaType, NewError("content type must be application/merge-patch+json"))
+
+		return
+	}
+
+	patch, err := io.ReadAll(gctx.Request.Body)
+	if err != nil {
+		log.Err(err).Msg("failed to read request body")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("failed to read request body", err))
+
+		return
+	}
+
+	ctx := gctx.Request.Context()
+
+	current, err := h.repository.GetEventById(ctx, id)
+	if err != nil {
+		abortWithUpdateError(gctx, err)
+		return
+	}
+
+	if !MatchesVersion(versions, current.Version) {
+		abortWithUpdateError(gctx, ErrVersionMismatch)
+		return
+	}
+
+	// Merges the patch into the current representation, then re-validates the result
+	document, err := json.Marshal(current)
+	if err != nil {
+		log.Err(err).Msg("failed to encode event")
+		gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("failed to encode event", err))
+
+		return
+	}
+
+	merged, err := MergePatch(document, patch)
+	if err != nil {
+		log.Err(err).Msg("failed to apply merge patch")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("failed to apply merge patch", err))
+
+		return
+	}
+
+	// Removing a member leaves its zero value, which required members cannot take
+	err = RequireMembers(merged, "title", "start_time", "end_time")
+	if err != nil {
+		log.Info().Err(err).Msg("merge patch removes a required member")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("failed to apply merge patch", err))
+
+		return
+	}
+
+	var event Event
+
+	err = json.Unmarshal(merged, &event)
+	if err != nil {
+		log.Err(err).Msg("failed to bind JSON")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("failed to bind JSON", err))
+
+		return
+	}
+
+	err = ValidateEvent(event)
+	if err != nil {
+		log.Err(err).Msg("event validation failed")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("event validation failed", err))
+
+		return
+	}
+
+	// id, createdAt and version are managed by the server
+	event.Id, event.CreatedAt, event.Version = current.Id, current.CreatedAt, current.Version
+	h.updateEvent(gctx, &event, current.Version)
+}
+
+func (h *Handlers) updateEvent(gctx *gin.Context, event *Event, version int) {
+	ctx := gctx.Request.Context()
+
+	updatedEvent, err := h.repository.UpdateEvent(ctx, event, version)
+	if err != nil {
+		abortWithUpdateError(gctx, err)
+		return
+	}
+
+	gctx.Header("ETag", ETag(updatedEvent.Version))
+	gctx.JSON(http.StatusOK, updatedEvent)
+}
+
+func abortWithPreconditionError(gctx *gin.Context, err error) {
+	log.Info().Err(err).Msg("precondition failed")
+
+	if errors.Is(err, ErrPreconditionRequired) {
+		gctx.AbortWithStatusJSON(http.StatusPreconditionRequired, NewError("precondition required", err))
+		return
+	}
+
+	gctx.AbortWithStatusJSON(http.StatusPreconditionFailed, NewError("precondition failed", err))
+}
+
+func abortWithUpdateError(gctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrEventNotFound):
+		log.Info().Msg("event not found")
+		gctx.AbortWithStatusJSON(http.StatusNotFound, NewError("event not found", err))
+	case errors.Is(err, ErrVersionMismatch):
+		log.Info().Msg("event version mismatch")
+		gctx.AbortWithStatusJSON(http.StatusPreconditionFailed, NewError("precondition failed", err))
+	case errors.Is(err, ErrEventConflict):
+		log.Info().Err(err).Msg("event conflicts with another event")
+		gctx.AbortWithStatusJSON(http.StatusConflict, NewConflictResponse("event conflicts with another event", err))
+	case errors.Is(err, ErrCalendarNotFound):
+		log.Info().Msg("calendar not found")
+		gctx.AbortWithStatusJSON(http.StatusNotFound, NewError("calendar not found", err))
+	default:
+		log.Err(err).Msg("updating event failed")
+		gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("updating event failed", err))
+	}
+}
+
+func (h *Handlers) DeleteEvents(gctx *gin.Context) {
+	// Checks that id param is there
+	id := gctx.Param("id")
+	if len(id) == 0 {
+		log.Info().Msg("parameter 'id' is required")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("parameter 'id' is required"))
+
+		return
+	}
+
+	// Moves the event to the trash
+	ctx := gctx.Request.Context()
+
+	err := h.repository.DeleteEvent(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrEventNotFound) {
+			log.Info().Msg("event not found")
+			gctx.AbortWithStatusJSON(http.StatusNotFound, NewError("event not found", err))
+
+			return
+		}
+
+		log.Err(err).Msg("deleting event failed")
+		gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("deleting event failed", err))
+
+		return
+	}
+
+	gctx.Status(http.StatusNoContent)
+}
+
+func (h *Handlers) RestoreEvents(gctx *gin.Context) {
+	// Checks that id param is there
+	id := gctx.Param("id")
+	if len(id) == 0 {
+		log.Info().Msg("parameter 'id' is required")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("parameter 'id' is required"))
+
+		return
+	}
+
+	// Takes the event out of the trash
+	ctx := gctx.Request.Context()
+
+	restoredEvent, err := h.repository.RestoreEvent(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrEventNotFound) {
+			log.Info().Msg("event not found in trash")
+			gctx.AbortWithStatusJSON(http.StatusNotFound, NewError("event not found in trash", err))
+
+			return
+		}
+
+		if errors.Is(err, ErrEventConflict) {
+			log.Info().Err(err).Msg("event conflicts with another event")
+			gctx.AbortWithStatusJSON(http.StatusConflict, NewConflictResponse("event conflicts with another event", err))
+
+			return
+		}
+
+		log.Err(err).Msg("restoring event failed")
+		gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("restoring event failed", err))
+
+		return
+	}
+
+	gctx.Header("ETag", ETag(restoredEvent.Version))
+	gctx.JSON(http.StatusOK, restoredEvent)
+}
+
+func (h *Handlers) ListTrash(gctx *gin.Context) {
+	// Checks limit, cursor and the time-window/title filters
+	filter, err := ParseEventFilter(gctx)
+	if err != nil {
+		log.Err(err).Msg("invalid query parameters")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("invalid query parameters", err))
+
+		return
+	}
+
+	// List Trash: GET /events/trash, same ordering and paging as GET /events
+	filter.Trashed = true
+	ctx := gctx.Request.Context()
+
+	page, err := h.repository.ListEvents(ctx, filter)
+	if err != nil {
+		log.Err(err).Msg("listing trash failed")
+		gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("listing trash failed", err))
+
+		return
+	}
+
+	gctx.JSON(http.StatusOK, page)
+}
+
+func (h *Handlers) ListEvents(gctx *gin.Context) {
+	// Checks limit, cursor and the time-window/title filters
+	filter, err := ParseEventFilter(gctx)
+	if err != nil {
+		log.Err(err).Msg("invalid query parameters")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("invalid query parameters", err))
+
+		return
+	}
+
+	// List Events: GET /events, ordered by start_time ascending
+	ctx := gctx.Request.Context()
+
+	page, err := h.repository.ListEvents(ctx, filter)
+	if err != nil {
+		log.Err(err).Msg("listing events failed")
+		gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("listing events failed", err))
+
+		return
+	}
+
+	gctx.JSON(http.StatusOK, page)
+}
+
+func (h *Handlers) StreamEvents(gctx *gin.Context) {
+	if h.stream == nil {
+		gctx.AbortWithStatusJSON(http.StatusServiceUnavailable, NewError("change stream unavailable", ErrStreamClosed))
+
+		return
+	}
+
+	// Checks Last-Event-ID, sent by the clients resuming their stream
+	var lastId *int64
+
+	if header := gctx.GetHeader("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseInt(header, 10, 64)
+		if err != nil {
+			log.Err(err).Msg("invalid Last-Event-ID")
+			gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("invalid Last-Event-ID", err))
+
+			return
+		}
+
+		lastId = &id
+	}
+
+	// Stream Events: GET /events/stream, until the client leaves or the stream is drained
+	sub, err := h.stream.Subscribe(lastId)
+	if err != nil {
+		gctx.AbortWithStatusJSON(http.StatusServiceUnavailable, NewError("change stream unavailable", err))
+
+		return
+	}
+	defer h.stream.Unsubscribe(sub)
+
+	gctx.Header("Content-Type", "text/event-stream")
+	gctx.Header("Cache-Control", "no-cache")
+	gctx.Header("X-Accel-Buffering", "no")
+	gctx.Status(http.StatusOK)
+
+	_, err = fmt.Fprintf(gctx.Writer, "retry: %d\n\n", StreamRetryDelay.Milliseconds())
+	gctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.stream.heartbeat)
+	defer heartbeat.Stop()
+
+	for err == nil {
+		select {
+		case <-gctx.Request.Context().Done():
+			return
+		case change, ok := <-sub.Changes():
+			if !ok {
+				return
+			}
+
+			err = writeChange(gctx.Writer, change)
+		case <-heartbeat.C:
+			// Comments keep proxies from closing idle streams
+			_, err = io.WriteString(gctx.Writer, ": heartbeat\n\n")
+		}
+
+		gctx.Writer.Flush()
+	}
+
+	log.Err(err).Msg("streaming events failed")
+}
+
+// writeChange writes the change as a server-sent event named after its type. A StreamReset has no id, so clients
+// keep resuming after the last change they got.
+func writeChange(w io.Writer, change Change) error {
+	if change.Type == StreamReset {
+		_, err := fmt.Fprintf(w, "event: %s\ndata: {}\n\n", StreamReset)
+		return err
+	}
+
+	data, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", change.Id, change.Type, data)
+
+	return err
+}
+
+func (h *Handlers) ListTags(gctx *gin.Context) {
+	// List Tags: GET /tags, the most used first
+	ctx := gctx.Request.Context()
+
+	tags, err := h.repository.ListTags(ctx)
+	if err != nil {
+		log.Err(err).Msg("listing tags failed")
+		gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("listing tags failed", err))
+
+		return
+	}
+
+	gctx.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+func (h *Handlers) SearchEvents(gctx *gin.Context) {
+	// Checks that q is there and lang is a supported text search configuration
+	query, err := ParseSearchQuery(gctx)
+	if err != nil {
+		log.Err(err).Msg("invalid query parameters")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("invalid query parameters", err))
+
+		return
+	}
+
+	// Search Events: GET /events/search, ordered by rank descending
+	ctx := gctx.Request.Context()
+
+	results, err := h.repository.SearchEvents(ctx, query)
+	if err != nil {
+		log.Err(err).Msg("searching events failed")
+		gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("searching events failed", err))
+
+		return
+	}
+
+	gctx.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+func (h *Handlers) ListOccurrences(gctx *gin.Context) {
+	// Checks that from and to are there and the window is not too long
+	window, err := ParseOccurrenceWindow(gctx)
+	if err != nil {
+		log.Err(err).Msg("invalid query parameters")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("invalid query parameters", err))
+
+		return
+	}
+
+	// List Occurrences: GET /events/occurrences, expanded in each event's time zone
+	ctx := gctx.Request.Context()
+
+	series, err := h.repository.ListSeries(ctx, window)
+	if err != nil {
+		abortWithOccurrencesError(gctx, err)
+		return
+	}
+
+	occurrences, err := ExpandOccurrences(series, window)
+	if err != nil {
+		abortWithOccurrencesError(gctx, err)
+		return
+	}
+
+	gctx.JSON(http.StatusOK, gin.H{"occurrences": occurrences})
+}
+
+func abortWithOccurrencesError(gctx *gin.Context, err error) {
+	if errors.Is(err, ErrTooManyOccurrences) {
+		log.Info().Err(err).Msg("too many occurrences")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("too many occurrences", err))
+
+		return
+	}
+
+	log.Err(err).Msg("listing occurrences failed")
+	gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("listing occurrences failed", err))
+}
+
+func (h *Handlers) PostFreeBusy(gctx *gin.Context) {
+	var query FreeBusyQuery
+
+	// Accepts a JSON payload with from, to and the optional title_prefix, calendar_id and scope filters
+	err := gctx.ShouldBindJSON(&query)
+	if err != nil {
+		log.Err(err).Msg("failed to bind JSON")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("failed to bind JSON", err))
+
+		return
+	}
+
+	err = ValidateFreeBusyQuery(query)
+	if err != nil {
+		log.Err(err).Msg("free/busy query validation failed")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("free/busy query validation failed", err))
+
+		return
+	}
+
+	// Free/Busy: POST /freebusy, busy intervals without the events behind them
+	ctx := gctx.Request.Context()
+
+	busy, err := h.repository.FreeBusy(ctx, query)
+	if err != nil {
+		abortWithFreeBusyError(gctx, err)
+		return
+	}
+
+	gctx.JSON(http.StatusOK, gin.H{"from": query.From, "to": query.To, "busy": busy})
+}
+
+func abortWithFreeBusyError(gctx *gin.Context, err error) {
+	if errors.Is(err, ErrTooManyOccurrences) {
+		log.Info().Err(err).Msg("too many occurrences")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("too many occurrences", err))
+
+		return
+	}
+
+	log.Err(err).Msg("getting free/busy failed")
+	gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("getting free/busy failed", err))
+}
+
+func (h *Handlers) PutOccurrences(gctx *gin.Context) {
+	// Accepts the new title, description, start_time and end_time of the occurrence, all of them optional
+	var override Override
+
+	err := gctx.ShouldBindJSON(&override)
+	if err != nil {
+		log.Err(err).Msg("failed to bind JSON")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("failed to bind JSON", err))
+
+		return
+	}
+
+	err = ValidateOverride(override)
+	if err != nil {
+		log.Err(err).Msg("override validation failed")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("override validation failed", err))
+
+		return
+	}
+
+	override.Cancelled = false
+	h.saveOverride(gctx, &override)
+}
+
+func (h *Handlers) DeleteOccurrences(gctx *gin.Context) {
+	// Cancels the occurrence, leaving the rest of the series untouched
+	override := Override{Cancelled: true}
+	h.saveOverride(gctx, &override)
+}
+
+func (h *Handlers) saveOverride(gctx *gin.Context, override *Override) {
+	// Checks that id and recurrence_id params are there
+	id := gctx.Param("id")
+	if len(id) == 0 {
+		log.Info().Msg("parameter 'id' is required")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("parameter 'id' is required"))
+
+		return
+	}
+
+	recurrenceId, err := time.Parse(time.RFC3339, gctx.Param("recurrence_id"))
+	if err != nil {
+		log.Err(err).Msg("invalid recurrence id")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("parameter 'recurrence_id' must be an RFC 3339 timestamp", err))
+
+		return
+	}
+
+	// Checks that the event has an occurrence originally starting at recurrence_id
+	ctx := gctx.Request.Context()
+
+	event, err := h.repository.GetEventById(ctx, id)
+	if err != nil {
+		abortWithOccurrenceError(gctx, err)
+		return
+	}
+
+	found, err := IsOccurrence(*event, recurrenceId)
+	if err != nil {
+		abortWithOccurrenceError(gctx, err)
+		return
+	}
+
+	if !found {
+		log.Info().Msg("occurrence not found")
+		gctx.AbortWithStatusJSON(http.StatusNotFound, NewError("occurrence not found"))
+
+		return
+	}
+
+	// Occurrences keep their original times unless moved
+	override.EventId, override.RecurrenceId = event.Id, recurrenceId
+	if override.StartTime.IsZero() {
+		override.StartTime, override.EndTime = recurrenceId, OccurrenceEnd(*event, recurrenceId)
+	}
+
+	savedOverride, err := h.repository.SaveOverride(ctx, override)
+	if err != nil {
+		abortWithOccurrenceError(gctx, err)
+		return
+	}
+
+	if savedOverride.Cancelled {
+		gctx.Status(http.StatusNoContent)
+		return
+	}
+
+	gctx.JSON(http.StatusOK, savedOverride)
+}
+
+func abortWithOccurrenceError(gctx *gin.Context, err error) {
+	if errors.Is(err, ErrEventNotFound) {
+		log.Info().Msg("event not found")
+		gctx.AbortWithStatusJSON(http.StatusNotFound, NewError("event not found", err))
+
+		return
+	}
+
+	log.Err(err).Msg("saving occurrence failed")
+	gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("saving occurrence failed", err))
+}
+
+func (h *Handlers) ExportEvent(gctx *gin.Context) {
+	// Checks that id param is there
+	id := strings.TrimSuffix(gctx.Param("id"), ".ics")
+	if len(id) == 0 {
+		log.Info().Msg("parameter 'id' is required")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("parameter 'id' is required"))
+
+		return
+	}
+
+	// Export Event: GET /events/{id}.ics, along with its moved and cancelled occurrences
+	ctx := gctx.Request.Context()
+
+	event, err := h.repository.GetEventById(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrEventNotFound) {
+			log.Info().Msg("event not found")
+			gctx.AbortWithStatusJSON(http.StatusNotFound, NewError("event not found", err))
+
+			return
+		}
+
+		log.Err(err).Msg("exporting event failed")
+		gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("exporting event failed", err))
+
+		return
+	}
+
+	h.writeCalendar(gctx, []Event{*event}, event.UpdatedAt)
+}
+
+func (h *Handlers) ExportEvents(gctx *gin.Context) {
+	// Checks the time-window/title filters; feeds are not paged, calendar clients expect the whole calendar
+	filter, err := ParseEventFilter(gctx)
+	if err != nil {
+		log.Err(err).Msg("invalid query parameters")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("invalid query parameters", err))
+
+		return
+	}
+
+	// Export Events: GET /events.ics, the first MaxFeedEvents events by start_time
+	filter.Limit, filter.Cursor, filter.Feed = MaxFeedEvents, nil, true
+	ctx := gctx.Request.Context()
+
+	page, err := h.repository.ListEvents(ctx, filter)
+	if err != nil {
+		log.Err(err).Msg("exporting events failed")
+		gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("exporting events failed", err))
+
+		return
+	}
+
+	// No Last-Modified: events deleted or moved out of the filter change the feed without leaving a newer updated_at in it
+	h.writeCalendar(gctx, page.Events, time.Time{})
+}
+
+func (h *Handlers) ImportEvents(gctx *gin.Context) {
+	// Checks that the body is an iCalendar document
+	mediaType, _, err := mime.ParseMediaType(gctx.ContentType())
+	if err != nil || mediaType != "text/calendar" {
+		log.Info().Str("content_type", gctx.ContentType()).Msg("unsupported content type")
+		gctx.AbortWithStatusJSON(http.StatusUnsupportedMediaType, NewError("content type must be text/calendar", err))
+
+		return
+	}
+
+	// Reads at most MaxImportSize bytes
+	body, err := io.ReadAll(http.MaxBytesReader(gctx.Writer, gctx.Request.Body, MaxImportSize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			log.Info().Err(err).Msg("request body too large")
+			gctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, NewError("request body too large", err))
+
+			return
+		}
+
+		log.Err(err).Msg("failed to read request body")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("failed to read request body", err))
+
+		return
+	}
+
+	entries, err := DecodeCalendar(bytes.NewReader(body))
+	if err != nil {
+		log.Info().Err(err).Msg("invalid calendar")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("invalid calendar", err))
+
+		return
+	}
+
+	// Import Events: POST /events/import, each VEVENT is created, skipped (already imported) or failed on its own
+	ctx := gctx.Request.Context()
+
+	report := ImportCalendar(ctx, h.repository, entries)
+
+	gctx.JSON(http.StatusOK, report)
+}
+
+// writeCalendar responds with the events as iCalendar, or 304 when the client's copy is still current.
+// A zero lastModified leaves the response to be validated by its ETag alone.
+func (h *Handlers) writeCalendar(gctx *gin.Context, events []Event, lastModified time.Time) {
+	ctx := gctx.Request.Context()
+
+	var ids []string
+
+	for _, event := range events {
+		if IsRecurring(event) {
+			ids = append(ids, event.Id)
+		}
+	}
+
+	var err error
+	var overrides []Override
+
+	if len(ids) > 0 {
+		overrides, err = h.repository.ListOverrides(ctx, ids)
+		if err != nil {
+			log.Err(err).Msg("listing overrides failed")
+			gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("listing overrides failed", err))
+
+			return
+		}
+	}
+
+	body, err := EncodeCalendar(events, overrides)
+	if err != nil {
+		log.Err(err).Msg("encoding calendar failed")
+		gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("encoding calendar failed", err))
+
+		return
+	}
+
+	etag := ContentETag(body)
+	gctx.Header("ETag", etag)
+
+	if !lastModified.IsZero() {
+		gctx.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if NotModified(gctx.Request, etag, lastModified) {
+		gctx.Status(http.StatusNotModified)
+		return
+	}
+
+	gctx.Data(http.StatusOK, CalendarContentType, body)
+}
+
+type getCalendarFunc func(ctx context.Context, id string) (*Calendar, error)
+
+// applyCalendar checks that the calendar of the event exists and fills in its time zone when the event has none,
+// keeping the days of all-day events.
+func applyCalendar(ctx context.Context, getCalendar getCalendarFunc, event *Event) error {
+	if event.CalendarId == "" {
+		return nil
+	}
+
+	calendar, err := getCalendar(ctx, event.CalendarId)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if event.TimeZone == "" && calendar.TimeZone != "" {
+		loc, err := Location(calendar.TimeZone)
+		if err != nil {
+			return err
+		}
+
+		event.TimeZone = calendar.TimeZone
+
+		// The days of all-day events were read in UTC, they are the same days in the calendar's time zone
+		if event.AllDay {
+			anchorDates(event, loc)
+		}
+	}
+
+	return nil
+}
+
+func abortWithCalendarError(gctx *gin.Context, err error) {
+	if errors.Is(err, ErrCalendarNotFound) {
+		log.Info().Msg("calendar not found")
+		gctx.AbortWithStatusJSON(http.StatusNotFound, NewError("calendar not found", err))
+
+		return
+	}
+
+	log.Err(err).Msg("getting calendar failed")
+	gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("getting calendar failed", err))
+}
+
+func (h *Handlers) PostCalendars(gctx *gin.Context) {
+	var calendar Calendar
+
+	// Accepts a JSON payload with name, description, time_zone and color
+	err := gctx.ShouldBindJSON(&calendar)
+	if err != nil {
+		log.Err(err).Msg("failed to bind JSON")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("failed to bind JSON", err))
+
+		return
+	}
+
+	err = ValidateCalendar(calendar)
+	if err != nil {
+		log.Err(err).Msg("calendar validation failed")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("calendar validation failed", err))
+
+		return
+	}
+
+	// Create Calendar: POST /calendars
+	ctx := gctx.Request.Context()
+
+	savedCalendar, err := h.repository.SaveCalendar(ctx, &calendar)
+	if err != nil {
+		log.Err(err).Msg("saving calendar failed")
+		gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("saving calendar failed", err))
+
+		return
+	}
+
+	gctx.JSON(http.StatusCreated, savedCalendar)
+}
+
+func (h *Handlers) ListCalendars(gctx *gin.Context) {
+	// List Calendars: GET /calendars, ordered by name
+	ctx := gctx.Request.Context()
+
+	calendars, err := h.repository.ListCalendars(ctx)
+	if err != nil {
+		log.Err(err).Msg("listing calendars failed")
+		gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("listing calendars failed", err))
+
+		return
+	}
+
+	gctx.JSON(http.StatusOK, gin.H{"calendars": calendars})
+}
+
+func (h *Handlers) GetCalendars(gctx *gin.Context) {
+	// Get Calendar by ID: GET /calendars/:id
+	ctx := gctx.Request.Context()
+
+	calendar, err := h.repository.GetCalendar(ctx, gctx.Param("id"))
+	if err != nil {
+		abortWithCalendarError(gctx, err)
+		return
+	}
+
+	gctx.JSON(http.StatusOK, calendar)
+}
+
+func (h *Handlers) PutCalendars(gctx *gin.Context) {
+	// Accepts the full replacement of the calendar as a JSON payload
+	var calendar Calendar
+
+	err := gctx.ShouldBindJSON(&calendar)
+	if err != nil {
+		log.Err(err).Msg("failed to bind JSON")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("failed to bind JSON", err))
+
+		return
+	}
+
+	err = ValidateCalendar(calendar)
+	if err != nil {
+		log.Err(err).Msg("calendar validation failed")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("calendar validation failed", err))
+
+		return
+	}
+
+	// Update Calendar: PUT /calendars/:id
+	calendar.Id = gctx.Param("id")
+	ctx := gctx.Request.Context()
+
+	updatedCalendar, err := h.repository.UpdateCalendar(ctx, &calendar)
+	if err != nil {
+		abortWithCalendarError(gctx, err)
+		return
+	}
+
+	gctx.JSON(http.StatusOK, updatedCalendar)
+}
+
+func (h *Handlers) DeleteCalendars(gctx *gin.Context) {
+	// Calendars with events are only deleted, along with their events, when asked to
+	cascade := gctx.Query("cascade") == "true"
+	ctx := gctx.Request.Context()
+
+	err := h.repository.DeleteCalendar(ctx, gctx.Param("id"), cascade)
+	if errors.Is(err, ErrCalendarNotEmpty) {
+		log.Info().Msg("calendar still has events")
+		gctx.AbortWithStatusJSON(http.StatusConflict, NewError("calendar still has events, use cascade=true to delete them", err))
+
+		return
+	}
+
+	if err != nil {
+		abortWithCalendarError(gctx, err)
+		return
+	}
+
+	gctx.Status(http.StatusNoContent)
+}
+
+func (h *Handlers) ListCalendarEvents(gctx *gin.Context) {
+	// Checks limit, cursor and the time-window/title filters
+	filter, err := ParseEventFilter(gctx)
+	if err != nil {
+		log.Err(err).Msg("invalid query parameters")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("invalid query parameters", err))
+
+		return
+	}
+
+	// Checks that the calendar exists, an unknown one is not just empty
+	ctx := gctx.Request.Context()
+
+	_, err = h.repository.GetCalendar(ctx, gctx.Param("id"))
+	if err != nil {
+		abortWithCalendarError(gctx, err)
+		return
+	}
+
+	// List Calendar Events: GET /calendars/:id/events, same ordering and paging as GET /events
+	filter.CalendarId = gctx.Param("id")
+
+	page, err := h.repository.ListEvents(ctx, filter)
+	if err != nil {
+		log.Err(err).Msg("listing events failed")
+		gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("listing events failed", err))
+
+		return
+	}
+
+	gctx.JSON(http.StatusOK, page)
+}
+
+func (h *Handlers) PostCalendarEvents(gctx *gin.Context) {
+	var event Event
+
+	// Accepts the same payload as POST /events, the calendar comes from the path
+	err := gctx.ShouldBindJSON(&event)
+	if err != nil {
+		log.Err(err).Msg("failed to bind JSON")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("failed to bind JSON", err))
+
+		return
+	}
+
+	event.CalendarId = gctx.Param("id")
+	h.createEvent(gctx, &event)
+}
+
+func (h *Handlers) PostAttendees(gctx *gin.Context) {
+	var attendee Attendee
+
+	// Accepts a JSON payload with the email and name of the attendee
+	err := gctx.ShouldBindJSON(&attendee)
+	if err != nil {
+		log.Err(err).Msg("failed to bind JSON")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("failed to bind JSON", err))
+
+		return
+	}
+
+	err = ValidateAttendee(attendee)
+	if err != nil {
+		log.Err(err).Msg("attendee validation failed")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("attendee validation failed", err))
+
+		return
+	}
+
+	// Invite Attendee: POST /events/:id/attendees, the RSVP starts as needs-action
+	attendee.EventId = gctx.Param("id")
+	ctx := gctx.Request.Context()
+
+	savedAttendee, err := h.repository.SaveAttendee(ctx, &attendee)
+	if err != nil {
+		abortWithAttendeeError(gctx, err)
+		return
+	}
+
+	gctx.JSON(http.StatusCreated, savedAttendee)
+}
+
+func (h *Handlers) ListAttendees(gctx *gin.Context) {
+	// Checks that the event exists, an unknown one has no attendees list at all
+	ctx := gctx.Request.Context()
+
+	_, err := h.repository.GetEventById(ctx, gctx.Param("id"))
+	if err != nil {
+		abortWithAttendeeError(gctx, err)
+		return
+	}
+
+	// List Attendees: GET /events/:id/attendees, in invitation order
+	attendees, err := h.repository.ListAttendees(ctx, gctx.Param("id"))
+	if err != nil {
+		abortWithAttendeeError(gctx, err)
+		return
+	}
+
+	gctx.JSON(http.StatusOK, gin.H{"attendees": attendees})
+}
+
+func (h *Handlers) PutRsvp(gctx *gin.Context) {
+	// Accepts a JSON payload with the status only
+	var attendee Attendee
+
+	err := gctx.ShouldBindJSON(&attendee)
+	if err != nil {
+		log.Err(err).Msg("failed to bind JSON")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("failed to bind JSON", err))
+
+		return
+	}
+
+	err = ValidateRsvpStatus(attendee.Status)
+	if err != nil {
+		log.Err(err).Msg("rsvp validation failed")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("rsvp validation failed", err))
+
+		return
+	}
+
+	// RSVP: PUT /events/:id/attendees/:attendee_id/rsvp
+	attendee.EventId, attendee.Id = gctx.Param("id"), gctx.Param("attendee_id")
+	ctx := gctx.Request.Context()
+
+	updatedAttendee, err := h.repository.UpdateAttendeeStatus(ctx, &attendee)
+	if err != nil {
+		abortWithAttendeeError(gctx, err)
+		return
+	}
+
+	gctx.JSON(http.StatusOK, updatedAttendee)
+}
+
+func (h *Handlers) DeleteAttendees(gctx *gin.Context) {
+	// Uninvite Attendee: DELETE /events/:id/attendees/:attendee_id
+	ctx := gctx.Request.Context()
+
+	err := h.repository.DeleteAttendee(ctx, gctx.Param("id"), gctx.Param("attendee_id"))
+	if err != nil {
+		abortWithAttendeeError(gctx, err)
+		return
+	}
+
+	gctx.Status(http.StatusNoContent)
+}
+
+func abortWithAttendeeError(gctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrEventNotFound):
+		log.Info().Msg("event not found")
+		gctx.AbortWithStatusJSON(http.StatusNotFound, NewError("event not found", err))
+	case errors.Is(err, ErrAttendeeNotFound):
+		log.Info().Msg("attendee not found")
+		gctx.AbortWithStatusJSON(http.StatusNotFound, NewError("attendee not found", err))
+	case errors.Is(err, ErrAttendeeExists):
+		log.Info().Msg("attendee already invited")
+		gctx.AbortWithStatusJSON(http.StatusConflict, NewError("attendee already invited", err))
+	default:
+		log.Err(err).Msg("attendees request failed")
+		gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("attendees request failed", err))
+	}
+}
+
+func (h *Handlers) PostReminders(gctx *gin.Context) {
+	var reminder Reminder
+
+	// Accepts a JSON payload with the offset in minutes before each occurrence and the channel
+	err := gctx.ShouldBindJSON(&reminder)
+	if err != nil {
+		log.Err(err).Msg("failed to bind JSON")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("failed to bind JSON", err))
+
+		return
+	}
+
+	err = ValidateReminder(reminder)
+	if err != nil {
+		log.Err(err).Msg("reminder validation failed")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("reminder validation failed", err))
+
+		return
+	}
+
+	// Set Reminder: POST /events/:id/reminders, scheduled by the next dispatch
+	reminder.EventId = gctx.Param("id")
+	ctx := gctx.Request.Context()
+
+	savedReminder, err := h.repository.SaveReminder(ctx, &reminder)
+	if err != nil {
+		abortWithReminderError(gctx, err)
+		return
+	}
+
+	gctx.JSON(http.StatusCreated, savedReminder)
+}
+
+func (h *Handlers) ListReminders(gctx *gin.Context) {
+	// Checks that the event exists, an unknown one has no reminders list at all
+	ctx := gctx.Request.Context()
+
+	_, err := h.repository.GetEventById(ctx, gctx.Param("id"))
+	if err != nil {
+		abortWithReminderError(gctx, err)
+		return
+	}
+
+	// List Reminders: GET /events/:id/reminders, the earliest sent first
+	reminders, err := h.repository.ListReminders(ctx, gctx.Param("id"))
+	if err != nil {
+		abortWithReminderError(gctx, err)
+		return
+	}
+
+	gctx.JSON(http.StatusOK, gin.H{"reminders": reminders})
+}
+
+func (h *Handlers) DeleteReminders(gctx *gin.Context) {
+	// Delete Reminder: DELETE /events/:id/reminders/:reminder_id
+	ctx := gctx.Request.Context()
+
+	err := h.repository.DeleteReminder(ctx, gctx.Param("id"), gctx.Param("reminder_id"))
+	if err != nil {
+		abortWithReminderError(gctx, err)
+		return
+	}
+
+	gctx.Status(http.StatusNoContent)
+}
+
+func abortWithReminderError(gctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrEventNotFound):
+		log.Info().Msg("event not found")
+		gctx.AbortWithStatusJSON(http.StatusNotFound, NewError("event not found", err))
+	case errors.Is(err, ErrReminderNotFound):
+		log.Info().Msg("reminder not found")
+		gctx.AbortWithStatusJSON(http.StatusNotFound, NewError("reminder not found", err))
+	case errors.Is(err, ErrReminderExists):
+		log.Info().Msg("reminder already set")
+		gctx.AbortWithStatusJSON(http.StatusConflict, NewError("reminder already set", err))
+	default:
+		log.Err(err).Msg("reminders request failed")
+		gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("reminders request failed", err))
+	}
+}
+
+func (h *Handlers) PostRegistrations(gctx *gin.Context) {
+	var registration Registration
+
+	// Accepts a JSON payload with the email and name of the registrant
+	err := gctx.ShouldBindJSON(&registration)
+	if err != nil {
+		log.Err(err).Msg("failed to bind JSON")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("failed to bind JSON", err))
+
+		return
+	}
+
+	err = ValidateRegistration(registration)
+	if err != nil {
+		log.Err(err).Msg("registration validation failed")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("registration validation failed", err))
+
+		return
+	}
+
+	// Register: POST /events/:id/registrations, confirmed until the event is full, waitlisted afterwards
+	registration.EventId = gctx.Param("id")
+	ctx := gctx.Request.Context()
+
+	savedRegistration, err := h.repository.Register(ctx, &registration)
+	if err != nil {
+		abortWithRegistrationError(gctx, err)
+		return
+	}
+
+	gctx.JSON(http.StatusCreated, savedRegistration)
+}
+
+func (h *Handlers) ListRegistrations(gctx *gin.Context) {
+	// Checks that the event exists, an unknown one has no registrations list at all
+	ctx := gctx.Request.Context()
+
+	_, err := h.repository.GetEventById(ctx, gctx.Param("id"))
+	if err != nil {
+		abortWithRegistrationError(gctx, err)
+		return
+	}
+
+	// List Registrations: GET /events/:id/registrations, confirmed first, then the waitlist
+	registrations, err := h.repository.ListRegistrations(ctx, gctx.Param("id"))
+	if err != nil {
+		abortWithRegistrationError(gctx, err)
+		return
+	}
+
+	gctx.JSON(http.StatusOK, gin.H{"registrations": registrations})
+}
+
+func (h *Handlers) DeleteRegistrations(gctx *gin.Context) {
+	// Cancel Registration: DELETE /events/:id/registrations/:registration_id, the seat goes to the waitlist
+	ctx := gctx.Request.Context()
+
+	err := h.repository.CancelRegistration(ctx, gctx.Param("id"), gctx.Param("registration_id"))
+	if err != nil {
+		abortWithRegistrationError(gctx, err)
+		return
+	}
+
+	gctx.Status(http.StatusNoContent)
+}
+
+func abortWithRegistrationError(gctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrEventNotFound):
+		log.Info().Msg("event not found")
+		gctx.AbortWithStatusJSON(http.StatusNotFound, NewError("event not found", err))
+	case errors.Is(err, ErrRegistrationNotFound):
+		log.Info().Msg("registration not found")
+		gctx.AbortWithStatusJSON(http.StatusNotFound, NewError("registration not found", err))
+	case errors.Is(err, ErrRegistrationExists):
+		log.Info().Msg("already registered")
+		gctx.AbortWithStatusJSON(http.StatusConflict, NewError("already registered", err))
+	default:
+		log.Err(err).Msg("registrations request failed")
+		gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("registrations request failed", err))
+	}
+}
+
+func (h *Handlers) PostWebhooks(gctx *gin.Context) {
+	var webhook Webhook
+
+	// Accepts a JSON payload with url, event_types and secret
+	err := gctx.ShouldBindJSON(&webhook)
+	if err != nil {
+		log.Err(err).Msg("failed to bind JSON")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("failed to bind JSON", err))
+
+		return
+	}
+
+	err = ValidateWebhook(webhook)
+	if err != nil {
+		log.Err(err).Msg("webhook validation failed")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("webhook validation failed", err))
+
+		return
+	}
+
+	// Webhooks created without a secret get a random one
+	if webhook.Secret == "" {
+		webhook.Secret, err = NewWebhookSecret()
+		if err != nil {
+			log.Err(err).Msg("saving webhook failed")
+			gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("saving webhook failed", err))
+
+			return
+		}
+	}
+
+	// Create Webhook: POST /webhooks, the only response holding the secret
+	ctx := gctx.Request.Context()
+
+	savedWebhook, err := h.repository.SaveWebhook(ctx, &webhook)
+	if err != nil {
+		abortWithWebhookError(gctx, err)
+		return
+	}
+
+	savedWebhook.Secret = webhook.Secret
+
+	gctx.JSON(http.StatusCreated, savedWebhook)
+}
+
+func (h *Handlers) ListWebhooks(gctx *gin.Context) {
+	// List Webhooks: GET /webhooks, the oldest first
+	ctx := gctx.Request.Context()
+
+	webhooks, err := h.repository.ListWebhooks(ctx)
+	if err != nil {
+		abortWithWebhookError(gctx, err)
+		return
+	}
+
+	gctx.JSON(http.StatusOK, gin.H{"webhooks": webhooks})
+}
+
+func (h *Handlers) GetWebhooks(gctx *gin.Context) {
+	// Get Webhook by ID: GET /webhooks/:id
+	ctx := gctx.Request.Context()
+
+	webhook, err := h.repository.GetWebhook(ctx, gctx.Param("id"))
+	if err != nil {
+		abortWithWebhookError(gctx, err)
+		return
+	}
+
+	gctx.JSON(http.StatusOK, webhook)
+}
+
+func (h *Handlers) PutWebhooks(gctx *gin.Context) {
+	// Accepts the full replacement of the webhook as a JSON payload, the secret is kept unless a new one is set
+	var webhook Webhook
+
+	err := gctx.ShouldBindJSON(&webhook)
+	if err != nil {
+		log.Err(err).Msg("failed to bind JSON")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("failed to bind JSON", err))
+
+		return
+	}
+
+	err = ValidateWebhook(webhook)
+	if err != nil {
+		log.Err(err).Msg("webhook validation failed")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("webhook validation failed", err))
+
+		return
+	}
+
+	// Update Webhook: PUT /webhooks/:id
+	webhook.Id = gctx.Param("id")
+	ctx := gctx.Request.Context()
+
+	updatedWebhook, err := h.repository.UpdateWebhook(ctx, &webhook)
+	if err != nil {
+		abortWithWebhookError(gctx, err)
+		return
+	}
+
+	updatedWebhook.Secret = webhook.Secret
+
+	gctx.JSON(http.StatusOK, updatedWebhook)
+}
+
+func (h *Handlers) DeleteWebhooks(gctx *gin.Context) {
+	// Delete Webhook: DELETE /webhooks/:id, along with its deliveries
+	ctx := gctx.Request.Context()
+
+	err := h.repository.DeleteWebhook(ctx, gctx.Param("id"))
+	if err != nil {
+		abortWithWebhookError(gctx, err)
+		return
+	}
+
+	gctx.Status(http.StatusNoContent)
+}
+
+func (h *Handlers) ListDeliveries(gctx *gin.Context) {
+	// Checks the status filter, every status unless given
+	status := gctx.Query("status")
+	if status != "" && status != DeliveryPending && status != DeliverySucceeded && status != DeliveryDead {
+		err := fmt.Errorf("status '%s' is invalid, it must be one of %s, %s or %s", status, DeliveryPending, DeliverySucceeded, DeliveryDead)
+		log.Err(err).Msg("invalid query parameters")
+		gctx.AbortWithStatusJSON(http.StatusBadRequest, NewError("invalid query parameters", err))
+
+		return
+	}
+
+	// Checks that the webhook exists, an unknown one has no deliveries list at all
+	ctx := gctx.Request.Context()
+
+	_, err := h.repository.GetWebhook(ctx, gctx.Param("id"))
+	if err != nil {
+		abortWithWebhookError(gctx, err)
+		return
+	}
+
+	// List Deliveries: GET /webhooks/:id/deliveries, the latest first
+	deliveries, err := h.repository.ListDeliveries(ctx, gctx.Param("id"), status)
+	if err != nil {
+		abortWithWebhookError(gctx, err)
+		return
+	}
+
+	gctx.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+func (h *Handlers) PostRedeliver(gctx *gin.Context) {
+	// Redeliver: POST /webhooks/:id/deliveries/:delivery_id/redeliver, sent by the next dispatch
+	ctx := gctx.Request.Context()
+
+	delivery, err := h.repository.RedeliverDelivery(ctx, gctx.Param("id"), gctx.Param("delivery_id"))
+	if err != nil {
+		abortWithWebhookError(gctx, err)
+		return
+	}
+
+	gctx.JSON(http.StatusAccepted, delivery)
+}
+
+func abortWithWebhookError(gctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrWebhookNotFound):
+		log.Info().Msg("webhook not found")
+		gctx.AbortWithStatusJSON(http.StatusNotFound, NewError("webhook not found", err))
+	case errors.Is(err, ErrDeliveryNotFound):
+		log.Info().Msg("delivery not found")
+		gctx.AbortWithStatusJSON(http.StatusNotFound, NewError("delivery not found", err))
+	default:
+		log.Err(err).Msg("webhooks request failed")
+		gctx.AbortWithStatusJSON(http.StatusInternalServerError, NewError("webhooks request failed", err))
+	}
+}
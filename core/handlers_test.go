@@ -5,15 +5,21 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	pgxmock "github.com/pashagolub/pgxmock/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockRepository is a mock of the RepositoryInterface
@@ -30,6 +36,267 @@ func (m *MockRepository) SaveEvent(ctx context.Context, event *Event) (*Event, e
 	return args.Get(0).(*Event), args.Error(1)
 }
 
+func (m *MockRepository) SaveEvents(ctx context.Context, events []Event) ([]Event, error) {
+	args := m.Called(ctx, events)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]Event), args.Error(1)
+}
+
+func (m *MockRepository) FindConflicts(ctx context.Context, event Event) ([]Event, error) {
+	args := m.Called(ctx, event)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]Event), args.Error(1)
+}
+
+func (m *MockRepository) SaveCalendar(ctx context.Context, calendar *Calendar) (*Calendar, error) {
+	args := m.Called(ctx, calendar)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*Calendar), args.Error(1)
+}
+
+func (m *MockRepository) GetCalendar(ctx context.Context, id string) (*Calendar, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*Calendar), args.Error(1)
+}
+
+func (m *MockRepository) GetCalendars(ctx context.Context, ids []string) ([]Calendar, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]Calendar), args.Error(1)
+}
+
+func (m *MockRepository) ListCalendars(ctx context.Context) ([]Calendar, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]Calendar), args.Error(1)
+}
+
+func (m *MockRepository) UpdateCalendar(ctx context.Context, calendar *Calendar) (*Calendar, error) {
+	args := m.Called(ctx, calendar)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*Calendar), args.Error(1)
+}
+
+func (m *MockRepository) DeleteCalendar(ctx context.Context, id string, cascade bool) error {
+	args := m.Called(ctx, id, cascade)
+	return args.Error(0)
+}
+
+func (m *MockRepository) SaveAttendee(ctx context.Context, attendee *Attendee) (*Attendee, error) {
+	args := m.Called(ctx, attendee)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*Attendee), args.Error(1)
+}
+
+func (m *MockRepository) ListAttendees(ctx context.Context, eventId string) ([]Attendee, error) {
+	args := m.Called(ctx, eventId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]Attendee), args.Error(1)
+}
+
+func (m *MockRepository) ListAttendeesByEvents(ctx context.Context, eventIds []string) ([]Attendee, error) {
+	args := m.Called(ctx, eventIds)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]Attendee), args.Error(1)
+}
+
+func (m *MockRepository) UpdateAttendeeStatus(ctx context.Context, attendee *Attendee) (*Attendee, error) {
+	args := m.Called(ctx, attendee)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*Attendee), args.Error(1)
+}
+
+func (m *MockRepository) DeleteAttendee(ctx context.Context, eventId string, id string) error {
+	args := m.Called(ctx, eventId, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) SaveReminder(ctx context.Context, reminder *Reminder) (*Reminder, error) {
+	args := m.Called(ctx, reminder)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*Reminder), args.Error(1)
+}
+
+func (m *MockRepository) ListReminders(ctx context.Context, eventId string) ([]Reminder, error) {
+	args := m.Called(ctx, eventId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]Reminder), args.Error(1)
+}
+
+func (m *MockRepository) DeleteReminder(ctx context.Context, eventId string, id string) error {
+	args := m.Called(ctx, eventId, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ClaimReminders(ctx context.Context, now time.Time, until time.Time, limit int) ([]Reminder, error) {
+	args := m.Called(ctx, now, until, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]Reminder), args.Error(1)
+}
+
+func (m *MockRepository) RescheduleReminder(ctx context.Context, reminder *Reminder) error {
+	args := m.Called(ctx, reminder)
+	return args.Error(0)
+}
+
+func (m *MockRepository) SaveWebhook(ctx context.Context, webhook *Webhook) (*Webhook, error) {
+	args := m.Called(ctx, webhook)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*Webhook), args.Error(1)
+}
+
+func (m *MockRepository) GetWebhook(ctx context.Context, id string) (*Webhook, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*Webhook), args.Error(1)
+}
+
+func (m *MockRepository) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]Webhook), args.Error(1)
+}
+
+func (m *MockRepository) UpdateWebhook(ctx context.Context, webhook *Webhook) (*Webhook, error) {
+	args := m.Called(ctx, webhook)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*Webhook), args.Error(1)
+}
+
+func (m *MockRepository) DeleteWebhook(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListDeliveries(ctx context.Context, webhookId string, status string) ([]Delivery, error) {
+	args := m.Called(ctx, webhookId, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]Delivery), args.Error(1)
+}
+
+func (m *MockRepository) RedeliverDelivery(ctx context.Context, webhookId string, id string) (*Delivery, error) {
+	args := m.Called(ctx, webhookId, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*Delivery), args.Error(1)
+}
+
+func (m *MockRepository) ClaimDeliveries(ctx context.Context, now time.Time, until time.Time, limit int) ([]PendingDelivery, error) {
+	args := m.Called(ctx, now, until, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]PendingDelivery), args.Error(1)
+}
+
+func (m *MockRepository) RecordDelivery(ctx context.Context, delivery *Delivery) error {
+	args := m.Called(ctx, delivery)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DispatchOutbox(ctx context.Context, limit int, publish func(ctx context.Context, message OutboxMessage) error) (int, error) {
+	args := m.Called(ctx, limit, publish)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) OutboxBacklog(ctx context.Context) (int64, *time.Time, error) {
+	args := m.Called(ctx)
+	if args.Get(1) == nil {
+		return args.Get(0).(int64), nil, args.Error(2)
+	}
+
+	return args.Get(0).(int64), args.Get(1).(*time.Time), args.Error(2)
+}
+
+func (m *MockRepository) PurgeOutbox(ctx context.Context, deliveredBefore time.Time) (int64, error) {
+	args := m.Called(ctx, deliveredBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) Register(ctx context.Context, registration *Registration) (*Registration, error) {
+	args := m.Called(ctx, registration)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*Registration), args.Error(1)
+}
+
+func (m *MockRepository) ListRegistrations(ctx context.Context, eventId string) ([]Registration, error) {
+	args := m.Called(ctx, eventId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]Registration), args.Error(1)
+}
+
+func (m *MockRepository) CancelRegistration(ctx context.Context, eventId string, id string) error {
+	args := m.Called(ctx, eventId, id)
+	return args.Error(0)
+}
+
 func (m *MockRepository) GetEventById(ctx context.Context, id string) (*Event, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -39,6 +306,106 @@ func (m *MockRepository) GetEventById(ctx context.Context, id string) (*Event, e
 	return args.Get(0).(*Event), args.Error(1)
 }
 
+func (m *MockRepository) UpdateEvent(ctx context.Context, event *Event, expectedVersion int) (*Event, error) {
+	args := m.Called(ctx, event, expectedVersion)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*Event), args.Error(1)
+}
+
+func (m *MockRepository) DeleteEvent(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RestoreEvent(ctx context.Context, id string) (*Event, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*Event), args.Error(1)
+}
+
+func (m *MockRepository) PurgeEvents(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	args := m.Called(ctx, deletedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) ListEvents(ctx context.Context, filter EventFilter) (*EventPage, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*EventPage), args.Error(1)
+}
+
+func (m *MockRepository) ListTags(ctx context.Context) ([]Tag, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]Tag), args.Error(1)
+}
+
+func (m *MockRepository) FreeBusy(ctx context.Context, query FreeBusyQuery) ([]BusyInterval, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]BusyInterval), args.Error(1)
+}
+
+func (m *MockRepository) SearchEvents(ctx context.Context, query SearchQuery) ([]SearchResult, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]SearchResult), args.Error(1)
+}
+
+func (m *MockRepository) ListSeries(ctx context.Context, window TimeWindow) ([]Series, error) {
+	args := m.Called(ctx, window)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]Series), args.Error(1)
+}
+
+func (m *MockRepository) ListOverrides(ctx context.Context, eventIds []string) ([]Override, error) {
+	args := m.Called(ctx, eventIds)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]Override), args.Error(1)
+}
+
+func (m *MockRepository) SaveOverride(ctx context.Context, override *Override) (*Override, error) {
+	args := m.Called(ctx, override)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*Override), args.Error(1)
+}
+
+func (m *MockRepository) ImportEvent(ctx context.Context, series *Series) (*Event, error) {
+	args := m.Called(ctx, series)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*Event), args.Error(1)
+}
+
 func TestHandlers_PostEvents(t *testing.T) {
 	t.Parallel()
 	gin.SetMode(gin.TestMode)
@@ -86,6 +453,18 @@ func TestHandlers_PostEvents(t *testing.T) {
 			mockErr:        errors.New("db error"),
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name: "conflict",
+			body: Event{
+				Title:     "Test Event",
+				StartTime: now,
+				EndTime:   now.Add(time.Hour),
+				Scope:     "room-1",
+			},
+			mockReturn:     nil,
+			mockErr:        fmt.Errorf("failed to save event: %w", &ConflictError{EventIds: []string{"uuid-456"}}),
+			expectedStatus: http.StatusConflict,
+		},
 		{
 			name:           "invalid json",
 			body:           "invalid",
@@ -98,11 +477,11 @@ func TestHandlers_PostEvents(t *testing.T) {
 			t.Parallel()
 
 			mockRepo := new(MockRepository)
-			if tt.name == "success" || tt.name == "repository failure" {
+			if tt.name == "success" || tt.name == "repository failure" || tt.name == "conflict" {
 				mockRepo.On("SaveEvent", mock.Anything, mock.Anything).Return(tt.mockReturn, tt.mockErr)
 			}
 
-			h := NewHandlers(mockRepo)
+			h := NewHandlers(mockRepo, nil)
 			w := httptest.NewRecorder()
 			c, _ := gin.CreateTestContext(w)
 
@@ -118,6 +497,13 @@ func TestHandlers_PostEvents(t *testing.T) {
 			h.PostEvents(c)
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedStatus == http.StatusConflict {
+				var got ConflictResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+				assert.Equal(t, []string{"uuid-456"}, got.Conflicts)
+			}
+
 			mockRepo.AssertExpectations(t)
 		})
 	}
@@ -194,7 +580,7 @@ func TestHandlers_GetEvents(t *testing.T) {
 				mockRepo.On("GetEventById", mock.Anything, tt.idParam).Return(tt.mockReturn, tt.mockErr)
 			}
 
-			h := NewHandlers(mockRepo)
+			h := NewHandlers(mockRepo, nil)
 			w := httptest.NewRecorder()
 			c, _ := gin.CreateTestContext(w)
 			c.Params = []gin.Param{{Key: "id", Value: tt.idParam}}
@@ -217,3 +603,2433 @@ func TestHandlers_GetEvents(t *testing.T) {
 		})
 	}
 }
+
+func TestHandlers_ListEvents(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	cursor := Cursor{StartTime: now, Id: "123"}
+
+	tests := []struct {
+		name           string
+		query          string
+		wantFilter     *EventFilter
+		mockReturn     *EventPage
+		mockErr        error
+		expectedStatus int
+	}{
+		{
+			name:           "success with defaults",
+			query:          "",
+			wantFilter:     &EventFilter{Limit: DefaultPageLimit},
+			mockReturn:     &EventPage{Events: []Event{{Id: "123", Title: "Event"}}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "success with cursor and capped limit",
+			query:          "?limit=1000&cursor=" + EncodeCursor(cursor),
+			wantFilter:     &EventFilter{Limit: MaxPageLimit, Cursor: &cursor},
+			mockReturn:     &EventPage{Events: []Event{}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "success with tags",
+			query:          "?tags=Release,oncall&tag_mode=all",
+			wantFilter:     &EventFilter{Limit: DefaultPageLimit, Tags: []string{"oncall", "release"}, TagMode: TagModeAll},
+			mockReturn:     &EventPage{Events: []Event{}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid limit",
+			query:          "?limit=abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid cursor",
+			query:          "?cursor=not-a-cursor",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "malformed timestamp",
+			query:          "?starts_after=yesterday",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "repository error",
+			query:          "",
+			wantFilter:     &EventFilter{Limit: DefaultPageLimit},
+			mockErr:        errors.New("db error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			if tt.wantFilter != nil {
+				mockRepo.On("ListEvents", mock.Anything, *tt.wantFilter).Return(tt.mockReturn, tt.mockErr)
+			}
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/events"+tt.query, nil)
+
+			h.ListEvents(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlers_ListTags(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		mockReturn     []Tag
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "success",
+			mockReturn:     []Tag{{Name: "oncall", Count: 3}, {Name: "release", Count: 1}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"tags":[{"name":"oncall","count":3},{"name":"release","count":1}]}`,
+		},
+		{
+			name:           "repository error",
+			mockErr:        errors.New("db error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			mockRepo.On("ListTags", mock.Anything).Return(tt.mockReturn, tt.mockErr)
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/tags", nil)
+
+			h.ListTags(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlers_SearchEvents(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		query          string
+		wantQuery      *SearchQuery
+		mockReturn     []SearchResult
+		mockErr        error
+		expectedStatus int
+	}{
+		{
+			name:      "success",
+			query:     "?q=kickoff",
+			wantQuery: &SearchQuery{Query: "kickoff", Language: IndexedSearchLanguage, Limit: DefaultPageLimit},
+			mockReturn: []SearchResult{
+				{Event: Event{Id: "123", Title: "Project Kickoff"}, Rank: 0.6, Snippet: "Project <mark>Kickoff</mark>"},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing q",
+			query:          "",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "repository error",
+			query:          "?q=kickoff&lang=french",
+			wantQuery:      &SearchQuery{Query: "kickoff", Language: "french", Limit: DefaultPageLimit},
+			mockErr:        errors.New("db error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			if tt.wantQuery != nil {
+				mockRepo.On("SearchEvents", mock.Anything, *tt.wantQuery).Return(tt.mockReturn, tt.mockErr)
+			}
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/events/search"+tt.query, nil)
+
+			h.SearchEvents(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlers_PutEvents(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	now := time.Now().Truncate(time.Second)
+	valid := Event{Title: "Updated", StartTime: now, EndTime: now.Add(time.Hour)}
+
+	tests := []struct {
+		name           string
+		idParam        string
+		ifMatch        string
+		body           any
+		current        *Event
+		mockReturn     *Event
+		mockErr        error
+		expectedStatus int
+		expectedETag   string
+	}{
+		{
+			name:           "success",
+			idParam:        "123",
+			ifMatch:        `"2"`,
+			body:           valid,
+			mockReturn:     &Event{Id: "123", Title: "Updated", Version: 3},
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"3"`,
+		},
+		{
+			name:           "missing id",
+			idParam:        "",
+			ifMatch:        `"2"`,
+			body:           valid,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "list of versions",
+			idParam:        "123",
+			ifMatch:        `"1", "2"`,
+			body:           valid,
+			current:        &Event{Id: "123", Version: 2},
+			mockReturn:     &Event{Id: "123", Title: "Updated", Version: 3},
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"3"`,
+		},
+		{
+			name:           "list of stale versions",
+			idParam:        "123",
+			ifMatch:        `"1", "3"`,
+			body:           valid,
+			current:        &Event{Id: "123", Version: 2},
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "missing if-match",
+			idParam:        "123",
+			body:           valid,
+			expectedStatus: http.StatusPreconditionRequired,
+		},
+		{
+			name:           "malformed if-match",
+			idParam:        "123",
+			ifMatch:        `W/"2"`,
+			body:           valid,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "invalid json",
+			idParam:        "123",
+			ifMatch:        `"2"`,
+			body:           "invalid",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "validation failure",
+			idParam:        "123",
+			ifMatch:        `"2"`,
+			body:           Event{Title: ""},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "not found",
+			idParam:        "123",
+			ifMatch:        `"2"`,
+			body:           valid,
+			mockErr:        ErrEventNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "version mismatch",
+			idParam:        "123",
+			ifMatch:        `"2"`,
+			body:           valid,
+			mockErr:        ErrVersionMismatch,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "repository error",
+			idParam:        "123",
+			ifMatch:        `"2"`,
+			body:           valid,
+			mockErr:        errors.New("db error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			if tt.current != nil {
+				mockRepo.On("GetEventById", mock.Anything, tt.idParam).Return(tt.current, nil)
+			}
+
+			if tt.mockReturn != nil || tt.mockErr != nil {
+				mockRepo.On("UpdateEvent", mock.Anything, mock.MatchedBy(func(e *Event) bool { return e.Id == tt.idParam }), 2).
+					Return(tt.mockReturn, tt.mockErr)
+			}
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = []gin.Param{{Key: "id", Value: tt.idParam}}
+
+			var jsonBody []byte
+			if s, ok := tt.body.(string); ok {
+				jsonBody = []byte(s)
+			} else {
+				jsonBody, _ = json.Marshal(tt.body)
+			}
+
+			c.Request = httptest.NewRequest(http.MethodPut, "/events/"+tt.idParam, bytes.NewBuffer(jsonBody))
+			c.Request.Header.Set("If-Match", tt.ifMatch)
+
+			h.PutEvents(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedETag, w.Header().Get("ETag"))
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlers_PatchEvents(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	current := &Event{
+		Id: "123", Title: "Standup", Description: "Daily", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 2,
+	}
+
+	tests := []struct {
+		name           string
+		idParam        string
+		ifMatch        string
+		contentType    string
+		body           any
+		getErr         error
+		wantUpdate     *Event
+		updateErr      error
+		expectedStatus int
+	}{
+		{
+			name:        "success",
+			idParam:     "123",
+			ifMatch:     `"2"`,
+			contentType: "application/merge-patch+json",
+			body:        `{"title":"Weekly review","description":null,"id":"ignored","version":99}`,
+			wantUpdate: &Event{
+				Id: "123", Title: "Weekly review", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 2,
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing id",
+			idParam:        "",
+			ifMatch:        `"2"`,
+			contentType:    "application/merge-patch+json",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing if-match",
+			idParam:        "123",
+			contentType:    "application/merge-patch+json",
+			body:           `{}`,
+			expectedStatus: http.StatusPreconditionRequired,
+		},
+		{
+			name:           "unsupported media type",
+			idParam:        "123",
+			ifMatch:        `"2"`,
+			contentType:    "application/json-patch+json",
+			body:           `[]`,
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "read body error",
+			idParam:        "123",
+			ifMatch:        `"2"`,
+			contentType:    "application/json",
+			body:           &errReader{},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "not found",
+			idParam:        "123",
+			ifMatch:        `"2"`,
+			contentType:    "application/merge-patch+json",
+			body:           `{}`,
+			getErr:         ErrEventNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "stale version",
+			idParam:        "123",
+			ifMatch:        `"1"`,
+			contentType:    "application/merge-patch+json",
+			body:           `{"title":"Weekly review"}`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:        "list of versions",
+			idParam:     "123",
+			ifMatch:     `"1", "2"`,
+			contentType: "application/merge-patch+json",
+			body:        `{"title":"Weekly review"}`,
+			wantUpdate: &Event{
+				Id: "123", Title: "Weekly review", Description: "Daily", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 2,
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "required member removed",
+			idParam:        "123",
+			ifMatch:        `"2"`,
+			contentType:    "application/merge-patch+json",
+			body:           `{"start_time":null}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid patch",
+			idParam:        "123",
+			ifMatch:        `"2"`,
+			contentType:    "application/merge-patch+json",
+			body:           `{"title":`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "patched document is not an event",
+			idParam:        "123",
+			ifMatch:        `"2"`,
+			contentType:    "application/merge-patch+json",
+			body:           `{"start_time":"tomorrow"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "merged result fails validation",
+			idParam:        "123",
+			ifMatch:        `*`,
+			contentType:    "application/merge-patch+json",
+			body:           `{"title":null}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "concurrent update",
+			idParam:     "123",
+			ifMatch:     `"2"`,
+			contentType: "application/merge-patch+json",
+			body:        `{"title":"Weekly review"}`,
+			wantUpdate: &Event{
+				Id: "123", Title: "Weekly review", Description: "Daily", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 2,
+			},
+			updateErr:      ErrVersionMismatch,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			if tt.idParam != "" && tt.ifMatch != "" && tt.contentType != "application/json-patch+json" && tt.name != "read body error" {
+				if tt.getErr != nil {
+					mockRepo.On("GetEventById", mock.Anything, tt.idParam).Return(nil, tt.getErr)
+				} else {
+					copied := *current
+					mockRepo.On("GetEventById", mock.Anything, tt.idParam).Return(&copied, nil)
+				}
+			}
+
+			if tt.wantUpdate != nil {
+				updated := *tt.wantUpdate
+				updated.Version++
+				mockRepo.On("UpdateEvent", mock.Anything, tt.wantUpdate, 2).Return(&updated, tt.updateErr)
+			}
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = []gin.Param{{Key: "id", Value: tt.idParam}}
+
+			var reader io.Reader
+			if r, ok := tt.body.(io.Reader); ok {
+				reader = r
+			} else {
+				reader = bytes.NewBufferString(tt.body.(string))
+			}
+
+			c.Request = httptest.NewRequest(http.MethodPatch, "/events/"+tt.idParam, reader)
+			c.Request.Header.Set("If-Match", tt.ifMatch)
+			c.Request.Header.Set("Content-Type", tt.contentType)
+
+			h.PatchEvents(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlers_DeleteEvents(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		idParam        string
+		mockErr        error
+		expectedStatus int
+	}{
+		{name: "success", idParam: "123", expectedStatus: http.StatusNoContent},
+		{name: "missing id", idParam: "", expectedStatus: http.StatusBadRequest},
+		{name: "not found", idParam: "123", mockErr: ErrEventNotFound, expectedStatus: http.StatusNotFound},
+		{name: "repository error", idParam: "123", mockErr: errors.New("db error"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			if tt.idParam != "" {
+				mockRepo.On("DeleteEvent", mock.Anything, tt.idParam).Return(tt.mockErr)
+			}
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = []gin.Param{{Key: "id", Value: tt.idParam}}
+			c.Request = httptest.NewRequest(http.MethodDelete, "/events/"+tt.idParam, nil)
+
+			h.DeleteEvents(c)
+			c.Writer.WriteHeaderNow()
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlers_RestoreEvents(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		idParam        string
+		mockReturn     *Event
+		mockErr        error
+		expectedStatus int
+	}{
+		{name: "success", idParam: "123", mockReturn: &Event{Id: "123", Version: 3}, expectedStatus: http.StatusOK},
+		{name: "missing id", idParam: "", expectedStatus: http.StatusBadRequest},
+		{name: "not in trash", idParam: "123", mockErr: ErrEventNotFound, expectedStatus: http.StatusNotFound},
+		{name: "repository error", idParam: "123", mockErr: errors.New("db error"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			if tt.idParam != "" {
+				mockRepo.On("RestoreEvent", mock.Anything, tt.idParam).Return(tt.mockReturn, tt.mockErr)
+			}
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = []gin.Param{{Key: "id", Value: tt.idParam}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/events/"+tt.idParam+"/restore", nil)
+
+			h.RestoreEvents(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlers_malformedEventId(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	// Postgres rejects ids that are not UUIDs before looking for them
+	malformed := &pgconn.PgError{Code: "22P02"}
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock pgxmock.PgxPoolIface)
+		handle    func(h *Handlers, c *gin.Context)
+		method    string
+		path      string
+	}{
+		{
+			name: "delete",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE events SET deleted_at").WithArgs("not-a-uuid").WillReturnError(malformed)
+				mock.ExpectRollback()
+			},
+			handle: (*Handlers).DeleteEvents,
+			method: http.MethodDelete,
+			path:   "/events/not-a-uuid",
+		},
+		{
+			name: "restore",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE events SET deleted_at = NULL").WithArgs("not-a-uuid").WillReturnError(malformed)
+				mock.ExpectRollback()
+			},
+			handle: (*Handlers).RestoreEvents,
+			method: http.MethodPost,
+			path:   "/events/not-a-uuid/restore",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pool, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer pool.Close()
+
+			tt.mockSetup(pool)
+
+			h := NewHandlers(NewRepository(pool), nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = []gin.Param{{Key: "id", Value: "not-a-uuid"}}
+			c.Request = httptest.NewRequest(tt.method, tt.path, nil)
+
+			tt.handle(h, c)
+
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			require.NoError(t, pool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestHandlers_ListTrash(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		query          string
+		wantFilter     *EventFilter
+		mockReturn     *EventPage
+		mockErr        error
+		expectedStatus int
+	}{
+		{
+			name:           "success",
+			query:          "?limit=10",
+			wantFilter:     &EventFilter{Limit: 10, Trashed: true},
+			mockReturn:     &EventPage{Events: []Event{{Id: "123"}}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid limit",
+			query:          "?limit=abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "repository error",
+			query:          "",
+			wantFilter:     &EventFilter{Limit: DefaultPageLimit, Trashed: true},
+			mockErr:        errors.New("db error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			if tt.wantFilter != nil {
+				mockRepo.On("ListEvents", mock.Anything, *tt.wantFilter).Return(tt.mockReturn, tt.mockErr)
+			}
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/events/trash"+tt.query, nil)
+
+			h.ListTrash(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlers_ListOccurrences(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)
+	weekly := Event{
+		Id:        "123",
+		Title:     "Standup",
+		StartTime: time.Date(2025, 2, 3, 9, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2025, 2, 3, 9, 15, 0, 0, time.UTC),
+		RRule:     "FREQ=WEEKLY",
+	}
+	hourly := Event{
+		Id:        "456",
+		Title:     "Ping",
+		StartTime: from,
+		EndTime:   from.Add(time.Minute),
+		RRule:     "FREQ=HOURLY",
+	}
+
+	tests := []struct {
+		name            string
+		query           string
+		mockCalled      bool
+		mockReturn      []Series
+		mockErr         error
+		expectedStatus  int
+		wantOccurrences int
+	}{
+		{
+			name:            "success",
+			query:           "?from=2025-03-01T00:00:00Z&to=2025-03-15T00:00:00Z",
+			mockCalled:      true,
+			mockReturn:      []Series{{Event: weekly}},
+			expectedStatus:  http.StatusOK,
+			wantOccurrences: 2,
+		},
+		{
+			name:           "missing to",
+			query:          "?from=2025-03-01T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "from after to",
+			query:          "?from=2025-03-15T00:00:00Z&to=2025-03-01T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "window too long",
+			query:          "?from=2025-01-01T00:00:00Z&to=2026-06-01T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "too many occurrences",
+			query:          "?from=2025-03-01T00:00:00Z&to=2025-03-15T00:00:00Z",
+			mockCalled:     true,
+			mockReturn:     slices.Repeat([]Series{{Event: hourly}}, 15), // 15 x 336 hourly occurrences
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "repository error",
+			query:          "?from=2025-03-01T00:00:00Z&to=2025-03-15T00:00:00Z",
+			mockCalled:     true,
+			mockErr:        errors.New("db error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			if tt.mockCalled {
+				mockRepo.On("ListSeries", mock.Anything, TimeWindow{From: from, To: to}).Return(tt.mockReturn, tt.mockErr)
+			}
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/events/occurrences"+tt.query, nil)
+
+			h.ListOccurrences(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				var body struct {
+					Occurrences []Occurrence `json:"occurrences"`
+				}
+
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Len(t, body.Occurrences, tt.wantOccurrences)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlers_PostFreeBusy(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	from := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+
+	tests := []struct {
+		name           string
+		body           string
+		wantQuery      *FreeBusyQuery
+		mockReturn     []BusyInterval
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "success",
+			body:           `{"from":"2025-03-03T00:00:00Z","to":"2025-03-04T00:00:00Z","calendar_id":"4acc05c4-3526-4c09-8739-621c4b57c8e6"}`,
+			wantQuery:      &FreeBusyQuery{From: from, To: to, CalendarId: "4acc05c4-3526-4c09-8739-621c4b57c8e6"},
+			mockReturn:     []BusyInterval{{Start: from.Add(9 * time.Hour), End: from.Add(11 * time.Hour)}},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"from":"2025-03-03T00:00:00Z","to":"2025-03-04T00:00:00Z",` +
+				`"busy":[{"start":"2025-03-03T09:00:00Z","end":"2025-03-03T11:00:00Z"}]}`,
+		},
+		{
+			name:           "malformed body",
+			body:           `{"from":"monday"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "reversed window",
+			body:           `{"from":"2025-03-04T00:00:00Z","to":"2025-03-03T00:00:00Z"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "too many occurrences",
+			body:           `{"from":"2025-03-03T00:00:00Z","to":"2025-03-04T00:00:00Z"}`,
+			wantQuery:      &FreeBusyQuery{From: from, To: to},
+			mockErr:        ErrTooManyOccurrences,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "repository error",
+			body:           `{"from":"2025-03-03T00:00:00Z","to":"2025-03-04T00:00:00Z"}`,
+			wantQuery:      &FreeBusyQuery{From: from, To: to},
+			mockErr:        errors.New("db error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			if tt.wantQuery != nil {
+				mockRepo.On("FreeBusy", mock.Anything, *tt.wantQuery).Return(tt.mockReturn, tt.mockErr)
+			}
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/freebusy", strings.NewReader(tt.body))
+
+			h.PostFreeBusy(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlers_PutOccurrences(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	event := &Event{Id: "123", Title: "Standup", StartTime: start, EndTime: start.Add(15 * time.Minute), RRule: "FREQ=WEEKLY"}
+	recurrenceId := start.AddDate(0, 0, 7)
+
+	tests := []struct {
+		name           string
+		recurrenceId   string
+		body           string
+		getCalled      bool
+		getErr         error
+		wantOverride   *Override
+		saveErr        error
+		expectedStatus int
+	}{
+		{
+			name:         "rename",
+			recurrenceId: "2025-03-10T09:00:00Z",
+			body:         `{"title":"Retro"}`,
+			getCalled:    true,
+			wantOverride: &Override{
+				EventId: "123", RecurrenceId: recurrenceId, Title: "Retro",
+				StartTime: recurrenceId, EndTime: recurrenceId.Add(15 * time.Minute),
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:         "move",
+			recurrenceId: "2025-03-10T09:00:00Z",
+			body:         `{"start_time":"2025-03-11T10:00:00Z","end_time":"2025-03-11T10:30:00Z"}`,
+			getCalled:    true,
+			wantOverride: &Override{
+				EventId: "123", RecurrenceId: recurrenceId,
+				StartTime: time.Date(2025, 3, 11, 10, 0, 0, 0, time.UTC), EndTime: time.Date(2025, 3, 11, 10, 30, 0, 0, time.UTC),
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid recurrence id",
+			recurrenceId:   "next-monday",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid body",
+			recurrenceId:   "2025-03-10T09:00:00Z",
+			body:           `{"start_time":"2025-03-11T10:00:00Z"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "not an occurrence",
+			recurrenceId:   "2025-03-11T09:00:00Z",
+			body:           `{"title":"Retro"}`,
+			getCalled:      true,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "event not found",
+			recurrenceId:   "2025-03-10T09:00:00Z",
+			body:           `{"title":"Retro"}`,
+			getCalled:      true,
+			getErr:         ErrEventNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:         "repository error",
+			recurrenceId: "2025-03-10T09:00:00Z",
+			body:         `{"title":"Retro"}`,
+			getCalled:    true,
+			wantOverride: &Override{
+				EventId: "123", RecurrenceId: recurrenceId, Title: "Retro",
+				StartTime: recurrenceId, EndTime: recurrenceId.Add(15 * time.Minute),
+			},
+			saveErr:        errors.New("db error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			if tt.getCalled {
+				if tt.getErr != nil {
+					mockRepo.On("GetEventById", mock.Anything, "123").Return(nil, tt.getErr)
+				} else {
+					mockRepo.On("GetEventById", mock.Anything, "123").Return(event, nil)
+				}
+			}
+
+			if tt.wantOverride != nil {
+				if tt.saveErr != nil {
+					mockRepo.On("SaveOverride", mock.Anything, tt.wantOverride).Return(nil, tt.saveErr)
+				} else {
+					mockRepo.On("SaveOverride", mock.Anything, tt.wantOverride).Return(tt.wantOverride, nil)
+				}
+			}
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = []gin.Param{{Key: "id", Value: "123"}, {Key: "recurrence_id", Value: tt.recurrenceId}}
+			c.Request = httptest.NewRequest(http.MethodPut, "/events/123/occurrences/"+tt.recurrenceId, bytes.NewBufferString(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			h.PutOccurrences(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlers_DeleteOccurrences(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	event := &Event{Id: "123", Title: "Standup", StartTime: start, EndTime: start.Add(15 * time.Minute), RRule: "FREQ=WEEKLY"}
+	recurrenceId := start.AddDate(0, 0, 7)
+	cancelled := &Override{
+		EventId: "123", RecurrenceId: recurrenceId, StartTime: recurrenceId, EndTime: recurrenceId.Add(15 * time.Minute), Cancelled: true,
+	}
+
+	tests := []struct {
+		name           string
+		idParam        string
+		getCalled      bool
+		saveErr        error
+		expectedStatus int
+	}{
+		{name: "success", idParam: "123", getCalled: true, expectedStatus: http.StatusNoContent},
+		{name: "missing id", idParam: "", expectedStatus: http.StatusBadRequest},
+		{name: "event not found", idParam: "123", getCalled: true, saveErr: ErrEventNotFound, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			if tt.getCalled {
+				mockRepo.On("GetEventById", mock.Anything, "123").Return(event, nil)
+
+				if tt.saveErr != nil {
+					mockRepo.On("SaveOverride", mock.Anything, cancelled).Return(nil, tt.saveErr)
+				} else {
+					mockRepo.On("SaveOverride", mock.Anything, cancelled).Return(cancelled, nil)
+				}
+			}
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = []gin.Param{{Key: "id", Value: tt.idParam}, {Key: "recurrence_id", Value: "2025-03-10T09:00:00Z"}}
+			c.Request = httptest.NewRequest(http.MethodDelete, "/events/"+tt.idParam+"/occurrences/2025-03-10T09:00:00Z", nil)
+
+			h.DeleteOccurrences(c)
+			c.Writer.WriteHeaderNow()
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlers_ExportEvent(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2025, 12, 22, 9, 0, 0, 0, time.UTC)
+	updated := time.Date(2025, 12, 20, 10, 0, 0, 0, time.UTC)
+	single := &Event{Id: "123", Title: "Kickoff", StartTime: start, EndTime: start.Add(time.Hour), CreatedAt: updated, UpdatedAt: updated, Version: 1}
+	weekly := &Event{Id: "123", Title: "Standup", StartTime: start, EndTime: start.Add(time.Hour), CreatedAt: updated, UpdatedAt: updated, Version: 1, RRule: "FREQ=WEEKLY"}
+
+	tests := []struct {
+		name              string
+		idParam           string
+		headers           map[string]string
+		mockReturn        *Event
+		mockErr           error
+		overridesCalled   bool
+		overridesErr      error
+		expectedStatus    int
+		wantContains      string
+		wantContentHeader bool
+	}{
+		{
+			name:              "success",
+			idParam:           "123.ics",
+			mockReturn:        single,
+			expectedStatus:    http.StatusOK,
+			wantContains:      "UID:123\r\n",
+			wantContentHeader: true,
+		},
+		{
+			name:              "series with overrides",
+			idParam:           "123.ics",
+			mockReturn:        weekly,
+			overridesCalled:   true,
+			expectedStatus:    http.StatusOK,
+			wantContains:      "RRULE:FREQ=WEEKLY\r\n",
+			wantContentHeader: true,
+		},
+		{
+			name:           "not modified since",
+			idParam:        "123.ics",
+			headers:        map[string]string{"If-Modified-Since": updated.Format(http.TimeFormat)},
+			mockReturn:     single,
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "missing id",
+			idParam:        ".ics",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "not found",
+			idParam:        "123.ics",
+			mockErr:        ErrEventNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "repository error",
+			idParam:        "123.ics",
+			mockErr:        errors.New("db error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:            "overrides error",
+			idParam:         "123.ics",
+			mockReturn:      weekly,
+			overridesCalled: true,
+			overridesErr:    errors.New("db error"),
+			expectedStatus:  http.StatusInternalServerError,
+		},
+		{
+			name:           "invalid time zone",
+			idParam:        "123.ics",
+			mockReturn:     &Event{Id: "123", TimeZone: "Nowhere/Special"},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			if tt.idParam != ".ics" {
+				if tt.mockErr != nil {
+					mockRepo.On("GetEventById", mock.Anything, "123").Return(nil, tt.mockErr)
+				} else {
+					mockRepo.On("GetEventById", mock.Anything, "123").Return(tt.mockReturn, nil)
+				}
+			}
+
+			if tt.overridesCalled {
+				if tt.overridesErr != nil {
+					mockRepo.On("ListOverrides", mock.Anything, []string{"123"}).Return(nil, tt.overridesErr)
+				} else {
+					mockRepo.On("ListOverrides", mock.Anything, []string{"123"}).Return([]Override{}, nil)
+				}
+			}
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = []gin.Param{{Key: "id", Value: tt.idParam}}
+			c.Request = httptest.NewRequest(http.MethodGet, "/events/"+tt.idParam, nil)
+
+			for k, v := range tt.headers {
+				c.Request.Header.Set(k, v)
+			}
+
+			// GET /events/:id.ics is routed through GetEvents
+			h.GetEvents(c)
+			c.Writer.WriteHeaderNow()
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantContains)
+
+			if tt.wantContentHeader {
+				assert.Equal(t, CalendarContentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, updated.Format(http.TimeFormat), w.Header().Get("Last-Modified"))
+				assert.Equal(t, ContentETag(w.Body.Bytes()), w.Header().Get("ETag"))
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlers_ExportEvents(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2025, 12, 22, 9, 0, 0, 0, time.UTC)
+	page := &EventPage{Events: []Event{
+		{Id: "123", Title: "Kickoff", StartTime: start, EndTime: start.Add(time.Hour), Version: 1, UpdatedAt: start},
+		{Id: "456", Title: "Review", StartTime: start.Add(2 * time.Hour), EndTime: start.Add(3 * time.Hour), Version: 1, UpdatedAt: start},
+	}}
+	body, err := EncodeCalendar(page.Events, nil)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		query          string
+		headers        map[string]string
+		wantFilter     *EventFilter
+		mockReturn     *EventPage
+		mockErr        error
+		expectedStatus int
+	}{
+		{
+			name:           "success",
+			query:          "?title_prefix=k&limit=5",
+			wantFilter:     &EventFilter{Limit: MaxFeedEvents, TitlePrefix: "k", Feed: true},
+			mockReturn:     page,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "not modified",
+			query:          "",
+			headers:        map[string]string{"If-None-Match": ContentETag(body)},
+			wantFilter:     &EventFilter{Limit: MaxFeedEvents, Feed: true},
+			mockReturn:     page,
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "if-modified-since ignored",
+			query:          "",
+			headers:        map[string]string{"If-Modified-Since": start.Add(time.Hour).Format(http.TimeFormat)},
+			wantFilter:     &EventFilter{Limit: MaxFeedEvents, Feed: true},
+			mockReturn:     page,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid filter",
+			query:          "?starts_after=monday",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "repository error",
+			query:          "",
+			wantFilter:     &EventFilter{Limit: MaxFeedEvents, Feed: true},
+			mockErr:        errors.New("db error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			if tt.wantFilter != nil {
+				mockRepo.On("ListEvents", mock.Anything, *tt.wantFilter).Return(tt.mockReturn, tt.mockErr)
+			}
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/events.ics"+tt.query, nil)
+
+			for k, v := range tt.headers {
+				c.Request.Header.Set(k, v)
+			}
+
+			h.ExportEvents(c)
+			c.Writer.WriteHeaderNow()
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, body, w.Body.Bytes())
+				assert.Equal(t, ContentETag(body), w.Header().Get("ETag"))
+				assert.Empty(t, w.Header().Get("Last-Modified"))
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlers_ImportEvents(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	document := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//test//EN\r\n" +
+		"BEGIN:VEVENT\r\nUID:one@example.com\r\nDTSTAMP:20251201T000000Z\r\nSUMMARY:Kickoff\r\n" +
+		"DTSTART:20251222T090000Z\r\nDTEND:20251222T100000Z\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:two@example.com\r\nDTSTAMP:20251201T000000Z\r\nSUMMARY:Review\r\n" +
+		"DTSTART:20251222T110000Z\r\nDTEND:20251222T120000Z\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		mockSetup      func(m *MockRepository)
+		expectedStatus int
+		wantReport     *ImportReport
+	}{
+		{
+			name:        "created and skipped",
+			contentType: "text/calendar; charset=utf-8",
+			body:        document,
+			mockSetup: func(m *MockRepository) {
+				m.On("ImportEvent", mock.Anything, mock.MatchedBy(func(s *Series) bool { return s.Event.Uid == "one@example.com" })).
+					Return(&Event{Id: "123"}, nil)
+				m.On("ImportEvent", mock.Anything, mock.MatchedBy(func(s *Series) bool { return s.Event.Uid == "two@example.com" })).
+					Return(nil, ErrEventExists)
+			},
+			expectedStatus: http.StatusOK,
+			wantReport: &ImportReport{Created: 1, Skipped: 1, Results: []ImportResult{
+				{Index: 0, Uid: "one@example.com", Status: ImportCreated, EventId: "123"},
+				{Index: 1, Uid: "two@example.com", Status: ImportSkipped, Reason: "already imported"},
+			}},
+		},
+		{
+			name:           "wrong content type",
+			contentType:    "application/json",
+			body:           "{}",
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "malformed calendar",
+			contentType:    "text/calendar",
+			body:           "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "too large",
+			contentType:    "text/calendar",
+			body:           strings.Repeat("X", MaxImportSize+1),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockRepo)
+			}
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/events/import", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", tt.contentType)
+
+			h.ImportEvents(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.wantReport != nil {
+				var got ImportReport
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+				assert.Equal(t, *tt.wantReport, got)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlers_BatchEvents(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	event := Event{Title: "Kickoff", StartTime: start, EndTime: start.Add(time.Hour)}
+
+	tests := []struct {
+		name           string
+		action         string
+		query          string
+		body           string
+		mockSetup      func(m *MockRepository)
+		expectedStatus int
+	}{
+		{
+			name:   "success",
+			action: ":batch",
+			body:   `[{"title":"Kickoff","start_time":"2025-03-03T09:00:00Z","end_time":"2025-03-03T10:00:00Z"}]`,
+			mockSetup: func(m *MockRepository) {
+				m.On("SaveEvents", mock.Anything, mock.MatchedBy(func(events []Event) bool {
+					return len(events) == 1 && events[0].StartTime.Equal(event.StartTime) && events[0].Title == event.Title
+				})).Return([]Event{{Id: "123", Title: "Kickoff"}}, nil)
+			},
+			expectedStatus: http.StatusMultiStatus,
+		},
+		{
+			name:           "unknown action",
+			action:         ":explode",
+			body:           `[]`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid mode",
+			action:         ":batch",
+			query:          "?mode=sometimes",
+			body:           `[]`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "not an array",
+			action:         ":batch",
+			body:           `{"title":"Kickoff"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "empty batch",
+			action:         ":batch",
+			body:           `[]`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockRepo)
+			}
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/events"+tt.action+tt.query, strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Params = gin.Params{{Key: "action", Value: tt.action}}
+
+			h.PostEventsAction(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedStatus == http.StatusMultiStatus {
+				var got BatchResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+				assert.Equal(t, 1, got.Created)
+				assert.Equal(t, "123", got.Results[0].Event.Id)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlers_CheckConflicts(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		body           string
+		mockReturn     []Event
+		mockErr        error
+		expectedStatus int
+	}{
+		{
+			name:           "conflicts",
+			body:           `{"title":"Sync","start_time":"2025-03-03T09:00:00Z","end_time":"2025-03-03T10:00:00Z","scope":"room-1"}`,
+			mockReturn:     []Event{{Id: "uuid-456", Title: "Standup", Scope: "room-1"}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid event",
+			body:           `{"title":"Sync","start_time":"2025-03-03T10:00:00Z","end_time":"2025-03-03T09:00:00Z","scope":"room-1"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid id",
+			body:           `{"id":"123","title":"Sync","start_time":"2025-03-03T09:00:00Z","end_time":"2025-03-03T10:00:00Z","scope":"room-1"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "repository failure",
+			body:           `{"title":"Sync","start_time":"2025-03-03T09:00:00Z","end_time":"2025-03-03T10:00:00Z","scope":"room-1"}`,
+			mockErr:        errors.New("db error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			if tt.expectedStatus != http.StatusBadRequest {
+				mockRepo.On("FindConflicts", mock.Anything, mock.MatchedBy(func(e Event) bool { return e.Scope == "room-1" })).
+					Return(tt.mockReturn, tt.mockErr)
+			}
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/events:conflicts", strings.NewReader(tt.body))
+			c.Params = gin.Params{{Key: "action", Value: ":conflicts"}}
+
+			h.PostEventsAction(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				var got struct {
+					Conflicts []Event `json:"conflicts"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+				assert.Equal(t, tt.mockReturn, got.Conflicts)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlers_Calendars(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	calendar := &Calendar{Id: "uuid-c", Name: "Work", TimeZone: "Europe/Madrid"}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		setupMock      func(m *MockRepository)
+		handle         func(h *Handlers, c *gin.Context)
+		expectedStatus int
+	}{
+		{
+			name:   "create",
+			method: http.MethodPost,
+			path:   "/calendars",
+			body:   `{"name":"Work","time_zone":"Europe/Madrid"}`,
+			setupMock: func(m *MockRepository) {
+				m.On("SaveCalendar", mock.Anything, &Calendar{Name: "Work", TimeZone: "Europe/Madrid"}).Return(calendar, nil)
+			},
+			handle:         (*Handlers).PostCalendars,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "create invalid",
+			method:         http.MethodPost,
+			path:           "/calendars",
+			body:           `{"name":"Work","color":"red"}`,
+			setupMock:      func(m *MockRepository) {},
+			handle:         (*Handlers).PostCalendars,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "list",
+			method: http.MethodGet,
+			path:   "/calendars",
+			setupMock: func(m *MockRepository) {
+				m.On("ListCalendars", mock.Anything).Return([]Calendar{*calendar}, nil)
+			},
+			handle:         (*Handlers).ListCalendars,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "get",
+			method: http.MethodGet,
+			path:   "/calendars/uuid-c",
+			setupMock: func(m *MockRepository) {
+				m.On("GetCalendar", mock.Anything, "uuid-c").Return(calendar, nil)
+			},
+			handle:         (*Handlers).GetCalendars,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "get not found",
+			method: http.MethodGet,
+			path:   "/calendars/uuid-c",
+			setupMock: func(m *MockRepository) {
+				m.On("GetCalendar", mock.Anything, "uuid-c").Return(nil, ErrCalendarNotFound)
+			},
+			handle:         (*Handlers).GetCalendars,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "update",
+			method: http.MethodPut,
+			path:   "/calendars/uuid-c",
+			body:   `{"name":"Work","time_zone":"Europe/Madrid"}`,
+			setupMock: func(m *MockRepository) {
+				m.On("UpdateCalendar", mock.Anything, calendar).Return(calendar, nil)
+			},
+			handle:         (*Handlers).PutCalendars,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "update not found",
+			method: http.MethodPut,
+			path:   "/calendars/uuid-c",
+			body:   `{"name":"Work","time_zone":"Europe/Madrid"}`,
+			setupMock: func(m *MockRepository) {
+				m.On("UpdateCalendar", mock.Anything, calendar).Return(nil, ErrCalendarNotFound)
+			},
+			handle:         (*Handlers).PutCalendars,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "delete",
+			method: http.MethodDelete,
+			path:   "/calendars/uuid-c",
+			setupMock: func(m *MockRepository) {
+				m.On("DeleteCalendar", mock.Anything, "uuid-c", false).Return(nil)
+			},
+			handle:         (*Handlers).DeleteCalendars,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:   "delete with events",
+			method: http.MethodDelete,
+			path:   "/calendars/uuid-c",
+			setupMock: func(m *MockRepository) {
+				m.On("DeleteCalendar", mock.Anything, "uuid-c", false).Return(ErrCalendarNotEmpty)
+			},
+			handle:         (*Handlers).DeleteCalendars,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "delete cascade",
+			method: http.MethodDelete,
+			path:   "/calendars/uuid-c?cascade=true",
+			setupMock: func(m *MockRepository) {
+				m.On("DeleteCalendar", mock.Anything, "uuid-c", true).Return(nil)
+			},
+			handle:         (*Handlers).DeleteCalendars,
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			tt.setupMock(mockRepo)
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+
+			if strings.HasPrefix(tt.path, "/calendars/") {
+				c.Params = gin.Params{{Key: "id", Value: "uuid-c"}}
+			}
+
+			tt.handle(h, c)
+			c.Writer.WriteHeaderNow()
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlers_ListCalendarEvents(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		calendarErr    error
+		expectedStatus int
+	}{
+		{name: "events of the calendar", expectedStatus: http.StatusOK},
+		{name: "unknown calendar", calendarErr: ErrCalendarNotFound, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			if tt.calendarErr != nil {
+				mockRepo.On("GetCalendar", mock.Anything, "uuid-c").Return(nil, tt.calendarErr)
+			} else {
+				mockRepo.On("GetCalendar", mock.Anything, "uuid-c").Return(&Calendar{Id: "uuid-c"}, nil)
+				mockRepo.On("ListEvents", mock.Anything, EventFilter{Limit: DefaultPageLimit, CalendarId: "uuid-c"}).
+					Return(&EventPage{Events: []Event{{Id: "uuid-1", CalendarId: "uuid-c"}}}, nil)
+			}
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/calendars/uuid-c/events", nil)
+			c.Params = gin.Params{{Key: "id", Value: "uuid-c"}}
+
+			h.ListCalendarEvents(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlers_PostCalendarEvents(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		body           string
+		calendarErr    error
+		wantTimeZone   string
+		wantStart      time.Time
+		expectedStatus int
+	}{
+		{
+			name:           "inherits the calendar time zone",
+			body:           `{"title":"Sync","start_time":"2025-03-03T09:00:00Z","end_time":"2025-03-03T10:00:00Z"}`,
+			wantTimeZone:   "Europe/Madrid",
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "keeps its own time zone",
+			body:           `{"title":"Sync","start_time":"2025-03-03T09:00:00Z","end_time":"2025-03-03T10:00:00Z","time_zone":"Asia/Tokyo"}`,
+			wantTimeZone:   "Asia/Tokyo",
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "all-day dates in the calendar time zone",
+			body:           `{"title":"Offsite","all_day":true,"start_time":"2025-03-03","end_time":"2025-03-04"}`,
+			wantTimeZone:   "Europe/Madrid",
+			wantStart:      time.Date(2025, 3, 3, 0, 0, 0, 0, madrid),
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "unknown calendar",
+			body:           `{"title":"Sync","start_time":"2025-03-03T09:00:00Z","end_time":"2025-03-03T10:00:00Z"}`,
+			calendarErr:    ErrCalendarNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			if tt.calendarErr != nil {
+				mockRepo.On("GetCalendar", mock.Anything, "uuid-c").Return(nil, tt.calendarErr)
+			} else {
+				mockRepo.On("GetCalendar", mock.Anything, "uuid-c").Return(&Calendar{Id: "uuid-c", TimeZone: "Europe/Madrid"}, nil)
+				mockRepo.On("SaveEvent", mock.Anything, mock.MatchedBy(func(e *Event) bool {
+					return e.CalendarId == "uuid-c" && e.TimeZone == tt.wantTimeZone && (tt.wantStart.IsZero() || e.StartTime.Equal(tt.wantStart))
+				})).Return(&Event{Id: "uuid-1", CalendarId: "uuid-c", TimeZone: tt.wantTimeZone, Version: 1}, nil)
+			}
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/calendars/uuid-c/events", strings.NewReader(tt.body))
+			c.Params = gin.Params{{Key: "id", Value: "uuid-c"}}
+
+			h.PostCalendarEvents(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlers_Attendees(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	attendee := &Attendee{Id: "uuid-a", EventId: "uuid-1", Email: "ada@example.com", Status: RsvpNeedsAction}
+
+	tests := []struct {
+		name           string
+		method         string
+		body           string
+		setupMock      func(m *MockRepository)
+		handle         func(h *Handlers, c *gin.Context)
+		expectedStatus int
+	}{
+		{
+			name:   "invite",
+			method: http.MethodPost,
+			body:   `{"email":"ada@example.com"}`,
+			setupMock: func(m *MockRepository) {
+				m.On("SaveAttendee", mock.Anything, &Attendee{EventId: "uuid-1", Email: "ada@example.com"}).Return(attendee, nil)
+			},
+			handle:         (*Handlers).PostAttendees,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "invite invalid email",
+			method:         http.MethodPost,
+			body:           `{"email":"ada"}`,
+			setupMock:      func(m *MockRepository) {},
+			handle:         (*Handlers).PostAttendees,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "invite twice",
+			method: http.MethodPost,
+			body:   `{"email":"ada@example.com"}`,
+			setupMock: func(m *MockRepository) {
+				m.On("SaveAttendee", mock.Anything, mock.Anything).Return(nil, ErrAttendeeExists)
+			},
+			handle:         (*Handlers).PostAttendees,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "invite to unknown event",
+			method: http.MethodPost,
+			body:   `{"email":"ada@example.com"}`,
+			setupMock: func(m *MockRepository) {
+				m.On("SaveAttendee", mock.Anything, mock.Anything).Return(nil, ErrEventNotFound)
+			},
+			handle:         (*Handlers).PostAttendees,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "list",
+			method: http.MethodGet,
+			setupMock: func(m *MockRepository) {
+				m.On("GetEventById", mock.Anything, "uuid-1").Return(&Event{Id: "uuid-1"}, nil)
+				m.On("ListAttendees", mock.Anything, "uuid-1").Return([]Attendee{*attendee}, nil)
+			},
+			handle:         (*Handlers).ListAttendees,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "list of unknown event",
+			method: http.MethodGet,
+			setupMock: func(m *MockRepository) {
+				m.On("GetEventById", mock.Anything, "uuid-1").Return(nil, ErrEventNotFound)
+			},
+			handle:         (*Handlers).ListAttendees,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "rsvp",
+			method: http.MethodPut,
+			body:   `{"status":"accepted"}`,
+			setupMock: func(m *MockRepository) {
+				m.On("UpdateAttendeeStatus", mock.Anything, &Attendee{Id: "uuid-a", EventId: "uuid-1", Status: RsvpAccepted}).
+					Return(attendee, nil)
+			},
+			handle:         (*Handlers).PutRsvp,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "rsvp invalid status",
+			method:         http.MethodPut,
+			body:           `{"status":"maybe"}`,
+			setupMock:      func(m *MockRepository) {},
+			handle:         (*Handlers).PutRsvp,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "rsvp of unknown attendee",
+			method: http.MethodPut,
+			body:   `{"status":"declined"}`,
+			setupMock: func(m *MockRepository) {
+				m.On("UpdateAttendeeStatus", mock.Anything, mock.Anything).Return(nil, ErrAttendeeNotFound)
+			},
+			handle:         (*Handlers).PutRsvp,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "uninvite",
+			method: http.MethodDelete,
+			setupMock: func(m *MockRepository) {
+				m.On("DeleteAttendee", mock.Anything, "uuid-1", "uuid-a").Return(nil)
+			},
+			handle:         (*Handlers).DeleteAttendees,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:   "uninvite failure",
+			method: http.MethodDelete,
+			setupMock: func(m *MockRepository) {
+				m.On("DeleteAttendee", mock.Anything, "uuid-1", "uuid-a").Return(errors.New("db error"))
+			},
+			handle:         (*Handlers).DeleteAttendees,
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			tt.setupMock(mockRepo)
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(tt.method, "/events/uuid-1/attendees", strings.NewReader(tt.body))
+			c.Params = gin.Params{{Key: "id", Value: "uuid-1"}, {Key: "attendee_id", Value: "uuid-a"}}
+
+			tt.handle(h, c)
+			c.Writer.WriteHeaderNow()
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlers_Reminders(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	reminder := &Reminder{Id: "uuid-r", EventId: "uuid-1", OffsetMinutes: 15, Channel: ChannelEmail}
+
+	tests := []struct {
+		name           string
+		method         string
+		body           string
+		setupMock      func(m *MockRepository)
+		handle         func(h *Handlers, c *gin.Context)
+		expectedStatus int
+	}{
+		{
+			name:   "set",
+			method: http.MethodPost,
+			body:   `{"offset_minutes":15,"channel":"email"}`,
+			setupMock: func(m *MockRepository) {
+				m.On("SaveReminder", mock.Anything, &Reminder{EventId: "uuid-1", OffsetMinutes: 15, Channel: ChannelEmail}).Return(reminder, nil)
+			},
+			handle:         (*Handlers).PostReminders,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "set unknown channel",
+			method:         http.MethodPost,
+			body:           `{"offset_minutes":15,"channel":"pigeon"}`,
+			setupMock:      func(m *MockRepository) {},
+			handle:         (*Handlers).PostReminders,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "set negative offset",
+			method:         http.MethodPost,
+			body:           `{"offset_minutes":-5,"channel":"log"}`,
+			setupMock:      func(m *MockRepository) {},
+			handle:         (*Handlers).PostReminders,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "set twice",
+			method: http.MethodPost,
+			body:   `{"offset_minutes":15,"channel":"email"}`,
+			setupMock: func(m *MockRepository) {
+				m.On("SaveReminder", mock.Anything, mock.Anything).Return(nil, ErrReminderExists)
+			},
+			handle:         (*Handlers).PostReminders,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "set on unknown event",
+			method: http.MethodPost,
+			body:   `{"offset_minutes":15,"channel":"email"}`,
+			setupMock: func(m *MockRepository) {
+				m.On("SaveReminder", mock.Anything, mock.Anything).Return(nil, ErrEventNotFound)
+			},
+			handle:         (*Handlers).PostReminders,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "list",
+			method: http.MethodGet,
+			setupMock: func(m *MockRepository) {
+				m.On("GetEventById", mock.Anything, "uuid-1").Return(&Event{Id: "uuid-1"}, nil)
+				m.On("ListReminders", mock.Anything, "uuid-1").Return([]Reminder{*reminder}, nil)
+			},
+			handle:         (*Handlers).ListReminders,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "list of unknown event",
+			method: http.MethodGet,
+			setupMock: func(m *MockRepository) {
+				m.On("GetEventById", mock.Anything, "uuid-1").Return(nil, ErrEventNotFound)
+			},
+			handle:         (*Handlers).ListReminders,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "delete",
+			method: http.MethodDelete,
+			setupMock: func(m *MockRepository) {
+				m.On("DeleteReminder", mock.Anything, "uuid-1", "uuid-r").Return(nil)
+			},
+			handle:         (*Handlers).DeleteReminders,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:   "delete unknown reminder",
+			method: http.MethodDelete,
+			setupMock: func(m *MockRepository) {
+				m.On("DeleteReminder", mock.Anything, "uuid-1", "uuid-r").Return(ErrReminderNotFound)
+			},
+			handle:         (*Handlers).DeleteReminders,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			tt.setupMock(mockRepo)
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(tt.method, "/events/uuid-1/reminders", strings.NewReader(tt.body))
+			c.Params = gin.Params{{Key: "id", Value: "uuid-1"}, {Key: "reminder_id", Value: "uuid-r"}}
+
+			tt.handle(h, c)
+			c.Writer.WriteHeaderNow()
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlers_Registrations(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	waitlisted := &Registration{Id: "uuid-r", EventId: "uuid-1", Email: "ada@example.com", Status: RegistrationWaitlisted, Position: 3}
+
+	tests := []struct {
+		name           string
+		method         string
+		body           string
+		setupMock      func(m *MockRepository)
+		handle         func(h *Handlers, c *gin.Context)
+		expectedStatus int
+	}{
+		{
+			name:   "register",
+			method: http.MethodPost,
+			body:   `{"email":"ada@example.com"}`,
+			setupMock: func(m *MockRepository) {
+				m.On("Register", mock.Anything, &Registration{EventId: "uuid-1", Email: "ada@example.com"}).Return(waitlisted, nil)
+			},
+			handle:         (*Handlers).PostRegistrations,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "register invalid email",
+			method:         http.MethodPost,
+			body:           `{"email":""}`,
+			setupMock:      func(m *MockRepository) {},
+			handle:         (*Handlers).PostRegistrations,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "register twice",
+			method: http.MethodPost,
+			body:   `{"email":"ada@example.com"}`,
+			setupMock: func(m *MockRepository) {
+				m.On("Register", mock.Anything, mock.Anything).Return(nil, ErrRegistrationExists)
+			},
+			handle:         (*Handlers).PostRegistrations,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "register to unknown event",
+			method: http.MethodPost,
+			body:   `{"email":"ada@example.com"}`,
+			setupMock: func(m *MockRepository) {
+				m.On("Register", mock.Anything, mock.Anything).Return(nil, ErrEventNotFound)
+			},
+			handle:         (*Handlers).PostRegistrations,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "list",
+			method: http.MethodGet,
+			setupMock: func(m *MockRepository) {
+				m.On("GetEventById", mock.Anything, "uuid-1").Return(&Event{Id: "uuid-1"}, nil)
+				m.On("ListRegistrations", mock.Anything, "uuid-1").Return([]Registration{*waitlisted}, nil)
+			},
+			handle:         (*Handlers).ListRegistrations,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "list of unknown event",
+			method: http.MethodGet,
+			setupMock: func(m *MockRepository) {
+				m.On("GetEventById", mock.Anything, "uuid-1").Return(nil, ErrEventNotFound)
+			},
+			handle:         (*Handlers).ListRegistrations,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "cancel",
+			method: http.MethodDelete,
+			setupMock: func(m *MockRepository) {
+				m.On("CancelRegistration", mock.Anything, "uuid-1", "uuid-r").Return(nil)
+			},
+			handle:         (*Handlers).DeleteRegistrations,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:   "cancel unknown registration",
+			method: http.MethodDelete,
+			setupMock: func(m *MockRepository) {
+				m.On("CancelRegistration", mock.Anything, "uuid-1", "uuid-r").Return(ErrRegistrationNotFound)
+			},
+			handle:         (*Handlers).DeleteRegistrations,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			tt.setupMock(mockRepo)
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(tt.method, "/events/uuid-1/registrations", strings.NewReader(tt.body))
+			c.Params = gin.Params{{Key: "id", Value: "uuid-1"}, {Key: "registration_id", Value: "uuid-r"}}
+
+			tt.handle(h, c)
+			c.Writer.WriteHeaderNow()
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlers_Webhooks(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	webhook := &Webhook{Id: "uuid-w", Url: "https://example.com/hooks", EventTypes: []string{EventCreated}}
+	secret := "0123456789abcdef"
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		body           string
+		setupMock      func(m *MockRepository)
+		handle         func(h *Handlers, c *gin.Context)
+		expectedStatus int
+		// expectedSecret is the secret in the response, "generated" for any generated one
+		expectedSecret string
+	}{
+		{
+			name:   "create",
+			method: http.MethodPost,
+			body:   `{"url":"https://example.com/hooks","event_types":["event.created"],"secret":"0123456789abcdef"}`,
+			setupMock: func(m *MockRepository) {
+				m.On("SaveWebhook", mock.Anything, &Webhook{Url: "https://example.com/hooks", EventTypes: []string{EventCreated}, Secret: secret}).
+					Return(webhook, nil)
+			},
+			handle:         (*Handlers).PostWebhooks,
+			expectedStatus: http.StatusCreated,
+			expectedSecret: secret,
+		},
+		{
+			name:   "create without secret",
+			method: http.MethodPost,
+			body:   `{"url":"https://example.com/hooks"}`,
+			setupMock: func(m *MockRepository) {
+				m.On("SaveWebhook", mock.Anything, mock.MatchedBy(func(w *Webhook) bool { return len(w.Secret) == 64 })).
+					Return(&Webhook{Id: "uuid-w", Url: "https://example.com/hooks"}, nil)
+			},
+			handle:         (*Handlers).PostWebhooks,
+			expectedStatus: http.StatusCreated,
+			expectedSecret: "generated",
+		},
+		{
+			name:           "create with relative url",
+			method:         http.MethodPost,
+			body:           `{"url":"/hooks"}`,
+			setupMock:      func(m *MockRepository) {},
+			handle:         (*Handlers).PostWebhooks,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "create with unknown event type",
+			method:         http.MethodPost,
+			body:           `{"url":"https://example.com/hooks","event_types":["event.moved"]}`,
+			setupMock:      func(m *MockRepository) {},
+			handle:         (*Handlers).PostWebhooks,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "list",
+			method: http.MethodGet,
+			setupMock: func(m *MockRepository) {
+				m.On("ListWebhooks", mock.Anything).Return([]Webhook{*webhook}, nil)
+			},
+			handle:         (*Handlers).ListWebhooks,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "get unknown webhook",
+			method: http.MethodGet,
+			setupMock: func(m *MockRepository) {
+				m.On("GetWebhook", mock.Anything, "uuid-w").Return(nil, ErrWebhookNotFound)
+			},
+			handle:         (*Handlers).GetWebhooks,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "update keeping the secret",
+			method: http.MethodPut,
+			body:   `{"url":"https://example.com/hooks","event_types":["event.created"]}`,
+			setupMock: func(m *MockRepository) {
+				m.On("UpdateWebhook", mock.Anything, &Webhook{Id: "uuid-w", Url: "https://example.com/hooks", EventTypes: []string{EventCreated}}).
+					Return(webhook, nil)
+			},
+			handle:         (*Handlers).PutWebhooks,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "delete",
+			method: http.MethodDelete,
+			setupMock: func(m *MockRepository) {
+				m.On("DeleteWebhook", mock.Anything, "uuid-w").Return(nil)
+			},
+			handle:         (*Handlers).DeleteWebhooks,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:   "list dead deliveries",
+			method: http.MethodGet,
+			target: "?status=dead",
+			setupMock: func(m *MockRepository) {
+				m.On("GetWebhook", mock.Anything, "uuid-w").Return(webhook, nil)
+				m.On("ListDeliveries", mock.Anything, "uuid-w", DeliveryDead).Return([]Delivery{{Id: "uuid-d", Status: DeliveryDead}}, nil)
+			},
+			handle:         (*Handlers).ListDeliveries,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "list deliveries of unknown status",
+			method:         http.MethodGet,
+			target:         "?status=lost",
+			setupMock:      func(m *MockRepository) {},
+			handle:         (*Handlers).ListDeliveries,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "redeliver",
+			method: http.MethodPost,
+			setupMock: func(m *MockRepository) {
+				m.On("RedeliverDelivery", mock.Anything, "uuid-w", "uuid-d").Return(&Delivery{Id: "uuid-d", Status: DeliveryPending}, nil)
+			},
+			handle:         (*Handlers).PostRedeliver,
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:   "redeliver unknown delivery",
+			method: http.MethodPost,
+			setupMock: func(m *MockRepository) {
+				m.On("RedeliverDelivery", mock.Anything, "uuid-w", "uuid-d").Return(nil, ErrDeliveryNotFound)
+			},
+			handle:         (*Handlers).PostRedeliver,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockRepo := new(MockRepository)
+			tt.setupMock(mockRepo)
+
+			h := NewHandlers(mockRepo, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(tt.method, "/webhooks/uuid-w"+tt.target, strings.NewReader(tt.body))
+			c.Params = gin.Params{{Key: "id", Value: "uuid-w"}, {Key: "delivery_id", Value: "uuid-d"}}
+
+			tt.handle(h, c)
+			c.Writer.WriteHeaderNow()
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockRepo.AssertExpectations(t)
+
+			if w.Code == http.StatusOK || w.Code == http.StatusCreated {
+				var got struct {
+					Secret string `json:"secret"`
+				}
+
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+
+				if tt.expectedSecret == "generated" {
+					assert.Len(t, got.Secret, 64)
+				} else {
+					assert.Equal(t, tt.expectedSecret, got.Secret)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlers_StreamEvents(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	created := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		lastEventId    string
+		heartbeat      time.Duration
+		withoutStream  bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "resumes after Last-Event-ID",
+			lastEventId:    "1",
+			expectedStatus: http.StatusOK,
+			expectedBody: "retry: 3000\n\n" +
+				"id: 2\nevent: event.deleted\ndata: {\"id\":2,\"type\":\"event.deleted\",\"event_id\":\"uuid-2\",\"title\":\"Retro\"," +
+				"\"start_time\":\"2025-03-04T09:00:00Z\",\"series_end\":\"2025-03-04T10:00:00Z\",\"created_at\":\"2025-03-03T09:00:00Z\"}\n\n",
+		},
+		{
+			name:           "resets from a change no longer buffered",
+			lastEventId:    "0",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "retry: 3000\n\nevent: stream.reset\ndata: {}\n\n",
+		},
+		{
+			name:           "new subscriber",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "retry: 3000\n\n",
+		},
+		{
+			name:           "heartbeats",
+			heartbeat:      time.Millisecond,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "retry: 3000\n\n: heartbeat\n\n",
+		},
+		{
+			name:           "invalid Last-Event-ID",
+			lastEventId:    "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "no stream",
+			withoutStream:  true,
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			heartbeat := time.Hour
+			if tt.heartbeat > 0 {
+				heartbeat = tt.heartbeat
+			}
+
+			stream := NewChangeStream(nil, heartbeat)
+			stream.publish(Change{Id: 1, Type: EventCreated, EventId: "uuid-1", CreatedAt: created})
+			stream.publish(Change{
+				Id: 2, Type: EventDeleted, EventId: "uuid-2", Title: "Retro", StartTime: created.Add(24 * time.Hour),
+				SeriesEnd: timePtr(created.Add(25 * time.Hour)), CreatedAt: created,
+			})
+
+			h := NewHandlers(new(MockRepository), stream)
+			if tt.withoutStream {
+				h = NewHandlers(new(MockRepository), nil)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/events/stream", nil)
+
+			if tt.lastEventId != "" {
+				c.Request.Header.Set("Last-Event-ID", tt.lastEventId)
+			}
+
+			done := make(chan struct{})
+
+			go func() {
+				h.StreamEvents(c)
+				close(done)
+			}()
+
+			// Draining the stream ends the response once the subscriber got what was buffered
+			if tt.expectedStatus == http.StatusOK {
+				assert.Eventually(t, func() bool { return stream.Subscribers() == 1 }, 5*time.Second, time.Millisecond)
+
+				if tt.heartbeat > 0 {
+					time.Sleep(20 * tt.heartbeat)
+				}
+
+				stream.Close()
+			}
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("stream did not end")
+			}
+
+			c.Writer.WriteHeaderNow()
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+				assert.True(t, strings.HasPrefix(w.Body.String(), tt.expectedBody), w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,361 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// CalendarContentType is the media type of iCalendar (RFC 5545) documents.
+	CalendarContentType = "text/calendar; charset=utf-8"
+	// MaxFeedEvents is the most events a single iCalendar feed holds.
+	MaxFeedEvents = 1000
+
+	calendarProductId = "-//ltk-code-challenge//events//EN"
+	// timeZoneYears is how many years of offset changes VTIMEZONE blocks describe for unbounded series.
+	timeZoneYears = 10
+	// maxLineOctets is the longest content line before folding (RFC 5545, section 3.1).
+	maxLineOctets = 75
+
+	icalDateTime = "20060102T150405"
+	icalDate     = "20060102"
+)
+
+// EncodeCalendar renders events as an RFC 5545 VCALENDAR. Moved or edited occurrences become VEVENTs with a
+// RECURRENCE-ID and cancelled ones become EXDATEs of their series. Times are written in each event's time zone,
+// which gets a VTIMEZONE block, so the output only depends on the events and is stable across requests.
+func EncodeCalendar(events []Event, overrides []Override) ([]byte, error) {
+	byEvent := make(map[string][]Override)
+	for _, o := range overrides {
+		byEvent[o.EventId] = append(byEvent[o.EventId], o)
+	}
+
+	w := &calendarWriter{}
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", calendarProductId)
+	w.line("CALSCALE", "GREGORIAN")
+
+	zones, err := calendarZones(events)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, zone := range zones {
+		w.timeZone(zone)
+	}
+
+	for _, event := range events {
+		loc, err := Location(event.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode event %s: %w", event.Id, err)
+		}
+
+		w.event(event, byEvent[event.Id], loc)
+	}
+
+	w.line("END", "VCALENDAR")
+
+	return w.buf.Bytes(), nil
+}
+
+type calendarWriter struct {
+	buf bytes.Buffer
+}
+
+// line writes a content line, folding it every 75 octets without splitting UTF-8 sequences.
+func (w *calendarWriter) line(name string, value string) {
+	line := name + ":" + value
+
+	for limit := maxLineOctets; len(line) > limit; limit = maxLineOctets - 1 {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		w.buf.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+	}
+
+	w.buf.WriteString(line + "\r\n")
+}
+
+func (w *calendarWriter) event(event Event, overrides []Override, loc *time.Location) {
+	var exdates []time.Time
+
+	exdates = append(exdates, event.ExDates...)
+	for _, o := range overrides {
+		if o.Cancelled {
+			exdates = append(exdates, o.RecurrenceId)
+		}
+	}
+
+	w.line("BEGIN", "VEVENT")
+	w.common(event)
+	w.times(event, loc, event.StartTime, event.EndTime)
+
+	if event.RRule != "" {
+		w.line("RRULE", strings.TrimPrefix(event.RRule, "RRULE:"))
+	}
+
+	if len(event.RDates) > 0 {
+		w.line(timeProperty("RDATE", event, loc, event.RDates...))
+	}
+
+	if len(exdates) > 0 {
+		w.line(timeProperty("EXDATE", event, loc, exdates...))
+	}
+
+	w.line("END", "VEVENT")
+
+	for _, o := range overrides {
+		if o.Cancelled {
+			continue
+		}
+
+		occurrence := event
+		if o.Title != "" {
+			occurrence.Title = o.Title
+		}
+
+		if o.Description != "" {
+			occurrence.Description = o.Description
+		}
+
+		w.line("BEGIN", "VEVENT")
+		w.common(occurrence)
+		w.line(timeProperty("RECURRENCE-ID", event, loc, o.RecurrenceId))
+		w.times(event, loc, o.StartTime, o.EndTime)
+		w.line("END", "VEVENT")
+	}
+}
+
+func (w *calendarWriter) common(event Event) {
+	// Imported events keep their original UID so calendar clients recognise them
+	uid := event.Id
+	if event.Uid != "" {
+		uid = event.Uid
+	}
+
+	w.line("UID", escapeText(uid))
+	w.line("DTSTAMP", event.CreatedAt.UTC().Format(icalDateTime)+"Z")
+
+	if !event.UpdatedAt.IsZero() {
+		w.line("LAST-MODIFIED", event.UpdatedAt.UTC().Format(icalDateTime)+"Z")
+	}
+
+	w.line("SEQUENCE", strconv.Itoa(max(event.Version-1, 0)))
+	w.line("SUMMARY", escapeText(event.Title))
+
+	if event.Description != "" {
+		w.line("DESCRIPTION", escapeText(event.Description))
+	}
+
+	place := event.Location
+	if place.Name != "" && place.Address != "" {
+		w.line("LOCATION", escapeText(place.Name+", "+place.Address))
+	} else if place.Name != "" || place.Address != "" {
+		w.line("LOCATION", escapeText(place.Name+place.Address))
+	}
+
+	if place.Latitude != nil && place.Longitude != nil {
+		w.line("GEO", strconv.FormatFloat(*place.Latitude, 'f', -1, 64)+";"+strconv.FormatFloat(*place.Longitude, 'f', -1, 64))
+	}
+
+	if len(event.Tags) > 0 {
+		categories := make([]string, 0, len(event.Tags))
+		for _, tag := range event.Tags {
+			categories = append(categories, escapeText(tag))
+		}
+
+		w.line("CATEGORIES", strings.Join(categories, ","))
+	}
+}
+
+func (w *calendarWriter) times(event Event, loc *time.Location, start time.Time, end time.Time) {
+	w.line(timeProperty("DTSTART", event, loc, start))
+	w.line(timeProperty("DTEND", event, loc, end))
+}
+
+// timeProperty formats DATE values for all-day events, UTC DATE-TIME values for UTC events and local DATE-TIME
+// values with a TZID otherwise.
+func timeProperty(name string, event Event, loc *time.Location, times ...time.Time) (string, string) {
+	layout, suffix := icalDateTime, ""
+
+	switch {
+	case event.AllDay:
+		name, layout = name+";VALUE=DATE", icalDate
+	case loc == time.UTC:
+		suffix = "Z"
+	default:
+		name += ";TZID=" + loc.String()
+	}
+
+	values := make([]string, 0, len(times))
+	for _, t := range times {
+		values = append(values, t.In(loc).Format(layout)+suffix)
+	}
+
+	return name, strings.Join(values, ",")
+}
+
+// escapeText escapes TEXT values (RFC 5545, section 3.3.11).
+func escapeText(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "").Replace(value)
+}
+
+type calendarZone struct {
+	loc      *time.Location
+	from, to time.Time
+}
+
+// calendarZones lists the time zones the events are written in, other than UTC, with the years they must cover.
+func calendarZones(events []Event) ([]calendarZone, error) {
+	zones := make(map[string]*calendarZone)
+
+	for _, event := range events {
+		loc, err := Location(event.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode event %s: %w", event.Id, err)
+		}
+
+		if loc == time.UTC || event.AllDay {
+			continue
+		}
+
+		// Unbounded series get the transitions of the years to come
+		last := event.EndTime.AddDate(timeZoneYears, 0, 0)
+
+		end, err := SeriesEnd(event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode event %s: %w", event.Id, err)
+		}
+
+		if end != nil {
+			last = *end
+		}
+
+		from := time.Date(event.StartTime.In(loc).Year(), 1, 1, 0, 0, 0, 0, loc)
+		to := time.Date(last.In(loc).Year()+1, 1, 1, 0, 0, 0, 0, loc)
+
+		zone, ok := zones[loc.String()]
+		if !ok {
+			zones[loc.String()] = &calendarZone{loc: loc, from: from, to: to}
+			continue
+		}
+
+		zone.from, zone.to = minTime(zone.from, from), maxTime(zone.to, to)
+	}
+
+	names := make([]string, 0, len(zones))
+	for name := range zones {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	result := make([]calendarZone, 0, len(names))
+	for _, name := range names {
+		result = append(result, *zones[name])
+	}
+
+	return result, nil
+}
+
+type zoneTransition struct {
+	at         time.Time
+	name       string
+	offsetFrom int
+	offsetTo   int
+	dst        bool
+}
+
+// timeZone writes a VTIMEZONE listing every offset change of the zone between from and to, since the tz database
+// cannot be reduced to RRULEs in general.
+func (w *calendarWriter) timeZone(zone calendarZone) {
+	w.line("BEGIN", "VTIMEZONE")
+	w.line("TZID", zone.loc.String())
+
+	for _, t := range zoneTransitions(zone.loc, zone.from, zone.to) {
+		component := "STANDARD"
+		if t.dst {
+			component = "DAYLIGHT"
+		}
+
+		w.line("BEGIN", component)
+		w.line("DTSTART", t.at.UTC().Add(time.Duration(t.offsetFrom)*time.Second).Format(icalDateTime))
+		w.line("TZOFFSETFROM", formatOffset(t.offsetFrom))
+		w.line("TZOFFSETTO", formatOffset(t.offsetTo))
+		w.line("TZNAME", escapeText(t.name))
+		w.line("END", component)
+	}
+
+	w.line("END", "VTIMEZONE")
+}
+
+// zoneTransitions returns the observance in effect at from, followed by the offset changes up to to.
+func zoneTransitions(loc *time.Location, from time.Time, to time.Time) []zoneTransition {
+	name, offset := from.In(loc).Zone()
+	transitions := []zoneTransition{{at: from, name: name, offsetFrom: offset, offsetTo: offset, dst: from.In(loc).IsDST()}}
+
+	for day := from; day.Before(to); day = day.Add(24 * time.Hour) {
+		next := day.Add(24 * time.Hour)
+		if nextName, nextOffset := next.In(loc).Zone(); nextName == name && nextOffset == offset {
+			continue
+		}
+
+		// Offsets change at most once a day, find the exact second
+		lo, hi := day, next
+		for hi.Sub(lo) > time.Second {
+			mid := lo.Add(hi.Sub(lo) / 2)
+			if midName, midOffset := mid.In(loc).Zone(); midName == name && midOffset == offset {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+
+		nextName, nextOffset := hi.In(loc).Zone()
+		transitions = append(transitions, zoneTransition{
+			at: hi, name: nextName, offsetFrom: offset, offsetTo: nextOffset, dst: hi.In(loc).IsDST(),
+		})
+		name, offset = nextName, nextOffset
+	}
+
+	return transitions
+}
+
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+
+	offset := fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds%3600/60)
+	if seconds%60 != 0 {
+		offset += fmt.Sprintf("%02d", seconds%60)
+	}
+
+	return offset
+}
+
+func minTime(a time.Time, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+
+	return b
+}
+
+func maxTime(a time.Time, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
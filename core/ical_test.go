@@ -0,0 +1,165 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeCalendar(t *testing.T) {
+	t.Parallel()
+
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
+	created := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	start := time.Date(2025, 3, 24, 10, 0, 0, 0, madrid)
+	weekly := Event{
+		Id: "uuid-1", Title: "Standup, daily; 10:00", Description: "Room 1\nRoom 2", StartTime: start, EndTime: start.Add(15 * time.Minute),
+		CreatedAt: created, UpdatedAt: created.Add(time.Hour), Version: 3, TimeZone: "Europe/Madrid", RRule: "RRULE:FREQ=WEEKLY;COUNT=4",
+		ExDates: []time.Time{start.AddDate(0, 0, 21)},
+	}
+	holiday := Event{
+		Id: "uuid-2", Title: "Holiday", StartTime: time.Date(2025, 12, 24, 0, 0, 0, 0, madrid), EndTime: time.Date(2025, 12, 26, 0, 0, 0, 0, madrid),
+		CreatedAt: created, Version: 1, TimeZone: "Europe/Madrid", AllDay: true,
+	}
+	kickoff := Event{
+		Id: "uuid-3", Title: "Kickoff", StartTime: time.Date(2025, 12, 22, 9, 0, 0, 0, time.UTC), EndTime: time.Date(2025, 12, 22, 10, 0, 0, 0, time.UTC),
+		CreatedAt: created, Version: 1, RDates: []time.Time{time.Date(2025, 12, 29, 9, 0, 0, 0, time.UTC)},
+		Location: Place{Name: "Sol", Address: "Puerta del Sol, Madrid", Latitude: ptr(40.4169), Longitude: ptr(-3.7035)},
+		Tags:     []string{"offsite", "release"},
+	}
+	overrides := []Override{
+		{EventId: "uuid-1", RecurrenceId: start.AddDate(0, 0, 7), Title: "Retro", StartTime: start.AddDate(0, 0, 8), EndTime: start.AddDate(0, 0, 8).Add(time.Hour)},
+		{EventId: "uuid-1", RecurrenceId: start.AddDate(0, 0, 14), StartTime: start.AddDate(0, 0, 14), EndTime: start.AddDate(0, 0, 14), Cancelled: true},
+	}
+
+	body, err := EncodeCalendar([]Event{weekly, holiday, kickoff}, overrides)
+	require.NoError(t, err)
+
+	calendar := string(body)
+	lines := strings.Split(strings.TrimSuffix(calendar, "\r\n"), "\r\n")
+
+	assert.Equal(t, "BEGIN:VCALENDAR", lines[0])
+	assert.Equal(t, "END:VCALENDAR", lines[len(lines)-1])
+	assert.NotContains(t, strings.ReplaceAll(calendar, "\r\n", ""), "\n")
+
+	t.Run("time zones", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, 1, strings.Count(calendar, "BEGIN:VTIMEZONE"))
+		assert.Contains(t, calendar, "TZID:Europe/Madrid\r\n")
+		assert.Contains(t, calendar, "BEGIN:DAYLIGHT\r\nDTSTART:20250330T020000\r\nTZOFFSETFROM:+0100\r\nTZOFFSETTO:+0200\r\nTZNAME:CEST\r\nEND:DAYLIGHT")
+		assert.Contains(t, calendar, "BEGIN:STANDARD\r\nDTSTART:20251026T030000\r\nTZOFFSETFROM:+0200\r\nTZOFFSETTO:+0100\r\nTZNAME:CET\r\nEND:STANDARD")
+		assert.NotContains(t, calendar, "DTSTART:2026", "finite series only need the transitions of their years")
+	})
+
+	t.Run("series", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Contains(t, calendar, "BEGIN:VEVENT\r\nUID:uuid-1\r\nDTSTAMP:20250301T120000Z\r\nLAST-MODIFIED:20250301T130000Z\r\nSEQUENCE:2\r\n")
+		assert.Contains(t, calendar, `SUMMARY:Standup\, daily\; 10:00`+"\r\n")
+		assert.Contains(t, calendar, `DESCRIPTION:Room 1\nRoom 2`+"\r\n")
+		assert.Contains(t, calendar, "DTSTART;TZID=Europe/Madrid:20250324T100000\r\nDTEND;TZID=Europe/Madrid:20250324T101500\r\n")
+		assert.Contains(t, calendar, "RRULE:FREQ=WEEKLY;COUNT=4\r\n")
+		assert.Contains(t, calendar, "EXDATE;TZID=Europe/Madrid:20250414T100000,20250407T100000\r\n")
+	})
+
+	t.Run("overrides", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, 2, strings.Count(calendar, "UID:uuid-1\r\n"))
+		assert.Contains(t, calendar, "SUMMARY:Retro\r\nDESCRIPTION:Room 1\\nRoom 2\r\n"+
+			"RECURRENCE-ID;TZID=Europe/Madrid:20250331T100000\r\n"+
+			"DTSTART;TZID=Europe/Madrid:20250401T100000\r\nDTEND;TZID=Europe/Madrid:20250401T110000\r\n")
+	})
+
+	t.Run("all-day and UTC events", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Contains(t, calendar, "DTSTART;VALUE=DATE:20251224\r\nDTEND;VALUE=DATE:20251226\r\n")
+		assert.Contains(t, calendar, "DTSTART:20251222T090000Z\r\nDTEND:20251222T100000Z\r\nRDATE:20251229T090000Z\r\n")
+	})
+
+	t.Run("location", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Contains(t, calendar, "SUMMARY:Kickoff\r\nLOCATION:Sol\\, Puerta del Sol\\, Madrid\r\nGEO:40.4169;-3.7035\r\n")
+		assert.Equal(t, 1, strings.Count(calendar, "LOCATION:"))
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Contains(t, calendar, "GEO:40.4169;-3.7035\r\nCATEGORIES:offsite,release\r\n")
+		assert.Equal(t, 1, strings.Count(calendar, "CATEGORIES:"))
+	})
+
+	t.Run("unbounded series", func(t *testing.T) {
+		t.Parallel()
+
+		event := weekly
+		event.RRule = "FREQ=WEEKLY"
+
+		body, err := EncodeCalendar([]Event{event, holiday}, nil)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), "DTSTART:20350325T020000\r\n")
+		assert.NotContains(t, string(body), "DTSTART:20360330T020000\r\n")
+	})
+
+	t.Run("invalid time zone", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := EncodeCalendar([]Event{{Id: "uuid-4", TimeZone: "Nowhere/Special"}}, nil)
+		require.Error(t, err)
+	})
+}
+
+func TestCalendarWriter_Line(t *testing.T) {
+	t.Parallel()
+
+	value := strings.Repeat("ñ", 100)
+
+	w := &calendarWriter{}
+	w.line("SUMMARY", value)
+
+	lines := strings.Split(strings.TrimSuffix(w.buf.String(), "\r\n"), "\r\n")
+	require.Greater(t, len(lines), 1)
+
+	for i, line := range lines {
+		assert.LessOrEqual(t, len(line), maxLineOctets, "line %d", i)
+
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(line, " "), "line %d", i)
+		}
+	}
+
+	assert.Equal(t, "SUMMARY:"+value, strings.ReplaceAll(w.buf.String(), "\r\n ", "")[:len("SUMMARY:"+value)])
+}
+
+func TestFormatOffset(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "+0100", formatOffset(3600))
+	assert.Equal(t, "-0330", formatOffset(-12600))
+	assert.Equal(t, "+0000", formatOffset(0))
+	assert.Equal(t, "+001715", formatOffset(1035))
+}
+
+func TestZoneTransitions(t *testing.T) {
+	t.Parallel()
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, tokyo)
+	transitions := zoneTransitions(tokyo, from, from.AddDate(1, 0, 0))
+
+	require.Len(t, transitions, 1)
+	assert.Equal(t, 9*3600, transitions[0].offsetFrom)
+	assert.Equal(t, 9*3600, transitions[0].offsetTo)
+	assert.False(t, transitions[0].dst)
+}
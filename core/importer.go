@@ -0,0 +1,442 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	ics "github.com/arran4/golang-ical"
+)
+
+const (
+	// MaxImportSize is the largest iCalendar document POST /events/import accepts, in bytes.
+	MaxImportSize = 10 << 20
+	// MaxImportEvents is the most VEVENTs a single import processes.
+	MaxImportEvents = 10000
+
+	ImportCreated = "created"
+	ImportSkipped = "skipped"
+	ImportFailed  = "failed"
+)
+
+// CalendarEntry is a VEVENT read from an iCalendar document: either an event (possibly recurring) or, when it
+// carries a RECURRENCE-ID, an override of one occurrence of the event with the same UID.
+type CalendarEntry struct {
+	Index    int
+	Uid      string
+	Event    Event
+	Override *Override
+	Err      error
+}
+
+type ImportResult struct {
+	Index        int        `json:"index"`
+	Uid          string     `json:"uid,omitempty"`
+	RecurrenceId *time.Time `json:"recurrence_id,omitempty"`
+	Status       string     `json:"status"`
+	Reason       string     `json:"reason,omitempty"`
+	EventId      string     `json:"event_id,omitempty"`
+}
+
+type ImportReport struct {
+	Created int            `json:"created"`
+	Skipped int            `json:"skipped"`
+	Failed  int            `json:"failed"`
+	Results []ImportResult `json:"results"`
+}
+
+func (r *ImportReport) add(result ImportResult) {
+	switch result.Status {
+	case ImportCreated:
+		r.Created++
+	case ImportSkipped:
+		r.Skipped++
+	default:
+		r.Failed++
+	}
+
+	r.Results = append(r.Results, result)
+}
+
+// DecodeCalendar reads the VEVENTs of an iCalendar document. Entries that cannot be converted to events keep the
+// reason in Err, so a single bad VEVENT does not fail the whole document.
+func DecodeCalendar(r io.Reader) ([]CalendarEntry, error) {
+	calendar, err := ics.ParseCalendar(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid iCalendar document: %w", err)
+	}
+
+	vevents := calendar.Events()
+	if len(vevents) > MaxImportEvents {
+		return nil, fmt.Errorf("too many events (%d tops)", MaxImportEvents)
+	}
+
+	decoder := &calendarDecoder{zones: make(map[string]string), defaultZone: "UTC"}
+
+	for _, p := range calendar.CalendarProperties {
+		if p.IANAToken == string(ics.PropertyXWRTimezone) && ValidateTimeZone(p.Value) == nil {
+			decoder.defaultZone = p.Value
+		}
+	}
+
+	// Custom TZIDs, as Outlook writes them, often name their IANA zone in X-LIC-LOCATION
+	for _, zone := range calendar.Timezones() {
+		tzid, location := zone.GetProperty(ics.ComponentPropertyTzid), zone.GetProperty("X-LIC-LOCATION")
+		if tzid != nil && location != nil {
+			decoder.zones[tzid.Value] = location.Value
+		}
+	}
+
+	entries := make([]CalendarEntry, 0, len(vevents))
+	for i, vevent := range vevents {
+		entries = append(entries, decoder.entry(i, vevent))
+	}
+
+	return entries, nil
+}
+
+type calendarDecoder struct {
+	zones       map[string]string
+	defaultZone string
+}
+
+// calendarTime is a DATE or DATE-TIME value along with the time zone it was written in.
+type calendarTime struct {
+	time   time.Time
+	zone   string
+	allDay bool
+}
+
+func (d *calendarDecoder) entry(index int, vevent *ics.VEvent) CalendarEntry {
+	entry := CalendarEntry{Index: index}
+
+	entry.Uid = propertyValue(vevent, ics.ComponentPropertyUniqueId)
+	if entry.Uid == "" {
+		entry.Err = errors.New("UID is required")
+		return entry
+	}
+
+	start, err := d.time(vevent.GetProperty(ics.ComponentPropertyDtStart))
+	if err != nil {
+		entry.Err = fmt.Errorf("invalid DTSTART: %w", err)
+		return entry
+	}
+
+	end, err := d.end(vevent, start)
+	if err != nil {
+		entry.Err = err
+		return entry
+	}
+
+	title := propertyValue(vevent, ics.ComponentPropertySummary)
+	description := propertyValue(vevent, ics.ComponentPropertyDescription)
+	cancelled := strings.EqualFold(propertyValue(vevent, ics.ComponentPropertyStatus), "CANCELLED")
+
+	if recurrenceId := vevent.GetProperty(ics.ComponentPropertyRecurrenceId); recurrenceId != nil {
+		original, err := d.time(recurrenceId)
+		if err != nil {
+			entry.Err = fmt.Errorf("invalid RECURRENCE-ID: %w", err)
+			return entry
+		}
+
+		entry.Override = &Override{
+			RecurrenceId: original.time,
+			Title:        title,
+			Description:  description,
+			StartTime:    start.time,
+			EndTime:      end,
+			Cancelled:    cancelled,
+		}
+
+		return entry
+	}
+
+	if cancelled {
+		entry.Err = errors.New("event is cancelled")
+		return entry
+	}
+
+	entry.Event = Event{
+		Title:       title,
+		Description: description,
+		StartTime:   start.time,
+		EndTime:     end,
+		TimeZone:    start.zone,
+		AllDay:      start.allDay,
+	}
+
+	for _, p := range vevent.GetProperties(ics.ComponentPropertyRrule) {
+		if entry.Event.RRule != "" {
+			entry.Err = errors.New("multiple RRULEs are not supported")
+			return entry
+		}
+
+		entry.Event.RRule = p.Value
+	}
+
+	entry.Event.RDates, err = d.times(vevent.GetProperties(ics.ComponentPropertyRdate))
+	if err != nil {
+		entry.Err = fmt.Errorf("invalid RDATE: %w", err)
+		return entry
+	}
+
+	entry.Event.ExDates, err = d.times(vevent.GetProperties(ics.ComponentPropertyExdate))
+	if err != nil {
+		entry.Err = fmt.Errorf("invalid EXDATE: %w", err)
+		return entry
+	}
+
+	return entry
+}
+
+// end reads DTEND, or DURATION; events without either last for a day when all-day and are instantaneous otherwise.
+func (d *calendarDecoder) end(vevent *ics.VEvent, start calendarTime) (time.Time, error) {
+	if p := vevent.GetProperty(ics.ComponentPropertyDtEnd); p != nil {
+		end, err := d.time(p)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid DTEND: %w", err)
+		}
+
+		return end.time, nil
+	}
+
+	if p := vevent.GetProperty(ics.ComponentPropertyDuration); p != nil {
+		days, duration, err := parseDuration(p.Value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid DURATION: %w", err)
+		}
+
+		return start.time.AddDate(0, 0, days).Add(duration), nil
+	}
+
+	if start.allDay {
+		return start.time.AddDate(0, 0, 1), nil
+	}
+
+	return start.time, nil
+}
+
+func (d *calendarDecoder) times(properties []*ics.IANAProperty) ([]time.Time, error) {
+	var times []time.Time
+
+	for _, p := range properties {
+		for value := range strings.SplitSeq(p.Value, ",") {
+			if strings.EqualFold(firstParam(p, "VALUE"), "PERIOD") {
+				value, _, _ = strings.Cut(value, "/")
+			}
+
+			t, err := d.parseTime(strings.TrimSpace(value), p)
+			if err != nil {
+				return nil, err
+			}
+
+			times = append(times, t.time)
+		}
+	}
+
+	return times, nil
+}
+
+func (d *calendarDecoder) time(p *ics.IANAProperty) (calendarTime, error) {
+	if p == nil {
+		return calendarTime{}, errors.New("missing value")
+	}
+
+	return d.parseTime(strings.TrimSpace(p.Value), p)
+}
+
+// parseTime reads UTC ("...Z") and local DATE-TIMEs, the latter in their TZID or else the calendar's
+// X-WR-TIMEZONE (UTC by default), and DATEs, which are midnight in that same zone. Events take the zone of their
+// DTSTART, or X-WR-TIMEZONE when it is in UTC.
+func (d *calendarDecoder) parseTime(value string, p *ics.IANAProperty) (calendarTime, error) {
+	zone := d.defaultZone
+	if tzid := firstParam(p, "TZID"); tzid != "" {
+		zone = tzid
+		if location, ok := d.zones[tzid]; ok {
+			zone = location
+		}
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(icalDateTime+"Z", value)
+		if err != nil {
+			return calendarTime{}, fmt.Errorf("malformed time %q", value)
+		}
+
+		return calendarTime{time: t, zone: d.defaultZone}, nil
+	}
+
+	loc, err := Location(zone)
+	if err != nil || ValidateTimeZone(zone) != nil {
+		return calendarTime{}, fmt.Errorf("unknown time zone %q", zone)
+	}
+
+	if len(value) == len(icalDate) {
+		t, err := time.ParseInLocation(icalDate, value, loc)
+		if err != nil {
+			return calendarTime{}, fmt.Errorf("malformed date %q", value)
+		}
+
+		return calendarTime{time: t, zone: zone, allDay: true}, nil
+	}
+
+	t, err := time.ParseInLocation(icalDateTime, value, loc)
+	if err != nil {
+		return calendarTime{}, fmt.Errorf("malformed time %q", value)
+	}
+
+	return calendarTime{time: t, zone: zone}, nil
+}
+
+var durationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseDuration reads an RFC 5545 DURATION as calendar days (which follow DST changes) plus exact time.
+func parseDuration(value string) (int, time.Duration, error) {
+	m := durationPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil || value == "P" || strings.HasSuffix(value, "T") {
+		return 0, 0, fmt.Errorf("malformed duration %q", value)
+	}
+
+	number := func(s string) int {
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+
+	days := number(m[2])*7 + number(m[3])
+	duration := time.Duration(number(m[4]))*time.Hour + time.Duration(number(m[5]))*time.Minute + time.Duration(number(m[6]))*time.Second
+
+	if m[1] == "-" {
+		return -days, -duration, nil
+	}
+
+	return days, duration, nil
+}
+
+func propertyValue(vevent *ics.VEvent, property ics.ComponentProperty) string {
+	p := vevent.GetProperty(property)
+	if p == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(p.Value)
+}
+
+func firstParam(p *ics.IANAProperty, name string) string {
+	values := p.ICalParameters[name]
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+// ImportCalendar validates and saves the decoded entries in document order, attaching each override to the event
+// with its UID. Events whose UID was already imported are skipped, which makes re-importing a file a no-op.
+func ImportCalendar(ctx context.Context, repository RepositoryInterface, entries []CalendarEntry) ImportReport {
+	report := ImportReport{Results: make([]ImportResult, 0, len(entries))}
+
+	type pending struct {
+		index     int
+		series    Series
+		overrides []ImportResult
+	}
+
+	byUid := make(map[string]*pending)
+	var order []string
+
+	for _, entry := range entries {
+		result := ImportResult{Index: entry.Index, Uid: entry.Uid, Status: ImportFailed}
+
+		switch {
+		case entry.Err != nil:
+			result.Reason = entry.Err.Error()
+		case entry.Override != nil:
+			continue
+		case byUid[entry.Uid] != nil:
+			result.Reason = "duplicate UID"
+		default:
+			entry.Event.Uid = entry.Uid
+			byUid[entry.Uid] = &pending{index: entry.Index, series: Series{Event: entry.Event}}
+			order = append(order, entry.Uid)
+
+			continue
+		}
+
+		report.add(result)
+	}
+
+	// Overrides are saved along with their event, check them first
+	for _, entry := range entries {
+		if entry.Err != nil || entry.Override == nil {
+			continue
+		}
+
+		result := ImportResult{Index: entry.Index, Uid: entry.Uid, RecurrenceId: &entry.Override.RecurrenceId, Status: ImportFailed}
+
+		p, ok := byUid[entry.Uid]
+		if !ok {
+			result.Reason = "no event with this UID in the document"
+			report.add(result)
+
+			continue
+		}
+
+		found, err := IsOccurrence(p.series.Event, entry.Override.RecurrenceId)
+		if err != nil || !found {
+			result.Reason = "RECURRENCE-ID is not an occurrence of the event"
+			report.add(result)
+
+			continue
+		}
+
+		err = ValidateOverride(*entry.Override)
+		if err != nil {
+			result.Reason = err.Error()
+			report.add(result)
+
+			continue
+		}
+
+		p.series.Overrides = append(p.series.Overrides, *entry.Override)
+		p.overrides = append(p.overrides, result)
+	}
+
+	for _, uid := range order {
+		p := byUid[uid]
+		result := ImportResult{Index: p.index, Uid: uid, Status: ImportFailed}
+
+		saved, err := importSeries(ctx, repository, &p.series)
+		switch {
+		case errors.Is(err, ErrEventExists):
+			result.Status, result.Reason = ImportSkipped, "already imported"
+		case err != nil:
+			result.Reason = err.Error()
+		default:
+			result.Status, result.EventId = ImportCreated, saved.Id
+		}
+
+		report.add(result)
+
+		// Overrides share the outcome of their event
+		for _, o := range p.overrides {
+			o.Status, o.Reason, o.EventId = result.Status, result.Reason, result.EventId
+			report.add(o)
+		}
+	}
+
+	return report
+}
+
+func importSeries(ctx context.Context, repository RepositoryInterface, series *Series) (*Event, error) {
+	err := ValidateEvent(series.Event)
+	if err != nil {
+		return nil, err
+	}
+
+	return repository.ImportEvent(ctx, series) //nolint:wrapcheck
+}
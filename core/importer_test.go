@@ -0,0 +1,233 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func calendarDocument(header string, vevents ...string) string {
+	doc := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//test//EN\r\n" + header
+	for _, vevent := range vevents {
+		doc += "BEGIN:VEVENT\r\nDTSTAMP:20250101T000000Z\r\n" + vevent + "END:VEVENT\r\n"
+	}
+
+	return doc + "END:VCALENDAR\r\n"
+}
+
+func TestDecodeCalendar(t *testing.T) {
+	t.Parallel()
+
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		header    string
+		vevent    string
+		want      Event
+		wantOver  *Override
+		wantError string
+	}{
+		{
+			name:   "utc",
+			vevent: "UID:a\r\nSUMMARY:Kickoff\\, Q1\r\nDESCRIPTION:Line one\\nLine two\r\nDTSTART:20250303T090000Z\r\nDTEND:20250303T100000Z\r\n",
+			want: Event{
+				Title: "Kickoff, Q1", Description: "Line one\nLine two", TimeZone: "UTC",
+				StartTime: time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC), EndTime: time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:   "tzid and duration",
+			vevent: "UID:a\r\nSUMMARY:Standup\r\nDTSTART;TZID=Europe/Madrid:20250303T090000\r\nDURATION:PT15M\r\nRRULE:FREQ=WEEKLY;COUNT=4\r\nEXDATE;TZID=Europe/Madrid:20250310T090000,20250317T090000\r\n",
+			want: Event{
+				Title: "Standup", TimeZone: "Europe/Madrid", RRule: "FREQ=WEEKLY;COUNT=4",
+				StartTime: time.Date(2025, 3, 3, 9, 0, 0, 0, madrid), EndTime: time.Date(2025, 3, 3, 9, 15, 0, 0, madrid),
+				ExDates: []time.Time{time.Date(2025, 3, 10, 9, 0, 0, 0, madrid), time.Date(2025, 3, 17, 9, 0, 0, 0, madrid)},
+			},
+		},
+		{
+			name:   "custom tzid with x-lic-location",
+			header: "BEGIN:VTIMEZONE\r\nTZID:Eastern Standard Time\r\nX-LIC-LOCATION:America/New_York\r\nEND:VTIMEZONE\r\n",
+			vevent: "UID:a\r\nSUMMARY:Call\r\nDTSTART;TZID=Eastern Standard Time:20250303T090000\r\nDTEND;TZID=Eastern Standard Time:20250303T093000\r\n",
+			want: Event{
+				Title: "Call", TimeZone: "America/New_York",
+				StartTime: time.Date(2025, 3, 3, 9, 0, 0, 0, newYork), EndTime: time.Date(2025, 3, 3, 9, 30, 0, 0, newYork),
+			},
+		},
+		{
+			name:   "floating time in the calendar time zone",
+			header: "X-WR-TIMEZONE:Europe/Madrid\r\n",
+			vevent: "UID:a\r\nSUMMARY:Lunch\r\nDTSTART:20250303T140000\r\nDTEND:20250303T150000\r\n",
+			want: Event{
+				Title: "Lunch", TimeZone: "Europe/Madrid",
+				StartTime: time.Date(2025, 3, 3, 14, 0, 0, 0, madrid), EndTime: time.Date(2025, 3, 3, 15, 0, 0, 0, madrid),
+			},
+		},
+		{
+			name:   "all-day without end",
+			vevent: "UID:a\r\nSUMMARY:Holiday\r\nDTSTART;VALUE=DATE:20251225\r\n",
+			want: Event{
+				Title: "Holiday", TimeZone: "UTC", AllDay: true,
+				StartTime: time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC), EndTime: time.Date(2025, 12, 26, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:   "cancelled occurrence",
+			vevent: "UID:a\r\nSUMMARY:Standup\r\nRECURRENCE-ID:20250310T090000Z\r\nDTSTART:20250310T090000Z\r\nDTEND:20250310T091500Z\r\nSTATUS:CANCELLED\r\n",
+			wantOver: &Override{
+				RecurrenceId: time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC), Title: "Standup", Cancelled: true,
+				StartTime: time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC), EndTime: time.Date(2025, 3, 10, 9, 15, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:      "missing uid",
+			vevent:    "SUMMARY:Kickoff\r\nDTSTART:20250303T090000Z\r\n",
+			wantError: "UID is required",
+		},
+		{
+			name:      "unknown time zone",
+			vevent:    "UID:a\r\nDTSTART;TZID=Somewhere Special:20250303T090000\r\n",
+			wantError: "unknown time zone",
+		},
+		{
+			name:      "cancelled event",
+			vevent:    "UID:a\r\nDTSTART:20250303T090000Z\r\nSTATUS:CANCELLED\r\n",
+			wantError: "cancelled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			entries, err := DecodeCalendar(strings.NewReader(calendarDocument(tt.header, tt.vevent)))
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+
+			entry := entries[0]
+			if tt.wantError != "" {
+				require.Error(t, entry.Err)
+				assert.Contains(t, entry.Err.Error(), tt.wantError)
+
+				return
+			}
+
+			require.NoError(t, entry.Err)
+			assert.Equal(t, "a", entry.Uid)
+
+			if tt.wantOver != nil {
+				require.NotNil(t, entry.Override)
+				assert.Equal(t, *tt.wantOver, *entry.Override)
+
+				return
+			}
+
+			assert.Nil(t, entry.Override)
+			assert.Equal(t, tt.want, entry.Event)
+		})
+	}
+
+	t.Run("malformed document", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := DecodeCalendar(strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\n"))
+		require.Error(t, err)
+	})
+}
+
+func TestParseDuration(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		value    string
+		days     int
+		duration time.Duration
+		wantErr  bool
+	}{
+		{value: "PT1H30M", duration: 90 * time.Minute},
+		{value: "P1D", days: 1},
+		{value: "P2W", days: 14},
+		{value: "P1DT12H", days: 1, duration: 12 * time.Hour},
+		{value: "-PT15M", duration: -15 * time.Minute},
+		{value: "P", wantErr: true},
+		{value: "PT", wantErr: true},
+		{value: "1H", wantErr: true},
+	} {
+		days, duration, err := parseDuration(tt.value)
+		if tt.wantErr {
+			require.Error(t, err, tt.value)
+			continue
+		}
+
+		require.NoError(t, err, tt.value)
+		assert.Equal(t, tt.days, days, tt.value)
+		assert.Equal(t, tt.duration, duration, tt.value)
+	}
+}
+
+func TestImportCalendar(t *testing.T) {
+	t.Parallel()
+
+	doc := calendarDocument("",
+		"UID:series\r\nSUMMARY:Standup\r\nDTSTART:20250303T090000Z\r\nDTEND:20250303T091500Z\r\nRRULE:FREQ=DAILY;COUNT=5\r\n",
+		"UID:series\r\nRECURRENCE-ID:20250304T090000Z\r\nDTSTART:20250304T100000Z\r\nDTEND:20250304T101500Z\r\n",
+		"UID:series\r\nRECURRENCE-ID:20250304T093000Z\r\nDTSTART:20250304T100000Z\r\nDTEND:20250304T101500Z\r\n",
+		"UID:orphan\r\nRECURRENCE-ID:20250304T090000Z\r\nDTSTART:20250304T100000Z\r\n",
+		"UID:series\r\nSUMMARY:Again\r\nDTSTART:20250303T090000Z\r\n",
+		"UID:backwards\r\nSUMMARY:Backwards\r\nDTSTART:20250303T090000Z\r\nDTEND:20250303T080000Z\r\n",
+		"UID:existing\r\nSUMMARY:Existing\r\nDTSTART:20250303T090000Z\r\n",
+		"UID:broken\r\nSUMMARY:Broken\r\nDTSTART:20250303T090000Z\r\n",
+		"SUMMARY:No UID\r\nDTSTART:20250303T090000Z\r\n",
+	)
+
+	entries, err := DecodeCalendar(strings.NewReader(doc))
+	require.NoError(t, err)
+
+	repo := new(MockRepository)
+	repo.On("ImportEvent", mock.Anything, mock.MatchedBy(func(s *Series) bool { return s.Event.Uid == "series" })).
+		Return(&Event{Id: "uuid-1"}, nil)
+	repo.On("ImportEvent", mock.Anything, mock.MatchedBy(func(s *Series) bool { return s.Event.Uid == "existing" })).
+		Return(nil, ErrEventExists)
+	repo.On("ImportEvent", mock.Anything, mock.MatchedBy(func(s *Series) bool { return s.Event.Uid == "broken" })).
+		Return(nil, errors.New("db error"))
+
+	report := ImportCalendar(context.Background(), repo, entries)
+
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, 6, report.Failed)
+
+	byIndex := make(map[int]ImportResult)
+	for _, r := range report.Results {
+		byIndex[r.Index] = r
+	}
+
+	require.Len(t, byIndex, len(entries))
+	assert.Equal(t, ImportResult{Index: 0, Uid: "series", Status: ImportCreated, EventId: "uuid-1"}, byIndex[0])
+	assert.Equal(t, ImportCreated, byIndex[1].Status)
+	assert.Equal(t, "uuid-1", byIndex[1].EventId)
+	assert.Contains(t, byIndex[2].Reason, "not an occurrence")
+	assert.Contains(t, byIndex[3].Reason, "no event with this UID")
+	assert.Equal(t, "duplicate UID", byIndex[4].Reason)
+	assert.Contains(t, byIndex[5].Reason, "end time")
+	assert.Equal(t, ImportSkipped, byIndex[6].Status)
+	assert.Equal(t, "db error", byIndex[7].Reason)
+	assert.Contains(t, byIndex[8].Reason, "UID is required")
+
+	// The valid override is saved along with its series
+	series := repo.Calls[0].Arguments.Get(1).(*Series)
+	require.Len(t, series.Overrides, 1)
+	assert.True(t, time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC).Equal(series.Overrides[0].StartTime))
+
+	repo.AssertExpectations(t)
+}
@@ -1,12 +1,281 @@
 package core
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Event struct {
+	Id          string      `json:"id,omitempty"`
+	Title       string      `json:"title,omitempty"`
+	Description string      `json:"description,omitempty"`
+	StartTime   time.Time   `json:"start_time,omitempty"`
+	EndTime     time.Time   `json:"end_time,omitempty"`
+	CreatedAt   time.Time   `json:"createdAt,omitempty"`
+	Version     int         `json:"version,omitempty"`
+	TimeZone    string      `json:"time_zone,omitempty"`
+	RRule       string      `json:"rrule,omitempty"`
+	ExDates     []time.Time `json:"exdates,omitempty"`
+	RDates      []time.Time `json:"rdates,omitempty"`
+	AllDay      bool        `json:"all_day,omitempty"`
+	UpdatedAt   time.Time   `json:"updatedAt,omitempty"`
+	Uid         string      `json:"uid,omitempty"`
+	Scope       string      `json:"scope,omitempty"`
+	CalendarId  string      `json:"calendar_id,omitempty"`
+	// Capacity is the number of registrations admitted before the waitlist starts, 0 for no limit
+	Capacity int   `json:"capacity,omitempty"`
+	Location Place `json:"location,omitzero"`
+	// Tags are normalized when saved, see NormalizeTags
+	Tags []string `json:"tags,omitempty"`
+	// Attendees is read-only, it is computed from the attendees of the event
+	Attendees *AttendeeCounts `json:"attendees,omitempty"`
+}
+
+// Calendar groups events, e.g. those of a team. Events created in a calendar default to its time zone.
+type Calendar struct {
 	Id          string    `json:"id,omitempty"`
-	Title       string    `json:"title,omitempty"`
+	Name        string    `json:"name,omitempty"`
 	Description string    `json:"description,omitempty"`
-	StartTime   time.Time `json:"start_time,omitempty"`
-	EndTime     time.Time `json:"end_time,omitempty"`
+	TimeZone    string    `json:"time_zone,omitempty"`
+	Color       string    `json:"color,omitempty"`
 	CreatedAt   time.Time `json:"createdAt,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt,omitempty"`
+}
+
+// Place is where an event takes place. Latitude and longitude are WGS 84 degrees, set together or not at all.
+type Place struct {
+	Name      string   `json:"name,omitempty"`
+	Address   string   `json:"address,omitempty"`
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+}
+
+// Tag is a label of events, with the number of events (outside the trash) carrying it.
+type Tag struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Tag modes of a tags filter: events with any of the tags, or with all of them.
+const (
+	TagModeAny = "any"
+	TagModeAll = "all"
+)
+
+type GeoPoint struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// RSVP statuses of an attendee, as the PARTSTAT values of RFC 5545.
+const (
+	RsvpNeedsAction = "needs-action"
+	RsvpAccepted    = "accepted"
+	RsvpDeclined    = "declined"
+	RsvpTentative   = "tentative"
+)
+
+// Attendee is a person invited to an event, identified by their email within it.
+type Attendee struct {
+	Id          string     `json:"id,omitempty"`
+	EventId     string     `json:"event_id,omitempty"`
+	Email       string     `json:"email,omitempty"`
+	Name        string     `json:"name,omitempty"`
+	Status      string     `json:"status,omitempty"`
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt,omitempty"`
+	UpdatedAt   time.Time  `json:"updatedAt,omitempty"`
+}
+
+type AttendeeCounts struct {
+	Total       int `json:"total"`
+	Accepted    int `json:"accepted"`
+	Declined    int `json:"declined"`
+	Tentative   int `json:"tentative"`
+	NeedsAction int `json:"needs_action"`
+}
+
+// Statuses of a registration: admitted, or waiting for a confirmed registrant to cancel.
+const (
+	RegistrationConfirmed  = "confirmed"
+	RegistrationWaitlisted = "waitlisted"
+)
+
+type Registration struct {
+	Id      string `json:"id,omitempty"`
+	EventId string `json:"event_id,omitempty"`
+	Email   string `json:"email,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Status  string `json:"status,omitempty"`
+	// Position is the place of a waitlisted registration in the waitlist, starting at 1
+	Position  int       `json:"position,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+}
+
+// Reminder channels, each delivered by the Notifier configured for it.
+const (
+	ChannelWebhook = "webhook"
+	ChannelEmail   = "email"
+	ChannelLog     = "log"
+)
+
+// Reminder notifies the attendees of each occurrence of an event OffsetMinutes before it starts, through Channel.
+type Reminder struct {
+	Id            string `json:"id,omitempty"`
+	EventId       string `json:"event_id,omitempty"`
+	OffsetMinutes int    `json:"offset_minutes"`
+	Channel       string `json:"channel,omitempty"`
+	// RemindedThrough is the start of the last occurrence reminded of
+	RemindedThrough *time.Time `json:"reminded_through,omitempty"`
+	// DueAt is when the dispatcher next looks at the reminder, nil once the event has no occurrence left
+	DueAt *time.Time `json:"-"`
+	// Revision changes along with the schedule of the event, see RescheduleReminder
+	Revision  int       `json:"-"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+}
+
+// Types of the lifecycle changes of events, written to the outbox and delivered to the webhooks subscribed to them.
+const (
+	EventCreated = "event.created"
+	EventUpdated = "event.updated"
+	EventDeleted = "event.deleted"
+)
+
+// OutboxMessage is a domain event, written to the outbox in the transaction of the change it records and published
+// once that transaction has committed.
+type OutboxMessage struct {
+	Id   int64  `json:"id"`
+	Type string `json:"type"`
+	// AggregateId is the id of what changed, the event for the lifecycle changes of events
+	AggregateId string `json:"aggregate_id"`
+	// Payload is the event as the API returns it, or only its id once deleted
+	Payload json.RawMessage `json:"payload"`
+	// TraceContext holds the W3C trace context of the request that made the change
+	TraceContext map[string]string `json:"-"`
+	// Attempts is how many times publishing the message failed so far
+	Attempts  int       `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Webhook subscribes a URL to the changes of events, of EventTypes only unless empty.
+type Webhook struct {
+	Id         string   `json:"id,omitempty"`
+	Url        string   `json:"url,omitempty"`
+	EventTypes []string `json:"event_types,omitempty"`
+	// Secret signs the deliveries; it is only shown when set, as it cannot be read back afterwards
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+}
+
+// Statuses of a delivery: still being attempted, received by the webhook, or given up on after too many attempts.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryDead      = "dead"
+)
+
+// Delivery is an event change sent, or to be sent, to a webhook.
+type Delivery struct {
+	Id        string          `json:"id,omitempty"`
+	WebhookId string          `json:"webhook_id,omitempty"`
+	EventType string          `json:"event_type,omitempty"`
+	EventId   string          `json:"event_id,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	// TraceContext holds the W3C trace context of the request that changed the event
+	TraceContext   map[string]string `json:"-"`
+	Status         string            `json:"status,omitempty"`
+	Attempts       int               `json:"attempts"`
+	NextAttemptAt  *time.Time        `json:"next_attempt_at,omitempty"`
+	LastStatusCode int               `json:"last_status_code,omitempty"`
+	LastError      string            `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time        `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time         `json:"createdAt,omitempty"`
+	UpdatedAt      time.Time         `json:"updatedAt,omitempty"`
+}
+
+type EventFilter struct {
+	Limit       int
+	Cursor      *Cursor
+	StartsAfter time.Time
+	EndsBefore  time.Time
+	Overlaps    *TimeWindow
+	TitlePrefix string
+	CalendarId  string
+	// Near keeps the events located within RadiusKm of the point
+	Near     *GeoPoint
+	RadiusKm float64
+	Tags     []string
+	TagMode  string
+	Trashed  bool
+	// Feed raises the largest honoured Limit from MaxPageLimit to MaxFeedEvents
+	Feed bool
+}
+
+type TimeWindow struct {
+	From time.Time
+	To   time.Time
+}
+
+type EventPage struct {
+	Events []Event `json:"events"`
+	Next   string  `json:"next,omitempty"`
+	Prev   string  `json:"prev,omitempty"`
+}
+
+type SearchQuery struct {
+	Query    string
+	Language string
+	Limit    int
+}
+
+type SearchResult struct {
+	Event
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet,omitempty"`
+}
+
+// Override replaces (or cancels) the single occurrence of a recurring event originally starting at RecurrenceId.
+type Override struct {
+	EventId      string    `json:"event_id,omitempty"`
+	RecurrenceId time.Time `json:"recurrence_id"`
+	Title        string    `json:"title,omitempty"`
+	Description  string    `json:"description,omitempty"`
+	StartTime    time.Time `json:"start_time,omitempty"`
+	EndTime      time.Time `json:"end_time,omitempty"`
+	Cancelled    bool      `json:"cancelled,omitempty"`
+}
+
+type Series struct {
+	Event     Event
+	Overrides []Override
+}
+
+type Occurrence struct {
+	EventId      string    `json:"event_id"`
+	RecurrenceId time.Time `json:"recurrence_id"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description,omitempty"`
+	StartTime    time.Time `json:"start_time"`
+	EndTime      time.Time `json:"end_time"`
+	TimeZone     string    `json:"time_zone,omitempty"`
+	AllDay       bool      `json:"all_day,omitempty"`
+	Overridden   bool      `json:"overridden,omitempty"`
+}
+
+// FreeBusyQuery asks when the events matching its filters keep the window busy.
+type FreeBusyQuery struct {
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	TitlePrefix string    `json:"title_prefix,omitempty"`
+	CalendarId  string    `json:"calendar_id,omitempty"`
+	Scope       string    `json:"scope,omitempty"`
+}
+
+// BusyInterval is a stretch of time taken by one or more events, with no detail about them.
+type BusyInterval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
 }
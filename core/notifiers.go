@@ -0,0 +1,172 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Notification reminds the attendees of an occurrence that it starts soon.
+type Notification struct {
+	ReminderId    string     `json:"reminder_id"`
+	Channel       string     `json:"channel"`
+	OffsetMinutes int        `json:"offset_minutes"`
+	Occurrence    Occurrence `json:"occurrence"`
+	Location      Place      `json:"location,omitzero"`
+	// Attendees are those who have not declined
+	Attendees []Attendee `json:"attendees"`
+}
+
+// Notifier delivers notifications through a channel. A notification is delivered at least once: it is sent again
+// when Notify fails, whatever part of it went through.
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// WebhookNotifier posts notifications as JSON to a URL, any response but a 2xx is a failure.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string, timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+
+	return nil
+}
+
+// EmailNotifier mails notifications to the attendees through an SMTP server, in a single message with the attendees
+// as blind recipients so they do not see each other's address.
+type EmailNotifier struct {
+	addr string
+	from string
+	auth smtp.Auth
+	send func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmailNotifier sends through the SMTP server at addr (host:port), authenticating with PLAIN auth unless username
+// is empty.
+func NewEmailNotifier(addr string, from string, username string, password string) *EmailNotifier {
+	var auth smtp.Auth
+
+	if username != "" {
+		host, _, _ := strings.Cut(addr, ":")
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &EmailNotifier{addr: addr, from: from, auth: auth, send: smtp.SendMail}
+}
+
+func (n *EmailNotifier) Notify(_ context.Context, notification Notification) error {
+	to := make([]string, 0, len(notification.Attendees))
+	for _, attendee := range notification.Attendees {
+		to = append(to, attendee.Email)
+	}
+
+	// Nobody to remind
+	if len(to) == 0 {
+		return nil
+	}
+
+	err := n.send(n.addr, n.auth, n.from, to, n.message(notification))
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+func (n *EmailNotifier) message(notification Notification) []byte {
+	o := notification.Occurrence
+
+	loc, err := Location(o.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	when := o.StartTime.In(loc).Format("Monday, January 2, 2006 at 15:04 MST")
+	if o.AllDay {
+		when = o.StartTime.In(loc).Format("Monday, January 2, 2006")
+	}
+
+	var msg bytes.Buffer
+
+	// Header values are Q-encoded whenever they hold anything but printable ASCII, line breaks included
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: undisclosed-recipients:;\r\n")
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "Reminder: "+o.Title))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=UTF-8\r\n")
+	fmt.Fprintf(&msg, "\r\n")
+
+	fmt.Fprintf(&msg, "%s starts %s.\r\n", o.Title, when)
+
+	place := notification.Location
+	if place.Name != "" && place.Address != "" {
+		fmt.Fprintf(&msg, "\r\nWhere: %s, %s\r\n", place.Name, place.Address)
+	} else if place.Name != "" || place.Address != "" {
+		fmt.Fprintf(&msg, "\r\nWhere: %s\r\n", place.Name+place.Address)
+	}
+
+	if o.Description != "" {
+		fmt.Fprintf(&msg, "\r\n%s\r\n", strings.ReplaceAll(strings.ReplaceAll(o.Description, "\r\n", "\n"), "\n", "\r\n"))
+	}
+
+	return msg.Bytes()
+}
+
+// LogNotifier writes notifications as JSON lines, to stdout for instance, for local development.
+type LogNotifier struct {
+	logger zerolog.Logger
+}
+
+func NewLogNotifier(w io.Writer) *LogNotifier {
+	return &LogNotifier{logger: zerolog.New(w).With().Timestamp().Logger()}
+}
+
+func (n *LogNotifier) Notify(_ context.Context, notification Notification) error {
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	n.logger.Info().RawJSON("notification", data).Msg("reminder")
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testNotification() Notification {
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+
+	return Notification{
+		ReminderId:    "uuid-r",
+		Channel:       ChannelEmail,
+		OffsetMinutes: 15,
+		Occurrence: Occurrence{
+			EventId: "uuid-1", RecurrenceId: start, Title: "Café review", Description: "Agenda\nNotes",
+			StartTime: start, EndTime: start.Add(time.Hour), TimeZone: "Europe/Madrid",
+		},
+		Location:  Place{Name: "Room 4", Address: "Calle Mayor 1"},
+		Attendees: []Attendee{{Email: "ada@example.com"}, {Email: "bob@example.com"}},
+	}
+}
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	t.Parallel()
+
+	t.Run("posts the notification", func(t *testing.T) {
+		t.Parallel()
+
+		var got Notification
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		err := NewWebhookNotifier(server.URL, time.Second).Notify(context.Background(), testNotification())
+		require.NoError(t, err)
+		assert.Equal(t, "uuid-r", got.ReminderId)
+		assert.Equal(t, "Café review", got.Occurrence.Title)
+		assert.Len(t, got.Attendees, 2)
+	})
+
+	t.Run("fails on any other status", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		err := NewWebhookNotifier(server.URL, time.Second).Notify(context.Background(), testNotification())
+		require.ErrorContains(t, err, "502")
+	})
+}
+
+func TestEmailNotifier_Notify(t *testing.T) {
+	t.Parallel()
+
+	t.Run("mails the attendees as blind recipients", func(t *testing.T) {
+		t.Parallel()
+
+		var to []string
+		var msg string
+
+		notifier := NewEmailNotifier("smtp.example.com:587", "calendar@example.com", "", "")
+		notifier.send = func(addr string, auth smtp.Auth, from string, recipients []string, body []byte) error {
+			assert.Equal(t, "smtp.example.com:587", addr)
+			assert.Nil(t, auth)
+			assert.Equal(t, "calendar@example.com", from)
+			to, msg = recipients, string(body)
+
+			return nil
+		}
+
+		err := notifier.Notify(context.Background(), testNotification())
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"ada@example.com", "bob@example.com"}, to)
+		assert.Contains(t, msg, "To: undisclosed-recipients:;\r\n")
+		assert.Contains(t, msg, "Subject: =?utf-8?q?Reminder:_Caf=C3=A9_review?=\r\n")
+		assert.Contains(t, msg, "Café review starts Monday, March 3, 2025 at 10:00 CET.\r\n")
+		assert.Contains(t, msg, "Where: Room 4, Calle Mayor 1\r\n")
+		assert.Contains(t, msg, "Agenda\r\nNotes\r\n")
+		assert.NotContains(t, msg, "ada@example.com")
+	})
+
+	t.Run("nobody to remind", func(t *testing.T) {
+		t.Parallel()
+
+		notifier := NewEmailNotifier("smtp.example.com:587", "calendar@example.com", "user", "secret")
+		notifier.send = func(string, smtp.Auth, string, []string, []byte) error {
+			t.Fatal("nothing should be sent")
+			return nil
+		}
+
+		notification := testNotification()
+		notification.Attendees = nil
+
+		require.NoError(t, notifier.Notify(context.Background(), notification))
+	})
+
+	t.Run("send failure", func(t *testing.T) {
+		t.Parallel()
+
+		notifier := NewEmailNotifier("smtp.example.com:587", "calendar@example.com", "", "")
+		notifier.send = func(string, smtp.Auth, string, []string, []byte) error {
+			return errors.New("connection refused")
+		}
+
+		require.ErrorContains(t, notifier.Notify(context.Background(), testNotification()), "connection refused")
+	})
+}
+
+func TestLogNotifier_Notify(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	require.NoError(t, NewLogNotifier(&buf).Notify(context.Background(), testNotification()))
+
+	var line struct {
+		Message      string       `json:"message"`
+		Notification Notification `json:"notification"`
+	}
+
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "reminder", line.Message)
+	assert.Equal(t, "uuid-r", line.Notification.ReminderId)
+}
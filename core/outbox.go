@@ -0,0 +1,250 @@
+package core
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// OutboxBatch is the most outbox messages a dispatcher publishes at once.
+	OutboxBatch = 100
+	// OutboxMaxAttempts is how many times publishing a message fails before it is dead.
+	OutboxMaxAttempts = 10
+	// OutboxRetryDelay is how long a message that failed waits before it is published again, the wait doubles after
+	// every attempt up to OutboxMaxRetryDelay.
+	OutboxRetryDelay    = time.Second
+	OutboxMaxRetryDelay = 10 * time.Minute
+)
+
+// OutboxBackoff returns how long a message waits after its given number of failed attempts.
+func OutboxBackoff(attempts int) time.Duration {
+	return backoff(attempts, OutboxRetryDelay, OutboxMaxRetryDelay)
+}
+
+// backoff returns delay doubled after every attempt past the first, up to ceiling.
+func backoff(attempts int, delay, ceiling time.Duration) time.Duration {
+	for i := 1; i < attempts && delay < ceiling; i++ {
+		delay *= 2
+	}
+
+	return min(delay, ceiling)
+}
+
+// Publisher publishes the outbox messages to whatever reacts to the changes of events. A message is published at
+// least once: it may be published again when marking it delivered fails, so publishers must tolerate repeats, which
+// they tell apart by its id.
+type Publisher interface {
+	Publish(ctx context.Context, message OutboxMessage) error
+}
+
+// MemoryPublisher keeps the messages it publishes, for tests.
+type MemoryPublisher struct {
+	mu       sync.Mutex
+	messages []OutboxMessage
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(_ context.Context, message OutboxMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.messages = append(p.messages, message)
+
+	return nil
+}
+
+// Messages returns the messages published so far, in order.
+func (p *MemoryPublisher) Messages() []OutboxMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return slices.Clone(p.messages)
+}
+
+// OutboxDispatcher publishes the messages the repository writes to the outbox along with the changes of events, every
+// interval until it is stopped. Replicas lock the messages they publish, so none is published twice at once, and the
+// messages written while no dispatcher ran are published once one does.
+type OutboxDispatcher struct {
+	name       string
+	repository RepositoryInterface
+	publisher  Publisher
+	interval   time.Duration
+	tracer     trace.Tracer
+	metrics    *OutboxMetrics
+	stop       chan struct{}
+	once       sync.Once
+}
+
+func NewOutboxDispatcher(repository RepositoryInterface, publisher Publisher, interval time.Duration) *OutboxDispatcher {
+	return &OutboxDispatcher{
+		name:       "outbox-dispatcher",
+		repository: repository,
+		publisher:  publisher,
+		interval:   interval,
+		tracer:     otel.GetTracerProvider().Tracer("ltk-code-challenge/outbox"),
+		metrics:    NewOutboxMetrics(repository),
+		stop:       make(chan struct{}),
+	}
+}
+
+// Dispatch publishes the due messages, a batch at a time until none is left, and returns the number of them published.
+// A message that fails is logged and published again with an exponential backoff, until it is dead after
+// OutboxMaxAttempts attempts; the messages after it are published meanwhile, so they may overtake it.
+func (d *OutboxDispatcher) Dispatch(ctx context.Context) (int, error) {
+	published := 0
+
+	for ctx.Err() == nil {
+		n, err := d.repository.DispatchOutbox(ctx, OutboxBatch, d.publish)
+		published += n
+
+		if err != nil {
+			log.Error().Str("component", d.name).Err(err).Msg("failed to dispatch outbox")
+			return published, err
+		}
+
+		if n < OutboxBatch {
+			break
+		}
+	}
+
+	if published > 0 {
+		log.Info().Str("component", d.name).Int("published", published).Msg("outbox dispatched")
+	}
+
+	return published, ctx.Err()
+}
+
+// publish publishes the message through the publisher. It continues the trace of the request that changed the event.
+func (d *OutboxDispatcher) publish(ctx context.Context, message OutboxMessage) error {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(message.TraceContext))
+
+	ctx, span := d.tracer.Start(ctx, "outbox.publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.Int64("outbox.id", message.Id),
+			attribute.String("outbox.type", message.Type),
+			attribute.String("outbox.aggregate_id", message.AggregateId),
+		),
+	)
+	defer span.End()
+
+	err := d.publisher.Publish(ctx, message)
+	d.metrics.Observe(ctx, message, err)
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		log.Error().Str("component", d.name).Int64("message", message.Id).Err(err).Msg("failed to publish outbox message")
+	}
+
+	return err
+}
+
+// Purge permanently removes the messages delivered longer than retention ago.
+func (d *OutboxDispatcher) Purge(ctx context.Context, retention time.Duration) (int64, error) {
+	purged, err := d.repository.PurgeOutbox(ctx, time.Now().Add(-retention))
+	if err != nil {
+		log.Error().Str("component", d.name).Err(err).Msg("failed to purge outbox")
+		return 0, err
+	}
+
+	log.Info().Str("component", d.name).Int64("purged", purged).Msg("outbox purged")
+
+	return purged, nil
+}
+
+func (d *OutboxDispatcher) Run(ctx context.Context) error {
+	log.Info().Str("stage", "startup").Str("component", d.name).Msg("starting up")
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		// errors are logged and retried on the next tick
+		_, _ = d.Dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-d.stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *OutboxDispatcher) Stop(ctx context.Context) error {
+	log.Info().Str("stage", "shut down").Str("component", d.name).Msg("stopping")
+	defer log.Info().Str("stage", "shut down").Str("component", d.name).Msg("stopped")
+
+	d.once.Do(func() { close(d.stop) })
+
+	return nil
+}
+
+// OutboxMetrics measures how far behind the changes of events their publication is: the lag of every message published
+// and, whenever metrics are collected, how many messages are pending and for how long the oldest of them has been.
+type OutboxMetrics struct {
+	published metric.Int64Counter
+	lag       metric.Float64Histogram
+}
+
+func NewOutboxMetrics(repository RepositoryInterface) *OutboxMetrics {
+	meter := otel.Meter("ltk-code-challenge/outbox")
+
+	published, _ := meter.Int64Counter("outbox.messages.published")
+	lag, _ := meter.Float64Histogram("outbox.publish.lag.ms")
+	backlog, _ := meter.Int64ObservableGauge("outbox.backlog")
+	age, _ := meter.Float64ObservableGauge("outbox.backlog.age.ms")
+
+	_, _ = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		pending, oldest, err := repository.OutboxBacklog(ctx)
+		if err != nil {
+			return err
+		}
+
+		o.ObserveInt64(backlog, pending)
+
+		if oldest != nil {
+			o.ObserveFloat64(age, float64(time.Since(*oldest).Milliseconds()))
+		} else {
+			o.ObserveFloat64(age, 0)
+		}
+
+		return nil
+	}, backlog, age)
+
+	return &OutboxMetrics{published: published, lag: lag}
+}
+
+func (m *OutboxMetrics) Observe(ctx context.Context, message OutboxMessage, err error) {
+	outcome := "published"
+	if err != nil {
+		outcome = "failed"
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("outbox.type", message.Type),
+		attribute.String("outbox.outcome", outcome),
+	}
+
+	m.published.Add(ctx, 1, metric.WithAttributes(attrs...))
+
+	if err == nil {
+		ms := float64(time.Since(message.CreatedAt).Milliseconds())
+		m.lag.Record(ctx, ms, metric.WithAttributes(attrs[0]))
+	}
+}
@@ -0,0 +1,189 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// publisherFunc publishes the messages through a function, for tests.
+type publisherFunc func(ctx context.Context, message OutboxMessage) error
+
+func (f publisherFunc) Publish(ctx context.Context, message OutboxMessage) error {
+	return f(ctx, message)
+}
+
+// dispatchOutbox makes the mocked DispatchOutbox publish the messages, and return how many were published.
+func dispatchOutbox(messages []OutboxMessage) func(args mock.Arguments) {
+	return func(args mock.Arguments) {
+		publish := args.Get(2).(func(ctx context.Context, message OutboxMessage) error)
+
+		for _, message := range messages {
+			_ = publish(args.Get(0).(context.Context), message)
+		}
+	}
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, time.Second, OutboxBackoff(1))
+	assert.Equal(t, 2*time.Second, OutboxBackoff(2))
+	assert.Equal(t, 256*time.Second, OutboxBackoff(9))
+	assert.Equal(t, OutboxMaxRetryDelay, OutboxBackoff(100))
+}
+
+func TestOutboxDispatcher_Dispatch(t *testing.T) {
+	t.Parallel()
+
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	messages := []OutboxMessage{
+		{Id: 1, Type: EventCreated, AggregateId: "uuid-1", Payload: json.RawMessage(`{"id":"uuid-1"}`), TraceContext: map[string]string{"traceparent": traceparent}},
+		{Id: 2, Type: EventDeleted, AggregateId: "uuid-2", Payload: json.RawMessage(`{"id":"uuid-2"}`)},
+	}
+
+	t.Run("publishes the pending messages", func(t *testing.T) {
+		t.Parallel()
+
+		repo := new(MockRepository)
+		repo.On("DispatchOutbox", mock.Anything, OutboxBatch, mock.Anything).Run(dispatchOutbox(messages)).Return(2, nil)
+
+		publisher := NewMemoryPublisher()
+
+		published, err := NewOutboxDispatcher(repo, publisher, time.Second).Dispatch(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 2, published)
+		assert.Equal(t, messages, publisher.Messages())
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("continues the trace of the change", func(t *testing.T) {
+		t.Parallel()
+
+		var spans []trace.SpanContext
+
+		publisher := publisherFunc(func(ctx context.Context, _ OutboxMessage) error {
+			spans = append(spans, trace.SpanContextFromContext(ctx))
+			return nil
+		})
+
+		repo := new(MockRepository)
+		repo.On("DispatchOutbox", mock.Anything, OutboxBatch, mock.Anything).Run(dispatchOutbox(messages[:1])).Return(1, nil)
+
+		_, err := NewOutboxDispatcher(repo, publisher, time.Second).Dispatch(context.Background())
+		require.NoError(t, err)
+		require.Len(t, spans, 1)
+		assert.Equal(t, traceparent[3:35], spans[0].TraceID().String())
+	})
+
+	t.Run("publishes batches until none is full", func(t *testing.T) {
+		t.Parallel()
+
+		repo := new(MockRepository)
+		repo.On("DispatchOutbox", mock.Anything, OutboxBatch, mock.Anything).Return(OutboxBatch, nil).Once()
+		repo.On("DispatchOutbox", mock.Anything, OutboxBatch, mock.Anything).Return(3, nil).Once()
+
+		published, err := NewOutboxDispatcher(repo, NewMemoryPublisher(), time.Second).Dispatch(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, OutboxBatch+3, published)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("dispatch failure", func(t *testing.T) {
+		t.Parallel()
+
+		repo := new(MockRepository)
+		repo.On("DispatchOutbox", mock.Anything, OutboxBatch, mock.Anything).Return(0, errors.New("db error"))
+
+		_, err := NewOutboxDispatcher(repo, NewMemoryPublisher(), time.Second).Dispatch(context.Background())
+		require.Error(t, err)
+	})
+}
+
+func TestOutboxDispatcher_Run(t *testing.T) {
+	t.Parallel()
+
+	repo := new(MockRepository)
+	repo.On("DispatchOutbox", mock.Anything, OutboxBatch, mock.Anything).Return(0, nil)
+
+	dispatcher := NewOutboxDispatcher(repo, NewMemoryPublisher(), time.Hour)
+
+	done := make(chan error)
+	go func() { done <- dispatcher.Run(context.Background()) }()
+
+	// Stopping twice is harmless
+	require.NoError(t, dispatcher.Stop(context.Background()))
+	require.NoError(t, dispatcher.Stop(context.Background()))
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("dispatcher did not stop")
+	}
+}
+
+func TestWebhookPublisher_Publish(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		messageType string
+		queueErr    error
+		wantQueued  bool
+		wantErr     bool
+	}{
+		{
+			name:        "changes of events",
+			messageType: EventUpdated,
+			wantQueued:  true,
+		},
+		{
+			name:        "other messages",
+			messageType: "calendar.deleted",
+		},
+		{
+			name:        "queue failure",
+			messageType: EventCreated,
+			queueErr:    errors.New("db error"),
+			wantQueued:  true,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			queued := false
+
+			queue := webhookQueueFunc(func(_ context.Context, message OutboxMessage) (int64, error) {
+				queued = true
+				return 1, tt.queueErr
+			})
+
+			err := NewWebhookPublisher(queue).Publish(context.Background(), OutboxMessage{Id: 1, Type: tt.messageType, AggregateId: "uuid-1"})
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantQueued, queued)
+		})
+	}
+}
+
+type webhookQueueFunc func(ctx context.Context, message OutboxMessage) (int64, error)
+
+func (f webhookQueueFunc) EnqueueDeliveries(ctx context.Context, message OutboxMessage) (int64, error) {
+	return f(ctx, message)
+}
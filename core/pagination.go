@@ -0,0 +1,63 @@
+package core
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the keyset position (start_time, id) a page starts after (or before, when Backward is set).
+type Cursor struct {
+	StartTime time.Time `json:"t"`
+	Id        string    `json:"i"`
+	Backward  bool      `json:"b,omitempty"`
+}
+
+func EncodeCursor(cursor Cursor) string {
+	//nolint:errchkjson
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(value string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	var cursor Cursor
+
+	err = json.Unmarshal(data, &cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	if cursor.Id == "" || cursor.StartTime.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+func ParseLimit(value string) (int, error) {
+	if value == "" {
+		return DefaultPageLimit, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 {
+		return 0, fmt.Errorf("limit must be a positive integer: %q", value)
+	}
+
+	return min(limit, MaxPageLimit), nil
+}
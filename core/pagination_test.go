@@ -0,0 +1,65 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+
+		cursor := Cursor{StartTime: now, Id: "uuid-1", Backward: true}
+
+		got, err := DecodeCursor(EncodeCursor(cursor))
+		require.NoError(t, err)
+		assert.Equal(t, cursor, *got)
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		t.Parallel()
+
+		for _, value := range []string{"%%%", "bm90LWpzb24", EncodeCursor(Cursor{StartTime: now})} {
+			_, err := DecodeCursor(value)
+			require.ErrorIs(t, err, ErrInvalidCursor)
+		}
+	})
+}
+
+func TestParseLimit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{name: "default", value: "", want: DefaultPageLimit},
+		{name: "explicit", value: "10", want: 10},
+		{name: "capped", value: "5000", want: MaxPageLimit},
+		{name: "zero", value: "0", wantErr: true},
+		{name: "not a number", value: "ten", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseLimit(tt.value)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MergePatch applies a JSON Merge Patch (RFC 7396) to a JSON document.
+func MergePatch(document []byte, patch []byte) ([]byte, error) {
+	var target any
+
+	err := json.Unmarshal(document, &target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode document: %w", err)
+	}
+
+	var changes any
+
+	err = json.Unmarshal(patch, &changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode merge patch: %w", err)
+	}
+
+	merged, err := json.Marshal(mergeValue(target, changes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode document: %w", err)
+	}
+
+	return merged, nil
+}
+
+// RequireMembers checks that the JSON object has the members, e.g. once merged with a patch that may set them to null.
+func RequireMembers(document []byte, names ...string) error {
+	var object map[string]json.RawMessage
+
+	err := json.Unmarshal(document, &object)
+	if err != nil {
+		return fmt.Errorf("failed to decode document: %w", err)
+	}
+
+	for _, name := range names {
+		if _, ok := object[name]; !ok {
+			return fmt.Errorf("member '%s' is required", name)
+		}
+	}
+
+	return nil
+}
+
+func mergeValue(target any, patch any) any {
+	changes, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	object, ok := target.(map[string]any)
+	if !ok {
+		object = map[string]any{}
+	}
+
+	for name, value := range changes {
+		if value == nil {
+			delete(object, name)
+			continue
+		}
+
+		object[name] = mergeValue(object[name], value)
+	}
+
+	return object
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePatch(t *testing.T) {
+	t.Parallel()
+
+	// Test cases from RFC 7396, appendix A
+	tests := []struct {
+		name     string
+		document string
+		patch    string
+		want     string
+		wantErr  bool
+	}{
+		{name: "replace member", document: `{"a":"b"}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{name: "add member", document: `{"a":"b"}`, patch: `{"b":"c"}`, want: `{"a":"b","b":"c"}`},
+		{name: "remove member", document: `{"a":"b"}`, patch: `{"a":null}`, want: `{}`},
+		{name: "replace array", document: `{"a":["b"]}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{name: "nested", document: `{"a":{"b":"c"}}`, patch: `{"a":{"b":"d","c":null}}`, want: `{"a":{"b":"d"}}`},
+		{name: "object into scalar", document: `{"a":"b"}`, patch: `{"a":{"c":null,"d":"e"}}`, want: `{"a":{"d":"e"}}`},
+		{name: "non object patch", document: `{"a":"b"}`, patch: `["c"]`, want: `["c"]`},
+		{name: "invalid document", document: `{`, patch: `{}`, wantErr: true},
+		{name: "invalid patch", document: `{}`, patch: `{`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := MergePatch([]byte(tt.document), []byte(tt.patch))
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.JSONEq(t, tt.want, string(got))
+			}
+		})
+	}
+}
+
+func TestRequireMembers(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, RequireMembers([]byte(`{"a":1,"b":null}`), "a", "b"))
+	require.EqualError(t, RequireMembers([]byte(`{"a":1}`), "a", "b"), "member 'b' is required")
+	require.Error(t, RequireMembers([]byte(`["a"]`), "a"))
+}
@@ -2,19 +2,109 @@ package core
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	pgx "github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 )
 
+// eventListColumns lists the events columns read by eventListFields, in order. Events outside any calendar read an
+// empty calendar id. Statements reading many events read these, then their tags and attendee counts all at once with
+// loadEventDetails.
+const eventListColumns = "id, title, description, start_time, end_time, created_at, version, " +
+	"time_zone, rrule, exdates, rdates, all_day, updated_at, ical_uid, scope, COALESCE(calendar_id::text,''), " +
+	"capacity, location_name, location_address, latitude, longitude"
+
+// eventColumns lists the events columns read by eventFields, in order: eventListColumns along with the tags and the
+// attendee counts of the event (see event_tag_names and attendee_counts in schema.sql), for statements of a single
+// event. Attendee counts are computed on read, so no counter can drift.
+const eventColumns = eventListColumns + ", event_tag_names(id), attendee_counts(id)"
+
+func eventListFields(e *Event) []any {
+	return []any{
+		&e.Id, &e.Title, &e.Description, &e.StartTime, &e.EndTime, &e.CreatedAt, &e.Version,
+		&e.TimeZone, &e.RRule, &e.ExDates, &e.RDates, &e.AllDay, &e.UpdatedAt, &e.Uid, &e.Scope, &e.CalendarId,
+		&e.Capacity, &e.Location.Name, &e.Location.Address, &e.Location.Latitude, &e.Location.Longitude,
+	}
+}
+
+func eventFields(e *Event) []any {
+	return append(eventListFields(e), &e.Tags, &e.Attendees)
+}
+
+// registrationColumns lists the registrations columns read by registrationFields, in order.
+const registrationColumns = "id, event_id, email, name, status, created_at, updated_at"
+
+func registrationFields(reg *Registration) []any {
+	return []any{&reg.Id, &reg.EventId, &reg.Email, &reg.Name, &reg.Status, &reg.CreatedAt, &reg.UpdatedAt}
+}
+
+// attendeeColumns lists the attendees columns read by attendeeFields, in order.
+const attendeeColumns = "id, event_id, email, name, status, responded_at, created_at, updated_at"
+
+func attendeeFields(a *Attendee) []any {
+	return []any{&a.Id, &a.EventId, &a.Email, &a.Name, &a.Status, &a.RespondedAt, &a.CreatedAt, &a.UpdatedAt}
+}
+
+// reminderColumns lists the reminders columns read by reminderFields, in order.
+const reminderColumns = "id, event_id, offset_minutes, channel, reminded_through, due_at, revision, created_at, updated_at"
+
+func reminderFields(rem *Reminder) []any {
+	return []any{&rem.Id, &rem.EventId, &rem.OffsetMinutes, &rem.Channel, &rem.RemindedThrough, &rem.DueAt, &rem.Revision, &rem.CreatedAt, &rem.UpdatedAt}
+}
+
+// webhookColumns lists the webhooks columns read by webhookFields, in order. The secret is left out, it is only read
+// to sign deliveries.
+const webhookColumns = "id, url, event_types, created_at, updated_at"
+
+func webhookFields(w *Webhook) []any {
+	return []any{&w.Id, &w.Url, &w.EventTypes, &w.CreatedAt, &w.UpdatedAt}
+}
+
+// deliveryColumns lists the webhook_deliveries columns read by deliveryFields, in order.
+const deliveryColumns = "id, webhook_id, event_type, event_id, payload, trace_context, status, attempts, next_attempt_at, " +
+	"last_status_code, last_error, delivered_at, created_at, updated_at"
+
+func deliveryFields(d *Delivery) []any {
+	return []any{
+		&d.Id, &d.WebhookId, &d.EventType, &d.EventId, &d.Payload, &d.TraceContext, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&d.LastStatusCode, &d.LastError, &d.DeliveredAt, &d.CreatedAt, &d.UpdatedAt,
+	}
+}
+
+// outboxColumns lists the outbox columns read by outboxFields, in order.
+const outboxColumns = "id, type, aggregate_id, payload, trace_context, attempts, created_at"
+
+func outboxFields(m *OutboxMessage) []any {
+	return []any{&m.Id, &m.Type, &m.AggregateId, &m.Payload, &m.TraceContext, &m.Attempts, &m.CreatedAt}
+}
+
+// overrideColumns lists the event_overrides columns read by overrideFields, in order.
+const overrideColumns = "event_id, recurrence_id, title, description, start_time, end_time, cancelled"
+
+func overrideFields(o *Override) []any {
+	return []any{&o.EventId, &o.RecurrenceId, &o.Title, &o.Description, &o.StartTime, &o.EndTime, &o.Cancelled}
+}
+
 type DBInstance interface {
 	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Acquire(ctx context.Context) (*pgxpool.Conn, error)
 }
 
 type Repository struct {
@@ -39,6 +129,11 @@ func (r *Repository) SaveEvent(ctx context.Context, event *Event) (*Event, error
 	ctx, span := r.tracer.Start(ctx, "repository.SaveEvent")
 	defer span.End()
 
+	seriesEnd, err := SeriesEnd(*event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute series end: %w", err)
+	}
+
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
@@ -46,12 +141,33 @@ func (r *Repository) SaveEvent(ctx context.Context, event *Event) (*Event, error
 
 	var savedEvent Event
 
-	_ = tx.QueryRow(ctx,
-		"INSERT INTO events (title, description, start_time, end_time) "+
-			"VALUES ($1, $2, $3, $4) "+
-			"RETURNING id, title, description, start_time, end_time, created_at",
-		event.Title, event.Description, event.StartTime, event.EndTime).
-		Scan(&savedEvent.Id, &savedEvent.Title, &savedEvent.Description, &savedEvent.StartTime, &savedEvent.EndTime, &savedEvent.CreatedAt)
+	err = tx.QueryRow(ctx,
+		"INSERT INTO events (title, description, start_time, end_time, time_zone, rrule, exdates, rdates, series_end, all_day, scope, calendar_id, capacity, "+
+			"location_name, location_address, latitude, longitude) "+
+			"VALUES ($1, $2, $3, $4, COALESCE(NULLIF($5, ''), 'UTC'), $6, $7, $8, $9, $10, $11, NULLIF($12, '')::uuid, $13, $14, $15, $16, $17) "+
+			"RETURNING "+eventColumns,
+		event.Title, event.Description, event.StartTime, event.EndTime,
+		event.TimeZone, event.RRule, event.ExDates, event.RDates, seriesEnd, event.AllDay, event.Scope, event.CalendarId, event.Capacity,
+		event.Location.Name, event.Location.Address, event.Location.Latitude, event.Location.Longitude).
+		Scan(eventFields(&savedEvent)...)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, fmt.Errorf("failed to save event: %w", r.writeError(ctx, err, *event))
+	}
+
+	savedEvent.Tags = NormalizeTags(event.Tags)
+
+	err = saveTags(ctx, tx, []Event{savedEvent})
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, fmt.Errorf("failed to save tags: %w", err)
+	}
+
+	err = writeOutbox(ctx, tx, EventCreated, []Event{savedEvent})
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, fmt.Errorf("failed to write outbox: %w", err)
+	}
 
 	err = tx.Commit(ctx)
 	if err != nil {
@@ -62,6 +178,158 @@ func (r *Repository) SaveEvent(ctx context.Context, event *Event) (*Event, error
 	return &savedEvent, nil
 }
 
+// SaveEvents inserts the events in a single statement, and their tags in a single transaction, so either all of them
+// are created or none is. The saved events are returned in input order.
+func (r *Repository) SaveEvents(ctx context.Context, events []Event) ([]Event, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "save_events", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.SaveEvents")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("events", len(events)))
+
+	if len(events) == 0 {
+		return []Event{}, nil
+	}
+
+	const fields = 18
+
+	rows := make([]string, 0, len(events))
+	args := make([]any, 0, len(events)*fields)
+
+	for i, event := range events {
+		seriesEnd, err := SeriesEnd(event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute series end: %w", err)
+		}
+
+		placeholders := make([]string, fields)
+		for j := range placeholders {
+			placeholders[j] = "$" + strconv.Itoa(i*fields+j+1)
+		}
+
+		// Parameter types are inferred from the first row
+		if i == 0 {
+			for j, cast := range []string{"int", "text", "text", "timestamptz", "timestamptz", "text", "text",
+				"timestamptz[]", "timestamptz[]", "timestamptz", "boolean", "text", "text", "int", "text", "text", "float8", "float8"} {
+				placeholders[j] += "::" + cast
+			}
+		}
+
+		rows = append(rows, "("+strings.Join(placeholders, ", ")+")")
+		args = append(args, i, event.Title, event.Description, event.StartTime, event.EndTime,
+			event.TimeZone, event.RRule, event.ExDates, event.RDates, seriesEnd, event.AllDay, event.Scope, event.CalendarId, event.Capacity,
+			event.Location.Name, event.Location.Address, event.Location.Latitude, event.Location.Longitude)
+	}
+
+	// Ids are generated before the insert so that the inserted rows can be joined back to their input position
+	sql := "WITH input AS (" +
+		"SELECT uuid_generate_v4() AS id, * FROM (VALUES " + strings.Join(rows, ", ") + ") AS v " +
+		"(n, title, description, start_time, end_time, time_zone, rrule, exdates, rdates, series_end, all_day, scope, calendar_id, capacity, " +
+		"location_name, location_address, latitude, longitude)" +
+		"), inserted AS (" +
+		"INSERT INTO events (id, title, description, start_time, end_time, time_zone, rrule, exdates, rdates, series_end, all_day, scope, calendar_id, capacity, " +
+		"location_name, location_address, latitude, longitude) " +
+		"SELECT id, title, description, start_time, end_time, COALESCE(NULLIF(time_zone, ''), 'UTC'), " +
+		"rrule, exdates, rdates, series_end, all_day, scope, NULLIF(calendar_id, '')::uuid, capacity, " +
+		"location_name, location_address, latitude, longitude FROM input " +
+		"RETURNING " + eventColumns +
+		") SELECT inserted.*, input.n FROM inserted JOIN input USING (id) ORDER BY input.n"
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	saved, err := insertEvents(ctx, tx, sql, args)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, err
+	}
+
+	for i := range saved {
+		saved[i].Tags = NormalizeTags(events[i].Tags)
+	}
+
+	err = saveTags(ctx, tx, saved)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, fmt.Errorf("failed to save tags: %w", err)
+	}
+
+	err = writeOutbox(ctx, tx, EventCreated, saved)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, fmt.Errorf("failed to write outbox: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return saved, nil
+}
+
+func insertEvents(ctx context.Context, tx pgx.Tx, sql string, args []any) ([]Event, error) {
+	result, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save events: %w", batchWriteError(err))
+	}
+	defer result.Close()
+
+	var saved []Event
+
+	for result.Next() {
+		var e Event
+		var n int
+
+		err = result.Scan(append(eventFields(&e), &n)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+
+		saved = append(saved, e)
+	}
+
+	err = result.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to save events: %w", batchWriteError(err))
+	}
+
+	return saved, nil
+}
+
+// saveTags links the events to their (normalized) tags, creating the tags used for the first time.
+func saveTags(ctx context.Context, tx pgx.Tx, events []Event) error {
+	var ids, names []string
+
+	for _, event := range events {
+		for _, tag := range event.Tags {
+			ids, names = append(ids, event.Id), append(names, tag)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	_, err := tx.Exec(ctx, "INSERT INTO tags (name) SELECT DISTINCT unnest($1::text[]) ON CONFLICT (name) DO NOTHING", names)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		"INSERT INTO event_tags (event_id, tag_id) "+
+			"SELECT input.event_id, tags.id FROM unnest($1::uuid[], $2::text[]) AS input (event_id, name) JOIN tags USING (name)",
+		ids, names)
+
+	return err
+}
+
 func (r *Repository) GetEventById(ctx context.Context, id string) (*Event, error) {
 	start := time.Now()
 	var err error
@@ -74,18 +342,15 @@ func (r *Repository) GetEventById(ctx context.Context, id string) (*Event, error
 
 	err = r.pool.QueryRow(
 		ctx,
-		`SELECT id, title, description, start_time, end_time, created_at
+		`SELECT `+eventColumns+`
 		 FROM events
-		 WHERE id = $1`,
+		 WHERE id = $1 AND deleted_at IS NULL`,
 		id,
-	).Scan(
-		&e.Id,
-		&e.Title,
-		&e.Description,
-		&e.StartTime,
-		&e.EndTime,
-		&e.CreatedAt,
-	)
+	).Scan(eventFields(&e)...)
+	if errors.Is(err, pgx.ErrNoRows) || pgErrorCode(err) == invalidTextRepresentation {
+		return nil, fmt.Errorf("failed to get event by id: %w", ErrEventNotFound)
+	}
+
 	if err != nil {
 		return nil, fmt.Errorf("failed to get event by id: %w", err)
 	}
@@ -93,6 +358,2365 @@ func (r *Repository) GetEventById(ctx context.Context, id string) (*Event, error
 	return &e, nil
 }
 
+// UpdateEvent replaces the event identified by event.Id, provided its current version is expectedVersion
+// (or expectedVersion is AnyVersion), and bumps the version.
+func (r *Repository) UpdateEvent(ctx context.Context, event *Event, expectedVersion int) (*Event, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "update_event", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.UpdateEvent")
+	defer span.End()
+
+	seriesEnd, err := SeriesEnd(*event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute series end: %w", err)
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	var current Event
+
+	err = tx.QueryRow(ctx,
+		"SELECT version, capacity, start_time, time_zone, rrule, exdates, rdates FROM events WHERE id = $1 AND deleted_at IS NULL FOR UPDATE",
+		event.Id).
+		Scan(&current.Version, &current.Capacity, &current.StartTime, &current.TimeZone, &current.RRule, &current.ExDates, &current.RDates)
+	if errors.Is(err, pgx.ErrNoRows) || pgErrorCode(err) == invalidTextRepresentation {
+		err = ErrEventNotFound
+	}
+
+	if err == nil && expectedVersion != AnyVersion && current.Version != expectedVersion {
+		err = ErrVersionMismatch
+	}
+
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, fmt.Errorf("failed to lock event: %w", err)
+	}
+
+	var updatedEvent Event
+
+	err = tx.QueryRow(ctx,
+		"UPDATE events SET title = $1, description = $2, start_time = $3, end_time = $4, "+
+			"time_zone = COALESCE(NULLIF($5, ''), 'UTC'), rrule = $6, exdates = $7, rdates = $8, series_end = $9, all_day = $10, "+
+			"scope = $11, calendar_id = NULLIF($12, '')::uuid, capacity = $13, "+
+			"location_name = $14, location_address = $15, latitude = $16, longitude = $17, version = version + 1, updated_at = now() "+
+			"WHERE id = $18 "+
+			"RETURNING "+eventColumns,
+		event.Title, event.Description, event.StartTime, event.EndTime,
+		event.TimeZone, event.RRule, event.ExDates, event.RDates, seriesEnd, event.AllDay, event.Scope, event.CalendarId,
+		event.Capacity, event.Location.Name, event.Location.Address, event.Location.Latitude, event.Location.Longitude, event.Id).
+		Scan(eventFields(&updatedEvent)...)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, fmt.Errorf("failed to update event: %w", r.writeError(ctx, err, *event))
+	}
+
+	// The returned tags are still the previous ones
+	tags := NormalizeTags(event.Tags)
+	if !slices.Equal(updatedEvent.Tags, tags) {
+		updatedEvent.Tags = tags
+
+		err = replaceTags(ctx, tx, updatedEvent)
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return nil, fmt.Errorf("failed to save tags: %w", err)
+		}
+	}
+
+	// Overrides are keyed by the start of the occurrence they replace, which a new recurrence may not have anymore
+	if !sameRecurrence(current, updatedEvent) {
+		err = dropStaleOverrides(ctx, tx, updatedEvent)
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return nil, fmt.Errorf("failed to drop stale overrides: %w", err)
+		}
+	}
+
+	// Seats added to the event go to the waitlist
+	if updatedEvent.Capacity != current.Capacity {
+		err = promoteRegistrations(ctx, tx, updatedEvent.Id, updatedEvent.Capacity)
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return nil, fmt.Errorf("failed to promote registrations: %w", err)
+		}
+	}
+
+	err = writeOutbox(ctx, tx, EventUpdated, []Event{updatedEvent})
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, fmt.Errorf("failed to write outbox: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &updatedEvent, nil
+}
+
+// sameRecurrence tells whether the events have the same occurrences, as far as their overrides are concerned.
+func sameRecurrence(a Event, b Event) bool {
+	return a.StartTime.Equal(b.StartTime) && a.TimeZone == b.TimeZone && a.RRule == b.RRule &&
+		slices.EqualFunc(a.ExDates, b.ExDates, time.Time.Equal) && slices.EqualFunc(a.RDates, b.RDates, time.Time.Equal)
+}
+
+// dropStaleOverrides deletes the overrides of the event replacing occurrences it does not have anymore.
+func dropStaleOverrides(ctx context.Context, tx pgx.Tx, event Event) error {
+	rows, err := tx.Query(ctx, "SELECT recurrence_id FROM event_overrides WHERE event_id = $1", event.Id)
+	if err != nil {
+		return err
+	}
+
+	recurrenceIds, err := pgx.CollectRows(rows, pgx.RowTo[time.Time])
+	if err != nil {
+		return err
+	}
+
+	stale := make([]time.Time, 0)
+
+	for _, recurrenceId := range recurrenceIds {
+		ok, err := IsOccurrence(event, recurrenceId)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			stale = append(stale, recurrenceId)
+		}
+	}
+
+	if len(stale) == 0 {
+		return nil
+	}
+
+	_, err = tx.Exec(ctx, "DELETE FROM event_overrides WHERE event_id = $1 AND recurrence_id = ANY($2)", event.Id, stale)
+
+	return err
+}
+
+func replaceTags(ctx context.Context, tx pgx.Tx, event Event) error {
+	_, err := tx.Exec(ctx, "DELETE FROM event_tags WHERE event_id = $1", event.Id)
+	if err != nil {
+		return err
+	}
+
+	return saveTags(ctx, tx, []Event{event})
+}
+
+// writeOutbox records the changes of the events in the outbox, within the transaction making them so that they are
+// published if and only if it commits. Deleted events only carry their id.
+func writeOutbox(ctx context.Context, tx pgx.Tx, eventType string, events []Event) error {
+	ids := make([]string, 0, len(events))
+	payloads := make([]string, 0, len(events))
+
+	for _, event := range events {
+		var payload any = event
+		if eventType == EventDeleted {
+			payload = map[string]string{"id": event.Id}
+		}
+
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+
+		ids, payloads = append(ids, event.Id), append(payloads, string(data))
+	}
+
+	// Publishing the changes continues the trace of the request that made them
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	_, err := tx.Exec(ctx,
+		"INSERT INTO outbox (type, aggregate_id, payload, trace_context) "+
+			"SELECT $1, input.id, input.payload, $4 FROM unnest($2::uuid[], $3::jsonb[]) WITH ORDINALITY AS input (id, payload, n) "+
+			"ORDER BY input.n",
+		eventType, ids, payloads, map[string]string(carrier))
+
+	return err
+}
+
+// DeleteEvent moves the event to the trash; it can be restored until PurgeEvents removes it.
+func (r *Repository) DeleteEvent(ctx context.Context, id string) error {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "delete_event", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.DeleteEvent")
+	defer span.End()
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx,
+		"UPDATE events SET deleted_at = now(), version = version + 1, updated_at = now() WHERE id = $1 AND deleted_at IS NULL", id)
+	if (err == nil && tag.RowsAffected() == 0) || pgErrorCode(err) == invalidTextRepresentation {
+		err = ErrEventNotFound
+	}
+
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("failed to delete event: %w", err)
+	}
+
+	err = writeOutbox(ctx, tx, EventDeleted, []Event{{Id: id}})
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("failed to write outbox: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RestoreEvent takes the event out of the trash.
+func (r *Repository) RestoreEvent(ctx context.Context, id string) (*Event, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "restore_event", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.RestoreEvent")
+	defer span.End()
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	var e Event
+
+	err = tx.QueryRow(ctx,
+		"UPDATE events SET deleted_at = NULL, version = version + 1, updated_at = now() "+
+			"WHERE id = $1 AND deleted_at IS NOT NULL "+
+			"RETURNING "+eventColumns,
+		id).
+		Scan(eventFields(&e)...)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) || pgErrorCode(err) == invalidTextRepresentation {
+		return nil, fmt.Errorf("failed to restore event: %w", ErrEventNotFound)
+	}
+
+	// Events of the same scope may have taken the slot while it was in the trash
+	if pgErrorCode(err) == exclusionViolation {
+		conflictErr := err
+
+		err = r.pool.QueryRow(ctx, "SELECT "+eventColumns+" FROM events WHERE id = $1", id).Scan(eventFields(&e)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get event by id: %w", err)
+		}
+
+		err = r.writeError(ctx, conflictErr, e)
+
+		return nil, fmt.Errorf("failed to restore event: %w", err)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore event: %w", err)
+	}
+
+	// Restored events are back as they were, subscribers see them updated
+	err = writeOutbox(ctx, tx, EventUpdated, []Event{e})
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, fmt.Errorf("failed to write outbox: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &e, nil
+}
+
+// PurgeEvents permanently removes the events moved to the trash before the given time.
+func (r *Repository) PurgeEvents(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "purge_events", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.PurgeEvents")
+	defer span.End()
+
+	tag, err := r.pool.Exec(ctx, "DELETE FROM events WHERE deleted_at < $1", deletedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge events: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// jobLockSpace keys the advisory locks of scheduled jobs ("cron" in ASCII), apart from any other advisory lock.
+const jobLockSpace = 0x63726f6e
+
+// TryLock takes the advisory lock of the named job unless another session holds it. The lock belongs to a transaction
+// kept open until unlock is called, so it is also released if this replica dies mid-run.
+func (r *Repository) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "try_lock", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.TryLock")
+	defer span.End()
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	var acquired bool
+
+	err = tx.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock($1, hashtext($2))", jobLockSpace, name).Scan(&acquired)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, false, fmt.Errorf("failed to take advisory lock: %w", err)
+	}
+
+	if !acquired {
+		_ = tx.Rollback(ctx)
+		return nil, false, nil
+	}
+
+	// The run may have timed out by the time it unlocks, the rollback must not depend on it
+	unlock := func() { _ = tx.Rollback(context.WithoutCancel(ctx)) }
+
+	return unlock, true, nil
+}
+
+// ListenChanges listens for the changes of events, notified on ChangesChannel as they commit, on a connection of its
+// own until ctx is done or the connection is lost. Changes committed while nobody listens are not notified.
+func (r *Repository) ListenChanges(ctx context.Context, listening func(), notify func(change Change)) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	// The connection is left listening, it must not go back to the pool
+	listener := conn.Hijack()
+	defer listener.Close(context.WithoutCancel(ctx))
+
+	_, err = listener.Exec(ctx, "LISTEN "+ChangesChannel)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	listening()
+
+	for {
+		notification, err := listener.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to wait for notification: %w", err)
+		}
+
+		var change Change
+
+		err = json.Unmarshal([]byte(notification.Payload), &change)
+		if err != nil {
+			return fmt.Errorf("failed to decode change: %w", err)
+		}
+
+		notify(change)
+	}
+}
+
+// DispatchOutbox publishes up to limit due outbox messages, the oldest first, and marks those published as delivered.
+// The messages stay locked until then, so other dispatchers skip them rather than publish them too; a message that
+// fails to be published records the error and is due again after OutboxBackoff, or dead after OutboxMaxAttempts
+// attempts. It returns how many messages were published.
+func (r *Repository) DispatchOutbox(ctx context.Context, limit int, publish func(ctx context.Context, message OutboxMessage) error) (int, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "dispatch_outbox", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.DispatchOutbox")
+	defer span.End()
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	messages, err := lockOutbox(ctx, tx, limit)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return 0, fmt.Errorf("failed to lock outbox: %w", err)
+	}
+
+	delivered := make([]int64, 0, len(messages))
+
+	for _, message := range messages {
+		publishErr := publish(ctx, message)
+		if publishErr == nil {
+			delivered = append(delivered, message.Id)
+			continue
+		}
+
+		attempts := message.Attempts + 1
+		now := time.Now()
+
+		var next, dead *time.Time
+		if attempts >= OutboxMaxAttempts {
+			dead = &now
+		} else {
+			at := now.Add(OutboxBackoff(attempts))
+			next = &at
+		}
+
+		_, err = tx.Exec(ctx,
+			"UPDATE outbox SET attempts = $2, last_error = $3, next_attempt_at = $4, dead_at = $5 WHERE id = $1",
+			message.Id, attempts, publishErr.Error(), next, dead)
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return 0, fmt.Errorf("failed to record outbox error: %w", err)
+		}
+	}
+
+	if len(delivered) > 0 {
+		_, err = tx.Exec(ctx,
+			"UPDATE outbox SET delivered_at = now(), next_attempt_at = NULL, attempts = attempts + 1, last_error = '' WHERE id = ANY($1)",
+			delivered)
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return 0, fmt.Errorf("failed to mark outbox delivered: %w", err)
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return len(delivered), nil
+}
+
+func lockOutbox(ctx context.Context, tx pgx.Tx, limit int) ([]OutboxMessage, error) {
+	rows, err := tx.Query(ctx,
+		"SELECT "+outboxColumns+" FROM outbox WHERE delivered_at IS NULL AND dead_at IS NULL AND next_attempt_at <= now() "+
+			"ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := make([]OutboxMessage, 0)
+
+	for rows.Next() {
+		var m OutboxMessage
+
+		err = rows.Scan(outboxFields(&m)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox message: %w", err)
+		}
+
+		messages = append(messages, m)
+	}
+
+	return messages, rows.Err()
+}
+
+// OutboxBacklog returns how many outbox messages are pending, dead ones aside, and when the oldest of them was written,
+// nil when none is pending.
+func (r *Repository) OutboxBacklog(ctx context.Context) (int64, *time.Time, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "outbox_backlog", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.OutboxBacklog")
+	defer span.End()
+
+	var pending int64
+	var oldest *time.Time
+
+	err = r.pool.QueryRow(ctx, "SELECT count(*), min(created_at) FROM outbox WHERE delivered_at IS NULL AND dead_at IS NULL").Scan(&pending, &oldest)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get outbox backlog: %w", err)
+	}
+
+	return pending, oldest, nil
+}
+
+// PurgeOutbox permanently removes the outbox messages delivered before the given time.
+func (r *Repository) PurgeOutbox(ctx context.Context, deliveredBefore time.Time) (int64, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "purge_outbox", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.PurgeOutbox")
+	defer span.End()
+
+	tag, err := r.pool.Exec(ctx, "DELETE FROM outbox WHERE delivered_at < $1", deliveredBefore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge outbox: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func (r *Repository) ListEvents(ctx context.Context, filter EventFilter) (*EventPage, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "list_events", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.ListEvents")
+	defer span.End()
+
+	maxLimit := MaxPageLimit
+	if filter.Feed {
+		maxLimit = MaxFeedEvents
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > maxLimit {
+		limit = DefaultPageLimit
+	}
+
+	var args []any
+	arg := func(value any) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	conditions := []string{"deleted_at IS NULL"}
+	if filter.Trashed {
+		conditions = []string{"deleted_at IS NOT NULL"}
+	}
+
+	// Keyset pagination on (start_time, id): backward pages walk the index in reverse and are flipped afterwards
+	order := "ASC"
+	if filter.Cursor != nil {
+		operator := ">"
+		if filter.Cursor.Backward {
+			operator, order = "<", "DESC"
+		}
+
+		conditions = append(conditions, fmt.Sprintf("(start_time, id) %s (%s, %s)",
+			operator, arg(filter.Cursor.StartTime), arg(filter.Cursor.Id)))
+	}
+
+	if !filter.StartsAfter.IsZero() {
+		conditions = append(conditions, "start_time >= "+arg(filter.StartsAfter))
+	}
+
+	if !filter.EndsBefore.IsZero() {
+		conditions = append(conditions, "end_time <= "+arg(filter.EndsBefore))
+	}
+
+	// Interval overlap: the event starts before the window ends and ends after the window starts
+	if filter.Overlaps != nil {
+		conditions = append(conditions, "start_time < "+arg(filter.Overlaps.To))
+		conditions = append(conditions, "end_time > "+arg(filter.Overlaps.From))
+	}
+
+	if filter.TitlePrefix != "" {
+		conditions = append(conditions, "lower(title) LIKE lower("+arg(escapeLike(filter.TitlePrefix)+"%")+")")
+	}
+
+	if filter.CalendarId != "" {
+		conditions = append(conditions, "calendar_id = "+arg(filter.CalendarId))
+	}
+
+	// Haversine distance on a sphere of the Earth's mean radius. A degree of latitude is always kmPerDegree long, so the
+	// band of latitudes within the radius narrows the candidates down first, through events_latitude_idx
+	if filter.Near != nil {
+		latitude, longitude, radius := arg(filter.Near.Latitude), arg(filter.Near.Longitude), arg(filter.RadiusKm)
+
+		conditions = append(conditions, fmt.Sprintf("latitude BETWEEN %[1]s - %[2]s / %[3]g AND %[1]s + %[2]s / %[3]g",
+			latitude, radius, kmPerDegree))
+		conditions = append(conditions, fmt.Sprintf("2 * %[3]g * asin(least(1, sqrt("+
+			"power(sin(radians(latitude - %[1]s) / 2), 2) + "+
+			"cos(radians(%[1]s)) * cos(radians(latitude)) * power(sin(radians(longitude - %[2]s) / 2), 2)"+
+			"))) <= %[4]s",
+			latitude, longitude, earthRadiusKm, radius))
+	}
+
+	// Tagged events are looked up from their tags, through event_tags_tag_id_event_id_idx. The tags are distinct, so
+	// an event has all of them when it has as many of them
+	if len(filter.Tags) > 0 {
+		tagged := "SELECT event_id FROM event_tags JOIN tags ON tags.id = tag_id WHERE tags.name = ANY(" + arg(filter.Tags) + ")"
+		if filter.TagMode == TagModeAll {
+			tagged += " GROUP BY event_id HAVING count(*) = " + arg(len(filter.Tags))
+		}
+
+		conditions = append(conditions, "id IN ("+tagged+")")
+	}
+
+	sql := "SELECT " + eventListColumns + " FROM events WHERE " + strings.Join(conditions, " AND ")
+	sql += fmt.Sprintf(" ORDER BY start_time %s, id %s LIMIT %s", order, order, arg(limit+1))
+
+	rows, err := r.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]Event, 0, limit+1)
+	for rows.Next() {
+		var e Event
+
+		err = rows.Scan(eventListFields(&e)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+
+		events = append(events, e)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+
+	page := paginate(events, limit, filter.Cursor)
+
+	details := make([]*Event, 0, len(page.Events))
+	for i := range page.Events {
+		details = append(details, &page.Events[i])
+	}
+
+	err = r.loadEventDetails(ctx, details)
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// loadEventDetails reads the tags and the attendee counts of the events, with a query for each rather than a lookup
+// for each event.
+func (r *Repository) loadEventDetails(ctx context.Context, events []*Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(events))
+	byId := make(map[string]*Event, len(events))
+
+	for _, e := range events {
+		e.Tags, e.Attendees = nil, &AttendeeCounts{}
+		ids = append(ids, e.Id)
+		byId[e.Id] = e
+	}
+
+	rows, err := r.pool.Query(ctx,
+		"SELECT event_tags.event_id, tags.name FROM event_tags JOIN tags ON tags.id = event_tags.tag_id "+
+			"WHERE event_tags.event_id = ANY($1) ORDER BY tags.name",
+		ids)
+	if err != nil {
+		return fmt.Errorf("failed to list tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var eventId, name string
+
+		err = rows.Scan(&eventId, &name)
+		if err != nil {
+			return fmt.Errorf("failed to scan tag: %w", err)
+		}
+
+		byId[eventId].Tags = append(byId[eventId].Tags, name)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	// Same counts as attendee_counts
+	rows, err = r.pool.Query(ctx,
+		"SELECT event_id, status, count(*) FROM attendees WHERE event_id = ANY($1) GROUP BY event_id, status", ids)
+	if err != nil {
+		return fmt.Errorf("failed to count attendees: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var eventId, status string
+		var count int
+
+		err = rows.Scan(&eventId, &status, &count)
+		if err != nil {
+			return fmt.Errorf("failed to scan attendee count: %w", err)
+		}
+
+		counts := byId[eventId].Attendees
+		counts.Total += count
+
+		switch status {
+		case RsvpAccepted:
+			counts.Accepted = count
+		case RsvpDeclined:
+			counts.Declined = count
+		case RsvpTentative:
+			counts.Tentative = count
+		case RsvpNeedsAction:
+			counts.NeedsAction = count
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("failed to count attendees: %w", err)
+	}
+
+	return nil
+}
+
+func paginate(events []Event, limit int, cursor *Cursor) *EventPage {
+	hasMore := len(events) > limit
+	if hasMore {
+		events = events[:limit]
+	}
+
+	backward := cursor != nil && cursor.Backward
+	if backward {
+		slices.Reverse(events)
+	}
+
+	page := &EventPage{Events: events}
+	if len(events) == 0 {
+		return page
+	}
+
+	first, last := events[0], events[len(events)-1]
+
+	if backward || hasMore {
+		page.Next = EncodeCursor(Cursor{StartTime: last.StartTime, Id: last.Id})
+	}
+
+	if (backward && hasMore) || (!backward && cursor != nil) {
+		page.Prev = EncodeCursor(Cursor{StartTime: first.StartTime, Id: first.Id, Backward: true})
+	}
+
+	return page
+}
+
+// ListTags returns the tags in use by events outside the trash, the most used first.
+func (r *Repository) ListTags(ctx context.Context) ([]Tag, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "list_tags", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.ListTags")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx,
+		"SELECT tags.name, count(*) FROM tags "+
+			"JOIN event_tags ON event_tags.tag_id = tags.id JOIN events ON events.id = event_tags.event_id "+
+			"WHERE events.deleted_at IS NULL GROUP BY tags.name ORDER BY count(*) DESC, tags.name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := make([]Tag, 0)
+
+	for rows.Next() {
+		var t Tag
+
+		err = rows.Scan(&t.Name, &t.Count)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+
+		tags = append(tags, t)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// ListSeries returns the events having occurrences that may overlap the window, along with their overrides.
+func (r *Repository) ListSeries(ctx context.Context, window TimeWindow) ([]Series, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "list_series", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.ListSeries")
+	defer span.End()
+
+	var series []Series
+
+	series, err = r.listSeries(ctx, nil, window.From, window.To, MaxOccurrences+1)
+
+	return series, err
+}
+
+// listSeries is ListSeries for the events also meeting the conditions, whose parameters follow the window ($1, $2)
+// and the limit ($3) in args.
+func (r *Repository) listSeries(ctx context.Context, conditions []string, args ...any) ([]Series, error) {
+	// series_end is the end of the last occurrence (NULL for unbounded series); occurrences moved into the window count too
+	sql := "SELECT " + eventListColumns + " FROM events " +
+		"WHERE deleted_at IS NULL AND (" +
+		"(start_time < $2 AND (series_end IS NULL OR series_end > $1)) OR " +
+		"id IN (SELECT event_id FROM event_overrides WHERE NOT cancelled AND start_time < $2 AND end_time > $1)" +
+		") "
+	for _, condition := range conditions {
+		sql += "AND " + condition + " "
+	}
+
+	rows, err := r.pool.Query(ctx, sql+"ORDER BY start_time ASC, id ASC LIMIT $3", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list series: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	series := make([]Series, 0)
+	positions := make(map[string]int)
+
+	for rows.Next() {
+		var e Event
+
+		err = rows.Scan(eventListFields(&e)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+
+		if IsRecurring(e) {
+			ids = append(ids, e.Id)
+		}
+
+		positions[e.Id] = len(series)
+		series = append(series, Series{Event: e})
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list series: %w", err)
+	}
+
+	if len(series) > MaxOccurrences {
+		return nil, ErrTooManyOccurrences
+	}
+
+	if len(ids) == 0 {
+		return series, nil
+	}
+
+	overrides, err := r.queryOverrides(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, o := range overrides {
+		i := positions[o.EventId]
+		series[i].Overrides = append(series[i].Overrides, o)
+	}
+
+	return series, nil
+}
+
+// FreeBusy returns when the events matching the query are busy, clipped to its window and coalesced where they
+// overlap or touch. One-off events are merged by the database, so only recurring series are loaded to be expanded.
+func (r *Repository) FreeBusy(ctx context.Context, query FreeBusyQuery) ([]BusyInterval, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "free_busy", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.FreeBusy")
+	defer span.End()
+
+	window := TimeWindow{From: query.From, To: query.To}
+
+	busy, err := r.mergeBusy(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []any{window.From, window.To, MaxOccurrences + 1}
+	conditions := append(freeBusyConditions(query, &args), "(rrule <> '' OR cardinality(rdates) > 0)")
+
+	series, err := r.listSeries(ctx, conditions, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences, err := ExpandOccurrences(series, window)
+	if err != nil {
+		return nil, err
+	}
+
+	return MergeBusy(append(busy, occurrencesBusy(occurrences, window)...)), nil
+}
+
+// mergeBusy returns the busy intervals of the one-off events matching the query, merged as gaps and islands: sorted
+// by start, an event starts a new island unless one before it is still going on by then.
+func (r *Repository) mergeBusy(ctx context.Context, query FreeBusyQuery) ([]BusyInterval, error) {
+	args := []any{query.From, query.To}
+	conditions := append([]string{
+		"deleted_at IS NULL", "rrule = ''", "coalesce(cardinality(rdates), 0) = 0",
+		"start_time < $2", "end_time > $1", "end_time > start_time",
+	}, freeBusyConditions(query, &args)...)
+
+	rows, err := r.pool.Query(ctx,
+		"WITH busy AS ("+
+			"SELECT greatest(start_time, $1) AS start_time, least(end_time, $2) AS end_time FROM events "+
+			"WHERE "+strings.Join(conditions, " AND ")+
+			"), marked AS ("+
+			"SELECT start_time, end_time, start_time > max(end_time) OVER "+
+			"(ORDER BY start_time, end_time ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING) IS NOT FALSE AS starts_island "+
+			"FROM busy"+
+			"), islands AS ("+
+			"SELECT start_time, end_time, count(*) FILTER (WHERE starts_island) OVER (ORDER BY start_time, end_time) AS island "+
+			"FROM marked"+
+			") SELECT min(start_time), max(end_time) FROM islands GROUP BY island ORDER BY island",
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge busy intervals: %w", err)
+	}
+	defer rows.Close()
+
+	busy := make([]BusyInterval, 0)
+
+	for rows.Next() {
+		var b BusyInterval
+
+		err = rows.Scan(&b.Start, &b.End)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan busy interval: %w", err)
+		}
+
+		busy = append(busy, b)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge busy intervals: %w", err)
+	}
+
+	return busy, nil
+}
+
+// freeBusyConditions returns the conditions of the filters of the query, appending their parameters to args.
+func freeBusyConditions(query FreeBusyQuery, args *[]any) []string {
+	arg := func(value any) string {
+		*args = append(*args, value)
+		return "$" + strconv.Itoa(len(*args))
+	}
+
+	var conditions []string
+
+	if query.TitlePrefix != "" {
+		conditions = append(conditions, "lower(title) LIKE lower("+arg(escapeLike(query.TitlePrefix)+"%")+")")
+	}
+
+	if query.CalendarId != "" {
+		conditions = append(conditions, "calendar_id = "+arg(query.CalendarId))
+	}
+
+	if query.Scope != "" {
+		conditions = append(conditions, "scope = "+arg(query.Scope))
+	}
+
+	return conditions
+}
+
+// ListOverrides returns the overrides of the given events, ordered by event and recurrence id.
+func (r *Repository) ListOverrides(ctx context.Context, eventIds []string) ([]Override, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "list_overrides", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.ListOverrides")
+	defer span.End()
+
+	overrides, err := r.queryOverrides(ctx, eventIds)
+
+	return overrides, err
+}
+
+func (r *Repository) queryOverrides(ctx context.Context, eventIds []string) ([]Override, error) {
+	rows, err := r.pool.Query(ctx,
+		"SELECT "+overrideColumns+" FROM event_overrides WHERE event_id = ANY($1) ORDER BY event_id, recurrence_id", eventIds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list overrides: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := make([]Override, 0)
+
+	for rows.Next() {
+		var o Override
+
+		err = rows.Scan(overrideFields(&o)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan override: %w", err)
+		}
+
+		overrides = append(overrides, o)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list overrides: %w", err)
+	}
+
+	return overrides, nil
+}
+
+// SaveOverride creates or replaces the override of a single occurrence of a recurring event.
+func (r *Repository) SaveOverride(ctx context.Context, override *Override) (*Override, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "save_override", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.SaveOverride")
+	defer span.End()
+
+	var o Override
+
+	err = r.pool.QueryRow(ctx,
+		"WITH touched AS (UPDATE events SET updated_at = now() WHERE id = $1 AND deleted_at IS NULL RETURNING id) "+
+			"INSERT INTO event_overrides ("+overrideColumns+") "+
+			"SELECT id, $2, $3, $4, $5, $6, $7 FROM touched "+
+			"ON CONFLICT (event_id, recurrence_id) DO UPDATE SET "+
+			"title = excluded.title, description = excluded.description, "+
+			"start_time = excluded.start_time, end_time = excluded.end_time, cancelled = excluded.cancelled "+
+			"RETURNING "+overrideColumns,
+		override.EventId, override.RecurrenceId, override.Title, override.Description,
+		override.StartTime, override.EndTime, override.Cancelled).
+		Scan(overrideFields(&o)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to save override: %w", ErrEventNotFound)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to save override: %w", err)
+	}
+
+	return &o, nil
+}
+
+// ImportEvent saves an event imported from an iCalendar document along with its overrides. It fails with
+// ErrEventExists when an event with the same UID was imported before.
+func (r *Repository) ImportEvent(ctx context.Context, series *Series) (*Event, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "import_event", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.ImportEvent")
+	defer span.End()
+
+	event := series.Event
+
+	seriesEnd, err := SeriesEnd(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute series end: %w", err)
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	var savedEvent Event
+
+	err = tx.QueryRow(ctx,
+		"INSERT INTO events (title, description, start_time, end_time, time_zone, rrule, exdates, rdates, series_end, all_day, ical_uid) "+
+			"VALUES ($1, $2, $3, $4, COALESCE(NULLIF($5, ''), 'UTC'), $6, $7, $8, $9, $10, $11) "+
+			"ON CONFLICT (ical_uid) WHERE ical_uid <> '' DO NOTHING "+
+			"RETURNING "+eventColumns,
+		event.Title, event.Description, event.StartTime, event.EndTime,
+		event.TimeZone, event.RRule, event.ExDates, event.RDates, seriesEnd, event.AllDay, event.Uid).
+		Scan(eventFields(&savedEvent)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = ErrEventExists
+	}
+
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, fmt.Errorf("failed to import event: %w", err)
+	}
+
+	for _, o := range series.Overrides {
+		_, err = tx.Exec(ctx,
+			"INSERT INTO event_overrides ("+overrideColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7)",
+			savedEvent.Id, o.RecurrenceId, o.Title, o.Description, o.StartTime, o.EndTime, o.Cancelled)
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return nil, fmt.Errorf("failed to import override: %w", err)
+		}
+	}
+
+	err = writeOutbox(ctx, tx, EventCreated, []Event{savedEvent})
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, fmt.Errorf("failed to write outbox: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &savedEvent, nil
+}
+
+// FindConflicts returns the live events of the event's scope that overlap with it, other than the event itself.
+func (r *Repository) FindConflicts(ctx context.Context, event Event) ([]Event, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "find_conflicts", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.FindConflicts")
+	defer span.End()
+
+	conflicts, err := r.queryConflicts(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+
+	details := make([]*Event, 0, len(conflicts))
+	for i := range conflicts {
+		details = append(details, &conflicts[i])
+	}
+
+	err = r.loadEventDetails(ctx, details)
+	if err != nil {
+		return nil, err
+	}
+
+	return conflicts, nil
+}
+
+func (r *Repository) queryConflicts(ctx context.Context, event Event) ([]Event, error) {
+	conflicts := make([]Event, 0)
+	if event.Scope == "" {
+		return conflicts, nil
+	}
+
+	// Same predicate as the events_scope_overlap_excl constraint
+	rows, err := r.pool.Query(ctx,
+		"SELECT "+eventListColumns+" FROM events "+
+			"WHERE scope = $1 AND deleted_at IS NULL AND tstzrange(start_time, end_time) && tstzrange($2, $3) "+
+			"AND id IS DISTINCT FROM NULLIF($4, '')::uuid "+
+			"ORDER BY start_time, id",
+		event.Scope, event.StartTime, event.EndTime, event.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find conflicts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e Event
+
+		err = rows.Scan(eventListFields(&e)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+
+		conflicts = append(conflicts, e)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find conflicts: %w", err)
+	}
+
+	return conflicts, nil
+}
+
+// writeError turns the constraint violations of an event write into domain errors: an unknown or malformed calendar
+// id into ErrCalendarNotFound and an overlap into a ConflictError naming the events in the way. Other errors are
+// returned unchanged.
+func (r *Repository) writeError(ctx context.Context, err error, event Event) error {
+	switch pgErrorCode(err) {
+	case foreignKeyViolation, invalidTextRepresentation:
+		return fmt.Errorf("%w: %w", ErrCalendarNotFound, err)
+	case exclusionViolation:
+	default:
+		return err
+	}
+
+	conflicts, queryErr := r.queryConflicts(ctx, event)
+	if queryErr != nil {
+		return fmt.Errorf("%w: %w", ErrEventConflict, err)
+	}
+
+	ids := make([]string, 0, len(conflicts))
+	for _, e := range conflicts {
+		ids = append(ids, e.Id)
+	}
+
+	return &ConflictError{EventIds: ids}
+}
+
+// batchWriteError is writeError for multi-row inserts, which cannot tell the offending row.
+func batchWriteError(err error) error {
+	switch pgErrorCode(err) {
+	case foreignKeyViolation, invalidTextRepresentation:
+		return fmt.Errorf("%w: %w", ErrCalendarNotFound, err)
+	case exclusionViolation:
+		return fmt.Errorf("%w: %w", ErrEventConflict, err)
+	default:
+		return err
+	}
+}
+
+const (
+	// earthRadiusKm is the mean radius of the Earth.
+	earthRadiusKm = 6371.0088
+	kmPerDegree   = earthRadiusKm * math.Pi / 180
+)
+
+// SQLSTATEs of the constraint violations and malformed ids turned into domain errors.
+const (
+	invalidTextRepresentation = "22P02"
+	foreignKeyViolation       = "23503"
+	uniqueViolation           = "23505"
+	exclusionViolation        = "23P01"
+)
+
+func pgErrorCode(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+
+	return ""
+}
+
+// calendarColumns lists the calendars columns read by calendarFields, in order.
+const calendarColumns = "id, name, description, time_zone, color, created_at, updated_at"
+
+func calendarFields(c *Calendar) []any {
+	return []any{&c.Id, &c.Name, &c.Description, &c.TimeZone, &c.Color, &c.CreatedAt, &c.UpdatedAt}
+}
+
+func (r *Repository) SaveCalendar(ctx context.Context, calendar *Calendar) (*Calendar, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "save_calendar", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.SaveCalendar")
+	defer span.End()
+
+	var c Calendar
+
+	err = r.pool.QueryRow(ctx,
+		"INSERT INTO calendars (name, description, time_zone, color) "+
+			"VALUES ($1, $2, COALESCE(NULLIF($3, ''), 'UTC'), $4) "+
+			"RETURNING "+calendarColumns,
+		calendar.Name, calendar.Description, calendar.TimeZone, calendar.Color).
+		Scan(calendarFields(&c)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save calendar: %w", err)
+	}
+
+	return &c, nil
+}
+
+func (r *Repository) GetCalendar(ctx context.Context, id string) (*Calendar, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "get_calendar", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.GetCalendar")
+	defer span.End()
+
+	var c Calendar
+
+	err = r.pool.QueryRow(ctx, "SELECT "+calendarColumns+" FROM calendars WHERE id = $1", id).Scan(calendarFields(&c)...)
+	if errors.Is(err, pgx.ErrNoRows) || pgErrorCode(err) == invalidTextRepresentation {
+		return nil, fmt.Errorf("failed to get calendar: %w", ErrCalendarNotFound)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar: %w", err)
+	}
+
+	return &c, nil
+}
+
+// GetCalendars returns the calendars of the given ids, ordered by id. Unknown ids are left out.
+func (r *Repository) GetCalendars(ctx context.Context, ids []string) ([]Calendar, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "get_calendars", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.GetCalendars")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx, "SELECT "+calendarColumns+" FROM calendars WHERE id = ANY($1) ORDER BY id", ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendars: %w", err)
+	}
+	defer rows.Close()
+
+	calendars := make([]Calendar, 0)
+
+	for rows.Next() {
+		var c Calendar
+
+		err = rows.Scan(calendarFields(&c)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan calendar: %w", err)
+		}
+
+		calendars = append(calendars, c)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendars: %w", err)
+	}
+
+	return calendars, nil
+}
+
+// ListCalendars returns every calendar, ordered by name.
+func (r *Repository) ListCalendars(ctx context.Context) ([]Calendar, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "list_calendars", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.ListCalendars")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx, "SELECT "+calendarColumns+" FROM calendars ORDER BY name, id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calendars: %w", err)
+	}
+	defer rows.Close()
+
+	calendars := make([]Calendar, 0)
+
+	for rows.Next() {
+		var c Calendar
+
+		err = rows.Scan(calendarFields(&c)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan calendar: %w", err)
+		}
+
+		calendars = append(calendars, c)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calendars: %w", err)
+	}
+
+	return calendars, nil
+}
+
+// UpdateCalendar replaces the calendar identified by calendar.Id. Its events keep their own time zone.
+func (r *Repository) UpdateCalendar(ctx context.Context, calendar *Calendar) (*Calendar, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "update_calendar", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.UpdateCalendar")
+	defer span.End()
+
+	var c Calendar
+
+	err = r.pool.QueryRow(ctx,
+		"UPDATE calendars SET name = $1, description = $2, time_zone = COALESCE(NULLIF($3, ''), 'UTC'), color = $4, "+
+			"updated_at = now() "+
+			"WHERE id = $5 "+
+			"RETURNING "+calendarColumns,
+		calendar.Name, calendar.Description, calendar.TimeZone, calendar.Color, calendar.Id).
+		Scan(calendarFields(&c)...)
+	if errors.Is(err, pgx.ErrNoRows) || pgErrorCode(err) == invalidTextRepresentation {
+		return nil, fmt.Errorf("failed to update calendar: %w", ErrCalendarNotFound)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to update calendar: %w", err)
+	}
+
+	return &c, nil
+}
+
+// DeleteCalendar permanently deletes the calendar along with all its events, trashed ones included. Unless cascade
+// is set, calendars that still have live events are kept and ErrCalendarNotEmpty is returned; otherwise the deletion
+// of the live events is written to the outbox.
+func (r *Repository) DeleteCalendar(ctx context.Context, id string, cascade bool) error {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "delete_calendar", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.DeleteCalendar")
+	defer span.End()
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	// Locks the calendar, events cannot be added to it until the transaction ends
+	var empty bool
+
+	err = tx.QueryRow(ctx,
+		"SELECT NOT EXISTS (SELECT 1 FROM events WHERE calendar_id = c.id AND deleted_at IS NULL) "+
+			"FROM calendars c WHERE c.id = $1 FOR UPDATE",
+		id).Scan(&empty)
+	if errors.Is(err, pgx.ErrNoRows) || pgErrorCode(err) == invalidTextRepresentation {
+		err = ErrCalendarNotFound
+	}
+
+	if err == nil && !empty && !cascade {
+		err = ErrCalendarNotEmpty
+	}
+
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("failed to lock calendar: %w", err)
+	}
+
+	// The live events going along with the calendar are published as deleted, those in the trash already were
+	if !empty {
+		err = writeCalendarDeletions(ctx, tx, id)
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return fmt.Errorf("failed to write outbox: %w", err)
+		}
+	}
+
+	// Events and their overrides go along with the calendar (ON DELETE CASCADE)
+	_, err = tx.Exec(ctx, "DELETE FROM calendars WHERE id = $1", id)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("failed to delete calendar: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// writeCalendarDeletions records in the outbox the deletion of the live events of the calendar.
+func writeCalendarDeletions(ctx context.Context, tx pgx.Tx, calendarId string) error {
+	rows, err := tx.Query(ctx, "SELECT id FROM events WHERE calendar_id = $1 AND deleted_at IS NULL ORDER BY id", calendarId)
+	if err != nil {
+		return err
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return err
+	}
+
+	events := make([]Event, 0, len(ids))
+	for _, id := range ids {
+		events = append(events, Event{Id: id})
+	}
+
+	return writeOutbox(ctx, tx, EventDeleted, events)
+}
+
+// SaveAttendee invites attendee.Email to the event, with a needs-action RSVP. It fails with ErrAttendeeExists when the
+// email, whatever its case, was already invited.
+func (r *Repository) SaveAttendee(ctx context.Context, attendee *Attendee) (*Attendee, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "save_attendee", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.SaveAttendee")
+	defer span.End()
+
+	var a Attendee
+
+	err = r.pool.QueryRow(ctx,
+		"INSERT INTO attendees (event_id, email, name) "+
+			"SELECT id, $2, $3 FROM events WHERE id = $1 AND deleted_at IS NULL "+
+			"RETURNING "+attendeeColumns,
+		attendee.EventId, attendee.Email, attendee.Name).
+		Scan(attendeeFields(&a)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to save attendee: %w", ErrEventNotFound)
+	}
+
+	if pgErrorCode(err) == uniqueViolation {
+		return nil, fmt.Errorf("failed to save attendee: %w: %w", ErrAttendeeExists, err)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to save attendee: %w", err)
+	}
+
+	return &a, nil
+}
+
+// ListAttendees returns the attendees of the event in the order they were invited.
+func (r *Repository) ListAttendees(ctx context.Context, eventId string) ([]Attendee, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "list_attendees", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.ListAttendees")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx,
+		"SELECT "+attendeeColumns+" FROM attendees WHERE event_id = $1 ORDER BY created_at, id", eventId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attendees: %w", err)
+	}
+	defer rows.Close()
+
+	attendees := make([]Attendee, 0)
+
+	for rows.Next() {
+		var a Attendee
+
+		err = rows.Scan(attendeeFields(&a)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attendee: %w", err)
+		}
+
+		attendees = append(attendees, a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attendees: %w", err)
+	}
+
+	return attendees, nil
+}
+
+// ListAttendeesByEvents returns the attendees of the given events, ordered by event then in the order they were invited.
+func (r *Repository) ListAttendeesByEvents(ctx context.Context, eventIds []string) ([]Attendee, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "list_attendees_by_events", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.ListAttendeesByEvents")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx,
+		"SELECT "+attendeeColumns+" FROM attendees WHERE event_id = ANY($1) ORDER BY event_id, created_at, id", eventIds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attendees: %w", err)
+	}
+	defer rows.Close()
+
+	attendees := make([]Attendee, 0)
+
+	for rows.Next() {
+		var a Attendee
+
+		err = rows.Scan(attendeeFields(&a)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attendee: %w", err)
+		}
+
+		attendees = append(attendees, a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attendees: %w", err)
+	}
+
+	return attendees, nil
+}
+
+// UpdateAttendeeStatus records the RSVP of the attendee. The status is written in a single statement rather than read,
+// changed and saved back, so concurrent answers are applied one after the other and the last one wins, with its
+// responded_at.
+func (r *Repository) UpdateAttendeeStatus(ctx context.Context, attendee *Attendee) (*Attendee, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "update_attendee_status", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.UpdateAttendeeStatus")
+	defer span.End()
+
+	var a Attendee
+
+	err = r.pool.QueryRow(ctx,
+		"UPDATE attendees SET status = $1, responded_at = now(), updated_at = now() "+
+			"WHERE id = $2 AND event_id = $3 AND EXISTS (SELECT 1 FROM events WHERE id = $3 AND deleted_at IS NULL) "+
+			"RETURNING "+attendeeColumns,
+		attendee.Status, attendee.Id, attendee.EventId).
+		Scan(attendeeFields(&a)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to update attendee status: %w", ErrAttendeeNotFound)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to update attendee status: %w", err)
+	}
+
+	return &a, nil
+}
+
+func (r *Repository) DeleteAttendee(ctx context.Context, eventId string, id string) error {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "delete_attendee", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.DeleteAttendee")
+	defer span.End()
+
+	tag, err := r.pool.Exec(ctx, "DELETE FROM attendees "+
+		"WHERE id = $1 AND event_id = $2 AND EXISTS (SELECT 1 FROM events WHERE id = $2 AND deleted_at IS NULL)",
+		id, eventId)
+	if err != nil {
+		return fmt.Errorf("failed to delete attendee: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("failed to delete attendee: %w", ErrAttendeeNotFound)
+	}
+
+	return nil
+}
+
+// SaveReminder sets a reminder on the event. It is due right away, so the next dispatch schedules it.
+func (r *Repository) SaveReminder(ctx context.Context, reminder *Reminder) (*Reminder, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "save_reminder", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.SaveReminder")
+	defer span.End()
+
+	var rem Reminder
+
+	err = r.pool.QueryRow(ctx,
+		"INSERT INTO reminders (event_id, offset_minutes, channel) "+
+			"SELECT id, $2, $3 FROM events WHERE id = $1 AND deleted_at IS NULL "+
+			"RETURNING "+reminderColumns,
+		reminder.EventId, reminder.OffsetMinutes, reminder.Channel).
+		Scan(reminderFields(&rem)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to save reminder: %w", ErrEventNotFound)
+	}
+
+	if pgErrorCode(err) == uniqueViolation {
+		return nil, fmt.Errorf("failed to save reminder: %w: %w", ErrReminderExists, err)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to save reminder: %w", err)
+	}
+
+	return &rem, nil
+}
+
+// ListReminders returns the reminders of the event, the earliest sent first.
+func (r *Repository) ListReminders(ctx context.Context, eventId string) ([]Reminder, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "list_reminders", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.ListReminders")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx,
+		"SELECT "+reminderColumns+" FROM reminders WHERE event_id = $1 ORDER BY offset_minutes DESC, channel", eventId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reminders: %w", err)
+	}
+
+	reminders, err := scanReminders(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reminders: %w", err)
+	}
+
+	return reminders, nil
+}
+
+func (r *Repository) DeleteReminder(ctx context.Context, eventId string, id string) error {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "delete_reminder", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.DeleteReminder")
+	defer span.End()
+
+	tag, err := r.pool.Exec(ctx, "DELETE FROM reminders "+
+		"WHERE id = $1 AND event_id = $2 AND EXISTS (SELECT 1 FROM events WHERE id = $2 AND deleted_at IS NULL)",
+		id, eventId)
+	if err != nil {
+		return fmt.Errorf("failed to delete reminder: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("failed to delete reminder: %w", ErrReminderNotFound)
+	}
+
+	return nil
+}
+
+// ClaimReminders leases up to limit reminders due at now until the given time, the earliest due first. Reminders
+// claimed by another dispatcher are skipped rather than waited for, and claimed again once their lease runs out.
+func (r *Repository) ClaimReminders(ctx context.Context, now time.Time, until time.Time, limit int) ([]Reminder, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "claim_reminders", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.ClaimReminders")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx,
+		"UPDATE reminders SET claimed_until = $2 "+
+			"WHERE id IN (SELECT id FROM reminders WHERE due_at <= $1 AND (claimed_until IS NULL OR claimed_until <= $1) "+
+			"ORDER BY due_at LIMIT $3 FOR UPDATE SKIP LOCKED) "+
+			"RETURNING "+reminderColumns,
+		now, until, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim reminders: %w", err)
+	}
+
+	reminders, err := scanReminders(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim reminders: %w", err)
+	}
+
+	return reminders, nil
+}
+
+// RescheduleReminder records the last occurrence reminded of and when the reminder is next due, and releases its
+// claim. The due time is kept if the schedule of the event changed since the reminder was claimed (its revision was
+// bumped), since it was computed from the former one.
+func (r *Repository) RescheduleReminder(ctx context.Context, reminder *Reminder) error {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "reschedule_reminder", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.RescheduleReminder")
+	defer span.End()
+
+	tag, err := r.pool.Exec(ctx,
+		"UPDATE reminders SET reminded_through = $2, due_at = CASE WHEN revision = $4 THEN $3 ELSE due_at END, "+
+			"claimed_until = NULL, updated_at = now() WHERE id = $1",
+		reminder.Id, reminder.RemindedThrough, reminder.DueAt, reminder.Revision)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule reminder: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("failed to reschedule reminder: %w", ErrReminderNotFound)
+	}
+
+	return nil
+}
+
+func scanReminders(rows pgx.Rows) ([]Reminder, error) {
+	defer rows.Close()
+
+	reminders := make([]Reminder, 0)
+
+	for rows.Next() {
+		var rem Reminder
+
+		err := rows.Scan(reminderFields(&rem)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reminder: %w", err)
+		}
+
+		reminders = append(reminders, rem)
+	}
+
+	return reminders, rows.Err()
+}
+
+func (r *Repository) SaveWebhook(ctx context.Context, webhook *Webhook) (*Webhook, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "save_webhook", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.SaveWebhook")
+	defer span.End()
+
+	var w Webhook
+
+	err = r.pool.QueryRow(ctx,
+		"INSERT INTO webhooks (url, event_types, secret) VALUES ($1, $2, $3) RETURNING "+webhookColumns,
+		webhook.Url, eventTypes(webhook.EventTypes), webhook.Secret).
+		Scan(webhookFields(&w)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save webhook: %w", err)
+	}
+
+	return &w, nil
+}
+
+func (r *Repository) GetWebhook(ctx context.Context, id string) (*Webhook, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "get_webhook", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.GetWebhook")
+	defer span.End()
+
+	var w Webhook
+
+	err = r.pool.QueryRow(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE id = $1", id).Scan(webhookFields(&w)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get webhook: %w", ErrWebhookNotFound)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	return &w, nil
+}
+
+// ListWebhooks returns every webhook, the oldest first.
+func (r *Repository) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "list_webhooks", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.ListWebhooks")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx, "SELECT "+webhookColumns+" FROM webhooks ORDER BY created_at, id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]Webhook, 0)
+
+	for rows.Next() {
+		var w Webhook
+
+		err = rows.Scan(webhookFields(&w)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+
+		webhooks = append(webhooks, w)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// UpdateWebhook replaces the webhook identified by webhook.Id, keeping its secret unless a new one is set. Pending
+// deliveries go to the new URL, signed with the new secret.
+func (r *Repository) UpdateWebhook(ctx context.Context, webhook *Webhook) (*Webhook, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "update_webhook", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.UpdateWebhook")
+	defer span.End()
+
+	var w Webhook
+
+	err = r.pool.QueryRow(ctx,
+		"UPDATE webhooks SET url = $1, event_types = $2, secret = COALESCE(NULLIF($3, ''), secret), updated_at = now() "+
+			"WHERE id = $4 "+
+			"RETURNING "+webhookColumns,
+		webhook.Url, eventTypes(webhook.EventTypes), webhook.Secret, webhook.Id).
+		Scan(webhookFields(&w)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to update webhook: %w", ErrWebhookNotFound)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	return &w, nil
+}
+
+// DeleteWebhook deletes the webhook along with its deliveries, pending ones included.
+func (r *Repository) DeleteWebhook(ctx context.Context, id string) error {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "delete_webhook", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.DeleteWebhook")
+	defer span.End()
+
+	tag, err := r.pool.Exec(ctx, "DELETE FROM webhooks WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("failed to delete webhook: %w", ErrWebhookNotFound)
+	}
+
+	return nil
+}
+
+// eventTypes keeps webhooks without event types from storing NULL, they subscribe to every change.
+func eventTypes(types []string) []string {
+	if types == nil {
+		return []string{}
+	}
+
+	return types
+}
+
+// EnqueueDeliveries adds a pending delivery of the outbox message for every webhook subscribed to its type, but those
+// it already has, and returns how many were added. The trace context of ctx is stored with them, so that sending them
+// continues its trace.
+func (r *Repository) EnqueueDeliveries(ctx context.Context, message OutboxMessage) (int64, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "enqueue_deliveries", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.EnqueueDeliveries")
+	defer span.End()
+
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	tag, err := r.pool.Exec(ctx,
+		"INSERT INTO webhook_deliveries (webhook_id, outbox_id, event_type, event_id, payload, trace_context) "+
+			"SELECT id, $1, $2, $3, $4, $5 FROM webhooks WHERE cardinality(event_types) = 0 OR $2 = ANY(event_types) "+
+			"ON CONFLICT (webhook_id, outbox_id) DO NOTHING",
+		message.Id, message.Type, message.AggregateId, message.Payload, map[string]string(carrier))
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue deliveries: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// ListDeliveries returns the latest MaxDeliveries deliveries of the webhook, the latest first, of the given status
+// only unless it is empty.
+func (r *Repository) ListDeliveries(ctx context.Context, webhookId string, status string) ([]Delivery, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "list_deliveries", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.ListDeliveries")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx,
+		"SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE webhook_id = $1 AND ($2 = '' OR status = $2) "+
+			"ORDER BY created_at DESC, id LIMIT $3",
+		webhookId, status, MaxDeliveries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deliveries: %w", err)
+	}
+
+	deliveries, err := scanDeliveries(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// RedeliverDelivery makes the delivery pending again, due right away with a fresh count of attempts, whatever its
+// status.
+func (r *Repository) RedeliverDelivery(ctx context.Context, webhookId string, id string) (*Delivery, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "redeliver_delivery", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.RedeliverDelivery")
+	defer span.End()
+
+	var d Delivery
+
+	err = r.pool.QueryRow(ctx,
+		"UPDATE webhook_deliveries SET status = 'pending', attempts = 0, next_attempt_at = now(), updated_at = now() "+
+			"WHERE id = $1 AND webhook_id = $2 "+
+			"RETURNING "+deliveryColumns,
+		id, webhookId).
+		Scan(deliveryFields(&d)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to redeliver delivery: %w", ErrDeliveryNotFound)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeliver delivery: %w", err)
+	}
+
+	return &d, nil
+}
+
+// ClaimDeliveries leases up to limit pending deliveries due at now until the given time, the earliest due first,
+// along with the URL and secret of their webhook. Deliveries claimed by another dispatcher are skipped rather than
+// waited for, and claimed again once their lease runs out.
+func (r *Repository) ClaimDeliveries(ctx context.Context, now time.Time, until time.Time, limit int) ([]PendingDelivery, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "claim_deliveries", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.ClaimDeliveries")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx,
+		"WITH claimed AS (UPDATE webhook_deliveries SET claimed_until = $2 "+
+			"WHERE id IN (SELECT id FROM webhook_deliveries WHERE status = 'pending' AND next_attempt_at <= $1 "+
+			"AND (claimed_until IS NULL OR claimed_until <= $1) ORDER BY next_attempt_at LIMIT $3 FOR UPDATE SKIP LOCKED) "+
+			"RETURNING "+deliveryColumns+") "+
+			"SELECT claimed.*, webhooks.url, webhooks.secret FROM claimed JOIN webhooks ON webhooks.id = claimed.webhook_id "+
+			"ORDER BY claimed.next_attempt_at",
+		now, until, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]PendingDelivery, 0)
+
+	for rows.Next() {
+		var d PendingDelivery
+
+		err = rows.Scan(append(deliveryFields(&d.Delivery), &d.Url, &d.Secret)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan delivery: %w", err)
+		}
+
+		deliveries = append(deliveries, d)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// RecordDelivery records the outcome of an attempt at the delivery (its status, attempts, next attempt and last
+// response) and releases its claim.
+func (r *Repository) RecordDelivery(ctx context.Context, delivery *Delivery) error {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "record_delivery", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.RecordDelivery")
+	defer span.End()
+
+	tag, err := r.pool.Exec(ctx,
+		"UPDATE webhook_deliveries SET status = $2, attempts = $3, next_attempt_at = $4, last_status_code = $5, "+
+			"last_error = $6, delivered_at = $7, claimed_until = NULL, updated_at = now() WHERE id = $1",
+		delivery.Id, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastStatusCode,
+		delivery.LastError, delivery.DeliveredAt)
+	if err != nil {
+		return fmt.Errorf("failed to record delivery: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("failed to record delivery: %w", ErrDeliveryNotFound)
+	}
+
+	return nil
+}
+
+func scanDeliveries(rows pgx.Rows) ([]Delivery, error) {
+	defer rows.Close()
+
+	deliveries := make([]Delivery, 0)
+
+	for rows.Next() {
+		var d Delivery
+
+		err := rows.Scan(deliveryFields(&d)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan delivery: %w", err)
+		}
+
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// Register admits the registrant unless the event is full, in which case they join the end of its waitlist. The
+// registrations of an event are serialized by locking its row, so however many sign-ups come at once the capacity is
+// never exceeded and the waitlist keeps their order.
+func (r *Repository) Register(ctx context.Context, registration *Registration) (*Registration, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "register", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.Register")
+	defer span.End()
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	capacity, err := lockRegistrations(ctx, tx, registration.EventId)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, fmt.Errorf("failed to lock event: %w", err)
+	}
+
+	confirmed, waitlisted, err := countRegistrations(ctx, tx, registration.EventId)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, fmt.Errorf("failed to count registrations: %w", err)
+	}
+
+	status, position := RegistrationConfirmed, 0
+	if capacity > 0 && confirmed >= capacity {
+		status, position = RegistrationWaitlisted, waitlisted+1
+	}
+
+	var reg Registration
+
+	err = tx.QueryRow(ctx,
+		"INSERT INTO registrations (event_id, email, name, status) VALUES ($1, $2, $3, $4) "+
+			"RETURNING "+registrationColumns,
+		registration.EventId, registration.Email, registration.Name, status).
+		Scan(registrationFields(&reg)...)
+	if pgErrorCode(err) == uniqueViolation {
+		err = fmt.Errorf("%w: %w", ErrRegistrationExists, err)
+	}
+
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, fmt.Errorf("failed to save registration: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	reg.Position = position
+
+	return &reg, nil
+}
+
+// ListRegistrations returns the confirmed registrations of the event followed by its waitlist, both in sign-up order.
+func (r *Repository) ListRegistrations(ctx context.Context, eventId string) ([]Registration, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "list_registrations", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.ListRegistrations")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx,
+		"SELECT "+registrationColumns+", "+
+			"CASE WHEN status = 'waitlisted' THEN row_number() OVER (PARTITION BY status ORDER BY seq) ELSE 0 END AS position "+
+			"FROM registrations WHERE event_id = $1 ORDER BY status, seq",
+		eventId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list registrations: %w", err)
+	}
+	defer rows.Close()
+
+	registrations := make([]Registration, 0)
+
+	for rows.Next() {
+		var reg Registration
+
+		err = rows.Scan(append(registrationFields(&reg), &reg.Position)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan registration: %w", err)
+		}
+
+		registrations = append(registrations, reg)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list registrations: %w", err)
+	}
+
+	return registrations, nil
+}
+
+// CancelRegistration deletes the registration and, when that frees a seat, confirms the first registration of the
+// waitlist, under the same lock as Register.
+func (r *Repository) CancelRegistration(ctx context.Context, eventId string, id string) error {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "cancel_registration", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.CancelRegistration")
+	defer span.End()
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	capacity, err := lockRegistrations(ctx, tx, eventId)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("failed to lock event: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, "DELETE FROM registrations WHERE id = $1 AND event_id = $2", id, eventId)
+	if err == nil && tag.RowsAffected() == 0 {
+		err = ErrRegistrationNotFound
+	}
+
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("failed to cancel registration: %w", err)
+	}
+
+	err = promoteRegistrations(ctx, tx, eventId, capacity)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("failed to promote registrations: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// lockRegistrations locks the event until the end of tx and returns its capacity. FOR NO KEY UPDATE still lets other
+// transactions reference the event, e.g. to invite attendees.
+func lockRegistrations(ctx context.Context, tx pgx.Tx, eventId string) (int, error) {
+	var capacity int
+
+	err := tx.QueryRow(ctx, "SELECT capacity FROM events WHERE id = $1 AND deleted_at IS NULL FOR NO KEY UPDATE", eventId).
+		Scan(&capacity)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrEventNotFound
+	}
+
+	return capacity, err
+}
+
+func countRegistrations(ctx context.Context, tx pgx.Tx, eventId string) (confirmed int, waitlisted int, err error) {
+	err = tx.QueryRow(ctx,
+		"SELECT count(*) FILTER (WHERE status = 'confirmed'), count(*) FILTER (WHERE status = 'waitlisted') "+
+			"FROM registrations WHERE event_id = $1",
+		eventId).
+		Scan(&confirmed, &waitlisted)
+
+	return confirmed, waitlisted, err
+}
+
+// promoteRegistrations confirms as many registrations of the waitlist, in order, as there are free seats. The event
+// must be locked by tx.
+func promoteRegistrations(ctx context.Context, tx pgx.Tx, eventId string, capacity int) error {
+	confirmed, waitlisted, err := countRegistrations(ctx, tx, eventId)
+	if err != nil {
+		return err
+	}
+
+	free := capacity - confirmed
+	if capacity == 0 {
+		free = waitlisted
+	}
+
+	if free <= 0 || waitlisted == 0 {
+		return nil
+	}
+
+	_, err = tx.Exec(ctx,
+		"UPDATE registrations SET status = 'confirmed', updated_at = now() WHERE id IN ("+
+			"SELECT id FROM registrations WHERE event_id = $1 AND status = 'waitlisted' ORDER BY seq LIMIT $2)",
+		eventId, free)
+
+	return err
+}
+
+func (r *Repository) SearchEvents(ctx context.Context, query SearchQuery) ([]SearchResult, error) {
+	start := time.Now()
+	var err error
+	defer func() { r.metrics.Observe(ctx, "search_events", start, err) }()
+
+	ctx, span := r.tracer.Start(ctx, "repository.SearchEvents")
+	defer span.End()
+
+	limit := query.Limit
+	if limit <= 0 || limit > MaxPageLimit {
+		limit = DefaultPageLimit
+	}
+
+	// Only the indexed language can use the generated search_vector column (and its GIN index)
+	document := "search_vector"
+	if query.Language != IndexedSearchLanguage {
+		document = "setweight(to_tsvector($1::regconfig, title), 'A') || setweight(to_tsvector($1::regconfig, description), 'B')"
+	}
+
+	// Matches are delimited with snippetStart and snippetStop, the text is escaped before they become <mark> tags
+	rows, err := r.pool.Query(ctx,
+		"SELECT "+eventListColumns+", "+
+			"ts_rank("+document+", q) AS rank, "+
+			"ts_headline($1::regconfig, translate(title || ' ' || description, chr(2) || chr(3), ''), q, "+
+			"'MaxFragments=2, StartSel=' || chr(2) || ', StopSel=' || chr(3)) AS snippet "+
+			"FROM events, websearch_to_tsquery($1::regconfig, $2) AS q "+
+			"WHERE "+document+" @@ q AND deleted_at IS NULL "+
+			"ORDER BY rank DESC, start_time ASC, id ASC "+
+			"LIMIT $3",
+		query.Language, query.Query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search events: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]SearchResult, 0, limit)
+	for rows.Next() {
+		var res SearchResult
+
+		err = rows.Scan(append(eventListFields(&res.Event), &res.Rank, &res.Snippet)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+
+		res.Snippet = HighlightSnippet(res.Snippet)
+		results = append(results, res)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to search events: %w", err)
+	}
+
+	details := make([]*Event, 0, len(results))
+	for i := range results {
+		details = append(details, &results[i].Event)
+	}
+
+	err = r.loadEventDetails(ctx, details)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
 /*
 
  */
@@ -2,15 +2,83 @@ package core
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
+	pgx "github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	pgxmock "github.com/pashagolub/pgxmock/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// eventRows builds the mocked result set of a query returning eventColumns, followed by the extra columns.
+func eventRows(extra ...string) *pgxmock.Rows {
+	return pgxmock.NewRows(append(strings.Split(eventColumns, ", "), extra...))
+}
+
+// eventRow returns the values of the eventColumns of e, followed by the extra values.
+func eventRow(e Event, extra ...any) []any {
+	return fieldValues(eventFields(&e), extra...)
+}
+
+// eventListRows builds the mocked result set of a query returning eventListColumns, followed by the extra columns.
+func eventListRows(extra ...string) *pgxmock.Rows {
+	return pgxmock.NewRows(append(strings.Split(eventListColumns, ", "), extra...))
+}
+
+// eventListRow returns the values of the eventListColumns of e, followed by the extra values.
+func eventListRow(e Event, extra ...any) []any {
+	return fieldValues(eventListFields(&e), extra...)
+}
+
+func fieldValues(fields []any, extra ...any) []any {
+	values := make([]any, 0, len(fields)+len(extra))
+	for _, field := range fields {
+		values = append(values, reflect.ValueOf(field).Elem().Interface())
+	}
+
+	return append(values, extra...)
+}
+
+// withDetails returns e as loaded along with the details of an event without tags nor attendees.
+func withDetails(e Event) Event {
+	e.Attendees = &AttendeeCounts{}
+
+	return e
+}
+
+// expectEventDetails expects the tags and attendee counts of the events with the given ids to be loaded, none of
+// them having any.
+func expectEventDetails(mock pgxmock.PgxPoolIface, ids ...string) {
+	mock.ExpectQuery("SELECT event_tags.event_id, tags.name FROM event_tags").
+		WithArgs(ids).
+		WillReturnRows(pgxmock.NewRows([]string{"event_id", "name"}))
+	mock.ExpectQuery("SELECT event_id, status, count\\(\\*\\) FROM attendees").
+		WithArgs(ids).
+		WillReturnRows(pgxmock.NewRows([]string{"event_id", "status", "count"}))
+}
+
+// expectOutbox expects the changes of the events with the given ids to be written to the outbox.
+func expectOutbox(mock pgxmock.PgxPoolIface, eventType string, ids ...string) {
+	mock.ExpectExec("INSERT INTO outbox \\(type, aggregate_id, payload, trace_context\\)").
+		WithArgs(eventType, ids, pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", int64(len(ids))))
+}
+
+// lockedEventRows returns the mocked row of the event locked by UpdateEvent, with no recurrence.
+func lockedEventRows(version int, capacity int, start time.Time) *pgxmock.Rows {
+	return pgxmock.NewRows([]string{"version", "capacity", "start_time", "time_zone", "rrule", "exdates", "rdates"}).
+		AddRow(version, capacity, start, "", "", []time.Time(nil), []time.Time(nil))
+}
+
 func TestRepository_SaveEvent(t *testing.T) {
 	t.Parallel()
 
@@ -35,11 +103,12 @@ func TestRepository_SaveEvent(t *testing.T) {
 			mockSetup: func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectBegin()
 
-				rows := pgxmock.NewRows([]string{"id", "title", "description", "start_time", "end_time", "created_at"}).
-					AddRow("uuid-1", "Test", "Desc", now, now.Add(time.Hour), now)
+				rows := eventRows().
+					AddRow(eventRow(Event{Id: "uuid-1", Title: "Test", Description: "Desc", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 1})...)
 				mock.ExpectQuery("INSERT INTO events").
-					WithArgs("Test", "Desc", now, now.Add(time.Hour)).
+					WithArgs("Test", "Desc", now, now.Add(time.Hour), "", "", []time.Time(nil), []time.Time(nil), pgxmock.AnyArg(), false, "", "", 0, "", "", (*float64)(nil), (*float64)(nil)).
 					WillReturnRows(rows)
+				expectOutbox(mock, EventCreated, "uuid-1")
 				mock.ExpectCommit()
 			},
 			wantErr: false,
@@ -50,6 +119,7 @@ func TestRepository_SaveEvent(t *testing.T) {
 				StartTime:   now,
 				EndTime:     now.Add(time.Hour),
 				CreatedAt:   now,
+				Version:     1,
 			},
 		},
 		{
@@ -66,11 +136,12 @@ func TestRepository_SaveEvent(t *testing.T) {
 			mockSetup: func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectBegin()
 
-				rows := pgxmock.NewRows([]string{"id", "title", "description", "start_time", "end_time", "created_at"}).
-					AddRow("uuid-1", "Test", "", time.Time{}, time.Time{}, time.Time{})
+				rows := eventRows().
+					AddRow(eventRow(Event{Id: "uuid-1", Title: "Test", Version: 1})...)
 				mock.ExpectQuery("INSERT INTO events").
-					WithArgs("Test", "", time.Time{}, time.Time{}).
+					WithArgs("Test", "", time.Time{}, time.Time{}, "", "", []time.Time(nil), []time.Time(nil), pgxmock.AnyArg(), false, "", "", 0, "", "", (*float64)(nil), (*float64)(nil)).
 					WillReturnRows(rows)
+				expectOutbox(mock, EventCreated, "uuid-1")
 				mock.ExpectCommit().WillReturnError(errors.New("commit error"))
 				mock.ExpectRollback()
 			},
@@ -114,19 +185,21 @@ func TestRepository_GetEventById(t *testing.T) {
 	now := time.Now().Truncate(time.Second)
 
 	tests := []struct {
-		name       string
-		id         string
-		mockSetup  func(mock pgxmock.PgxPoolIface)
-		wantErr    bool
+		name      string
+		id        string
+		mockSetup func(mock pgxmock.PgxPoolIface)
+		wantErr   bool
+		// wantErrIs is the error the one returned wraps, if any
+		wantErrIs  error
 		wantResult *Event
 	}{
 		{
 			name: "success",
 			id:   "uuid-1",
 			mockSetup: func(mock pgxmock.PgxPoolIface) {
-				rows := pgxmock.NewRows([]string{"id", "title", "description", "start_time", "end_time", "created_at"}).
-					AddRow("uuid-1", "Test", "Desc", now, now.Add(time.Hour), now)
-				mock.ExpectQuery("SELECT (.+) FROM events WHERE id = \\$1").
+				rows := eventRows().
+					AddRow(eventRow(Event{Id: "uuid-1", Title: "Test", Description: "Desc", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 1})...)
+				mock.ExpectQuery("SELECT (.+) FROM events WHERE id = \\$1 AND deleted_at IS NULL").
 					WithArgs("uuid-1").
 					WillReturnRows(rows)
 			},
@@ -138,14 +211,53 @@ func TestRepository_GetEventById(t *testing.T) {
 				StartTime:   now,
 				EndTime:     now.Add(time.Hour),
 				CreatedAt:   now,
+				Version:     1,
+			},
+		},
+		{
+			name: "with attendees",
+			id:   "uuid-1",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				counts := &AttendeeCounts{Total: 3, Accepted: 2, NeedsAction: 1}
+				rows := eventRows().
+					AddRow(eventRow(Event{Id: "uuid-1", Title: "Test", StartTime: now, EndTime: now, CreatedAt: now, Version: 1, Attendees: counts})...)
+				mock.ExpectQuery("SELECT (.+), attendee_counts\\(id\\) FROM events WHERE id = \\$1 AND deleted_at IS NULL").
+					WithArgs("uuid-1").
+					WillReturnRows(rows)
+			},
+			wantResult: &Event{
+				Id: "uuid-1", Title: "Test", StartTime: now, EndTime: now, CreatedAt: now, Version: 1,
+				Attendees: &AttendeeCounts{Total: 3, Accepted: 2, NeedsAction: 1},
 			},
 		},
 		{
 			name: "not found",
 			id:   "uuid-empty",
 			mockSetup: func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectQuery("SELECT (.+) FROM events WHERE id = \\$1").
+				mock.ExpectQuery("SELECT (.+) FROM events WHERE id = \\$1 AND deleted_at IS NULL").
 					WithArgs("uuid-empty").
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr:   true,
+			wantErrIs: ErrEventNotFound,
+		},
+		{
+			name: "malformed id",
+			id:   "not-a-uuid",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT (.+) FROM events WHERE id = \\$1 AND deleted_at IS NULL").
+					WithArgs("not-a-uuid").
+					WillReturnError(&pgconn.PgError{Code: "22P02"})
+			},
+			wantErr:   true,
+			wantErrIs: ErrEventNotFound,
+		},
+		{
+			name: "query failure",
+			id:   "uuid-1",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT (.+) FROM events WHERE id = \\$1 AND deleted_at IS NULL").
+					WithArgs("uuid-1").
 					WillReturnError(errors.New("no rows in result set"))
 			},
 			wantErr: true,
@@ -168,6 +280,256 @@ func TestRepository_GetEventById(t *testing.T) {
 
 			if tt.wantErr {
 				require.Error(t, err)
+
+				if tt.wantErrIs != nil {
+					require.ErrorIs(t, err, tt.wantErrIs)
+				}
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantResult, got)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_UpdateEvent(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	event := &Event{Id: "uuid-1", Title: "Updated", Description: "Desc", StartTime: now, EndTime: now.Add(time.Hour)}
+
+	tests := []struct {
+		name            string
+		expectedVersion int
+		capacity        int
+		tags            []string
+		rrule           string
+		mockSetup       func(mock pgxmock.PgxPoolIface)
+		wantErr         error
+		wantResult      *Event
+	}{
+		{
+			name:            "success",
+			expectedVersion: 2,
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT version, capacity, start_time, time_zone, rrule, exdates, rdates FROM events WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+					WithArgs("uuid-1").
+					WillReturnRows(lockedEventRows(2, 0, now))
+				mock.ExpectQuery("UPDATE events SET (.+), version = version \\+ 1, updated_at = now\\(\\) WHERE id = \\$18").
+					WithArgs("Updated", "Desc", now, now.Add(time.Hour), "", "", []time.Time(nil), []time.Time(nil), pgxmock.AnyArg(), false, "", "", 0, "", "", (*float64)(nil), (*float64)(nil), "uuid-1").
+					WillReturnRows(eventRows().AddRow(eventRow(Event{
+						Id: "uuid-1", Title: "Updated", Description: "Desc", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 3,
+					})...))
+				expectOutbox(mock, EventUpdated, "uuid-1")
+				mock.ExpectCommit()
+			},
+			wantResult: &Event{
+				Id: "uuid-1", Title: "Updated", Description: "Desc", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 3,
+			},
+		},
+		{
+			name:            "raised capacity promotes the waitlist",
+			expectedVersion: 2,
+			capacity:        12,
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT version, capacity, start_time, time_zone, rrule, exdates, rdates FROM events WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+					WithArgs("uuid-1").
+					WillReturnRows(lockedEventRows(2, 10, now))
+				mock.ExpectQuery("UPDATE events SET").
+					WithArgs("Updated", "Desc", now, now.Add(time.Hour), "", "", []time.Time(nil), []time.Time(nil), pgxmock.AnyArg(), false, "", "", 12, "", "", (*float64)(nil), (*float64)(nil), "uuid-1").
+					WillReturnRows(eventRows().AddRow(eventRow(Event{
+						Id: "uuid-1", Title: "Updated", Description: "Desc", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 3, Capacity: 12,
+					})...))
+				mock.ExpectQuery("SELECT count\\(\\*\\) FILTER \\(WHERE status = 'confirmed'\\), count\\(\\*\\) FILTER \\(WHERE status = 'waitlisted'\\) FROM registrations").
+					WithArgs("uuid-1").
+					WillReturnRows(pgxmock.NewRows([]string{"confirmed", "waitlisted"}).AddRow(10, 5))
+				mock.ExpectExec("UPDATE registrations SET status = 'confirmed', updated_at = now\\(\\) WHERE id IN \\(SELECT id FROM registrations WHERE event_id = \\$1 AND status = 'waitlisted' ORDER BY seq LIMIT \\$2\\)").
+					WithArgs("uuid-1", 2).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+				expectOutbox(mock, EventUpdated, "uuid-1")
+				mock.ExpectCommit()
+			},
+			wantResult: &Event{
+				Id: "uuid-1", Title: "Updated", Description: "Desc", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 3, Capacity: 12,
+			},
+		},
+		{
+			name:            "changed tags are replaced",
+			expectedVersion: 2,
+			tags:            []string{"Release"},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT version, capacity, start_time, time_zone, rrule, exdates, rdates FROM events").
+					WithArgs("uuid-1").
+					WillReturnRows(lockedEventRows(2, 0, now))
+				mock.ExpectQuery("UPDATE events SET").
+					WithArgs("Updated", "Desc", now, now.Add(time.Hour), "", "", []time.Time(nil), []time.Time(nil), pgxmock.AnyArg(), false, "", "", 0, "", "", (*float64)(nil), (*float64)(nil), "uuid-1").
+					WillReturnRows(eventRows().AddRow(eventRow(Event{
+						Id: "uuid-1", Title: "Updated", Description: "Desc", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 3, Tags: []string{"offsite"},
+					})...))
+				mock.ExpectExec("DELETE FROM event_tags WHERE event_id = \\$1").
+					WithArgs("uuid-1").
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				mock.ExpectExec("INSERT INTO tags").
+					WithArgs([]string{"release"}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 0))
+				mock.ExpectExec("INSERT INTO event_tags").
+					WithArgs([]string{"uuid-1"}, []string{"release"}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				expectOutbox(mock, EventUpdated, "uuid-1")
+				mock.ExpectCommit()
+			},
+			wantResult: &Event{
+				Id: "uuid-1", Title: "Updated", Description: "Desc", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 3, Tags: []string{"release"},
+			},
+		},
+		{
+			name:            "changed recurrence drops overrides of former occurrences",
+			expectedVersion: 2,
+			rrule:           "FREQ=DAILY;COUNT=3",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT version, capacity, start_time, time_zone, rrule, exdates, rdates FROM events").
+					WithArgs("uuid-1").
+					WillReturnRows(lockedEventRows(2, 0, now))
+				mock.ExpectQuery("UPDATE events SET").
+					WithArgs("Updated", "Desc", now, now.Add(time.Hour), "", "FREQ=DAILY;COUNT=3", []time.Time(nil), []time.Time(nil), pgxmock.AnyArg(), false, "", "", 0, "", "", (*float64)(nil), (*float64)(nil), "uuid-1").
+					WillReturnRows(eventRows().AddRow(eventRow(Event{
+						Id: "uuid-1", Title: "Updated", Description: "Desc", StartTime: now, EndTime: now.Add(time.Hour), RRule: "FREQ=DAILY;COUNT=3", CreatedAt: now, Version: 3,
+					})...))
+				mock.ExpectQuery("SELECT recurrence_id FROM event_overrides WHERE event_id = \\$1").
+					WithArgs("uuid-1").
+					WillReturnRows(pgxmock.NewRows([]string{"recurrence_id"}).AddRow(now.Add(24 * time.Hour)).AddRow(now.Add(72 * time.Hour)))
+				mock.ExpectExec("DELETE FROM event_overrides WHERE event_id = \\$1 AND recurrence_id = ANY\\(\\$2\\)").
+					WithArgs("uuid-1", []time.Time{now.Add(72 * time.Hour)}).
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				expectOutbox(mock, EventUpdated, "uuid-1")
+				mock.ExpectCommit()
+			},
+			wantResult: &Event{
+				Id: "uuid-1", Title: "Updated", Description: "Desc", StartTime: now, EndTime: now.Add(time.Hour), RRule: "FREQ=DAILY;COUNT=3", CreatedAt: now, Version: 3,
+			},
+		},
+		{
+			name:            "any version",
+			expectedVersion: AnyVersion,
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT version, capacity, start_time, time_zone, rrule, exdates, rdates FROM events").
+					WithArgs("uuid-1").
+					WillReturnRows(lockedEventRows(7, 0, time.Time{}))
+				mock.ExpectQuery("UPDATE events").
+					WithArgs("Updated", "Desc", now, now.Add(time.Hour), "", "", []time.Time(nil), []time.Time(nil), pgxmock.AnyArg(), false, "", "", 0, "", "", (*float64)(nil), (*float64)(nil), "uuid-1").
+					WillReturnRows(eventRows().AddRow(eventRow(Event{Id: "uuid-1", Title: "Updated", Version: 8})...))
+				expectOutbox(mock, EventUpdated, "uuid-1")
+				mock.ExpectCommit()
+			},
+			wantResult: &Event{Id: "uuid-1", Title: "Updated", Version: 8},
+		},
+		{
+			name:            "begin failure",
+			expectedVersion: 1,
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin().WillReturnError(errors.New("begin error"))
+			},
+			wantErr: errors.New("begin error"),
+		},
+		{
+			name:            "not found",
+			expectedVersion: 1,
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT version, capacity, start_time, time_zone, rrule, exdates, rdates FROM events").
+					WithArgs("uuid-1").
+					WillReturnError(pgx.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: ErrEventNotFound,
+		},
+		{
+			name:            "malformed id",
+			expectedVersion: 1,
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT version, capacity, start_time, time_zone, rrule, exdates, rdates FROM events").
+					WithArgs("uuid-1").
+					WillReturnError(&pgconn.PgError{Code: "22P02"})
+				mock.ExpectRollback()
+			},
+			wantErr: ErrEventNotFound,
+		},
+		{
+			name:            "version mismatch",
+			expectedVersion: 1,
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT version, capacity, start_time, time_zone, rrule, exdates, rdates FROM events").
+					WithArgs("uuid-1").
+					WillReturnRows(lockedEventRows(2, 0, now))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrVersionMismatch,
+		},
+		{
+			name:            "update failure",
+			expectedVersion: 2,
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT version, capacity, start_time, time_zone, rrule, exdates, rdates FROM events").
+					WithArgs("uuid-1").
+					WillReturnRows(lockedEventRows(2, 0, now))
+				mock.ExpectQuery("UPDATE events").
+					WithArgs("Updated", "Desc", now, now.Add(time.Hour), "", "", []time.Time(nil), []time.Time(nil), pgxmock.AnyArg(), false, "", "", 0, "", "", (*float64)(nil), (*float64)(nil), "uuid-1").
+					WillReturnError(errors.New("update error"))
+				mock.ExpectRollback()
+			},
+			wantErr: errors.New("update error"),
+		},
+		{
+			name:            "commit failure",
+			expectedVersion: 2,
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT version, capacity, start_time, time_zone, rrule, exdates, rdates FROM events").
+					WithArgs("uuid-1").
+					WillReturnRows(lockedEventRows(2, 0, time.Time{}))
+				mock.ExpectQuery("UPDATE events").
+					WithArgs("Updated", "Desc", now, now.Add(time.Hour), "", "", []time.Time(nil), []time.Time(nil), pgxmock.AnyArg(), false, "", "", 0, "", "", (*float64)(nil), (*float64)(nil), "uuid-1").
+					WillReturnRows(eventRows().AddRow(eventRow(Event{Id: "uuid-1", Version: 3})...))
+				expectOutbox(mock, EventUpdated, "uuid-1")
+				mock.ExpectCommit().WillReturnError(errors.New("commit error"))
+				mock.ExpectRollback()
+			},
+			wantErr: errors.New("commit error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			input := *event
+			input.Capacity = tt.capacity
+			input.Tags = tt.tags
+			input.RRule = tt.rrule
+			got, err := repo.UpdateEvent(ctx, &input, tt.expectedVersion)
+
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr.Error())
 			} else {
 				require.NoError(t, err)
 				assert.Equal(t, tt.wantResult, got)
@@ -177,3 +539,3078 @@ func TestRepository_GetEventById(t *testing.T) {
 		})
 	}
 }
+
+func TestRepository_DeleteEvent(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock pgxmock.PgxPoolIface)
+		wantErr   error
+	}{
+		{
+			name: "success",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE events SET deleted_at = now\\(\\), version = version \\+ 1, updated_at = now\\(\\) WHERE id = \\$1 AND deleted_at IS NULL").
+					WithArgs("uuid-1").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				expectOutbox(mock, EventDeleted, "uuid-1")
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "not found",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE events SET deleted_at").
+					WithArgs("uuid-1").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrEventNotFound,
+		},
+		{
+			name: "malformed id",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE events SET deleted_at").
+					WithArgs("uuid-1").
+					WillReturnError(&pgconn.PgError{Code: "22P02"})
+				mock.ExpectRollback()
+			},
+			wantErr: ErrEventNotFound,
+		},
+		{
+			name: "exec failure",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE events SET deleted_at").
+					WithArgs("uuid-1").
+					WillReturnError(errors.New("exec error"))
+				mock.ExpectRollback()
+			},
+			wantErr: errors.New("exec error"),
+		},
+		{
+			name: "outbox failure",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE events SET deleted_at").
+					WithArgs("uuid-1").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectExec("INSERT INTO outbox").
+					WithArgs(EventDeleted, []string{"uuid-1"}, pgxmock.AnyArg(), pgxmock.AnyArg()).
+					WillReturnError(errors.New("insert error"))
+				mock.ExpectRollback()
+			},
+			wantErr: errors.New("insert error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			err = repo.DeleteEvent(ctx, "uuid-1")
+
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr.Error())
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_RestoreEvent(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	tests := []struct {
+		name       string
+		mockSetup  func(mock pgxmock.PgxPoolIface)
+		wantErr    error
+		wantResult *Event
+	}{
+		{
+			name: "success",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE events SET deleted_at = NULL, version = version \\+ 1, updated_at = now\\(\\) WHERE id = \\$1 AND deleted_at IS NOT NULL").
+					WithArgs("uuid-1").
+					WillReturnRows(eventRows().AddRow(eventRow(Event{Id: "uuid-1", Title: "A", CreatedAt: now, Version: 3})...))
+				expectOutbox(mock, EventUpdated, "uuid-1")
+				mock.ExpectCommit()
+			},
+			wantResult: &Event{Id: "uuid-1", Title: "A", CreatedAt: now, Version: 3},
+		},
+		{
+			name: "not in trash",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE events SET deleted_at = NULL").
+					WithArgs("uuid-1").
+					WillReturnError(pgx.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: ErrEventNotFound,
+		},
+		{
+			name: "malformed id",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE events SET deleted_at = NULL").
+					WithArgs("uuid-1").
+					WillReturnError(&pgconn.PgError{Code: "22P02"})
+				mock.ExpectRollback()
+			},
+			wantErr: ErrEventNotFound,
+		},
+		{
+			name: "query failure",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE events SET deleted_at = NULL").
+					WithArgs("uuid-1").
+					WillReturnError(errors.New("query error"))
+				mock.ExpectRollback()
+			},
+			wantErr: errors.New("query error"),
+		},
+		{
+			name: "conflicting event not readable",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE events SET deleted_at = NULL").
+					WithArgs("uuid-1").
+					WillReturnError(&pgconn.PgError{Code: "23P01", ConstraintName: "events_scope_overlap_excl"})
+				mock.ExpectRollback()
+				mock.ExpectQuery("SELECT (.+) FROM events WHERE id = \\$1").
+					WithArgs("uuid-1").
+					WillReturnError(errors.New("select error"))
+			},
+			wantErr: errors.New("failed to get event by id: select error"),
+		},
+		{
+			name: "commit failure",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE events SET deleted_at = NULL").
+					WithArgs("uuid-1").
+					WillReturnRows(eventRows().AddRow(eventRow(Event{Id: "uuid-1", Version: 3})...))
+				expectOutbox(mock, EventUpdated, "uuid-1")
+				mock.ExpectCommit().WillReturnError(errors.New("commit error"))
+				mock.ExpectRollback()
+			},
+			wantErr: errors.New("commit error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			got, err := repo.RestoreEvent(ctx, "uuid-1")
+
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr.Error())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantResult, got)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_PurgeEvents(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cutoff := time.Now().Add(-30 * 24 * time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock pgxmock.PgxPoolIface)
+		wantErr   bool
+		want      int64
+	}{
+		{
+			name: "success",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec("DELETE FROM events WHERE deleted_at < \\$1").
+					WithArgs(cutoff).
+					WillReturnResult(pgxmock.NewResult("DELETE", 4))
+			},
+			want: 4,
+		},
+		{
+			name: "exec failure",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec("DELETE FROM events").
+					WithArgs(cutoff).
+					WillReturnError(errors.New("exec error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			got, err := repo.PurgeEvents(ctx, cutoff)
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_TryLock(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		mockSetup    func(mock pgxmock.PgxPoolIface)
+		wantErr      bool
+		wantAcquired bool
+	}{
+		{
+			name: "acquired until unlocked",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT pg_try_advisory_xact_lock\\(\\$1, hashtext\\(\\$2\\)\\)").
+					WithArgs(jobLockSpace, "trash-purge").
+					WillReturnRows(pgxmock.NewRows([]string{"pg_try_advisory_xact_lock"}).AddRow(true))
+				mock.ExpectRollback()
+			},
+			wantAcquired: true,
+		},
+		{
+			name: "held by another session",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT pg_try_advisory_xact_lock").
+					WithArgs(jobLockSpace, "trash-purge").
+					WillReturnRows(pgxmock.NewRows([]string{"pg_try_advisory_xact_lock"}).AddRow(false))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name: "query failure",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT pg_try_advisory_xact_lock").
+					WithArgs(jobLockSpace, "trash-purge").
+					WillReturnError(errors.New("query error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "begin failure",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin().WillReturnError(errors.New("begin error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			unlock, acquired, err := repo.TryLock(ctx, "trash-purge")
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantAcquired, acquired)
+
+			if acquired {
+				unlock()
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_ListEvents(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	tests := []struct {
+		name      string
+		filter    EventFilter
+		mockSetup func(mock pgxmock.PgxPoolIface)
+		wantErr   bool
+		wantIds   []string
+		wantNext  *Cursor
+		wantPrev  *Cursor
+	}{
+		{
+			name:   "first page with more results",
+			filter: EventFilter{Limit: 2},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				rows := eventListRows().
+					AddRow(eventListRow(Event{Id: "uuid-1", Title: "A", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 1})...).
+					AddRow(eventListRow(Event{Id: "uuid-2", Title: "B", StartTime: now.Add(time.Hour), EndTime: now.Add(2 * time.Hour), CreatedAt: now, Version: 1})...).
+					AddRow(eventListRow(Event{Id: "uuid-3", Title: "C", StartTime: now.Add(2 * time.Hour), EndTime: now.Add(3 * time.Hour), CreatedAt: now, Version: 1})...)
+				mock.ExpectQuery("SELECT (.+) FROM events WHERE deleted_at IS NULL ORDER BY start_time ASC, id ASC LIMIT \\$1").
+					WithArgs(3).
+					WillReturnRows(rows)
+				expectEventDetails(mock, "uuid-1", "uuid-2")
+			},
+			wantIds:  []string{"uuid-1", "uuid-2"},
+			wantNext: &Cursor{StartTime: now.Add(time.Hour), Id: "uuid-2"},
+		},
+		{
+			name:   "limit above the page cap",
+			filter: EventFilter{Limit: MaxFeedEvents},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT (.+) FROM events WHERE deleted_at IS NULL ORDER BY start_time ASC, id ASC LIMIT \\$1").
+					WithArgs(DefaultPageLimit + 1).
+					WillReturnRows(eventListRows())
+			},
+			wantIds: []string{},
+		},
+		{
+			name:   "feed limit above the page cap",
+			filter: EventFilter{Limit: MaxFeedEvents, Feed: true},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT (.+) FROM events WHERE deleted_at IS NULL ORDER BY start_time ASC, id ASC LIMIT \\$1").
+					WithArgs(MaxFeedEvents + 1).
+					WillReturnRows(eventListRows())
+			},
+			wantIds: []string{},
+		},
+		{
+			name:   "last page after a cursor",
+			filter: EventFilter{Limit: 2, Cursor: &Cursor{StartTime: now, Id: "uuid-1"}},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				rows := eventListRows().
+					AddRow(eventListRow(Event{Id: "uuid-2", Title: "B", StartTime: now.Add(time.Hour), EndTime: now.Add(2 * time.Hour), CreatedAt: now, Version: 1})...)
+				mock.ExpectQuery("SELECT (.+) FROM events WHERE deleted_at IS NULL AND \\(start_time, id\\) > \\(\\$1, \\$2\\) ORDER BY start_time ASC, id ASC LIMIT \\$3").
+					WithArgs(now, "uuid-1", 3).
+					WillReturnRows(rows)
+				expectEventDetails(mock, "uuid-2")
+			},
+			wantIds:  []string{"uuid-2"},
+			wantPrev: &Cursor{StartTime: now.Add(time.Hour), Id: "uuid-2", Backward: true},
+		},
+		{
+			name:   "backward page is returned in ascending order",
+			filter: EventFilter{Limit: 1, Cursor: &Cursor{StartTime: now.Add(2 * time.Hour), Id: "uuid-3", Backward: true}},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				rows := eventListRows().
+					AddRow(eventListRow(Event{Id: "uuid-2", Title: "B", StartTime: now.Add(time.Hour), EndTime: now.Add(2 * time.Hour), CreatedAt: now, Version: 1})...).
+					AddRow(eventListRow(Event{Id: "uuid-1", Title: "A", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 1})...)
+				mock.ExpectQuery("SELECT (.+) FROM events WHERE deleted_at IS NULL AND \\(start_time, id\\) < \\(\\$1, \\$2\\) ORDER BY start_time DESC, id DESC LIMIT \\$3").
+					WithArgs(now.Add(2*time.Hour), "uuid-3", 2).
+					WillReturnRows(rows)
+				expectEventDetails(mock, "uuid-2")
+			},
+			wantIds:  []string{"uuid-2"},
+			wantNext: &Cursor{StartTime: now.Add(time.Hour), Id: "uuid-2"},
+			wantPrev: &Cursor{StartTime: now.Add(time.Hour), Id: "uuid-2", Backward: true},
+		},
+		{
+			name: "time window and title filters",
+			filter: EventFilter{
+				Limit:       5,
+				StartsAfter: now,
+				EndsBefore:  now.Add(24 * time.Hour),
+				Overlaps:    &TimeWindow{From: now.Add(9 * time.Hour), To: now.Add(17 * time.Hour)},
+				TitlePrefix: "50%_off",
+			},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				rows := eventListRows().
+					AddRow(eventListRow(Event{Id: "uuid-1", Title: "50%_off sale", StartTime: now.Add(10 * time.Hour), EndTime: now.Add(11 * time.Hour), CreatedAt: now, Version: 1})...)
+				mock.ExpectQuery("SELECT (.+) FROM events WHERE deleted_at IS NULL AND start_time >= \\$1 AND end_time <= \\$2 "+
+					"AND start_time < \\$3 AND end_time > \\$4 AND lower\\(title\\) LIKE lower\\(\\$5\\) "+
+					"ORDER BY start_time ASC, id ASC LIMIT \\$6").
+					WithArgs(now, now.Add(24*time.Hour), now.Add(17*time.Hour), now.Add(9*time.Hour), `50\%\_off%`, 6).
+					WillReturnRows(rows)
+				expectEventDetails(mock, "uuid-1")
+			},
+			wantIds: []string{"uuid-1"},
+		},
+		{
+			name:   "calendar",
+			filter: EventFilter{Limit: 1, CalendarId: "uuid-c"},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				rows := eventListRows().
+					AddRow(eventListRow(Event{Id: "uuid-1", Title: "A", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 1, CalendarId: "uuid-c"})...)
+				mock.ExpectQuery("SELECT (.+) FROM events WHERE deleted_at IS NULL AND calendar_id = \\$1 ORDER BY start_time ASC, id ASC LIMIT \\$2").
+					WithArgs("uuid-c", 2).
+					WillReturnRows(rows)
+				expectEventDetails(mock, "uuid-1")
+			},
+			wantIds: []string{"uuid-1"},
+		},
+		{
+			name:   "near",
+			filter: EventFilter{Limit: 1, Near: &GeoPoint{Latitude: 40.4169, Longitude: -3.7035}, RadiusKm: 5},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				rows := eventListRows().
+					AddRow(eventListRow(Event{Id: "uuid-1", Title: "A", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 1,
+						Location: Place{Name: "Sol", Latitude: ptr(40.4169), Longitude: ptr(-3.7036)}})...)
+				mock.ExpectQuery("SELECT (.+) FROM events WHERE deleted_at IS NULL "+
+					"AND latitude BETWEEN \\$1 - \\$3 / 111.19\\d+ AND \\$1 \\+ \\$3 / 111.19\\d+ "+
+					"AND 2 \\* 6371.0088 \\* asin\\(least\\(1, sqrt\\(.+radians\\(longitude - \\$2\\).+\\)\\)\\) <= \\$3 "+
+					"ORDER BY start_time ASC, id ASC LIMIT \\$4").
+					WithArgs(40.4169, -3.7035, 5.0, 2).
+					WillReturnRows(rows)
+				expectEventDetails(mock, "uuid-1")
+			},
+			wantIds: []string{"uuid-1"},
+		},
+		{
+			name:   "any tag",
+			filter: EventFilter{Limit: 1, Tags: []string{"oncall", "release"}, TagMode: TagModeAny},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				rows := eventListRows().
+					AddRow(eventListRow(Event{Id: "uuid-1", Title: "A", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 1, Tags: []string{"oncall"}})...)
+				mock.ExpectQuery("SELECT (.+) FROM events WHERE deleted_at IS NULL "+
+					"AND id IN \\(SELECT event_id FROM event_tags JOIN tags ON tags.id = tag_id WHERE tags.name = ANY\\(\\$1\\)\\) "+
+					"ORDER BY start_time ASC, id ASC LIMIT \\$2").
+					WithArgs([]string{"oncall", "release"}, 2).
+					WillReturnRows(rows)
+				expectEventDetails(mock, "uuid-1")
+			},
+			wantIds: []string{"uuid-1"},
+		},
+		{
+			name:   "all tags",
+			filter: EventFilter{Limit: 1, Tags: []string{"oncall", "release"}, TagMode: TagModeAll},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				rows := eventListRows().
+					AddRow(eventListRow(Event{Id: "uuid-1", Title: "A", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 1, Tags: []string{"oncall", "release"}})...)
+				mock.ExpectQuery("SELECT (.+) FROM events WHERE deleted_at IS NULL "+
+					"AND id IN \\(SELECT event_id FROM event_tags JOIN tags ON tags.id = tag_id WHERE tags.name = ANY\\(\\$1\\) "+
+					"GROUP BY event_id HAVING count\\(\\*\\) = \\$2\\) "+
+					"ORDER BY start_time ASC, id ASC LIMIT \\$3").
+					WithArgs([]string{"oncall", "release"}, 2, 2).
+					WillReturnRows(rows)
+				expectEventDetails(mock, "uuid-1")
+			},
+			wantIds: []string{"uuid-1"},
+		},
+		{
+			name:   "trash",
+			filter: EventFilter{Limit: 1, Trashed: true},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				rows := eventListRows().
+					AddRow(eventListRow(Event{Id: "uuid-1", Title: "A", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 2})...)
+				mock.ExpectQuery("SELECT (.+) FROM events WHERE deleted_at IS NOT NULL ORDER BY start_time ASC, id ASC LIMIT \\$1").
+					WithArgs(2).
+					WillReturnRows(rows)
+				expectEventDetails(mock, "uuid-1")
+			},
+			wantIds: []string{"uuid-1"},
+		},
+		{
+			name:   "empty page",
+			filter: EventFilter{},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT (.+) FROM events").
+					WithArgs(DefaultPageLimit + 1).
+					WillReturnRows(eventListRows())
+			},
+			wantIds: []string{},
+		},
+		{
+			name:   "query failure",
+			filter: EventFilter{Limit: 10},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT (.+) FROM events").
+					WithArgs(11).
+					WillReturnError(errors.New("query error"))
+			},
+			wantErr: true,
+		},
+		{
+			name:   "scan failure",
+			filter: EventFilter{Limit: 10},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				row := eventListRow(Event{Id: "uuid-1", Title: "A"})
+				row[3] = "not-a-time"
+				rows := eventListRows().AddRow(row...)
+				mock.ExpectQuery("SELECT (.+) FROM events").
+					WithArgs(11).
+					WillReturnRows(rows)
+			},
+			wantErr: true,
+		},
+		{
+			name:   "rows failure",
+			filter: EventFilter{Limit: 10},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				rows := eventListRows().
+					AddRow(eventListRow(Event{Id: "uuid-1", Title: "A", StartTime: now, EndTime: now, CreatedAt: now, Version: 1})...).
+					RowError(0, errors.New("row error"))
+				mock.ExpectQuery("SELECT (.+) FROM events").
+					WithArgs(11).
+					WillReturnRows(rows)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			got, err := repo.ListEvents(ctx, tt.filter)
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+
+				ids := make([]string, 0, len(got.Events))
+				for _, e := range got.Events {
+					ids = append(ids, e.Id)
+				}
+
+				assert.Equal(t, tt.wantIds, ids)
+				assertCursor(t, tt.wantNext, got.Next)
+				assertCursor(t, tt.wantPrev, got.Prev)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_ListTags(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	defer mock.Close()
+
+	tags := []Tag{{Name: "oncall", Count: 3}, {Name: "release", Count: 1}}
+
+	mock.ExpectQuery("SELECT tags.name, count\\(\\*\\) FROM tags (.+) WHERE events.deleted_at IS NULL GROUP BY tags.name ORDER BY count\\(\\*\\) DESC, tags.name").
+		WillReturnRows(pgxmock.NewRows([]string{"name", "count"}).AddRow("oncall", 3).AddRow("release", 1))
+
+	repo := NewRepository(mock)
+	got, err := repo.ListTags(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, tags, got)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_SearchEvents(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	tests := []struct {
+		name       string
+		query      SearchQuery
+		mockSetup  func(mock pgxmock.PgxPoolIface)
+		wantErr    bool
+		wantResult []SearchResult
+	}{
+		{
+			name:  "indexed language",
+			query: SearchQuery{Query: "kickoff", Language: IndexedSearchLanguage, Limit: 10},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				rows := eventListRows("rank", "snippet").
+					AddRow(eventListRow(Event{Id: "uuid-1", Title: "Kickoff", Description: "Desc", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 1},
+						float32(0.6), "\x02Kickoff\x03 <b>Desc</b>")...)
+				mock.ExpectQuery("ts_rank\\(search_vector, q\\) (.+) WHERE search_vector @@ q AND deleted_at IS NULL").
+					WithArgs("english", "kickoff", 10).
+					WillReturnRows(rows)
+				mock.ExpectQuery("SELECT event_tags.event_id, tags.name FROM event_tags JOIN tags ON tags.id = event_tags.tag_id " +
+					"WHERE event_tags.event_id = ANY\\(\\$1\\) ORDER BY tags.name").
+					WithArgs([]string{"uuid-1"}).
+					WillReturnRows(pgxmock.NewRows([]string{"event_id", "name"}).AddRow("uuid-1", "kickoff").AddRow("uuid-1", "team"))
+				mock.ExpectQuery("SELECT event_id, status, count\\(\\*\\) FROM attendees WHERE event_id = ANY\\(\\$1\\) GROUP BY event_id, status").
+					WithArgs([]string{"uuid-1"}).
+					WillReturnRows(pgxmock.NewRows([]string{"event_id", "status", "count"}).AddRow("uuid-1", RsvpAccepted, 2).AddRow("uuid-1", RsvpNeedsAction, 1))
+			},
+			wantResult: []SearchResult{
+				{
+					Event: Event{
+						Id: "uuid-1", Title: "Kickoff", Description: "Desc", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 1,
+						Tags: []string{"kickoff", "team"}, Attendees: &AttendeeCounts{Total: 3, Accepted: 2, NeedsAction: 1},
+					},
+					Rank:    0.6,
+					Snippet: "<mark>Kickoff</mark> &lt;b&gt;Desc&lt;/b&gt;",
+				},
+			},
+		},
+		{
+			name:  "other language",
+			query: SearchQuery{Query: "reunión", Language: "spanish"},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("WHERE setweight\\(to_tsvector\\(\\$1::regconfig, title\\), 'A'\\)").
+					WithArgs("spanish", "reunión", DefaultPageLimit).
+					WillReturnRows(eventListRows("rank", "snippet"))
+			},
+			wantResult: []SearchResult{},
+		},
+		{
+			name:  "attendee counts failure",
+			query: SearchQuery{Query: "kickoff", Language: IndexedSearchLanguage, Limit: 10},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				rows := eventListRows("rank", "snippet").
+					AddRow(eventListRow(Event{Id: "uuid-1", Title: "Kickoff", Version: 1}, float32(0.1), "")...)
+				mock.ExpectQuery("SELECT (.+) FROM events").
+					WithArgs("english", "kickoff", 10).
+					WillReturnRows(rows)
+				mock.ExpectQuery("SELECT event_tags.event_id, tags.name FROM event_tags").
+					WithArgs([]string{"uuid-1"}).
+					WillReturnRows(pgxmock.NewRows([]string{"event_id", "name"}))
+				mock.ExpectQuery("SELECT event_id, status, count\\(\\*\\) FROM attendees").
+					WithArgs([]string{"uuid-1"}).
+					WillReturnError(errors.New("count error"))
+			},
+			wantErr: true,
+		},
+		{
+			name:  "query failure",
+			query: SearchQuery{Query: "kickoff", Language: IndexedSearchLanguage, Limit: 10},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT (.+) FROM events").
+					WithArgs("english", "kickoff", 10).
+					WillReturnError(errors.New("query error"))
+			},
+			wantErr: true,
+		},
+		{
+			name:  "scan failure",
+			query: SearchQuery{Query: "kickoff", Language: IndexedSearchLanguage, Limit: 10},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				rows := eventListRows("rank", "snippet").
+					AddRow(eventListRow(Event{Id: "uuid-1", Title: "Kickoff", Version: 1}, "not-a-rank", "")...)
+				mock.ExpectQuery("SELECT (.+) FROM events").
+					WithArgs("english", "kickoff", 10).
+					WillReturnRows(rows)
+			},
+			wantErr: true,
+		},
+		{
+			name:  "rows failure",
+			query: SearchQuery{Query: "kickoff", Language: IndexedSearchLanguage, Limit: 10},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				rows := eventListRows("rank", "snippet").
+					AddRow(eventListRow(Event{Id: "uuid-1", Title: "Kickoff", Version: 1}, float32(0.1), "")...).
+					RowError(0, errors.New("row error"))
+				mock.ExpectQuery("SELECT (.+) FROM events").
+					WithArgs("english", "kickoff", 10).
+					WillReturnRows(rows)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			got, err := repo.SearchEvents(ctx, tt.query)
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantResult, got)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func assertCursor(t *testing.T, want *Cursor, got string) {
+	t.Helper()
+
+	if want == nil {
+		assert.Empty(t, got)
+		return
+	}
+
+	cursor, err := DecodeCursor(got)
+	require.NoError(t, err)
+	assert.True(t, want.StartTime.Equal(cursor.StartTime))
+	assert.Equal(t, want.Id, cursor.Id)
+	assert.Equal(t, want.Backward, cursor.Backward)
+}
+
+// overrideRow returns the values of the overrideColumns of o.
+func overrideRow(o Override) []any {
+	return fieldValues(overrideFields(&o))
+}
+
+func TestRepository_ListSeries(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	window := TimeWindow{From: now, To: now.AddDate(0, 1, 0)}
+	weekly := Event{Id: "uuid-1", Title: "Standup", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 1, TimeZone: "UTC", RRule: "FREQ=WEEKLY"}
+	single := Event{Id: "uuid-2", Title: "Kickoff", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 1, TimeZone: "UTC"}
+	override := Override{EventId: "uuid-1", RecurrenceId: now.AddDate(0, 0, 7), Title: "Retro", StartTime: now.AddDate(0, 0, 7), EndTime: now.AddDate(0, 0, 7).Add(time.Hour)}
+
+	tests := []struct {
+		name       string
+		mockSetup  func(mock pgxmock.PgxPoolIface)
+		wantErr    error
+		wantResult []Series
+	}{
+		{
+			name: "success",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM events WHERE deleted_at IS NULL AND \\(\\(start_time < \\$2 AND \\(series_end IS NULL OR series_end > \\$1\\)\\) OR id IN").
+					WithArgs(window.From, window.To, MaxOccurrences+1).
+					WillReturnRows(eventListRows().AddRow(eventListRow(weekly)...).AddRow(eventListRow(single)...))
+				mock.ExpectQuery("FROM event_overrides WHERE event_id = ANY\\(\\$1\\)").
+					WithArgs([]string{"uuid-1"}).
+					WillReturnRows(pgxmock.NewRows(strings.Split(overrideColumns, ", ")).AddRow(overrideRow(override)...))
+			},
+			wantResult: []Series{{Event: weekly, Overrides: []Override{override}}, {Event: single}},
+		},
+		{
+			name: "no recurring events",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM events").
+					WithArgs(window.From, window.To, MaxOccurrences+1).
+					WillReturnRows(eventListRows().AddRow(eventListRow(single)...))
+			},
+			wantResult: []Series{{Event: single}},
+		},
+		{
+			name: "query failure",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM events").
+					WithArgs(window.From, window.To, MaxOccurrences+1).
+					WillReturnError(errors.New("query error"))
+			},
+			wantErr: errors.New("query error"),
+		},
+		{
+			name: "too many events",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				rows := eventListRows()
+				for range MaxOccurrences + 1 {
+					rows.AddRow(eventListRow(single)...)
+				}
+
+				mock.ExpectQuery("FROM events").
+					WithArgs(window.From, window.To, MaxOccurrences+1).
+					WillReturnRows(rows)
+			},
+			wantErr: ErrTooManyOccurrences,
+		},
+		{
+			name: "overrides failure",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM events").
+					WithArgs(window.From, window.To, MaxOccurrences+1).
+					WillReturnRows(eventListRows().AddRow(eventListRow(weekly)...))
+				mock.ExpectQuery("FROM event_overrides").
+					WithArgs([]string{"uuid-1"}).
+					WillReturnError(errors.New("overrides error"))
+			},
+			wantErr: errors.New("overrides error"),
+		},
+		{
+			name: "overrides scan failure",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM events").
+					WithArgs(window.From, window.To, MaxOccurrences+1).
+					WillReturnRows(eventListRows().AddRow(eventListRow(weekly)...))
+				mock.ExpectQuery("FROM event_overrides").
+					WithArgs([]string{"uuid-1"}).
+					WillReturnRows(pgxmock.NewRows(strings.Split(overrideColumns, ", ")).
+						AddRow("uuid-1", "not-a-time", "", "", now, now, false))
+			},
+			wantErr: errors.New("failed to scan override"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			got, err := repo.ListSeries(ctx, window)
+
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr.Error())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantResult, got)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_FreeBusy(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	at := func(day, hour, minute int) time.Time { return time.Date(2025, 3, day, hour, minute, 0, 0, time.UTC) }
+	query := FreeBusyQuery{From: at(3, 8, 0), To: at(5, 0, 0), CalendarId: "uuid-c"}
+	daily := Event{
+		Id: "uuid-r", Title: "Standup", StartTime: at(3, 10, 0), EndTime: at(3, 11, 0), CreatedAt: at(1, 0, 0), Version: 1,
+		TimeZone: "UTC", RRule: "FREQ=DAILY;COUNT=5", CalendarId: "uuid-c",
+	}
+
+	expectMerge := func(mock pgxmock.PgxPoolIface) *pgxmock.ExpectedQuery {
+		return mock.ExpectQuery("WITH busy AS \\(SELECT greatest\\(start_time, \\$1\\) AS start_time, least\\(end_time, \\$2\\) AS end_time FROM events "+
+			"WHERE deleted_at IS NULL AND rrule = '' AND coalesce\\(cardinality\\(rdates\\), 0\\) = 0 "+
+			"AND start_time < \\$2 AND end_time > \\$1 AND end_time > start_time AND calendar_id = \\$3\\), marked AS (.+) "+
+			"SELECT min\\(start_time\\), max\\(end_time\\) FROM islands GROUP BY island ORDER BY island").
+			WithArgs(query.From, query.To, "uuid-c")
+	}
+
+	expectSeries := func(mock pgxmock.PgxPoolIface) *pgxmock.ExpectedQuery {
+		return mock.ExpectQuery("FROM events WHERE deleted_at IS NULL AND \\(\\(start_time < \\$2 (.+)\\) "+
+			"AND calendar_id = \\$4 AND \\(rrule <> '' OR cardinality\\(rdates\\) > 0\\) ORDER BY start_time ASC, id ASC LIMIT \\$3").
+			WithArgs(query.From, query.To, MaxOccurrences+1, "uuid-c")
+	}
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock pgxmock.PgxPoolIface)
+		want      []BusyInterval
+		wantErr   string
+	}{
+		{
+			name: "one-off events and occurrences are merged",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				expectMerge(mock).WillReturnRows(pgxmock.NewRows([]string{"min", "max"}).
+					AddRow(at(3, 9, 0), at(3, 10, 30)).
+					AddRow(at(4, 14, 0), at(4, 15, 0)))
+				expectSeries(mock).WillReturnRows(eventListRows().AddRow(eventListRow(daily)...))
+				mock.ExpectQuery("FROM event_overrides WHERE event_id = ANY\\(\\$1\\)").
+					WithArgs([]string{"uuid-r"}).
+					WillReturnRows(pgxmock.NewRows(strings.Split(overrideColumns, ", ")))
+			},
+			want: []BusyInterval{
+				{Start: at(3, 9, 0), End: at(3, 11, 0)},
+				{Start: at(4, 10, 0), End: at(4, 11, 0)},
+				{Start: at(4, 14, 0), End: at(4, 15, 0)},
+			},
+		},
+		{
+			name: "nothing busy",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				expectMerge(mock).WillReturnRows(pgxmock.NewRows([]string{"min", "max"}))
+				expectSeries(mock).WillReturnRows(eventListRows())
+			},
+			want: []BusyInterval{},
+		},
+		{
+			name: "merge failure",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				expectMerge(mock).WillReturnError(errors.New("query error"))
+			},
+			wantErr: "failed to merge busy intervals: query error",
+		},
+		{
+			name: "series failure",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				expectMerge(mock).WillReturnRows(pgxmock.NewRows([]string{"min", "max"}))
+				expectSeries(mock).WillReturnError(errors.New("query error"))
+			},
+			wantErr: "failed to list series: query error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			got, err := repo.FreeBusy(ctx, query)
+
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_SaveOverride(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	override := &Override{EventId: "uuid-1", RecurrenceId: now, StartTime: now, EndTime: now.Add(time.Hour), Cancelled: true}
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock pgxmock.PgxPoolIface)
+		wantErr   error
+	}{
+		{
+			name: "success",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("WITH touched AS \\(UPDATE events SET updated_at = now\\(\\) WHERE id = \\$1 AND deleted_at IS NULL RETURNING id\\) INSERT INTO event_overrides (.+) FROM touched ON CONFLICT \\(event_id, recurrence_id\\) DO UPDATE").
+					WithArgs("uuid-1", now, "", "", now, now.Add(time.Hour), true).
+					WillReturnRows(pgxmock.NewRows(strings.Split(overrideColumns, ", ")).AddRow(overrideRow(*override)...))
+			},
+		},
+		{
+			name: "event not found",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("INSERT INTO event_overrides").
+					WithArgs("uuid-1", now, "", "", now, now.Add(time.Hour), true).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: ErrEventNotFound,
+		},
+		{
+			name: "query failure",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("INSERT INTO event_overrides").
+					WithArgs("uuid-1", now, "", "", now, now.Add(time.Hour), true).
+					WillReturnError(errors.New("insert error"))
+			},
+			wantErr: errors.New("insert error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			got, err := repo.SaveOverride(ctx, override)
+
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr.Error())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, override, got)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_ListOverrides(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	override := Override{EventId: "uuid-1", RecurrenceId: now, Title: "Retro", StartTime: now, EndTime: now.Add(time.Hour)}
+
+	tests := []struct {
+		name       string
+		mockSetup  func(mock pgxmock.PgxPoolIface)
+		wantErr    bool
+		wantResult []Override
+	}{
+		{
+			name: "success",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT (.+) FROM event_overrides WHERE event_id = ANY\\(\\$1\\) ORDER BY event_id, recurrence_id").
+					WithArgs([]string{"uuid-1"}).
+					WillReturnRows(pgxmock.NewRows(strings.Split(overrideColumns, ", ")).AddRow(overrideRow(override)...))
+			},
+			wantResult: []Override{override},
+		},
+		{
+			name: "query failure",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM event_overrides").
+					WithArgs([]string{"uuid-1"}).
+					WillReturnError(errors.New("query error"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "rows failure",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM event_overrides").
+					WithArgs([]string{"uuid-1"}).
+					WillReturnRows(pgxmock.NewRows(strings.Split(overrideColumns, ", ")).
+						AddRow(overrideRow(override)...).
+						RowError(0, errors.New("row error")))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			got, err := repo.ListOverrides(ctx, []string{"uuid-1"})
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantResult, got)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_ImportEvent(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	event := Event{Title: "Standup", StartTime: now, EndTime: now.Add(time.Hour), RRule: "FREQ=DAILY", Uid: "abc@example.com"}
+	override := Override{RecurrenceId: now.AddDate(0, 0, 1), StartTime: now.AddDate(0, 0, 1), EndTime: now.AddDate(0, 0, 1).Add(time.Hour), Cancelled: true}
+	series := &Series{Event: event, Overrides: []Override{override}}
+
+	saved := event
+	saved.Id, saved.CreatedAt, saved.Version = "uuid-1", now, 1
+
+	expectInsert := func(mock pgxmock.PgxPoolIface) *pgxmock.ExpectedQuery {
+		return mock.ExpectQuery("INSERT INTO events (.+) ON CONFLICT \\(ical_uid\\) WHERE ical_uid <> '' DO NOTHING").
+			WithArgs("Standup", "", now, now.Add(time.Hour), "", "FREQ=DAILY", []time.Time(nil), []time.Time(nil), pgxmock.AnyArg(), false, "abc@example.com")
+	}
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock pgxmock.PgxPoolIface)
+		wantErr   error
+	}{
+		{
+			name: "success",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				expectInsert(mock).WillReturnRows(eventRows().AddRow(eventRow(saved)...))
+				mock.ExpectExec("INSERT INTO event_overrides").
+					WithArgs("uuid-1", override.RecurrenceId, "", "", override.StartTime, override.EndTime, true).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				expectOutbox(mock, EventCreated, "uuid-1")
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "already imported",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				expectInsert(mock).WillReturnError(pgx.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: ErrEventExists,
+		},
+		{
+			name: "override failure",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				expectInsert(mock).WillReturnRows(eventRows().AddRow(eventRow(saved)...))
+				mock.ExpectExec("INSERT INTO event_overrides").
+					WithArgs("uuid-1", override.RecurrenceId, "", "", override.StartTime, override.EndTime, true).
+					WillReturnError(errors.New("insert error"))
+				mock.ExpectRollback()
+			},
+			wantErr: errors.New("insert error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			got, err := repo.ImportEvent(ctx, series)
+
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr.Error())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, &saved, got)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_SaveEvents(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	events := []Event{
+		{Title: "Kickoff", StartTime: now, EndTime: now.Add(time.Hour)},
+		{Title: "Review", StartTime: now.Add(2 * time.Hour), EndTime: now.Add(3 * time.Hour), Tags: []string{"Release", " oncall ", "release"}},
+	}
+	saved := []Event{
+		{Id: "uuid-1", Title: "Kickoff", StartTime: now, EndTime: now.Add(time.Hour), CreatedAt: now, Version: 1},
+		{Id: "uuid-2", Title: "Review", StartTime: now.Add(2 * time.Hour), EndTime: now.Add(3 * time.Hour), CreatedAt: now, Version: 1},
+	}
+
+	args := []any{
+		0, "Kickoff", "", now, now.Add(time.Hour), "", "", []time.Time(nil), []time.Time(nil), pgxmock.AnyArg(), false, "", "", 0,
+		"", "", (*float64)(nil), (*float64)(nil),
+		1, "Review", "", now.Add(2 * time.Hour), now.Add(3 * time.Hour), "", "", []time.Time(nil), []time.Time(nil), pgxmock.AnyArg(), false, "", "", 0,
+		"", "", (*float64)(nil), (*float64)(nil),
+	}
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock pgxmock.PgxPoolIface)
+		wantErr   bool
+	}{
+		{
+			name: "success",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("WITH input AS \\(SELECT uuid_generate_v4\\(\\) AS id, \\* FROM \\(VALUES \\(\\$1::int, .+\\), \\(\\$19, .+, \\$36\\)\\) .+INSERT INTO events .+ ORDER BY input.n").
+					WithArgs(args...).
+					WillReturnRows(eventRows("n").AddRow(eventRow(saved[0], 0)...).AddRow(eventRow(saved[1], 1)...))
+				mock.ExpectExec("INSERT INTO tags \\(name\\) SELECT DISTINCT unnest\\(\\$1::text\\[\\]\\) ON CONFLICT \\(name\\) DO NOTHING").
+					WithArgs([]string{"oncall", "release"}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 2))
+				mock.ExpectExec("INSERT INTO event_tags \\(event_id, tag_id\\) SELECT (.+) JOIN tags USING \\(name\\)").
+					WithArgs([]string{"uuid-2", "uuid-2"}, []string{"oncall", "release"}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 2))
+				expectOutbox(mock, EventCreated, "uuid-1", "uuid-2")
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "query failure",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO events").
+					WithArgs(args...).
+					WillReturnError(errors.New("insert error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "tags failure",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO events").
+					WithArgs(args...).
+					WillReturnRows(eventRows("n").AddRow(eventRow(saved[0], 0)...).AddRow(eventRow(saved[1], 1)...))
+				mock.ExpectExec("INSERT INTO tags").
+					WithArgs([]string{"oncall", "release"}).
+					WillReturnError(errors.New("insert error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			got, err := repo.SaveEvents(ctx, events)
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, got, 2)
+				assert.Equal(t, saved[0], got[0])
+				assert.Equal(t, []string{"oncall", "release"}, got[1].Tags)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_FindConflicts(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	conflict := Event{Id: "uuid-2", Title: "Standup", StartTime: now, EndTime: now.Add(time.Hour), Scope: "room-1", Version: 1}
+
+	tests := []struct {
+		name      string
+		event     Event
+		mockSetup func(mock pgxmock.PgxPoolIface)
+		want      []Event
+		wantErr   bool
+	}{
+		{
+			name:  "overlapping events",
+			event: Event{Id: "uuid-1", StartTime: now.Add(30 * time.Minute), EndTime: now.Add(90 * time.Minute), Scope: "room-1"},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT (.+) FROM events WHERE scope = \\$1 AND deleted_at IS NULL AND tstzrange\\(start_time, end_time\\) && tstzrange\\(\\$2, \\$3\\) AND id IS DISTINCT FROM NULLIF\\(\\$4, ''\\)::uuid").
+					WithArgs("room-1", now.Add(30*time.Minute), now.Add(90*time.Minute), "uuid-1").
+					WillReturnRows(eventListRows().AddRow(eventListRow(conflict)...))
+				expectEventDetails(mock, "uuid-2")
+			},
+			want: []Event{withDetails(conflict)},
+		},
+		{
+			name:      "no scope",
+			event:     Event{StartTime: now, EndTime: now.Add(time.Hour)},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {},
+			want:      []Event{},
+		},
+		{
+			name:  "query failure",
+			event: Event{StartTime: now, EndTime: now.Add(time.Hour), Scope: "room-1"},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT (.+) FROM events WHERE scope").
+					WithArgs("room-1", now, now.Add(time.Hour), "").
+					WillReturnError(errors.New("query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			got, err := repo.FindConflicts(ctx, tt.event)
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_SaveEventConflict(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	event := &Event{Title: "Sync", StartTime: now, EndTime: now.Add(time.Hour), Scope: "room-1"}
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	defer mock.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO events").
+		WithArgs("Sync", "", now, now.Add(time.Hour), "", "", []time.Time(nil), []time.Time(nil), pgxmock.AnyArg(), false, "room-1", "", 0, "", "", (*float64)(nil), (*float64)(nil)).
+		WillReturnError(&pgconn.PgError{Code: "23P01", ConstraintName: "events_scope_overlap_excl"})
+	mock.ExpectRollback()
+	mock.ExpectQuery("SELECT (.+) FROM events WHERE scope = \\$1").
+		WithArgs("room-1", now, now.Add(time.Hour), "").
+		WillReturnRows(eventListRows().AddRow(eventListRow(Event{Id: "uuid-2", Scope: "room-1"})...))
+
+	repo := NewRepository(mock)
+	_, err = repo.SaveEvent(ctx, event)
+
+	var conflictErr *ConflictError
+	require.ErrorAs(t, err, &conflictErr)
+	require.ErrorIs(t, err, ErrEventConflict)
+	assert.Equal(t, []string{"uuid-2"}, conflictErr.EventIds)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func calendarRows() *pgxmock.Rows {
+	return pgxmock.NewRows(strings.Split(calendarColumns, ", "))
+}
+
+func calendarRow(c Calendar) []any {
+	return []any{c.Id, c.Name, c.Description, c.TimeZone, c.Color, c.CreatedAt, c.UpdatedAt}
+}
+
+func TestRepository_SaveCalendar(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	saved := Calendar{Id: "uuid-c", Name: "Work", TimeZone: "UTC", CreatedAt: now, UpdatedAt: now}
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock pgxmock.PgxPoolIface)
+		wantErr   bool
+	}{
+		{
+			name: "successful save",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("INSERT INTO calendars \\(name, description, time_zone, color\\) VALUES \\(\\$1, \\$2, COALESCE\\(NULLIF\\(\\$3, ''\\), 'UTC'\\), \\$4\\)").
+					WithArgs("Work", "", "", "").
+					WillReturnRows(calendarRows().AddRow(calendarRow(saved)...))
+			},
+		},
+		{
+			name: "insert failure",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("INSERT INTO calendars").
+					WithArgs("Work", "", "", "").
+					WillReturnError(errors.New("insert error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			got, err := repo.SaveCalendar(ctx, &Calendar{Name: "Work"})
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, saved, *got)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_GetCalendar(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	calendar := Calendar{Id: "uuid-c", Name: "Work", TimeZone: "Europe/Madrid", Color: "#336699"}
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock pgxmock.PgxPoolIface)
+		wantErr   error
+	}{
+		{
+			name: "found",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT (.+) FROM calendars WHERE id = \\$1").
+					WithArgs("uuid-c").
+					WillReturnRows(calendarRows().AddRow(calendarRow(calendar)...))
+			},
+		},
+		{
+			name: "not found",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT (.+) FROM calendars WHERE id = \\$1").
+					WithArgs("uuid-c").
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: ErrCalendarNotFound,
+		},
+		{
+			name: "malformed id",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT (.+) FROM calendars WHERE id = \\$1").
+					WithArgs("uuid-c").
+					WillReturnError(&pgconn.PgError{Code: "22P02"})
+			},
+			wantErr: ErrCalendarNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			got, err := repo.GetCalendar(ctx, "uuid-c")
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, calendar, *got)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_ListCalendars(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	defer mock.Close()
+
+	calendars := []Calendar{{Id: "uuid-1", Name: "Home", TimeZone: "UTC"}, {Id: "uuid-2", Name: "Work", TimeZone: "UTC"}}
+
+	mock.ExpectQuery("SELECT (.+) FROM calendars ORDER BY name, id").
+		WillReturnRows(calendarRows().AddRow(calendarRow(calendars[0])...).AddRow(calendarRow(calendars[1])...))
+
+	repo := NewRepository(mock)
+	got, err := repo.ListCalendars(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, calendars, got)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_GetCalendars(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	defer mock.Close()
+
+	calendars := []Calendar{{Id: "uuid-1", Name: "Home", TimeZone: "UTC"}, {Id: "uuid-2", Name: "Work", TimeZone: "UTC"}}
+
+	mock.ExpectQuery("SELECT (.+) FROM calendars WHERE id = ANY\\(\\$1\\) ORDER BY id").
+		WithArgs([]string{"uuid-1", "uuid-2", "uuid-3"}).
+		WillReturnRows(calendarRows().AddRow(calendarRow(calendars[0])...).AddRow(calendarRow(calendars[1])...))
+
+	repo := NewRepository(mock)
+	got, err := repo.GetCalendars(ctx, []string{"uuid-1", "uuid-2", "uuid-3"})
+
+	require.NoError(t, err)
+	assert.Equal(t, calendars, got)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_UpdateCalendar(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	calendar := Calendar{Id: "uuid-c", Name: "Work", TimeZone: "Europe/Madrid", Color: "#336699"}
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock pgxmock.PgxPoolIface)
+		wantErr   error
+	}{
+		{
+			name: "successful update",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("UPDATE calendars SET name = \\$1, description = \\$2, time_zone = COALESCE\\(NULLIF\\(\\$3, ''\\), 'UTC'\\), color = \\$4, updated_at = now\\(\\) WHERE id = \\$5").
+					WithArgs("Work", "", "Europe/Madrid", "#336699", "uuid-c").
+					WillReturnRows(calendarRows().AddRow(calendarRow(calendar)...))
+			},
+		},
+		{
+			name: "not found",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("UPDATE calendars").
+					WithArgs("Work", "", "Europe/Madrid", "#336699", "uuid-c").
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: ErrCalendarNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			input := calendar
+			got, err := repo.UpdateCalendar(ctx, &input)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, calendar, *got)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_DeleteCalendar(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	lockQuery := "SELECT NOT EXISTS \\(SELECT 1 FROM events WHERE calendar_id = c.id AND deleted_at IS NULL\\) FROM calendars c WHERE c.id = \\$1 FOR UPDATE"
+	liveQuery := "SELECT id FROM events WHERE calendar_id = \\$1 AND deleted_at IS NULL ORDER BY id"
+
+	tests := []struct {
+		name      string
+		cascade   bool
+		mockSetup func(mock pgxmock.PgxPoolIface)
+		wantErr   error
+		errMsg    string
+	}{
+		{
+			name: "empty calendar",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockQuery).WithArgs("uuid-c").WillReturnRows(pgxmock.NewRows([]string{"empty"}).AddRow(true))
+				mock.ExpectExec("DELETE FROM calendars WHERE id = \\$1").WithArgs("uuid-c").WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "calendar with events",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockQuery).WithArgs("uuid-c").WillReturnRows(pgxmock.NewRows([]string{"empty"}).AddRow(false))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrCalendarNotEmpty,
+		},
+		{
+			name:    "cascade",
+			cascade: true,
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockQuery).WithArgs("uuid-c").WillReturnRows(pgxmock.NewRows([]string{"empty"}).AddRow(false))
+				mock.ExpectQuery(liveQuery).WithArgs("uuid-c").WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow("uuid-1").AddRow("uuid-2"))
+				expectOutbox(mock, EventDeleted, "uuid-1", "uuid-2")
+				mock.ExpectExec("DELETE FROM calendars WHERE id = \\$1").WithArgs("uuid-c").WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:    "cascade outbox failure",
+			cascade: true,
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockQuery).WithArgs("uuid-c").WillReturnRows(pgxmock.NewRows([]string{"empty"}).AddRow(false))
+				mock.ExpectQuery(liveQuery).WithArgs("uuid-c").WillReturnError(errors.New("query error"))
+				mock.ExpectRollback()
+			},
+			errMsg: "failed to write outbox: query error",
+		},
+		{
+			name:    "not found",
+			cascade: true,
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockQuery).WithArgs("uuid-c").WillReturnError(pgx.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: ErrCalendarNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			err = repo.DeleteCalendar(ctx, "uuid-c", tt.cascade)
+
+			switch {
+			case tt.wantErr != nil:
+				require.ErrorIs(t, err, tt.wantErr)
+			case tt.errMsg != "":
+				require.ErrorContains(t, err, tt.errMsg)
+			default:
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_SaveEventUnknownCalendar(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	event := &Event{Title: "Sync", StartTime: now, EndTime: now.Add(time.Hour), CalendarId: "uuid-c"}
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	defer mock.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO events").
+		WithArgs("Sync", "", now, now.Add(time.Hour), "", "", []time.Time(nil), []time.Time(nil), pgxmock.AnyArg(), false, "", "uuid-c", 0, "", "", (*float64)(nil), (*float64)(nil)).
+		WillReturnError(&pgconn.PgError{Code: "23503", ConstraintName: "events_calendar_id_fkey"})
+	mock.ExpectRollback()
+
+	repo := NewRepository(mock)
+	_, err = repo.SaveEvent(ctx, event)
+
+	require.ErrorIs(t, err, ErrCalendarNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func attendeeRows() *pgxmock.Rows {
+	return pgxmock.NewRows(strings.Split(attendeeColumns, ", "))
+}
+
+func attendeeRow(a Attendee) []any {
+	return []any{a.Id, a.EventId, a.Email, a.Name, a.Status, a.RespondedAt, a.CreatedAt, a.UpdatedAt}
+}
+
+func TestRepository_SaveAttendee(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	saved := Attendee{Id: "uuid-a", EventId: "uuid-1", Email: "ada@example.com", Name: "Ada", Status: RsvpNeedsAction, CreatedAt: now, UpdatedAt: now}
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock pgxmock.PgxPoolIface)
+		wantErr   error
+	}{
+		{
+			name: "invited",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("INSERT INTO attendees \\(event_id, email, name\\) SELECT id, \\$2, \\$3 FROM events WHERE id = \\$1 AND deleted_at IS NULL").
+					WithArgs("uuid-1", "ada@example.com", "Ada").
+					WillReturnRows(attendeeRows().AddRow(attendeeRow(saved)...))
+			},
+		},
+		{
+			name: "event not found",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("INSERT INTO attendees").
+					WithArgs("uuid-1", "ada@example.com", "Ada").
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: ErrEventNotFound,
+		},
+		{
+			name: "already invited",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("INSERT INTO attendees").
+					WithArgs("uuid-1", "ada@example.com", "Ada").
+					WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "attendees_event_id_email_idx"})
+			},
+			wantErr: ErrAttendeeExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			got, err := repo.SaveAttendee(ctx, &Attendee{EventId: "uuid-1", Email: "ada@example.com", Name: "Ada"})
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, saved, *got)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_ListAttendees(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	defer mock.Close()
+
+	attendees := []Attendee{
+		{Id: "uuid-a", EventId: "uuid-1", Email: "ada@example.com", Status: RsvpAccepted, RespondedAt: &now},
+		{Id: "uuid-b", EventId: "uuid-1", Email: "bob@example.com", Status: RsvpNeedsAction},
+	}
+
+	mock.ExpectQuery("SELECT (.+) FROM attendees WHERE event_id = \\$1 ORDER BY created_at, id").
+		WithArgs("uuid-1").
+		WillReturnRows(attendeeRows().AddRow(attendeeRow(attendees[0])...).AddRow(attendeeRow(attendees[1])...))
+
+	repo := NewRepository(mock)
+	got, err := repo.ListAttendees(ctx, "uuid-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, attendees, got)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_ListAttendeesByEvents(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	defer mock.Close()
+
+	attendees := []Attendee{
+		{Id: "uuid-a", EventId: "uuid-1", Email: "ada@example.com", Status: RsvpAccepted},
+		{Id: "uuid-b", EventId: "uuid-2", Email: "bob@example.com", Status: RsvpNeedsAction},
+	}
+
+	mock.ExpectQuery("SELECT (.+) FROM attendees WHERE event_id = ANY\\(\\$1\\) ORDER BY event_id, created_at, id").
+		WithArgs([]string{"uuid-1", "uuid-2"}).
+		WillReturnRows(attendeeRows().AddRow(attendeeRow(attendees[0])...).AddRow(attendeeRow(attendees[1])...))
+
+	repo := NewRepository(mock)
+	got, err := repo.ListAttendeesByEvents(ctx, []string{"uuid-1", "uuid-2"})
+
+	require.NoError(t, err)
+	assert.Equal(t, attendees, got)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_UpdateAttendeeStatus(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	updated := Attendee{Id: "uuid-a", EventId: "uuid-1", Email: "ada@example.com", Status: RsvpTentative, RespondedAt: &now}
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock pgxmock.PgxPoolIface)
+		wantErr   error
+	}{
+		{
+			name: "answered",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("UPDATE attendees SET status = \\$1, responded_at = now\\(\\), updated_at = now\\(\\) "+
+					"WHERE id = \\$2 AND event_id = \\$3 AND EXISTS \\(SELECT 1 FROM events WHERE id = \\$3 AND deleted_at IS NULL\\)").
+					WithArgs(RsvpTentative, "uuid-a", "uuid-1").
+					WillReturnRows(attendeeRows().AddRow(attendeeRow(updated)...))
+			},
+		},
+		{
+			name: "not found",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("UPDATE attendees").
+					WithArgs(RsvpTentative, "uuid-a", "uuid-1").
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: ErrAttendeeNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			got, err := repo.UpdateAttendeeStatus(ctx, &Attendee{Id: "uuid-a", EventId: "uuid-1", Status: RsvpTentative})
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, updated, *got)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_DeleteAttendee(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		affected int64
+		wantErr  error
+	}{
+		{name: "deleted", affected: 1},
+		{name: "not found", affected: 0, wantErr: ErrAttendeeNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			mock.ExpectExec("DELETE FROM attendees WHERE id = \\$1 AND event_id = \\$2").
+				WithArgs("uuid-a", "uuid-1").
+				WillReturnResult(pgxmock.NewResult("DELETE", tt.affected))
+
+			repo := NewRepository(mock)
+			err = repo.DeleteAttendee(ctx, "uuid-1", "uuid-a")
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func registrationRows() *pgxmock.Rows {
+	return pgxmock.NewRows(strings.Split(registrationColumns, ", "))
+}
+
+func registrationRow(reg Registration) []any {
+	return []any{reg.Id, reg.EventId, reg.Email, reg.Name, reg.Status, reg.CreatedAt, reg.UpdatedAt}
+}
+
+const (
+	lockRegistrationsQuery  = "SELECT capacity FROM events WHERE id = \\$1 AND deleted_at IS NULL FOR NO KEY UPDATE"
+	countRegistrationsQuery = "SELECT count\\(\\*\\) FILTER \\(WHERE status = 'confirmed'\\), count\\(\\*\\) FILTER \\(WHERE status = 'waitlisted'\\) " +
+		"FROM registrations WHERE event_id = \\$1"
+	promoteRegistrationsQuery = "UPDATE registrations SET status = 'confirmed', updated_at = now\\(\\) WHERE id IN \\(" +
+		"SELECT id FROM registrations WHERE event_id = \\$1 AND status = 'waitlisted' ORDER BY seq LIMIT \\$2\\)"
+)
+
+func reminderRows() *pgxmock.Rows {
+	return pgxmock.NewRows(strings.Split(reminderColumns, ", "))
+}
+
+func reminderRow(rem Reminder) []any {
+	return []any{rem.Id, rem.EventId, rem.OffsetMinutes, rem.Channel, rem.RemindedThrough, rem.DueAt, rem.Revision, rem.CreatedAt, rem.UpdatedAt}
+}
+
+func TestRepository_SaveReminder(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	saved := Reminder{Id: "uuid-r", EventId: "uuid-1", OffsetMinutes: 15, Channel: ChannelEmail, DueAt: &now, Revision: 1, CreatedAt: now, UpdatedAt: now}
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock pgxmock.PgxPoolIface)
+		wantErr   error
+	}{
+		{
+			name: "set",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("INSERT INTO reminders \\(event_id, offset_minutes, channel\\) SELECT id, \\$2, \\$3 FROM events WHERE id = \\$1 AND deleted_at IS NULL").
+					WithArgs("uuid-1", 15, ChannelEmail).
+					WillReturnRows(reminderRows().AddRow(reminderRow(saved)...))
+			},
+		},
+		{
+			name: "event not found",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("INSERT INTO reminders").
+					WithArgs("uuid-1", 15, ChannelEmail).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: ErrEventNotFound,
+		},
+		{
+			name: "already set",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("INSERT INTO reminders").
+					WithArgs("uuid-1", 15, ChannelEmail).
+					WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "reminders_event_id_offset_minutes_channel_idx"})
+			},
+			wantErr: ErrReminderExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			got, err := repo.SaveReminder(ctx, &Reminder{EventId: "uuid-1", OffsetMinutes: 15, Channel: ChannelEmail})
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, saved, *got)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_ListReminders(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	defer mock.Close()
+
+	reminders := []Reminder{
+		{Id: "uuid-r", EventId: "uuid-1", OffsetMinutes: 60, Channel: ChannelEmail},
+		{Id: "uuid-s", EventId: "uuid-1", OffsetMinutes: 15, Channel: ChannelWebhook},
+	}
+
+	mock.ExpectQuery("SELECT (.+) FROM reminders WHERE event_id = \\$1 ORDER BY offset_minutes DESC, channel").
+		WithArgs("uuid-1").
+		WillReturnRows(reminderRows().AddRow(reminderRow(reminders[0])...).AddRow(reminderRow(reminders[1])...))
+
+	repo := NewRepository(mock)
+	got, err := repo.ListReminders(ctx, "uuid-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, reminders, got)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_DeleteReminder(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock pgxmock.PgxPoolIface)
+		wantErr   error
+	}{
+		{
+			name: "deleted",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec("DELETE FROM reminders WHERE id = \\$1 AND event_id = \\$2").
+					WithArgs("uuid-r", "uuid-1").
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+			},
+		},
+		{
+			name: "not found",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec("DELETE FROM reminders").
+					WithArgs("uuid-r", "uuid-1").
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+			},
+			wantErr: ErrReminderNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			err = repo.DeleteReminder(ctx, "uuid-1", "uuid-r")
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_ClaimReminders(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	until := now.Add(ReminderLease)
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	defer mock.Close()
+
+	due := Reminder{Id: "uuid-r", EventId: "uuid-1", OffsetMinutes: 15, Channel: ChannelLog, DueAt: &now, Revision: 2}
+
+	mock.ExpectQuery("UPDATE reminders SET claimed_until = \\$2 WHERE id IN \\(SELECT id FROM reminders "+
+		"WHERE due_at <= \\$1 AND \\(claimed_until IS NULL OR claimed_until <= \\$1\\) ORDER BY due_at LIMIT \\$3 FOR UPDATE SKIP LOCKED\\)").
+		WithArgs(now, until, ReminderBatch).
+		WillReturnRows(reminderRows().AddRow(reminderRow(due)...))
+
+	repo := NewRepository(mock)
+	got, err := repo.ClaimReminders(ctx, now, until, ReminderBatch)
+
+	require.NoError(t, err)
+	assert.Equal(t, []Reminder{due}, got)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_RescheduleReminder(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	next := now.Add(24 * time.Hour)
+	reminder := Reminder{Id: "uuid-r", RemindedThrough: &now, DueAt: &next, Revision: 2}
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock pgxmock.PgxPoolIface)
+		wantErr   error
+	}{
+		{
+			name: "rescheduled",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec("UPDATE reminders SET reminded_through = \\$2, due_at = CASE WHEN revision = \\$4 THEN \\$3 ELSE due_at END, claimed_until = NULL").
+					WithArgs("uuid-r", &now, &next, 2).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+		},
+		{
+			name: "deleted meanwhile",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec("UPDATE reminders").
+					WithArgs("uuid-r", &now, &next, 2).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: ErrReminderNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			err = repo.RescheduleReminder(ctx, &reminder)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func webhookRows() *pgxmock.Rows {
+	return pgxmock.NewRows(strings.Split(webhookColumns, ", "))
+}
+
+func webhookRow(w Webhook) []any {
+	return []any{w.Id, w.Url, w.EventTypes, w.CreatedAt, w.UpdatedAt}
+}
+
+func deliveryRows(extra ...string) *pgxmock.Rows {
+	return pgxmock.NewRows(append(strings.Split(deliveryColumns, ", "), extra...))
+}
+
+func deliveryRow(d Delivery, extra ...any) []any {
+	return append([]any{
+		d.Id, d.WebhookId, d.EventType, d.EventId, d.Payload, d.TraceContext, d.Status, d.Attempts, d.NextAttemptAt,
+		d.LastStatusCode, d.LastError, d.DeliveredAt, d.CreatedAt, d.UpdatedAt,
+	}, extra...)
+}
+
+func TestRepository_SaveWebhook(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	defer mock.Close()
+
+	saved := Webhook{Id: "uuid-w", Url: "https://example.com/hooks", EventTypes: []string{}, CreatedAt: now, UpdatedAt: now}
+
+	// Webhooks without event types subscribe to every change, stored as an empty array rather than NULL
+	mock.ExpectQuery("INSERT INTO webhooks \\(url, event_types, secret\\) VALUES \\(\\$1, \\$2, \\$3\\) RETURNING id, url, event_types").
+		WithArgs("https://example.com/hooks", []string{}, "0123456789abcdef").
+		WillReturnRows(webhookRows().AddRow(webhookRow(saved)...))
+
+	repo := NewRepository(mock)
+	got, err := repo.SaveWebhook(ctx, &Webhook{Url: "https://example.com/hooks", Secret: "0123456789abcdef"})
+
+	require.NoError(t, err)
+	assert.Equal(t, saved, *got)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_UpdateWebhook(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	webhook := Webhook{Id: "uuid-w", Url: "https://example.com/hooks", EventTypes: []string{EventDeleted}}
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock pgxmock.PgxPoolIface)
+		wantErr   error
+	}{
+		{
+			name: "updated keeping the secret",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("UPDATE webhooks SET url = \\$1, event_types = \\$2, secret = COALESCE\\(NULLIF\\(\\$3, ''\\), secret\\)").
+					WithArgs(webhook.Url, webhook.EventTypes, "", "uuid-w").
+					WillReturnRows(webhookRows().AddRow(webhookRow(Webhook{Id: "uuid-w", Url: webhook.Url, EventTypes: webhook.EventTypes, CreatedAt: now, UpdatedAt: now})...))
+			},
+		},
+		{
+			name: "not found",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("UPDATE webhooks").
+					WithArgs(webhook.Url, webhook.EventTypes, "", "uuid-w").
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: ErrWebhookNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			got, err := repo.UpdateWebhook(ctx, &webhook)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, webhook.Url, got.Url)
+				assert.Empty(t, got.Secret)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_DeleteWebhook(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	defer mock.Close()
+
+	mock.ExpectExec("DELETE FROM webhooks WHERE id = \\$1").
+		WithArgs("uuid-w").
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+	repo := NewRepository(mock)
+	err = repo.DeleteWebhook(ctx, "uuid-w")
+
+	require.ErrorIs(t, err, ErrWebhookNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_EnqueueDeliveries(t *testing.T) {
+	t.Parallel()
+
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), testSpanContext)
+	message := OutboxMessage{Id: 42, Type: EventDeleted, AggregateId: "uuid-1", Payload: json.RawMessage(`{"id":"uuid-1"}`)}
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	defer mock.Close()
+
+	var traceContext map[string]string
+
+	// Publishing a message again queues no delivery twice
+	mock.ExpectExec("INSERT INTO webhook_deliveries \\(webhook_id, outbox_id, event_type, event_id, payload, trace_context\\) "+
+		"SELECT id, \\$1, \\$2, \\$3, \\$4, \\$5 FROM webhooks WHERE cardinality\\(event_types\\) = 0 OR \\$2 = ANY\\(event_types\\) "+
+		"ON CONFLICT \\(webhook_id, outbox_id\\) DO NOTHING").
+		WithArgs(int64(42), EventDeleted, "uuid-1", json.RawMessage(`{"id":"uuid-1"}`), capture(&traceContext)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 2))
+
+	repo := NewRepository(mock)
+	n, err := repo.EnqueueDeliveries(ctx, message)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", traceContext["traceparent"])
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// testSpanContext is the span context of the traceparent 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.
+var testSpanContext = trace.NewSpanContext(trace.SpanContextConfig{
+	TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+	SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	TraceFlags: trace.FlagsSampled,
+	Remote:     true,
+})
+
+func TestWriteOutbox(t *testing.T) {
+	t.Parallel()
+
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), testSpanContext)
+
+	tests := []struct {
+		name         string
+		eventType    string
+		events       []Event
+		wantIds      []string
+		wantPayloads []string
+	}{
+		{
+			name:         "created",
+			eventType:    EventCreated,
+			events:       []Event{{Id: "uuid-1", Title: "Standup"}, {Id: "uuid-2", Title: "Review"}},
+			wantIds:      []string{"uuid-1", "uuid-2"},
+			wantPayloads: []string{`"title":"Standup"`, `"title":"Review"`},
+		},
+		{
+			name:         "deleted events only carry their id",
+			eventType:    EventDeleted,
+			events:       []Event{{Id: "uuid-1", Title: "Standup"}},
+			wantIds:      []string{"uuid-1"},
+			wantPayloads: []string{`{"id":"uuid-1"}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			var payloads []string
+			var traceContext map[string]string
+
+			mock.ExpectBegin()
+			mock.ExpectExec("INSERT INTO outbox \\(type, aggregate_id, payload, trace_context\\) "+
+				"SELECT \\$1, input.id, input.payload, \\$4 FROM unnest\\(\\$2::uuid\\[\\], \\$3::jsonb\\[\\]\\) WITH ORDINALITY AS input \\(id, payload, n\\) "+
+				"ORDER BY input.n").
+				WithArgs(tt.eventType, tt.wantIds, capture(&payloads), capture(&traceContext)).
+				WillReturnResult(pgxmock.NewResult("INSERT", int64(len(tt.events))))
+
+			tx, err := mock.Begin(ctx)
+			require.NoError(t, err)
+
+			err = writeOutbox(ctx, tx, tt.eventType, tt.events)
+			require.NoError(t, err)
+
+			require.Len(t, payloads, len(tt.wantPayloads))
+			for i, want := range tt.wantPayloads {
+				assert.Contains(t, payloads[i], want)
+			}
+
+			assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", traceContext["traceparent"])
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// capturer matches any argument, keeping it in the value pointed to by target.
+type capturer[T any] struct {
+	target *T
+}
+
+func capture[T any](target *T) pgxmock.Argument {
+	return capturer[T]{target: target}
+}
+
+func (c capturer[T]) Match(v any) bool {
+	value, ok := v.(T)
+	if ok {
+		*c.target = value
+	}
+
+	return ok
+}
+
+func TestRepository_ListDeliveries(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	defer mock.Close()
+
+	dead := Delivery{
+		Id: "uuid-d", WebhookId: "uuid-w", EventType: EventCreated, EventId: "uuid-1", Payload: json.RawMessage(`{"id":"uuid-1"}`),
+		Status: DeliveryDead, Attempts: WebhookMaxAttempts, LastStatusCode: 500, LastError: "webhook answered 500 Internal Server Error",
+	}
+
+	mock.ExpectQuery("SELECT (.+) FROM webhook_deliveries WHERE webhook_id = \\$1 AND \\(\\$2 = '' OR status = \\$2\\) "+
+		"ORDER BY created_at DESC, id LIMIT \\$3").
+		WithArgs("uuid-w", DeliveryDead, MaxDeliveries).
+		WillReturnRows(deliveryRows().AddRow(deliveryRow(dead)...))
+
+	repo := NewRepository(mock)
+	got, err := repo.ListDeliveries(ctx, "uuid-w", DeliveryDead)
+
+	require.NoError(t, err)
+	assert.Equal(t, []Delivery{dead}, got)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_RedeliverDelivery(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock pgxmock.PgxPoolIface)
+		wantErr   error
+	}{
+		{
+			name: "made pending again",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("UPDATE webhook_deliveries SET status = 'pending', attempts = 0, next_attempt_at = now\\(\\)").
+					WithArgs("uuid-d", "uuid-w").
+					WillReturnRows(deliveryRows().AddRow(deliveryRow(Delivery{Id: "uuid-d", WebhookId: "uuid-w", Status: DeliveryPending})...))
+			},
+		},
+		{
+			name: "not found",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("UPDATE webhook_deliveries").
+					WithArgs("uuid-d", "uuid-w").
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: ErrDeliveryNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			got, err := repo.RedeliverDelivery(ctx, "uuid-w", "uuid-d")
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, DeliveryPending, got.Status)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_ClaimDeliveries(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	until := now.Add(WebhookLease)
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	defer mock.Close()
+
+	due := PendingDelivery{
+		Delivery: Delivery{
+			Id: "uuid-d", WebhookId: "uuid-w", EventType: EventDeleted, EventId: "uuid-1", Payload: json.RawMessage(`{"id":"uuid-1"}`),
+			TraceContext: map[string]string{}, Status: DeliveryPending, NextAttemptAt: &now,
+		},
+		Url:    "https://example.com/hooks",
+		Secret: "0123456789abcdef",
+	}
+
+	mock.ExpectQuery("WITH claimed AS \\(UPDATE webhook_deliveries SET claimed_until = \\$2 WHERE id IN \\(SELECT id FROM webhook_deliveries "+
+		"WHERE status = 'pending' AND next_attempt_at <= \\$1 AND \\(claimed_until IS NULL OR claimed_until <= \\$1\\) "+
+		"ORDER BY next_attempt_at LIMIT \\$3 FOR UPDATE SKIP LOCKED\\)").
+		WithArgs(now, until, WebhookBatch).
+		WillReturnRows(deliveryRows("url", "secret").AddRow(deliveryRow(due.Delivery, due.Url, due.Secret)...))
+
+	repo := NewRepository(mock)
+	got, err := repo.ClaimDeliveries(ctx, now, until, WebhookBatch)
+
+	require.NoError(t, err)
+	assert.Equal(t, []PendingDelivery{due}, got)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_RecordDelivery(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	defer mock.Close()
+
+	delivery := Delivery{Id: "uuid-d", Status: DeliverySucceeded, Attempts: 2, LastStatusCode: 204, DeliveredAt: &now}
+
+	mock.ExpectExec("UPDATE webhook_deliveries SET status = \\$2, attempts = \\$3, next_attempt_at = \\$4, last_status_code = \\$5, "+
+		"last_error = \\$6, delivered_at = \\$7, claimed_until = NULL").
+		WithArgs("uuid-d", DeliverySucceeded, 2, (*time.Time)(nil), 204, "", &now).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	repo := NewRepository(mock)
+	err = repo.RecordDelivery(ctx, &delivery)
+
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_ListenChanges(t *testing.T) {
+	t.Parallel()
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	defer mock.Close()
+
+	// Listening takes a connection of its own, which the mocked pool cannot give
+	repo := NewRepository(mock)
+	err = repo.ListenChanges(context.Background(), func() { t.Fatal("not listening") }, func(Change) {})
+
+	require.ErrorContains(t, err, "failed to acquire connection")
+}
+
+func TestRepository_DispatchOutbox(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	rows := func() *pgxmock.Rows {
+		return pgxmock.NewRows(strings.Split(outboxColumns, ", ")).
+			AddRow(int64(1), EventCreated, "uuid-1", json.RawMessage(`{"id":"uuid-1"}`), map[string]string{}, 0, now).
+			AddRow(int64(2), EventDeleted, "uuid-2", json.RawMessage(`{"id":"uuid-2"}`), map[string]string{}, OutboxMaxAttempts-1, now)
+	}
+
+	lock := "SELECT " + regexp.QuoteMeta(outboxColumns) + " FROM outbox " +
+		"WHERE delivered_at IS NULL AND dead_at IS NULL AND next_attempt_at <= now\\(\\) ORDER BY id LIMIT \\$1 FOR UPDATE SKIP LOCKED"
+	deliver := "UPDATE outbox SET delivered_at = now\\(\\), next_attempt_at = NULL, attempts = attempts \\+ 1, last_error = '' WHERE id = ANY\\(\\$1\\)"
+	fail := "UPDATE outbox SET attempts = \\$2, last_error = \\$3, next_attempt_at = \\$4, dead_at = \\$5 WHERE id = \\$1"
+
+	tests := []struct {
+		name       string
+		publishErr map[int64]error
+		mockSetup  func(mock pgxmock.PgxPoolIface)
+		wantErr    bool
+		want       int
+	}{
+		{
+			name: "publishes and marks delivered",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lock).WithArgs(OutboxBatch).WillReturnRows(rows())
+				mock.ExpectExec(deliver).WithArgs([]int64{1, 2}).WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+				mock.ExpectCommit()
+			},
+			want: 2,
+		},
+		{
+			name:       "failed messages are retried later",
+			publishErr: map[int64]error{1: errors.New("queue down")},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lock).WithArgs(OutboxBatch).WillReturnRows(rows())
+				mock.ExpectExec(fail).
+					WithArgs(int64(1), 1, "queue down", pgxmock.AnyArg(), (*time.Time)(nil)).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectExec(deliver).WithArgs([]int64{2}).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
+			},
+			want: 1,
+		},
+		{
+			name:       "messages failing their last attempt are dead",
+			publishErr: map[int64]error{2: errors.New("queue down")},
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lock).WithArgs(OutboxBatch).WillReturnRows(rows())
+				mock.ExpectExec(fail).
+					WithArgs(int64(2), OutboxMaxAttempts, "queue down", (*time.Time)(nil), pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectExec(deliver).WithArgs([]int64{1}).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
+			},
+			want: 1,
+		},
+		{
+			name: "nothing pending",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lock).WithArgs(OutboxBatch).WillReturnRows(pgxmock.NewRows(strings.Split(outboxColumns, ", ")))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "lock failure",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lock).WithArgs(OutboxBatch).WillReturnError(errors.New("query error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "commit failure",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lock).WithArgs(OutboxBatch).WillReturnRows(rows())
+				mock.ExpectExec(deliver).WithArgs([]int64{1, 2}).WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+				mock.ExpectCommit().WillReturnError(errors.New("commit error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			got, err := repo.DispatchOutbox(ctx, OutboxBatch, func(_ context.Context, message OutboxMessage) error {
+				return tt.publishErr[message.Id]
+			})
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_OutboxBacklog(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	defer mock.Close()
+
+	mock.ExpectQuery("SELECT count\\(\\*\\), min\\(created_at\\) FROM outbox WHERE delivered_at IS NULL AND dead_at IS NULL").
+		WillReturnRows(pgxmock.NewRows([]string{"count", "min"}).AddRow(int64(3), &now))
+
+	repo := NewRepository(mock)
+	pending, oldest, err := repo.OutboxBacklog(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), pending)
+	require.NotNil(t, oldest)
+	assert.True(t, now.Equal(*oldest))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_PurgeOutbox(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cutoff := time.Now().Add(-7 * 24 * time.Hour).Truncate(time.Second)
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	defer mock.Close()
+
+	mock.ExpectExec("DELETE FROM outbox WHERE delivered_at < \\$1").
+		WithArgs(cutoff).
+		WillReturnResult(pgxmock.NewResult("DELETE", 12))
+
+	repo := NewRepository(mock)
+	purged, err := repo.PurgeOutbox(ctx, cutoff)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), purged)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Register(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock pgxmock.PgxPoolIface)
+		want      *Registration
+		wantErr   error
+	}{
+		{
+			name: "seat left",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockRegistrationsQuery).WithArgs("uuid-1").WillReturnRows(pgxmock.NewRows([]string{"capacity"}).AddRow(10))
+				mock.ExpectQuery(countRegistrationsQuery).WithArgs("uuid-1").WillReturnRows(pgxmock.NewRows([]string{"confirmed", "waitlisted"}).AddRow(9, 0))
+				mock.ExpectQuery("INSERT INTO registrations \\(event_id, email, name, status\\) VALUES \\(\\$1, \\$2, \\$3, \\$4\\)").
+					WithArgs("uuid-1", "ada@example.com", "", RegistrationConfirmed).
+					WillReturnRows(registrationRows().AddRow(registrationRow(Registration{Id: "uuid-r", EventId: "uuid-1", Email: "ada@example.com", Status: RegistrationConfirmed})...))
+				mock.ExpectCommit()
+			},
+			want: &Registration{Id: "uuid-r", EventId: "uuid-1", Email: "ada@example.com", Status: RegistrationConfirmed},
+		},
+		{
+			name: "full event",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockRegistrationsQuery).WithArgs("uuid-1").WillReturnRows(pgxmock.NewRows([]string{"capacity"}).AddRow(10))
+				mock.ExpectQuery(countRegistrationsQuery).WithArgs("uuid-1").WillReturnRows(pgxmock.NewRows([]string{"confirmed", "waitlisted"}).AddRow(10, 3))
+				mock.ExpectQuery("INSERT INTO registrations").
+					WithArgs("uuid-1", "ada@example.com", "", RegistrationWaitlisted).
+					WillReturnRows(registrationRows().AddRow(registrationRow(Registration{Id: "uuid-r", EventId: "uuid-1", Email: "ada@example.com", Status: RegistrationWaitlisted})...))
+				mock.ExpectCommit()
+			},
+			want: &Registration{Id: "uuid-r", EventId: "uuid-1", Email: "ada@example.com", Status: RegistrationWaitlisted, Position: 4},
+		},
+		{
+			name: "no capacity",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockRegistrationsQuery).WithArgs("uuid-1").WillReturnRows(pgxmock.NewRows([]string{"capacity"}).AddRow(0))
+				mock.ExpectQuery(countRegistrationsQuery).WithArgs("uuid-1").WillReturnRows(pgxmock.NewRows([]string{"confirmed", "waitlisted"}).AddRow(500, 0))
+				mock.ExpectQuery("INSERT INTO registrations").
+					WithArgs("uuid-1", "ada@example.com", "", RegistrationConfirmed).
+					WillReturnRows(registrationRows().AddRow(registrationRow(Registration{Id: "uuid-r", EventId: "uuid-1", Email: "ada@example.com", Status: RegistrationConfirmed})...))
+				mock.ExpectCommit()
+			},
+			want: &Registration{Id: "uuid-r", EventId: "uuid-1", Email: "ada@example.com", Status: RegistrationConfirmed},
+		},
+		{
+			name: "event not found",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockRegistrationsQuery).WithArgs("uuid-1").WillReturnError(pgx.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: ErrEventNotFound,
+		},
+		{
+			name: "already registered",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockRegistrationsQuery).WithArgs("uuid-1").WillReturnRows(pgxmock.NewRows([]string{"capacity"}).AddRow(10))
+				mock.ExpectQuery(countRegistrationsQuery).WithArgs("uuid-1").WillReturnRows(pgxmock.NewRows([]string{"confirmed", "waitlisted"}).AddRow(1, 0))
+				mock.ExpectQuery("INSERT INTO registrations").
+					WithArgs("uuid-1", "ada@example.com", "", RegistrationConfirmed).
+					WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "registrations_event_id_email_idx"})
+				mock.ExpectRollback()
+			},
+			wantErr: ErrRegistrationExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			got, err := repo.Register(ctx, &Registration{EventId: "uuid-1", Email: "ada@example.com"})
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_ListRegistrations(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	defer mock.Close()
+
+	registrations := []Registration{
+		{Id: "uuid-a", EventId: "uuid-1", Email: "ada@example.com", Status: RegistrationConfirmed},
+		{Id: "uuid-b", EventId: "uuid-1", Email: "bob@example.com", Status: RegistrationWaitlisted, Position: 1},
+	}
+
+	mock.ExpectQuery("SELECT (.+) FROM registrations WHERE event_id = \\$1 ORDER BY status, seq").
+		WithArgs("uuid-1").
+		WillReturnRows(pgxmock.NewRows(append(strings.Split(registrationColumns, ", "), "position")).
+			AddRow(append(registrationRow(registrations[0]), 0)...).
+			AddRow(append(registrationRow(registrations[1]), 1)...))
+
+	repo := NewRepository(mock)
+	got, err := repo.ListRegistrations(ctx, "uuid-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, registrations, got)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_CancelRegistration(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock pgxmock.PgxPoolIface)
+		wantErr   error
+	}{
+		{
+			name: "seat goes to the waitlist",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockRegistrationsQuery).WithArgs("uuid-1").WillReturnRows(pgxmock.NewRows([]string{"capacity"}).AddRow(10))
+				mock.ExpectExec("DELETE FROM registrations WHERE id = \\$1 AND event_id = \\$2").
+					WithArgs("uuid-r", "uuid-1").
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				mock.ExpectQuery(countRegistrationsQuery).WithArgs("uuid-1").WillReturnRows(pgxmock.NewRows([]string{"confirmed", "waitlisted"}).AddRow(9, 4))
+				mock.ExpectExec(promoteRegistrationsQuery).WithArgs("uuid-1", 1).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "empty waitlist",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockRegistrationsQuery).WithArgs("uuid-1").WillReturnRows(pgxmock.NewRows([]string{"capacity"}).AddRow(10))
+				mock.ExpectExec("DELETE FROM registrations").
+					WithArgs("uuid-r", "uuid-1").
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				mock.ExpectQuery(countRegistrationsQuery).WithArgs("uuid-1").WillReturnRows(pgxmock.NewRows([]string{"confirmed", "waitlisted"}).AddRow(9, 0))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "not found",
+			mockSetup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockRegistrationsQuery).WithArgs("uuid-1").WillReturnRows(pgxmock.NewRows([]string{"capacity"}).AddRow(10))
+				mock.ExpectExec("DELETE FROM registrations").
+					WithArgs("uuid-r", "uuid-1").
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrRegistrationNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+
+			defer mock.Close()
+
+			tt.mockSetup(mock)
+
+			repo := NewRepository(mock)
+			err = repo.CancelRegistration(ctx, "uuid-1", "uuid-r")
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_Register_locksBeforeCounting(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	defer mock.Close()
+
+	mock.MatchExpectationsInOrder(true)
+
+	// Each registration counts the seats taken only once it holds the event row, and places itself by that count
+	// before it commits and lets the next one in
+	registrations := []struct {
+		confirmed, waitlisted int
+		status                string
+		position              int
+	}{
+		{confirmed: 0, waitlisted: 0, status: RegistrationConfirmed},
+		{confirmed: 1, waitlisted: 0, status: RegistrationWaitlisted, position: 1},
+		{confirmed: 1, waitlisted: 1, status: RegistrationWaitlisted, position: 2},
+	}
+
+	for i, r := range registrations {
+		email := fmt.Sprintf("user%d@example.com", i)
+		id := fmt.Sprintf("uuid-r%d", i)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockRegistrationsQuery).WithArgs("uuid-1").WillReturnRows(pgxmock.NewRows([]string{"capacity"}).AddRow(1))
+		mock.ExpectQuery(countRegistrationsQuery).WithArgs("uuid-1").
+			WillReturnRows(pgxmock.NewRows([]string{"confirmed", "waitlisted"}).AddRow(r.confirmed, r.waitlisted))
+		mock.ExpectQuery("INSERT INTO registrations \\(event_id, email, name, status\\) VALUES \\(\\$1, \\$2, \\$3, \\$4\\) RETURNING").
+			WithArgs("uuid-1", email, "", r.status).
+			WillReturnRows(registrationRows().AddRow(registrationRow(Registration{Id: id, EventId: "uuid-1", Email: email, Status: r.status})...))
+		mock.ExpectCommit()
+	}
+
+	repo := NewRepository(mock)
+
+	for i, r := range registrations {
+		got, err := repo.Register(ctx, &Registration{EventId: "uuid-1", Email: fmt.Sprintf("user%d@example.com", i)})
+		require.NoError(t, err)
+		assert.Equal(t, r.status, got.Status)
+		assert.Equal(t, r.position, got.Position)
+	}
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,35 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// TrashPurger hard-deletes the events that have been in the trash for longer than the retention period.
+type TrashPurger struct {
+	name       string
+	repository RepositoryInterface
+	retention  time.Duration
+}
+
+func NewTrashPurger(repository RepositoryInterface, retention time.Duration) *TrashPurger {
+	return &TrashPurger{
+		name:       "trash-purger",
+		repository: repository,
+		retention:  retention,
+	}
+}
+
+func (p *TrashPurger) Purge(ctx context.Context) (int64, error) {
+	purged, err := p.repository.PurgeEvents(ctx, time.Now().Add(-p.retention))
+	if err != nil {
+		log.Error().Str("component", p.name).Err(err).Msg("failed to purge trash")
+		return 0, err
+	}
+
+	log.Info().Str("component", p.name).Int64("purged", purged).Msg("trash purged")
+
+	return purged, nil
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrashPurger_Purge(t *testing.T) {
+	t.Parallel()
+
+	retention := 24 * time.Hour
+	aroundCutoff := mock.MatchedBy(func(cutoff time.Time) bool {
+		return time.Since(cutoff.Add(retention)) < time.Minute
+	})
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockRepository)
+		mockRepo.On("PurgeEvents", mock.Anything, aroundCutoff).Return(int64(3), nil)
+
+		purged, err := NewTrashPurger(mockRepo, retention).Purge(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), purged)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockRepository)
+		mockRepo.On("PurgeEvents", mock.Anything, aroundCutoff).Return(int64(0), errors.New("db error"))
+
+		_, err := NewTrashPurger(mockRepo, retention).Purge(context.Background())
+		require.Error(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,287 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/teambition/rrule-go"
+)
+
+const (
+	// MaxOccurrenceWindow is the longest window occurrences can be expanded for in a single request.
+	MaxOccurrenceWindow = 366 * 24 * time.Hour
+	// MaxOccurrences is the most occurrences a single request expands to.
+	MaxOccurrences = 5000
+	// MaxRecurrenceDates is the most EXDATEs (and RDATEs) an event can have.
+	MaxRecurrenceDates = 1000
+	// maxSeriesEndIterations bounds the scan for the last occurrence of a finite series;
+	// longer series are treated as unbounded.
+	maxSeriesEndIterations = 100000
+)
+
+var ErrTooManyOccurrences = errors.New("too many occurrences, narrow the time window")
+
+// Location returns the IANA time zone an event is expanded in, UTC by default.
+func Location(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q: %w", name, err)
+	}
+
+	return loc, nil
+}
+
+func IsRecurring(event Event) bool {
+	return event.RRule != "" || len(event.RDates) > 0
+}
+
+// RecurrenceSet builds the RFC 5545 recurrence set of the event: its start time, the occurrences of its
+// RRULE and its RDATEs, minus its EXDATEs, all of them computed in the event's time zone.
+func RecurrenceSet(event Event) (*rrule.Set, error) {
+	loc, err := Location(event.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	dtstart := event.StartTime.In(loc)
+
+	set := &rrule.Set{}
+	set.RDate(dtstart)
+
+	if event.RRule != "" {
+		options, err := rrule.StrToROptionInLocation(event.RRule, loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rrule: %w", err)
+		}
+
+		if !options.Dtstart.IsZero() {
+			return nil, errors.New("invalid rrule: DTSTART is not allowed, start_time is used instead")
+		}
+
+		if options.Freq == rrule.SECONDLY || options.Freq == rrule.MINUTELY {
+			return nil, errors.New("invalid rrule: frequencies below HOURLY are not supported")
+		}
+
+		options.Dtstart = dtstart
+
+		rule, err := rrule.NewRRule(*options)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rrule: %w", err)
+		}
+
+		set.RRule(rule)
+	}
+
+	for _, rdate := range event.RDates {
+		set.RDate(rdate.In(loc))
+	}
+
+	for _, exdate := range event.ExDates {
+		set.ExDate(exdate.In(loc))
+	}
+
+	return set, nil
+}
+
+// SeriesEnd returns when the last occurrence of the event ends, or nil if it recurs forever.
+func SeriesEnd(event Event) (*time.Time, error) {
+	end := event.EndTime
+	if !IsRecurring(event) {
+		return &end, nil
+	}
+
+	set, err := RecurrenceSet(event)
+	if err != nil {
+		return nil, err
+	}
+
+	if rule := set.GetRRule(); rule != nil && rule.OrigOptions.Count == 0 && rule.OrigOptions.Until.IsZero() {
+		return nil, nil //nolint:nilnil
+	}
+
+	next := set.Iterator()
+
+	for range maxSeriesEndIterations {
+		start, ok := next()
+		if !ok {
+			return &end, nil
+		}
+
+		end = OccurrenceEnd(event, start)
+	}
+
+	return nil, nil //nolint:nilnil
+}
+
+// OccurrenceEnd returns when the occurrence of the event starting at start ends. All-day occurrences span as many
+// days as the event does, whether or not a DST change makes those days shorter or longer.
+func OccurrenceEnd(event Event, start time.Time) time.Time {
+	loc, err := Location(event.TimeZone)
+	if !event.AllDay || err != nil {
+		return start.Add(event.EndTime.Sub(event.StartTime))
+	}
+
+	y1, m1, d1 := event.StartTime.In(loc).Date()
+	y2, m2, d2 := event.EndTime.In(loc).Date()
+	days := time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC).Sub(time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)) / (24 * time.Hour)
+
+	return start.In(loc).AddDate(0, 0, int(days))
+}
+
+// IsOccurrence tells whether an occurrence of the event originally starts at the given time.
+func IsOccurrence(event Event, recurrenceId time.Time) (bool, error) {
+	set, err := RecurrenceSet(event)
+	if err != nil {
+		return false, err
+	}
+
+	return set.After(recurrenceId, true).Equal(recurrenceId), nil
+}
+
+// ExpandOccurrences returns the occurrences of the given events overlapping the window, ordered by start time,
+// with their overrides applied.
+func ExpandOccurrences(series []Series, window TimeWindow) ([]Occurrence, error) {
+	occurrences := make([]Occurrence, 0)
+
+	for _, s := range series {
+		expanded, err := expandSeries(s, window)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand event %s: %w", s.Event.Id, err)
+		}
+
+		occurrences = append(occurrences, expanded...)
+		if len(occurrences) > MaxOccurrences {
+			return nil, ErrTooManyOccurrences
+		}
+	}
+
+	slices.SortStableFunc(occurrences, func(a, b Occurrence) int {
+		if c := a.StartTime.Compare(b.StartTime); c != 0 {
+			return c
+		}
+
+		if a.EventId < b.EventId {
+			return -1
+		}
+
+		if a.EventId > b.EventId {
+			return 1
+		}
+
+		return 0
+	})
+
+	return occurrences, nil
+}
+
+// NextOccurrence returns the first occurrence of the series starting after the given time, with its override applied,
+// or nil when none starts within MaxOccurrenceWindow of it.
+func NextOccurrence(s Series, after time.Time) (*Occurrence, error) {
+	horizon := after.Add(MaxOccurrenceWindow)
+
+	// Windows grow as they come up empty, so sparse series are not expanded a week at a time
+	for window := (TimeWindow{From: after, To: after.Add(7 * 24 * time.Hour)}); window.From.Before(horizon); {
+		occurrences, err := expandSeries(s, window)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand event %s: %w", s.Event.Id, err)
+		}
+
+		var next *Occurrence
+
+		for i, o := range occurrences {
+			if o.StartTime.After(after) && (next == nil || o.StartTime.Before(next.StartTime)) {
+				next = &occurrences[i]
+			}
+		}
+
+		if next != nil {
+			return next, nil
+		}
+
+		window.From, window.To = window.To, earlierOf(window.To.Add(2*window.To.Sub(window.From)), horizon)
+	}
+
+	return nil, nil //nolint:nilnil
+}
+
+func expandSeries(s Series, window TimeWindow) ([]Occurrence, error) {
+	loc, err := Location(s.Event.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	set, err := RecurrenceSet(s.Event)
+	if err != nil {
+		return nil, err
+	}
+
+	// All-day occurrences may last an hour longer than the event itself across a DST change
+	lookback := s.Event.EndTime.Sub(s.Event.StartTime)
+	if s.Event.AllDay {
+		lookback += time.Hour
+	}
+
+	overridden := make(map[int64]bool, len(s.Overrides))
+	for _, o := range s.Overrides {
+		overridden[o.RecurrenceId.Unix()] = true
+	}
+
+	var occurrences []Occurrence
+
+	// Occurrences scheduled to overlap the window, unless moved or cancelled
+	for _, start := range set.Between(window.From.Add(-lookback), window.To, false) {
+		occurrence := newOccurrence(s.Event, start)
+		if overridden[start.Unix()] || !occurrence.EndTime.After(window.From) {
+			continue
+		}
+
+		occurrences = append(occurrences, occurrence)
+	}
+
+	// Overridden occurrences, wherever they have been moved to
+	for _, o := range s.Overrides {
+		if o.Cancelled || !set.After(o.RecurrenceId, true).Equal(o.RecurrenceId) {
+			continue
+		}
+
+		occurrence := newOccurrence(s.Event, o.RecurrenceId.In(loc))
+		occurrence.Overridden = true
+
+		if o.Title != "" {
+			occurrence.Title = o.Title
+		}
+
+		if o.Description != "" {
+			occurrence.Description = o.Description
+		}
+
+		if !o.StartTime.IsZero() {
+			occurrence.StartTime, occurrence.EndTime = o.StartTime.In(loc), o.EndTime.In(loc)
+		}
+
+		if occurrence.StartTime.Before(window.To) && occurrence.EndTime.After(window.From) {
+			occurrences = append(occurrences, occurrence)
+		}
+	}
+
+	return occurrences, nil
+}
+
+func newOccurrence(event Event, start time.Time) Occurrence {
+	return Occurrence{
+		EventId:      event.Id,
+		RecurrenceId: start,
+		Title:        event.Title,
+		Description:  event.Description,
+		StartTime:    start,
+		EndTime:      OccurrenceEnd(event, start),
+		TimeZone:     event.TimeZone,
+		AllDay:       event.AllDay,
+	}
+}
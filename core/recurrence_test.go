@@ -0,0 +1,371 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecurrenceSet(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		event   Event
+		wantErr string
+	}{
+		{name: "single event", event: Event{StartTime: start}},
+		{name: "rrule", event: Event{StartTime: start, RRule: "FREQ=DAILY;COUNT=3"}},
+		{name: "rrule with prefix", event: Event{StartTime: start, RRule: "RRULE:FREQ=DAILY;COUNT=3"}},
+		{name: "unknown time zone", event: Event{StartTime: start, TimeZone: "Nowhere/Special"}, wantErr: "unknown time zone"},
+		{name: "malformed rrule", event: Event{StartTime: start, RRule: "FREQ=DAILY;COUNT=many"}, wantErr: "invalid rrule"},
+		{name: "dtstart in rrule", event: Event{StartTime: start, RRule: "DTSTART:20250303T090000Z\nRRULE:FREQ=DAILY"}, wantErr: "DTSTART"},
+		{name: "minutely", event: Event{StartTime: start, RRule: "FREQ=MINUTELY"}, wantErr: "below HOURLY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := RecurrenceSet(tt.event)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSeriesEnd(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	tests := []struct {
+		name  string
+		event Event
+		want  *time.Time
+	}{
+		{
+			name:  "single event",
+			event: Event{StartTime: start, EndTime: end},
+			want:  &end,
+		},
+		{
+			name:  "forever",
+			event: Event{StartTime: start, EndTime: end, RRule: "FREQ=WEEKLY"},
+			want:  nil,
+		},
+		{
+			name:  "count",
+			event: Event{StartTime: start, EndTime: end, RRule: "FREQ=DAILY;COUNT=3"},
+			want:  timePtr(time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:  "until",
+			event: Event{StartTime: start, EndTime: end, RRule: "FREQ=WEEKLY;UNTIL=20250317T090000Z"},
+			want:  timePtr(time.Date(2025, 3, 17, 10, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:  "rdate after the last rule occurrence",
+			event: Event{StartTime: start, EndTime: end, RRule: "FREQ=DAILY;COUNT=2", RDates: []time.Time{start.AddDate(0, 1, 0)}},
+			want:  timePtr(time.Date(2025, 4, 3, 10, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:  "last occurrence excluded",
+			event: Event{StartTime: start, EndTime: end, RRule: "FREQ=DAILY;COUNT=3", ExDates: []time.Time{start.AddDate(0, 0, 2)}},
+			want:  timePtr(time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := SeriesEnd(tt.event)
+			require.NoError(t, err)
+
+			if tt.want == nil {
+				assert.Nil(t, got)
+			} else {
+				require.NotNil(t, got)
+				assert.True(t, tt.want.Equal(*got), "want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestIsOccurrence(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	event := Event{StartTime: start, EndTime: start.Add(time.Hour), RRule: "FREQ=WEEKLY", ExDates: []time.Time{start.AddDate(0, 0, 14)}}
+
+	for _, tt := range []struct {
+		name         string
+		recurrenceId time.Time
+		want         bool
+	}{
+		{name: "first", recurrenceId: start, want: true},
+		{name: "next week", recurrenceId: start.AddDate(0, 0, 7), want: true},
+		{name: "same instant in another zone", recurrenceId: start.AddDate(0, 0, 7).In(time.FixedZone("CET", 3600)), want: true},
+		{name: "excluded", recurrenceId: start.AddDate(0, 0, 14), want: false},
+		{name: "wrong time", recurrenceId: start.AddDate(0, 0, 7).Add(time.Hour), want: false},
+		{name: "before start", recurrenceId: start.AddDate(0, 0, -7), want: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := IsOccurrence(event, tt.recurrenceId)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExpandOccurrences(t *testing.T) {
+	t.Parallel()
+
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
+	// Monday 10:00 in Madrid, the week before the switch to summer time (2025-03-30)
+	start := time.Date(2025, 3, 24, 10, 0, 0, 0, madrid)
+	weekly := Event{
+		Id:        "weekly",
+		Title:     "Standup",
+		StartTime: start,
+		EndTime:   start.Add(15 * time.Minute),
+		TimeZone:  "Europe/Madrid",
+		RRule:     "FREQ=WEEKLY;COUNT=4",
+	}
+	single := Event{
+		Id:        "single",
+		Title:     "Kickoff",
+		StartTime: time.Date(2025, 3, 31, 7, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2025, 3, 31, 8, 0, 0, 0, time.UTC),
+	}
+	window := TimeWindow{From: time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC)}
+
+	t.Run("keeps the wall clock time across DST", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := ExpandOccurrences([]Series{{Event: weekly}}, window)
+		require.NoError(t, err)
+		require.Len(t, got, 4)
+
+		for i, o := range got {
+			assert.Equal(t, 10, o.StartTime.Hour(), "occurrence %d", i)
+			assert.Equal(t, madrid, o.StartTime.Location())
+			assert.Equal(t, 15*time.Minute, o.EndTime.Sub(o.StartTime))
+		}
+
+		assert.Equal(t, 9, got[0].StartTime.UTC().Hour())
+		assert.Equal(t, 8, got[1].StartTime.UTC().Hour())
+	})
+
+	t.Run("orders series and single events by start time", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := ExpandOccurrences([]Series{{Event: weekly}, {Event: single}}, window)
+		require.NoError(t, err)
+		require.Len(t, got, 5)
+
+		assert.Equal(t, "weekly", got[0].EventId)
+		assert.Equal(t, "single", got[1].EventId)
+		assert.Equal(t, "weekly", got[2].EventId)
+	})
+
+	t.Run("includes occurrences started before the window", func(t *testing.T) {
+		t.Parallel()
+
+		from := start.AddDate(0, 0, 7).Add(5 * time.Minute)
+		got, err := ExpandOccurrences([]Series{{Event: weekly}}, TimeWindow{From: from, To: from.Add(time.Hour)})
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.True(t, start.AddDate(0, 0, 7).Equal(got[0].StartTime))
+	})
+
+	t.Run("applies exdates and rdates", func(t *testing.T) {
+		t.Parallel()
+
+		event := weekly
+		event.ExDates = []time.Time{start.AddDate(0, 0, 7)}
+		event.RDates = []time.Time{start.AddDate(0, 0, 8)}
+
+		got, err := ExpandOccurrences([]Series{{Event: event}}, window)
+		require.NoError(t, err)
+		require.Len(t, got, 4)
+		assert.True(t, start.AddDate(0, 0, 8).Equal(got[1].StartTime))
+	})
+
+	t.Run("applies overrides", func(t *testing.T) {
+		t.Parallel()
+
+		second, third, fourth := start.AddDate(0, 0, 7), start.AddDate(0, 0, 14), start.AddDate(0, 0, 21)
+		moved := fourth.AddDate(0, 1, 0)
+		series := Series{Event: weekly, Overrides: []Override{
+			{EventId: "weekly", RecurrenceId: second, Title: "Retro", StartTime: second, EndTime: second.Add(time.Hour)},
+			{EventId: "weekly", RecurrenceId: third, StartTime: third, EndTime: third.Add(15 * time.Minute), Cancelled: true},
+			{EventId: "weekly", RecurrenceId: fourth, StartTime: moved, EndTime: moved.Add(15 * time.Minute)},
+			{EventId: "weekly", RecurrenceId: second.Add(time.Minute), Title: "Stale"},
+		}}
+
+		got, err := ExpandOccurrences([]Series{series}, window)
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+
+		assert.Equal(t, "Standup", got[0].Title)
+		assert.False(t, got[0].Overridden)
+
+		assert.Equal(t, "Retro", got[1].Title)
+		assert.True(t, got[1].Overridden)
+		assert.True(t, second.Equal(got[1].RecurrenceId))
+		assert.Equal(t, time.Hour, got[1].EndTime.Sub(got[1].StartTime))
+
+		got, err = ExpandOccurrences([]Series{series}, TimeWindow{From: moved.Add(-time.Hour), To: moved.Add(time.Hour)})
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.True(t, fourth.Equal(got[0].RecurrenceId))
+		assert.True(t, moved.Equal(got[0].StartTime))
+	})
+
+	t.Run("keeps all-day occurrences on whole days across DST", func(t *testing.T) {
+		t.Parallel()
+
+		// Every Sunday, all day; 2025-03-30 only lasts 23 hours in Madrid
+		sunday := time.Date(2025, 3, 23, 0, 0, 0, 0, madrid)
+		event := Event{
+			Id:        "sundays",
+			Title:     "Day off",
+			StartTime: sunday,
+			EndTime:   sunday.AddDate(0, 0, 1),
+			TimeZone:  "Europe/Madrid",
+			AllDay:    true,
+			RRule:     "FREQ=WEEKLY;COUNT=3",
+		}
+
+		got, err := ExpandOccurrences([]Series{{Event: event}}, TimeWindow{From: sunday.AddDate(0, 0, 7).Add(22 * time.Hour), To: sunday.AddDate(0, 0, 8)})
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+
+		assert.True(t, got[0].AllDay)
+		assert.True(t, sunday.AddDate(0, 0, 8).Equal(got[0].EndTime))
+		assert.Equal(t, 23*time.Hour, got[0].EndTime.Sub(got[0].StartTime))
+
+		end, err := SeriesEnd(event)
+		require.NoError(t, err)
+		assert.True(t, sunday.AddDate(0, 0, 15).Equal(*end))
+	})
+
+	t.Run("too many occurrences", func(t *testing.T) {
+		t.Parallel()
+
+		hourly := Event{Id: "hourly", Title: "Ping", StartTime: window.From, EndTime: window.From.Add(time.Minute), RRule: "FREQ=HOURLY"}
+
+		_, err := ExpandOccurrences([]Series{{Event: hourly}, {Event: hourly}, {Event: hourly}, {Event: hourly}, {Event: hourly}, {Event: hourly}}, window)
+		require.ErrorIs(t, err, ErrTooManyOccurrences)
+	})
+
+	t.Run("invalid series", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := ExpandOccurrences([]Series{{Event: Event{Id: "broken", RRule: "FREQ=SOMETIMES"}}}, window)
+		require.Error(t, err)
+	})
+}
+
+func TestNextOccurrence(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 3, 24, 9, 0, 0, 0, time.UTC)
+	weekly := Event{Id: "weekly", Title: "Standup", StartTime: start, EndTime: start.Add(15 * time.Minute), RRule: "FREQ=WEEKLY;COUNT=4"}
+	second, third, fourth := start.AddDate(0, 0, 7), start.AddDate(0, 0, 14), start.AddDate(0, 0, 21)
+	moved := fourth.AddDate(0, 2, 0)
+
+	tests := []struct {
+		name   string
+		series Series
+		after  time.Time
+		want   *time.Time
+	}{
+		{
+			name:   "single event ahead",
+			series: Series{Event: Event{Id: "single", StartTime: start, EndTime: start.Add(time.Hour)}},
+			after:  start.Add(-time.Minute),
+			want:   &start,
+		},
+		{
+			name:   "single event started",
+			series: Series{Event: Event{Id: "single", StartTime: start, EndTime: start.Add(time.Hour)}},
+			after:  start,
+		},
+		{
+			name:   "next of a series",
+			series: Series{Event: weekly},
+			after:  start,
+			want:   &second,
+		},
+		{
+			name: "skips cancelled occurrences",
+			series: Series{Event: weekly, Overrides: []Override{
+				{EventId: "weekly", RecurrenceId: second, StartTime: second, EndTime: second.Add(15 * time.Minute), Cancelled: true},
+			}},
+			after: start,
+			want:  &third,
+		},
+		{
+			name: "finds moved occurrences beyond the first window",
+			series: Series{Event: weekly, Overrides: []Override{
+				{EventId: "weekly", RecurrenceId: fourth, StartTime: moved, EndTime: moved.Add(15 * time.Minute)},
+			}},
+			after: third,
+			want:  &moved,
+		},
+		{
+			name:   "series over",
+			series: Series{Event: weekly},
+			after:  fourth,
+		},
+		{
+			name:   "nothing within reach",
+			series: Series{Event: Event{Id: "far", StartTime: start.AddDate(2, 0, 0), EndTime: start.AddDate(2, 0, 0).Add(time.Hour)}},
+			after:  start,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := NextOccurrence(tt.series, tt.after)
+			require.NoError(t, err)
+
+			if tt.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+
+			require.NotNil(t, got)
+			assert.True(t, tt.want.Equal(got.StartTime), "got %s", got.StartTime)
+		})
+	}
+
+	t.Run("invalid series", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NextOccurrence(Series{Event: Event{Id: "broken", RRule: "FREQ=SOMETIMES"}}, start)
+		require.Error(t, err)
+	})
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,172 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// ReminderBatch is the most reminders a dispatcher claims at once.
+	ReminderBatch = 50
+	// ReminderLease is how long claimed reminders are kept from other dispatchers (see claimLoop).
+	ReminderLease = 10 * time.Minute
+	// ReminderRetryDelay is how long a reminder that failed to be sent waits before being tried again.
+	ReminderRetryDelay = time.Minute
+)
+
+var errNoNotifier = errors.New("no notifier configured for the channel")
+
+// ReminderDispatcher sends the due reminders through the notifier of their channel. Replicas claim the reminders they
+// send, so none is sent twice, and the reminders that came due while no dispatcher ran are caught up on as long as
+// their occurrence has not ended.
+type ReminderDispatcher struct {
+	name       string
+	repository RepositoryInterface
+	notifiers  map[string]Notifier
+}
+
+func NewReminderDispatcher(repository RepositoryInterface, notifiers map[string]Notifier) *ReminderDispatcher {
+	return &ReminderDispatcher{
+		name:       "reminder-dispatcher",
+		repository: repository,
+		notifiers:  notifiers,
+	}
+}
+
+// Dispatch sends the due reminders and returns the number of notifications sent. A reminder that fails is retried
+// after ReminderRetryDelay.
+func (d *ReminderDispatcher) Dispatch(ctx context.Context) (int, error) {
+	loop := claimLoop[Reminder]{
+		component: d.name,
+		item:      "reminder",
+		items:     "reminders",
+		batch:     ReminderBatch,
+		lease:     ReminderLease,
+		claim:     d.repository.ClaimReminders,
+		dispatch:  d.dispatch,
+		id:        func(reminder Reminder) string { return reminder.Id },
+	}
+
+	return loop.run(ctx)
+}
+
+// dispatch sends the reminder of every occurrence it is due for, then schedules it for the next one.
+func (d *ReminderDispatcher) dispatch(ctx context.Context, reminder Reminder, now time.Time) (int, error) {
+	sent, err := d.schedule(ctx, &reminder, now)
+	if err != nil {
+		retry := now.Add(ReminderRetryDelay)
+		reminder.DueAt = &retry
+	}
+
+	// A reminder deleted meanwhile has nothing left to schedule
+	rescheduleErr := d.repository.RescheduleReminder(ctx, &reminder)
+	if errors.Is(rescheduleErr, ErrReminderNotFound) {
+		rescheduleErr = nil
+	}
+
+	return sent, errors.Join(err, rescheduleErr)
+}
+
+// schedule walks the occurrences of the event after the last one reminded of, sends the reminder of those due and not
+// ended yet, and sets when the reminder is next due.
+func (d *ReminderDispatcher) schedule(ctx context.Context, reminder *Reminder, now time.Time) (int, error) {
+	reminder.DueAt = nil
+
+	// Trashed events are not reminded of, restoring one makes its reminders due again
+	event, err := d.repository.GetEventById(ctx, reminder.EventId)
+	if errors.Is(err, ErrEventNotFound) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	overrides, err := d.repository.ListOverrides(ctx, []string{event.Id})
+	if err != nil {
+		return 0, err
+	}
+
+	series := Series{Event: *event, Overrides: overrides}
+	offset := time.Duration(reminder.OffsetMinutes) * time.Minute
+
+	// Occurrences set before the reminder are not reminded of, nor those ended long ago
+	after := reminder.CreatedAt
+	if reminder.RemindedThrough != nil {
+		after = *reminder.RemindedThrough
+	}
+
+	after = laterOf(after, now.Add(-event.EndTime.Sub(event.StartTime)))
+
+	sent := 0
+
+	for {
+		occurrence, err := NextOccurrence(series, after)
+		if err != nil {
+			return sent, err
+		}
+
+		// Nothing within reach, look again later unless the series is over
+		if occurrence == nil {
+			end, err := SeriesEnd(*event)
+			if err != nil {
+				return sent, err
+			}
+
+			if horizon := after.Add(MaxOccurrenceWindow); end == nil || end.After(horizon) {
+				reminder.DueAt = &horizon
+			}
+
+			return sent, nil
+		}
+
+		due := occurrence.StartTime.Add(-offset)
+		if due.After(now) {
+			reminder.DueAt = &due
+			return sent, nil
+		}
+
+		if occurrence.EndTime.After(now) {
+			err = d.notify(ctx, *reminder, *occurrence, event)
+			if err != nil {
+				return sent, err
+			}
+
+			sent++
+		}
+
+		reminded := occurrence.StartTime
+		reminder.RemindedThrough, after = &reminded, reminded
+	}
+}
+
+func (d *ReminderDispatcher) notify(ctx context.Context, reminder Reminder, occurrence Occurrence, event *Event) error {
+	notifier, ok := d.notifiers[reminder.Channel]
+	if !ok {
+		return fmt.Errorf("%w: %s", errNoNotifier, reminder.Channel)
+	}
+
+	attendees, err := d.repository.ListAttendees(ctx, event.Id)
+	if err != nil {
+		return err
+	}
+
+	notification := Notification{
+		ReminderId:    reminder.Id,
+		Channel:       reminder.Channel,
+		OffsetMinutes: reminder.OffsetMinutes,
+		Occurrence:    occurrence,
+		Location:      event.Location,
+		Attendees:     make([]Attendee, 0, len(attendees)),
+	}
+
+	for _, attendee := range attendees {
+		if attendee.Status != RsvpDeclined {
+			notification.Attendees = append(notification.Attendees, attendee)
+		}
+	}
+
+	return notifier.Notify(ctx, notification)
+}
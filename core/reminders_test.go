@@ -0,0 +1,193 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type notifierFunc func(ctx context.Context, notification Notification) error
+
+func (f notifierFunc) Notify(ctx context.Context, notification Notification) error {
+	return f(ctx, notification)
+}
+
+func TestReminderDispatcher_Dispatch(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().Truncate(time.Second)
+	soon := now.Add(10 * time.Minute)
+	daily := &Event{Id: "uuid-1", Title: "Standup", StartTime: soon, EndTime: soon.Add(15 * time.Minute), RRule: "FREQ=DAILY"}
+	started := &Event{Id: "uuid-1", Title: "Workshop", StartTime: now.Add(-5 * time.Minute), EndTime: now.Add(55 * time.Minute)}
+	later := &Event{Id: "uuid-1", Title: "Review", StartTime: now.Add(2 * time.Hour), EndTime: now.Add(3 * time.Hour)}
+	ended := &Event{Id: "uuid-1", Title: "Retro", StartTime: now.Add(-2 * time.Hour), EndTime: now.Add(-time.Hour)}
+
+	attendees := []Attendee{
+		{Id: "uuid-a", Email: "ada@example.com", Status: RsvpAccepted},
+		{Id: "uuid-b", Email: "bob@example.com", Status: RsvpDeclined},
+	}
+
+	tests := []struct {
+		name        string
+		channel     string
+		event       *Event
+		eventErr    error
+		notifyErr   error
+		wantSent    int
+		wantFailure bool
+		// wantDue is when the reminder is next due, nil when it is done
+		wantDue      *time.Time
+		wantReminded *time.Time
+	}{
+		{
+			name:         "sends and schedules the next occurrence",
+			channel:      ChannelLog,
+			event:        daily,
+			wantSent:     1,
+			wantDue:      timePtr(soon.AddDate(0, 0, 1).Add(-15 * time.Minute)),
+			wantReminded: &soon,
+		},
+		{
+			name:         "catches up on an occurrence not ended yet",
+			channel:      ChannelLog,
+			event:        started,
+			wantSent:     1,
+			wantReminded: &started.StartTime,
+		},
+		{
+			name:    "not due yet",
+			channel: ChannelLog,
+			event:   later,
+			wantDue: timePtr(later.StartTime.Add(-15 * time.Minute)),
+		},
+		{
+			name:    "skips ended occurrences",
+			channel: ChannelLog,
+			event:   ended,
+		},
+		{
+			name:     "trashed event",
+			channel:  ChannelLog,
+			eventErr: ErrEventNotFound,
+		},
+		{
+			name:        "notifier failure is retried",
+			channel:     ChannelLog,
+			event:       daily,
+			notifyErr:   errors.New("smtp down"),
+			wantFailure: true,
+			wantDue:     timePtr(now.Add(ReminderRetryDelay)),
+		},
+		{
+			name:        "channel not configured",
+			channel:     ChannelWebhook,
+			event:       daily,
+			wantFailure: true,
+			wantDue:     timePtr(now.Add(ReminderRetryDelay)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reminder := Reminder{Id: "uuid-r", EventId: "uuid-1", OffsetMinutes: 15, Channel: tt.channel, Revision: 3, CreatedAt: now.Add(-24 * time.Hour)}
+
+			repo := new(MockRepository)
+			repo.On("ClaimReminders", mock.Anything, mock.Anything, mock.Anything, ReminderBatch).Return([]Reminder{reminder}, nil)
+			repo.On("GetEventById", mock.Anything, "uuid-1").Return(tt.event, tt.eventErr)
+			repo.On("ListOverrides", mock.Anything, []string{"uuid-1"}).Return([]Override{}, nil).Maybe()
+			repo.On("ListAttendees", mock.Anything, "uuid-1").Return(attendees, nil).Maybe()
+
+			var rescheduled Reminder
+
+			repo.On("RescheduleReminder", mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) { rescheduled = *args.Get(1).(*Reminder) }).
+				Return(nil)
+
+			var notifications []Notification
+
+			notifier := notifierFunc(func(_ context.Context, notification Notification) error {
+				if tt.notifyErr != nil {
+					return tt.notifyErr
+				}
+
+				notifications = append(notifications, notification)
+
+				return nil
+			})
+
+			dispatcher := NewReminderDispatcher(repo, map[string]Notifier{ChannelLog: notifier})
+
+			sent, err := dispatcher.Dispatch(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSent, sent)
+			require.Len(t, notifications, tt.wantSent)
+
+			if tt.wantSent > 0 {
+				assert.Equal(t, "uuid-r", notifications[0].ReminderId)
+				assert.Equal(t, tt.event.Title, notifications[0].Occurrence.Title)
+				assert.Equal(t, []Attendee{attendees[0]}, notifications[0].Attendees)
+			}
+
+			assert.Equal(t, 3, rescheduled.Revision)
+
+			if tt.wantReminded == nil {
+				assert.Nil(t, rescheduled.RemindedThrough)
+			} else {
+				require.NotNil(t, rescheduled.RemindedThrough)
+				assert.True(t, tt.wantReminded.Equal(*rescheduled.RemindedThrough))
+			}
+
+			switch {
+			case tt.wantDue == nil:
+				assert.Nil(t, rescheduled.DueAt)
+			case tt.wantFailure:
+				// Retries are counted from the dispatch, a little after the test started
+				require.NotNil(t, rescheduled.DueAt)
+				assert.WithinDuration(t, *tt.wantDue, *rescheduled.DueAt, 5*time.Second)
+			default:
+				require.NotNil(t, rescheduled.DueAt)
+				assert.True(t, tt.wantDue.Equal(*rescheduled.DueAt), "due at %s", rescheduled.DueAt)
+			}
+
+			repo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("claims batches until none is full", func(t *testing.T) {
+		t.Parallel()
+
+		batch := make([]Reminder, ReminderBatch)
+		for i := range batch {
+			batch[i] = Reminder{Id: "uuid-r", EventId: "uuid-1", Channel: ChannelLog}
+		}
+
+		repo := new(MockRepository)
+		repo.On("ClaimReminders", mock.Anything, mock.Anything, mock.Anything, ReminderBatch).Return(batch, nil).Once()
+		repo.On("ClaimReminders", mock.Anything, mock.Anything, mock.Anything, ReminderBatch).Return([]Reminder{}, nil).Once()
+		repo.On("GetEventById", mock.Anything, "uuid-1").Return(nil, ErrEventNotFound)
+		repo.On("RescheduleReminder", mock.Anything, mock.Anything).Return(ErrReminderNotFound)
+
+		sent, err := NewReminderDispatcher(repo, nil).Dispatch(context.Background())
+		require.NoError(t, err)
+		assert.Zero(t, sent)
+		repo.AssertNumberOfCalls(t, "RescheduleReminder", ReminderBatch)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("claim failure", func(t *testing.T) {
+		t.Parallel()
+
+		repo := new(MockRepository)
+		repo.On("ClaimReminders", mock.Anything, mock.Anything, mock.Anything, ReminderBatch).Return(nil, errors.New("db error"))
+
+		_, err := NewReminderDispatcher(repo, nil).Dispatch(context.Background())
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IndexedSearchLanguage is the text search configuration events.search_vector is generated with.
+// Searches in any other language are computed on the fly and do not use the GIN index.
+const IndexedSearchLanguage = "english"
+
+var SearchLanguages = []string{
+	"simple", "danish", "dutch", "english", "finnish", "french", "german", "hungarian",
+	"italian", "norwegian", "portuguese", "romanian", "russian", "spanish", "swedish", "turkish",
+}
+
+// Matches are delimited in snippets by the control characters chr(2) and chr(3), which are stripped from the text
+// beforehand, so that the text can be escaped before the matches are wrapped in <mark> tags.
+const (
+	snippetStart = "\x02"
+	snippetStop  = "\x03"
+)
+
+var snippetMarks = strings.NewReplacer(snippetStart, "<mark>", snippetStop, "</mark>")
+
+// HighlightSnippet escapes the snippet built by ts_headline as HTML, and wraps its matches in <mark> tags.
+func HighlightSnippet(snippet string) string {
+	return snippetMarks.Replace(html.EscapeString(snippet))
+}
+
+func ParseSearchQuery(gctx *gin.Context) (SearchQuery, error) {
+	var err error
+	var query SearchQuery
+
+	query.Query = strings.TrimSpace(gctx.Query("q"))
+	if query.Query == "" {
+		return query, errors.New("parameter 'q' is required")
+	}
+
+	if utf8.RuneCountInString(query.Query) > 256 {
+		return query, errors.New("parameter 'q' is too long (256 characters tops)")
+	}
+
+	query.Language = strings.ToLower(gctx.DefaultQuery("lang", IndexedSearchLanguage))
+	if !slices.Contains(SearchLanguages, query.Language) {
+		return query, fmt.Errorf("parameter 'lang' must be one of %s", strings.Join(SearchLanguages, ", "))
+	}
+
+	query.Limit, err = ParseLimit(gctx.Query("limit"))
+	if err != nil {
+		return query, err
+	}
+
+	return query, nil
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		query   string
+		want    SearchQuery
+		wantErr bool
+	}{
+		{
+			name:  "defaults",
+			query: "q=kickoff",
+			want:  SearchQuery{Query: "kickoff", Language: IndexedSearchLanguage, Limit: DefaultPageLimit},
+		},
+		{
+			name:  "language and limit",
+			query: "q=reuni%C3%B3n+semanal&lang=Spanish&limit=5",
+			want:  SearchQuery{Query: "reunión semanal", Language: "spanish", Limit: 5},
+		},
+		{name: "missing q", query: "q=%20", wantErr: true},
+		{
+			name:  "q of non-ASCII characters",
+			query: "q=" + url.QueryEscape(strings.Repeat("é", 256)),
+			want:  SearchQuery{Query: strings.Repeat("é", 256), Language: IndexedSearchLanguage, Limit: DefaultPageLimit},
+		},
+		{name: "q too long", query: "q=" + string(make([]byte, 257)), wantErr: true},
+		{name: "unsupported lang", query: "q=kickoff&lang=klingon", wantErr: true},
+		{name: "invalid limit", query: "q=kickoff&limit=none", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/events/search", nil)
+			c.Request.URL.RawQuery = tt.query
+
+			got, err := ParseSearchQuery(c)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		snippet string
+		want    string
+	}{
+		{name: "matches", snippet: "Project \x02Kickoff\x03 meeting", want: "Project <mark>Kickoff</mark> meeting"},
+		{name: "markup escaped", snippet: "\x02Kickoff\x03 <script>alert('x')</script> & co", want: "<mark>Kickoff</mark> &lt;script&gt;alert(&#39;x&#39;)&lt;/script&gt; &amp; co"},
+		{name: "no match", snippet: "Project", want: "Project"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, HighlightSnippet(tt.snippet))
+		})
+	}
+}
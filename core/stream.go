@@ -0,0 +1,273 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// ChangesChannel is the channel the changes of events are notified on once committed, see schema.sql.
+	ChangesChannel = "event_changes"
+	// StreamBuffer is how many of the latest changes are kept for the subscribers resuming their stream.
+	StreamBuffer = 256
+	// StreamQueue is how many changes a subscriber may fall behind by before it is dropped.
+	StreamQueue = 64
+	// StreamRetryDelay is how long the stream waits before listening again after losing its connection, and how long
+	// the clients of GET /events/stream are told to wait before reconnecting.
+	StreamRetryDelay = 3 * time.Second
+	// StreamReset is the type of the change telling a subscriber that it may have missed changes: those it resumed
+	// from are no longer buffered, or the stream stopped listening for a while. It should fetch what it shows again.
+	StreamReset = "stream.reset"
+)
+
+var (
+	// ErrStreamClosed is returned by ChangeStream.Subscribe once the stream is drained, and ends the subscriptions
+	// then.
+	ErrStreamClosed = errors.New("change stream closed")
+	// ErrSubscriberBehind ends the subscriptions that fell more than StreamQueue changes behind.
+	ErrSubscriberBehind = errors.New("subscriber too far behind")
+)
+
+// Change is a lifecycle change of an event, as notified once committed. Id is the id of its outbox message; it orders
+// the changes, and subscribers resume their stream after the last one they got. The event is described as changed,
+// enough to tell whether it is of interest: SeriesEnd is the end of its last occurrence, nil for unbounded series.
+type Change struct {
+	Id        int64      `json:"id"`
+	Type      string     `json:"type"`
+	EventId   string     `json:"event_id"`
+	Title     string     `json:"title"`
+	StartTime time.Time  `json:"start_time"`
+	SeriesEnd *time.Time `json:"series_end,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// ChangeSource listens for the changes of events. It calls listening once it does, then notify with every change,
+// until ctx is done or the connection is lost.
+type ChangeSource interface {
+	ListenChanges(ctx context.Context, listening func(), notify func(change Change)) error
+}
+
+// Subscription receives the changes of events from a ChangeStream.
+type Subscription struct {
+	changes chan Change
+	err     error
+}
+
+// Changes returns the changes received, closed once the subscription ends: it was cancelled, fell too far behind, or
+// the stream was drained.
+func (s *Subscription) Changes() <-chan Change {
+	return s.changes
+}
+
+// Err returns why the subscription ended once Changes is closed: ErrSubscriberBehind, ErrStreamClosed, or nil when
+// it was cancelled.
+func (s *Subscription) Err() error {
+	return s.err
+}
+
+// ChangeStream fans the changes of events out to its subscribers from a single listening connection. The latest
+// changes are buffered so that subscribers reconnecting can resume where they left, and subscribers too slow to keep
+// up are dropped rather than holding up the others.
+type ChangeStream struct {
+	name        string
+	source      ChangeSource
+	heartbeat   time.Duration
+	retryDelay  time.Duration
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	buffer      []Change
+	// lost is whether changes may have been missed since the stream last listened
+	lost   bool
+	closed bool
+	stop   chan struct{}
+	once   sync.Once
+}
+
+// NewChangeStream has the subscribers sent a heartbeat every heartbeat without changes.
+func NewChangeStream(source ChangeSource, heartbeat time.Duration) *ChangeStream {
+	return &ChangeStream{
+		name:        "change-stream",
+		source:      source,
+		heartbeat:   heartbeat,
+		retryDelay:  StreamRetryDelay,
+		subscribers: make(map[*Subscription]struct{}),
+		stop:        make(chan struct{}),
+	}
+}
+
+// Subscribe subscribes to the changes to come. With lastId, the changes buffered after that one are received first,
+// or a StreamReset when they are not buffered anymore.
+func (s *ChangeStream) Subscribe(lastId *int64) (*Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrStreamClosed
+	}
+
+	var missed []Change
+	if lastId != nil {
+		missed = s.replay(*lastId)
+	}
+
+	sub := &Subscription{changes: make(chan Change, len(missed)+StreamQueue)}
+	for _, change := range missed {
+		sub.changes <- change
+	}
+
+	s.subscribers[sub] = struct{}{}
+
+	return sub, nil
+}
+
+// replay returns the buffered changes that came after lastId.
+func (s *ChangeStream) replay(lastId int64) []Change {
+	// Changes are notified as they commit, not always in the order of their ids
+	for i := len(s.buffer) - 1; i >= 0; i-- {
+		if s.buffer[i].Id == lastId {
+			return slices.Clone(s.buffer[i+1:])
+		}
+	}
+
+	if len(s.buffer) == 0 || lastId < s.buffer[0].Id {
+		return []Change{{Type: StreamReset}}
+	}
+
+	missed := make([]Change, 0)
+
+	for _, change := range s.buffer {
+		if change.Id > lastId {
+			missed = append(missed, change)
+		}
+	}
+
+	return missed
+}
+
+// Unsubscribe ends the subscription, if it has not ended already.
+func (s *ChangeStream) Unsubscribe(sub *Subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.drop(sub, nil)
+}
+
+func (s *ChangeStream) drop(sub *Subscription, err error) {
+	if _, ok := s.subscribers[sub]; !ok {
+		return
+	}
+
+	delete(s.subscribers, sub)
+	sub.err = err
+	close(sub.changes)
+}
+
+// Subscribers returns how many subscriptions are ongoing.
+func (s *ChangeStream) Subscribers() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.subscribers)
+}
+
+// publish buffers the change and sends it to the subscribers.
+func (s *ChangeStream) publish(change Change) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buffer = append(s.buffer, change)
+	if len(s.buffer) > StreamBuffer {
+		s.buffer = slices.Clone(s.buffer[len(s.buffer)-StreamBuffer:])
+	}
+
+	s.broadcast(change)
+}
+
+func (s *ChangeStream) broadcast(change Change) {
+	for sub := range s.subscribers {
+		select {
+		case sub.changes <- change:
+		default:
+			// Dropped subscribers reconnect and resume from the buffer
+			log.Warn().Str("component", s.name).Msg("dropping subscriber too far behind")
+			s.drop(sub, ErrSubscriberBehind)
+		}
+	}
+}
+
+// listening resets the subscribers when changes may have been missed while the stream was not listening. Those
+// buffered cannot be resumed from anymore.
+func (s *ChangeStream) listening() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.lost {
+		return
+	}
+
+	s.lost, s.buffer = false, nil
+	s.broadcast(Change{Type: StreamReset})
+}
+
+// Close drains the stream: the subscriptions end, and no other can begin. It lets the HTTP server shut down, the
+// streams would keep their connections busy otherwise.
+func (s *ChangeStream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+
+	for sub := range s.subscribers {
+		s.drop(sub, ErrStreamClosed)
+	}
+}
+
+func (s *ChangeStream) Run(ctx context.Context) error {
+	log.Info().Str("stage", "startup").Str("component", s.name).Msg("starting up")
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-s.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		err := s.source.ListenChanges(ctx, s.listening, s.publish)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		// errors are logged and listened again after a while
+		log.Error().Str("component", s.name).Err(err).Msg("failed to listen for changes")
+
+		s.mu.Lock()
+		s.lost = true
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(s.retryDelay):
+		}
+	}
+}
+
+func (s *ChangeStream) Stop(ctx context.Context) error {
+	log.Info().Str("stage", "shut down").Str("component", s.name).Msg("stopping")
+	defer log.Info().Str("stage", "shut down").Str("component", s.name).Msg("stopped")
+
+	s.once.Do(func() { close(s.stop) })
+	s.Close()
+
+	return nil
+}
@@ -0,0 +1,252 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// changeSourceFunc listens for changes through a function, for tests.
+type changeSourceFunc func(ctx context.Context, listening func(), notify func(change Change)) error
+
+func (f changeSourceFunc) ListenChanges(ctx context.Context, listening func(), notify func(change Change)) error {
+	return f(ctx, listening, notify)
+}
+
+// received drains the changes the subscription has received so far.
+func received(sub *Subscription) []Change {
+	changes := make([]Change, 0)
+
+	for {
+		select {
+		case change, ok := <-sub.Changes():
+			if !ok {
+				return changes
+			}
+
+			changes = append(changes, change)
+		default:
+			return changes
+		}
+	}
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func ids(changes []Change) []int64 {
+	result := make([]int64, 0, len(changes))
+	for _, change := range changes {
+		result = append(result, change.Id)
+	}
+
+	return result
+}
+
+func TestChangeStream_Subscribe(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		lastId  *int64
+		buffer  []int64
+		wantIds []int64
+	}{
+		{
+			name:    "new subscriber",
+			buffer:  []int64{1, 2, 3},
+			wantIds: []int64{},
+		},
+		{
+			name:    "resumes after the last change",
+			lastId:  int64Ptr(2),
+			buffer:  []int64{1, 2, 3, 4},
+			wantIds: []int64{3, 4},
+		},
+		{
+			name:    "resumes in the order changes committed",
+			lastId:  int64Ptr(5),
+			buffer:  []int64{4, 6, 5, 7},
+			wantIds: []int64{7},
+		},
+		{
+			name:    "resumes from a change it did not get",
+			lastId:  int64Ptr(5),
+			buffer:  []int64{4, 6, 7},
+			wantIds: []int64{6, 7},
+		},
+		{
+			name:    "up to date",
+			lastId:  int64Ptr(3),
+			buffer:  []int64{1, 2, 3},
+			wantIds: []int64{},
+		},
+		{
+			name:    "resets once no longer buffered",
+			lastId:  int64Ptr(1),
+			buffer:  []int64{5, 6},
+			wantIds: []int64{0},
+		},
+		{
+			name:    "resets with nothing buffered",
+			lastId:  int64Ptr(1),
+			wantIds: []int64{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stream := NewChangeStream(nil, time.Second)
+			for _, id := range tt.buffer {
+				stream.publish(Change{Id: id, Type: EventUpdated})
+			}
+
+			sub, err := stream.Subscribe(tt.lastId)
+			require.NoError(t, err)
+
+			changes := received(sub)
+			assert.Equal(t, tt.wantIds, ids(changes))
+
+			if len(changes) == 1 && changes[0].Id == 0 {
+				assert.Equal(t, StreamReset, changes[0].Type)
+			}
+
+			// Changes to come follow
+			stream.publish(Change{Id: 100, Type: EventCreated})
+			assert.Equal(t, []int64{100}, ids(received(sub)))
+		})
+	}
+}
+
+func TestChangeStream_Publish(t *testing.T) {
+	t.Parallel()
+
+	t.Run("buffers the latest changes", func(t *testing.T) {
+		t.Parallel()
+
+		stream := NewChangeStream(nil, time.Second)
+		for id := int64(1); id <= StreamBuffer+10; id++ {
+			stream.publish(Change{Id: id})
+		}
+
+		sub, err := stream.Subscribe(int64Ptr(9))
+		require.NoError(t, err)
+		assert.Equal(t, []int64{0}, ids(received(sub)))
+
+		sub, err = stream.Subscribe(int64Ptr(11))
+		require.NoError(t, err)
+		assert.Len(t, received(sub), StreamBuffer-1)
+	})
+
+	t.Run("drops subscribers too far behind", func(t *testing.T) {
+		t.Parallel()
+
+		stream := NewChangeStream(nil, time.Second)
+
+		slow, err := stream.Subscribe(nil)
+		require.NoError(t, err)
+
+		for id := int64(1); id <= StreamQueue; id++ {
+			stream.publish(Change{Id: id})
+		}
+
+		fast, err := stream.Subscribe(nil)
+		require.NoError(t, err)
+
+		stream.publish(Change{Id: StreamQueue + 1})
+
+		assert.Len(t, received(slow), StreamQueue)
+		_, ok := <-slow.Changes()
+		assert.False(t, ok, "the slow subscription ended")
+		assert.ErrorIs(t, slow.Err(), ErrSubscriberBehind)
+
+		assert.Equal(t, []int64{StreamQueue + 1}, ids(received(fast)))
+		assert.Equal(t, 1, stream.Subscribers())
+	})
+}
+
+func TestChangeStream_Close(t *testing.T) {
+	t.Parallel()
+
+	stream := NewChangeStream(nil, time.Second)
+
+	sub, err := stream.Subscribe(nil)
+	require.NoError(t, err)
+
+	stream.Close()
+
+	_, ok := <-sub.Changes()
+	assert.False(t, ok)
+	assert.ErrorIs(t, sub.Err(), ErrStreamClosed)
+	assert.Zero(t, stream.Subscribers())
+
+	// Unsubscribing once drained is harmless
+	stream.Unsubscribe(sub)
+
+	_, err = stream.Subscribe(nil)
+	require.ErrorIs(t, err, ErrStreamClosed)
+}
+
+func TestChangeStream_Run(t *testing.T) {
+	t.Parallel()
+
+	listens := make(chan struct{}, 2)
+	attempts := 0
+
+	source := changeSourceFunc(func(ctx context.Context, listening func(), notify func(change Change)) error {
+		attempts++
+		listening()
+		listens <- struct{}{}
+
+		if attempts == 1 {
+			notify(Change{Id: 1, Type: EventCreated})
+			return errors.New("connection lost")
+		}
+
+		<-ctx.Done()
+
+		return ctx.Err()
+	})
+
+	stream := NewChangeStream(source, time.Second)
+	stream.retryDelay = time.Millisecond
+
+	sub, err := stream.Subscribe(nil)
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() { done <- stream.Run(context.Background()) }()
+
+	for range 2 {
+		select {
+		case <-listens:
+		case <-time.After(5 * time.Second):
+			t.Fatal("stream did not listen again")
+		}
+	}
+
+	// The changes made while the connection was lost are missed, the subscribers are told to fetch again
+	changes := received(sub)
+	require.Len(t, changes, 2)
+	assert.Equal(t, Change{Id: 1, Type: EventCreated}, changes[0])
+	assert.Equal(t, StreamReset, changes[1].Type)
+
+	require.NoError(t, stream.Stop(context.Background()))
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not stop")
+	}
+
+	_, ok := <-sub.Changes()
+	assert.False(t, ok, "stopping drains the stream")
+}
@@ -2,7 +2,19 @@ package core
 
 import (
 	"errors"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/teambition/rrule-go"
+	"golang.org/x/text/cases"
 )
 
 func ValidateEvent(event Event) error {
@@ -19,5 +31,355 @@ func ValidateEvent(event Event) error {
 		return errors.New("end time must be after start time")
 	}
 
+	if len(event.Scope) > 100 {
+		return errors.New("scope is too long (100 characters tops)")
+	}
+
+	if event.Capacity < 0 {
+		return errors.New("capacity must not be negative")
+	}
+
+	// The overlap constraint only sees the first occurrence of a series
+	if event.Scope != "" && IsRecurring(event) {
+		return errors.New("scope is not supported on recurring events")
+	}
+
+	err := ValidateTimeZone(event.TimeZone)
+	if err != nil {
+		return err
+	}
+
+	err = ValidateLocation(event.Location)
+	if err != nil {
+		return err
+	}
+
+	err = ValidateTags(event.Tags)
+	if err != nil {
+		return err
+	}
+
+	err = ValidateRecurrence(event)
+	if err != nil {
+		return err
+	}
+
+	return ValidateAllDay(event)
+}
+
+// uuidPattern matches the ids generated by the database, anything else would fail its uuid casts.
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// IsUUID tells whether the value has the form of an id.
+func IsUUID(value string) bool {
+	return uuidPattern.MatchString(value)
+}
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+func ValidateCalendar(calendar Calendar) error {
+	calendar.Name = strings.TrimSpace(calendar.Name)
+	if len(calendar.Name) == 0 {
+		return errors.New("name is required")
+	}
+
+	if len(calendar.Name) > 100 {
+		return errors.New("name is too long (100 characters tops)")
+	}
+
+	if calendar.Color != "" && !colorPattern.MatchString(calendar.Color) {
+		return errors.New("color must be a hex RGB value such as '#1a73e8'")
+	}
+
+	return ValidateTimeZone(calendar.TimeZone)
+}
+
+func ValidateLocation(location Place) error {
+	if utf8.RuneCountInString(location.Name) > 100 {
+		return errors.New("location name is too long (100 characters tops)")
+	}
+
+	if utf8.RuneCountInString(location.Address) > 255 {
+		return errors.New("location address is too long (255 characters tops)")
+	}
+
+	if (location.Latitude == nil) != (location.Longitude == nil) {
+		return errors.New("location latitude and longitude must be set together")
+	}
+
+	if location.Latitude == nil {
+		return nil
+	}
+
+	return ValidateCoordinates(*location.Latitude, *location.Longitude)
+}
+
+func ValidateCoordinates(latitude float64, longitude float64) error {
+	if math.IsNaN(latitude) || latitude < -90 || latitude > 90 {
+		return errors.New("latitude must be between -90 and 90")
+	}
+
+	if math.IsNaN(longitude) || longitude < -180 || longitude > 180 {
+		return errors.New("longitude must be between -180 and 180")
+	}
+
+	return nil
+}
+
+const (
+	MaxTags      = 20
+	MaxTagLength = 50
+)
+
+var tagPattern = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N}._-]*$`)
+
+// NormalizeTags trims and case-folds the tags, drops the blank ones and duplicates, and sorts them.
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+
+	// Casers keep state, one is made for each call
+	fold := cases.Fold()
+
+	for _, tag := range tags {
+		tag = fold.String(strings.TrimSpace(tag))
+		if tag != "" {
+			normalized = append(normalized, tag)
+		}
+	}
+
+	slices.Sort(normalized)
+
+	return slices.Compact(normalized)
+}
+
+// ValidateTags checks the tags once normalized, as they are saved.
+func ValidateTags(tags []string) error {
+	tags = NormalizeTags(tags)
+	if len(tags) > MaxTags {
+		return fmt.Errorf("too many tags (%d tops)", MaxTags)
+	}
+
+	for _, tag := range tags {
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			return fmt.Errorf("tag '%s' is too long (%d characters tops)", tag, MaxTagLength)
+		}
+
+		if !tagPattern.MatchString(tag) {
+			return fmt.Errorf("tag '%s' must be letters, digits, '.', '_' and '-', starting with a letter or digit", tag)
+		}
+	}
+
+	return nil
+}
+
+func ValidateFreeBusyQuery(query FreeBusyQuery) error {
+	if query.From.IsZero() || query.To.IsZero() {
+		return errors.New("from and to are required")
+	}
+
+	if !query.From.Before(query.To) {
+		return errors.New("from must be before to")
+	}
+
+	if query.To.Sub(query.From) > MaxOccurrenceWindow {
+		return fmt.Errorf("window is too long (%d days tops)", MaxOccurrenceWindow/(24*time.Hour))
+	}
+
+	if utf8.RuneCountInString(query.TitlePrefix) > 100 {
+		return errors.New("title_prefix is too long (100 characters tops)")
+	}
+
+	if query.CalendarId != "" && !IsUUID(query.CalendarId) {
+		return errors.New("calendar_id must be a UUID")
+	}
+
+	return nil
+}
+
+func ValidateAttendee(attendee Attendee) error {
+	return validateContact(attendee.Email, attendee.Name)
+}
+
+func ValidateRegistration(registration Registration) error {
+	return validateContact(registration.Email, registration.Name)
+}
+
+func validateContact(email string, name string) error {
+	if len(email) == 0 {
+		return errors.New("email is required")
+	}
+
+	if len(email) > 254 {
+		return errors.New("email is too long (254 characters tops)")
+	}
+
+	// Only bare addresses, "Name <address>" forms go in name and email separately
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return fmt.Errorf("email '%s' is invalid", email)
+	}
+
+	if len(strings.TrimSpace(name)) > 100 {
+		return errors.New("name is too long (100 characters tops)")
+	}
+
+	return nil
+}
+
+func ValidateRsvpStatus(status string) error {
+	if !slices.Contains([]string{RsvpNeedsAction, RsvpAccepted, RsvpDeclined, RsvpTentative}, status) {
+		return fmt.Errorf("status must be one of %s, %s, %s or %s", RsvpAccepted, RsvpDeclined, RsvpTentative, RsvpNeedsAction)
+	}
+
+	return nil
+}
+
+// MaxReminderOffset is the longest a reminder can be sent before its occurrence, in minutes (4 weeks).
+const MaxReminderOffset = 4 * 7 * 24 * 60
+
+func ValidateReminder(reminder Reminder) error {
+	if reminder.OffsetMinutes < 0 || reminder.OffsetMinutes > MaxReminderOffset {
+		return fmt.Errorf("offset_minutes must be between 0 and %d", MaxReminderOffset)
+	}
+
+	if !slices.Contains([]string{ChannelWebhook, ChannelEmail, ChannelLog}, reminder.Channel) {
+		return fmt.Errorf("channel must be one of %s, %s or %s", ChannelWebhook, ChannelEmail, ChannelLog)
+	}
+
+	return nil
+}
+
+func ValidateWebhook(webhook Webhook) error {
+	if len(webhook.Url) == 0 {
+		return errors.New("url is required")
+	}
+
+	if len(webhook.Url) > 2048 {
+		return errors.New("url is too long (2048 characters tops)")
+	}
+
+	target, err := url.Parse(webhook.Url)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("url '%s' is invalid, it must be an absolute http or https URL", webhook.Url)
+	}
+
+	// Host names are checked again once resolved, when a delivery dials them
+	addr, err := netip.ParseAddr(target.Hostname())
+	if strings.EqualFold(target.Hostname(), "localhost") || (err == nil && !isPublicAddr(addr)) {
+		return fmt.Errorf("url '%s' is invalid, its host must be public", webhook.Url)
+	}
+
+	for _, eventType := range webhook.EventTypes {
+		if !slices.Contains(webhookEventTypes, eventType) {
+			return fmt.Errorf("event type '%s' is invalid, it must be one of %s, %s or %s", eventType, EventCreated, EventUpdated, EventDeleted)
+		}
+	}
+
+	// An empty secret is generated
+	if webhook.Secret != "" && (len(webhook.Secret) < 16 || len(webhook.Secret) > 256) {
+		return errors.New("secret must be between 16 and 256 characters")
+	}
+
+	return nil
+}
+
+// ValidateTimeZone checks that name is an IANA time zone; the server's local zone is not portable, so it is rejected.
+func ValidateTimeZone(name string) error {
+	if name == "Local" {
+		return errors.New("time zone is invalid: use an IANA name such as 'Europe/Madrid'")
+	}
+
+	_, err := Location(name)
+	if err != nil {
+		return fmt.Errorf("time zone is invalid: %w", err)
+	}
+
+	return nil
+}
+
+func ValidateRecurrence(event Event) error {
+	if len(event.ExDates) > MaxRecurrenceDates || len(event.RDates) > MaxRecurrenceDates {
+		return fmt.Errorf("too many exdates or rdates (%d tops)", MaxRecurrenceDates)
+	}
+
+	if len(event.ExDates) > 0 && !IsRecurring(event) {
+		return errors.New("exdates require an rrule or rdates")
+	}
+
+	for _, rdate := range event.RDates {
+		if rdate.Before(event.StartTime) {
+			return errors.New("rdates must not be before start time")
+		}
+	}
+
+	_, err := RecurrenceSet(event)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ValidateAllDay checks that all-day events, and each of their occurrences, span whole days in their time zone.
+func ValidateAllDay(event Event) error {
+	if !event.AllDay {
+		return nil
+	}
+
+	loc, err := Location(event.TimeZone)
+	if err != nil {
+		return err
+	}
+
+	if !IsMidnight(event.StartTime, loc) || !IsMidnight(event.EndTime, loc) {
+		return fmt.Errorf("all-day events must start and end at midnight in %s", loc)
+	}
+
+	if !event.EndTime.After(event.StartTime) {
+		return errors.New("all-day events must last at least one day")
+	}
+
+	for _, date := range slices.Concat(event.RDates, event.ExDates) {
+		if !IsMidnight(date, loc) {
+			return fmt.Errorf("rdates and exdates of all-day events must be at midnight in %s", loc)
+		}
+	}
+
+	set, err := RecurrenceSet(event)
+	if err != nil {
+		return err
+	}
+
+	if rule := set.GetRRule(); rule != nil {
+		options := rule.OrigOptions
+		if options.Freq == rrule.HOURLY || len(options.Byhour) > 0 || len(options.Byminute) > 0 || len(options.Bysecond) > 0 {
+			return errors.New("all-day events must recur on whole days")
+		}
+	}
+
+	return nil
+}
+
+// IsMidnight tells whether t is the start of a day in loc.
+func IsMidnight(t time.Time, loc *time.Location) bool {
+	t = t.In(loc)
+	y, m, d := t.Date()
+
+	return t.Equal(time.Date(y, m, d, 0, 0, 0, 0, loc))
+}
+
+func ValidateOverride(override Override) error {
+	if len(strings.TrimSpace(override.Title)) > 100 {
+		return errors.New("title is too long (100 characters tops)")
+	}
+
+	if override.StartTime.IsZero() != override.EndTime.IsZero() {
+		return errors.New("start time and end time must be set together")
+	}
+
+	if override.EndTime.Before(override.StartTime) {
+		return errors.New("end time must be after start time")
+	}
+
 	return nil
 }
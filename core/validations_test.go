@@ -1,6 +1,10 @@
 package core
 
 import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,6 +17,9 @@ func TestValidateEvent(t *testing.T) {
 
 	now := time.Now()
 
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
 	tests := []struct {
 		name    string
 		event   Event
@@ -58,6 +65,211 @@ func TestValidateEvent(t *testing.T) {
 			wantErr: true,
 			errMsg:  "end time must be after start time",
 		},
+		{
+			name: "scoped event",
+			event: Event{
+				Title:     "Valid Title",
+				StartTime: now,
+				EndTime:   now.Add(time.Hour),
+				Scope:     "room-1",
+			},
+			wantErr: false,
+		},
+		{
+			name: "scope too long",
+			event: Event{
+				Title:     "Valid Title",
+				StartTime: now,
+				EndTime:   now.Add(time.Hour),
+				Scope:     string(make([]byte, 101)),
+			},
+			wantErr: true,
+			errMsg:  "scope is too long (100 characters tops)",
+		},
+		{
+			name: "scoped recurring event",
+			event: Event{
+				Title:     "Valid Title",
+				StartTime: now,
+				EndTime:   now.Add(time.Hour),
+				Scope:     "room-1",
+				RRule:     "FREQ=DAILY",
+			},
+			wantErr: true,
+			errMsg:  "scope is not supported on recurring events",
+		},
+		{
+			name: "negative capacity",
+			event: Event{
+				Title:     "Workshop",
+				StartTime: now,
+				EndTime:   now.Add(time.Hour),
+				Capacity:  -1,
+			},
+			wantErr: true,
+			errMsg:  "capacity must not be negative",
+		},
+		{
+			name: "location off the map",
+			event: Event{
+				Title:     "Workshop",
+				StartTime: now,
+				EndTime:   now.Add(time.Hour),
+				Location:  Place{Name: "North", Latitude: ptr(91.0), Longitude: ptr(0.0)},
+			},
+			wantErr: true,
+			errMsg:  "latitude must be between -90 and 90",
+		},
+		{
+			name: "invalid tag",
+			event: Event{
+				Title:     "Workshop",
+				StartTime: now,
+				EndTime:   now.Add(time.Hour),
+				Tags:      []string{"offsite", "on call"},
+			},
+			wantErr: true,
+			errMsg:  "tag 'on call' must be letters, digits",
+		},
+		{
+			name: "recurring event",
+			event: Event{
+				Title:     "Standup",
+				StartTime: now,
+				EndTime:   now.Add(15 * time.Minute),
+				TimeZone:  "Europe/Madrid",
+				RRule:     "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+				ExDates:   []time.Time{now.AddDate(0, 0, 7)},
+				RDates:    []time.Time{now.AddDate(0, 0, 1)},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown time zone",
+			event: Event{
+				Title:     "Valid Title",
+				StartTime: now,
+				EndTime:   now.Add(time.Hour),
+				TimeZone:  "Mars/Olympus_Mons",
+			},
+			wantErr: true,
+			errMsg:  "time zone is invalid",
+		},
+		{
+			name: "invalid rrule",
+			event: Event{
+				Title:     "Valid Title",
+				StartTime: now,
+				EndTime:   now.Add(time.Hour),
+				RRule:     "FREQ=SOMETIMES",
+			},
+			wantErr: true,
+			errMsg:  "invalid rrule",
+		},
+		{
+			name: "exdates without recurrence",
+			event: Event{
+				Title:     "Valid Title",
+				StartTime: now,
+				EndTime:   now.Add(time.Hour),
+				ExDates:   []time.Time{now},
+			},
+			wantErr: true,
+			errMsg:  "exdates require an rrule or rdates",
+		},
+		{
+			name: "rdate before start time",
+			event: Event{
+				Title:     "Valid Title",
+				StartTime: now,
+				EndTime:   now.Add(time.Hour),
+				RDates:    []time.Time{now.Add(-time.Hour)},
+			},
+			wantErr: true,
+			errMsg:  "rdates must not be before start time",
+		},
+		{
+			name: "too many rdates",
+			event: Event{
+				Title:     "Valid Title",
+				StartTime: now,
+				EndTime:   now.Add(time.Hour),
+				RDates:    make([]time.Time, MaxRecurrenceDates+1),
+			},
+			wantErr: true,
+			errMsg:  "too many exdates or rdates",
+		},
+		{
+			name: "local time zone",
+			event: Event{
+				Title:     "Valid Title",
+				StartTime: now,
+				EndTime:   now.Add(time.Hour),
+				TimeZone:  "Local",
+			},
+			wantErr: true,
+			errMsg:  "time zone is invalid",
+		},
+		{
+			name: "all-day event",
+			event: Event{
+				Title:     "Holiday",
+				StartTime: time.Date(2025, 12, 24, 0, 0, 0, 0, madrid),
+				EndTime:   time.Date(2025, 12, 26, 0, 0, 0, 0, madrid),
+				TimeZone:  "Europe/Madrid",
+				AllDay:    true,
+				RRule:     "FREQ=YEARLY",
+				ExDates:   []time.Time{time.Date(2026, 12, 24, 0, 0, 0, 0, madrid)},
+			},
+			wantErr: false,
+		},
+		{
+			name: "all-day event not at midnight in its time zone",
+			event: Event{
+				Title:     "Holiday",
+				StartTime: time.Date(2025, 12, 24, 0, 0, 0, 0, time.UTC),
+				EndTime:   time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC),
+				TimeZone:  "Europe/Madrid",
+				AllDay:    true,
+			},
+			wantErr: true,
+			errMsg:  "all-day events must start and end at midnight in Europe/Madrid",
+		},
+		{
+			name: "all-day event without duration",
+			event: Event{
+				Title:     "Holiday",
+				StartTime: time.Date(2025, 12, 24, 0, 0, 0, 0, time.UTC),
+				EndTime:   time.Date(2025, 12, 24, 0, 0, 0, 0, time.UTC),
+				AllDay:    true,
+			},
+			wantErr: true,
+			errMsg:  "all-day events must last at least one day",
+		},
+		{
+			name: "all-day event with rdate not at midnight",
+			event: Event{
+				Title:     "Holiday",
+				StartTime: time.Date(2025, 12, 24, 0, 0, 0, 0, time.UTC),
+				EndTime:   time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC),
+				AllDay:    true,
+				RDates:    []time.Time{time.Date(2025, 12, 31, 12, 0, 0, 0, time.UTC)},
+			},
+			wantErr: true,
+			errMsg:  "rdates and exdates of all-day events must be at midnight",
+		},
+		{
+			name: "all-day event recurring hourly",
+			event: Event{
+				Title:     "Holiday",
+				StartTime: time.Date(2025, 12, 24, 0, 0, 0, 0, time.UTC),
+				EndTime:   time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC),
+				AllDay:    true,
+				RRule:     "FREQ=DAILY;BYHOUR=9",
+			},
+			wantErr: true,
+			errMsg:  "all-day events must recur on whole days",
+		},
 	}
 
 	for _, tt := range tests {
@@ -74,3 +286,335 @@ func TestValidateEvent(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateOverride(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		override Override
+		wantErr  bool
+		errMsg   string
+	}{
+		{name: "rename", override: Override{Title: "Retro"}},
+		{name: "move", override: Override{StartTime: now, EndTime: now.Add(time.Hour)}},
+		{
+			name:     "title too long",
+			override: Override{Title: string(make([]byte, 101))},
+			wantErr:  true,
+			errMsg:   "title is too long (100 characters tops)",
+		},
+		{
+			name:     "start time without end time",
+			override: Override{StartTime: now},
+			wantErr:  true,
+			errMsg:   "start time and end time must be set together",
+		},
+		{
+			name:     "end time before start time",
+			override: Override{StartTime: now, EndTime: now.Add(-time.Hour)},
+			wantErr:  true,
+			errMsg:   "end time must be after start time",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateOverride(tt.override)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateCalendar(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		calendar Calendar
+		wantErr  bool
+		errMsg   string
+	}{
+		{name: "valid", calendar: Calendar{Name: "Work", TimeZone: "Europe/Madrid", Color: "#1A73e8"}},
+		{name: "default time zone", calendar: Calendar{Name: "Work"}},
+		{
+			name:     "missing name",
+			calendar: Calendar{Name: "  "},
+			wantErr:  true,
+			errMsg:   "name is required",
+		},
+		{
+			name:     "name too long",
+			calendar: Calendar{Name: strings.Repeat("a", 101)},
+			wantErr:  true,
+			errMsg:   "name is too long (100 characters tops)",
+		},
+		{
+			name:     "invalid color",
+			calendar: Calendar{Name: "Work", Color: "blue"},
+			wantErr:  true,
+			errMsg:   "color must be a hex RGB value",
+		},
+		{
+			name:     "invalid time zone",
+			calendar: Calendar{Name: "Work", TimeZone: "Mars/Olympus"},
+			wantErr:  true,
+			errMsg:   "time zone is invalid",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateCalendar(tt.calendar)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateLocation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		location Place
+		errMsg   string
+	}{
+		{name: "empty", location: Place{}},
+		{name: "name only", location: Place{Name: "Room 1"}},
+		{name: "coordinates", location: Place{Name: "Sol", Latitude: ptr(40.4169), Longitude: ptr(-3.7035)}},
+		{name: "edges", location: Place{Latitude: ptr(-90.0), Longitude: ptr(180.0)}},
+		{name: "name too long", location: Place{Name: strings.Repeat("a", 101)}, errMsg: "location name is too long"},
+		{name: "address too long", location: Place{Address: strings.Repeat("a", 256)}, errMsg: "location address is too long"},
+		{name: "characters counted, not bytes", location: Place{Name: strings.Repeat("é", 100), Address: strings.Repeat("ß", 255)}},
+		{name: "latitude only", location: Place{Latitude: ptr(40.0)}, errMsg: "must be set together"},
+		{name: "longitude only", location: Place{Longitude: ptr(-3.0)}, errMsg: "must be set together"},
+		{name: "longitude out of range", location: Place{Latitude: ptr(0.0), Longitude: ptr(180.5)}, errMsg: "longitude must be between -180 and 180"},
+		{name: "latitude not a number", location: Place{Latitude: ptr(math.NaN()), Longitude: ptr(0.0)}, errMsg: "latitude must be between -90 and 90"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateLocation(tt.location)
+			if tt.errMsg != "" {
+				require.ErrorContains(t, err, tt.errMsg)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"oncall", "release", "été"}, NormalizeTags([]string{" Release", "oncall", "", "RELEASE ", "Été"}))
+	assert.Equal(t, []string{"strasse"}, NormalizeTags([]string{"Straße", "STRASSE"}))
+	assert.Nil(t, NormalizeTags([]string{" ", ""}))
+	assert.Nil(t, NormalizeTags(nil))
+}
+
+func TestValidateTags(t *testing.T) {
+	t.Parallel()
+
+	tooMany := make([]string, MaxTags+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("tag-%d", i)
+	}
+
+	tests := []struct {
+		name   string
+		tags   []string
+		errMsg string
+	}{
+		{name: "none"},
+		{name: "valid", tags: []string{"oncall", "Release", "q3.offsite", "team_a", "2026"}},
+		{name: "duplicates count once", tags: append(slices.Repeat([]string{"Oncall", "oncall"}, MaxTags), "release")},
+		{name: "too many", tags: tooMany, errMsg: "too many tags (20 tops)"},
+		{name: "too long", tags: []string{strings.Repeat("a", MaxTagLength+1)}, errMsg: "is too long (50 characters tops)"},
+		{name: "characters counted, not bytes", tags: []string{strings.Repeat("é", MaxTagLength)}},
+		{name: "whitespace", tags: []string{"on call"}, errMsg: "tag 'on call' must be letters, digits"},
+		{name: "comma", tags: []string{"a,b"}, errMsg: "must be letters, digits"},
+		{name: "leading punctuation", tags: []string{"-oncall"}, errMsg: "starting with a letter or digit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateTags(tt.tags)
+			if tt.errMsg != "" {
+				require.ErrorContains(t, err, tt.errMsg)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateFreeBusyQuery(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		query  FreeBusyQuery
+		errMsg string
+	}{
+		{name: "valid", query: FreeBusyQuery{From: from, To: from.AddDate(0, 0, 7), TitlePrefix: "standup", CalendarId: "4acc05c4-3526-4c09-8739-621c4b57c8e6"}},
+		{name: "longest window", query: FreeBusyQuery{From: from, To: from.Add(MaxOccurrenceWindow)}},
+		{name: "missing to", query: FreeBusyQuery{From: from}, errMsg: "from and to are required"},
+		{name: "empty window", query: FreeBusyQuery{From: from, To: from}, errMsg: "from must be before to"},
+		{name: "window too long", query: FreeBusyQuery{From: from, To: from.Add(MaxOccurrenceWindow + time.Second)}, errMsg: "window is too long (366 days tops)"},
+		{
+			name:   "title_prefix too long",
+			query:  FreeBusyQuery{From: from, To: from.Add(time.Hour), TitlePrefix: strings.Repeat("a", 101)},
+			errMsg: "title_prefix is too long",
+		},
+		{name: "calendar_id not a UUID", query: FreeBusyQuery{From: from, To: from.Add(time.Hour), CalendarId: "uuid-c"}, errMsg: "calendar_id must be a UUID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateFreeBusyQuery(tt.query)
+			if tt.errMsg != "" {
+				require.ErrorContains(t, err, tt.errMsg)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateAttendee(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		attendee Attendee
+		wantErr  bool
+		errMsg   string
+	}{
+		{name: "valid", attendee: Attendee{Email: "ada@example.com", Name: "Ada Lovelace"}},
+		{name: "missing email", attendee: Attendee{Name: "Ada"}, wantErr: true, errMsg: "email is required"},
+		{name: "invalid email", attendee: Attendee{Email: "ada"}, wantErr: true, errMsg: "email 'ada' is invalid"},
+		{
+			name:     "email with display name",
+			attendee: Attendee{Email: "Ada <ada@example.com>"},
+			wantErr:  true,
+			errMsg:   "is invalid",
+		},
+		{
+			name:     "email too long",
+			attendee: Attendee{Email: strings.Repeat("a", 250) + "@example.com"},
+			wantErr:  true,
+			errMsg:   "email is too long (254 characters tops)",
+		},
+		{
+			name:     "name too long",
+			attendee: Attendee{Email: "ada@example.com", Name: strings.Repeat("a", 101)},
+			wantErr:  true,
+			errMsg:   "name is too long (100 characters tops)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateAttendee(tt.attendee)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateRsvpStatus(t *testing.T) {
+	t.Parallel()
+
+	for _, status := range []string{RsvpAccepted, RsvpDeclined, RsvpTentative, RsvpNeedsAction} {
+		require.NoError(t, ValidateRsvpStatus(status), status)
+	}
+
+	for _, status := range []string{"", "maybe", "ACCEPTED"} {
+		require.Error(t, ValidateRsvpStatus(status), status)
+	}
+}
+
+func TestValidateReminder(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, ValidateReminder(Reminder{OffsetMinutes: 15, Channel: ChannelEmail}))
+	require.NoError(t, ValidateReminder(Reminder{Channel: ChannelLog}))
+	require.NoError(t, ValidateReminder(Reminder{OffsetMinutes: MaxReminderOffset, Channel: ChannelWebhook}))
+	require.ErrorContains(t, ValidateReminder(Reminder{OffsetMinutes: -1, Channel: ChannelLog}), "offset_minutes must be between")
+	require.ErrorContains(t, ValidateReminder(Reminder{OffsetMinutes: MaxReminderOffset + 1, Channel: ChannelLog}), "offset_minutes must be between")
+	require.ErrorContains(t, ValidateReminder(Reminder{OffsetMinutes: 15}), "channel must be one of")
+	require.ErrorContains(t, ValidateReminder(Reminder{OffsetMinutes: 15, Channel: "sms"}), "channel must be one of")
+}
+
+func TestValidateWebhook(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, ValidateWebhook(Webhook{Url: "https://example.com/hooks"}))
+	require.NoError(t, ValidateWebhook(Webhook{Url: "http://hooks.example.com:9000", EventTypes: []string{EventCreated, EventDeleted}, Secret: "0123456789abcdef"}))
+	require.NoError(t, ValidateWebhook(Webhook{Url: "https://93.184.215.14/hooks"}))
+	require.ErrorContains(t, ValidateWebhook(Webhook{}), "url is required")
+	require.ErrorContains(t, ValidateWebhook(Webhook{Url: "https://example.com/" + strings.Repeat("a", 2048)}), "url is too long")
+	require.ErrorContains(t, ValidateWebhook(Webhook{Url: "/hooks"}), "is invalid")
+	require.ErrorContains(t, ValidateWebhook(Webhook{Url: "ftp://example.com/hooks"}), "is invalid")
+
+	for _, url := range []string{"http://localhost:9000", "http://127.0.0.1/hooks", "http://[::1]/hooks", "http://10.0.0.1/hooks", "http://169.254.169.254/latest/meta-data"} {
+		require.ErrorContains(t, ValidateWebhook(Webhook{Url: url}), "its host must be public", url)
+	}
+	require.ErrorContains(t, ValidateWebhook(Webhook{Url: "https://example.com", EventTypes: []string{"event.moved"}}), "event type 'event.moved' is invalid")
+	require.ErrorContains(t, ValidateWebhook(Webhook{Url: "https://example.com", Secret: "short"}), "secret must be between")
+}
+
+func TestValidateRegistration(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, ValidateRegistration(Registration{Email: "ada@example.com", Name: "Ada"}))
+	require.ErrorContains(t, ValidateRegistration(Registration{}), "email is required")
+	require.ErrorContains(t, ValidateRegistration(Registration{Email: "ada"}), "is invalid")
+}
+
+func TestIsUUID(t *testing.T) {
+	t.Parallel()
+
+	for _, id := range []string{"4acc05c4-3526-4c09-8739-621c4b57c8e6", "4ACC05C4-3526-4C09-8739-621C4B57C8E6"} {
+		require.True(t, IsUUID(id), id)
+	}
+
+	for _, id := range []string{"", "123", "4acc05c4-3526-4c09-8739-621c4b57c8e", "4acc05c435264c098739621c4b57c8e6", "4acc05c4-3526-4c09-8739-621c4b57c8eg"} {
+		require.False(t, IsUUID(id), id)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,302 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// WebhookBatch is the most deliveries a dispatcher claims at once.
+	WebhookBatch = 50
+	// WebhookLease is how long claimed deliveries are kept from other dispatchers (see claimLoop).
+	WebhookLease = 10 * time.Minute
+	// WebhookMaxAttempts is how many times a delivery is attempted before it is dead.
+	WebhookMaxAttempts = 8
+	// WebhookRetryDelay is how long a failed delivery waits before its second attempt, the wait doubles after every
+	// attempt up to WebhookMaxRetryDelay.
+	WebhookRetryDelay    = 30 * time.Second
+	WebhookMaxRetryDelay = 6 * time.Hour
+	// MaxDeliveries is the most deliveries listed at once.
+	MaxDeliveries = 100
+)
+
+// Headers of the deliveries. The signature is "sha256=" followed by the hex HMAC-SHA256, keyed with the secret of
+// the webhook, of the timestamp, a dot and the body; receivers recompute it to authenticate the delivery, and reject
+// old timestamps to stop replays.
+const (
+	HeaderWebhookId        = "X-Webhook-Id"
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp"
+	HeaderWebhookSignature = "X-Webhook-Signature"
+)
+
+// webhookEventTypes are the outbox messages webhooks subscribe to.
+var webhookEventTypes = []string{EventCreated, EventUpdated, EventDeleted}
+
+// WebhookQueue queues the deliveries of the changes of events to the webhooks subscribed to them.
+type WebhookQueue interface {
+	EnqueueDeliveries(ctx context.Context, message OutboxMessage) (int64, error)
+}
+
+// PendingDelivery is a delivery claimed by a dispatcher, along with where to send it and how to sign it.
+type PendingDelivery struct {
+	Delivery
+	Url    string
+	Secret string
+}
+
+// WebhookPayload is the body of the deliveries, Data is the event as the API returns it, or only its id once deleted.
+type WebhookPayload struct {
+	Id        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// NewWebhookSecret returns a random secret for webhooks created without one.
+func NewWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	return hex.EncodeToString(secret), nil
+}
+
+// SignWebhook returns the X-Webhook-Signature header of a delivery of body sent at timestamp.
+func SignWebhook(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10) + "."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookBackoff returns how long a delivery waits after its given number of failed attempts.
+func WebhookBackoff(attempts int) time.Duration {
+	return backoff(attempts, WebhookRetryDelay, WebhookMaxRetryDelay)
+}
+
+// WebhookPublisher publishes the outbox messages of the changes of events as deliveries to the webhooks subscribed to
+// them, the dispatcher sends them afterwards.
+type WebhookPublisher struct {
+	queue WebhookQueue
+}
+
+func NewWebhookPublisher(queue WebhookQueue) *WebhookPublisher {
+	return &WebhookPublisher{queue: queue}
+}
+
+// Publish queues the deliveries of the message, those of a type no webhook can subscribe to are left alone.
+func (p *WebhookPublisher) Publish(ctx context.Context, message OutboxMessage) error {
+	if !slices.Contains(webhookEventTypes, message.Type) {
+		return nil
+	}
+
+	_, err := p.queue.EnqueueDeliveries(ctx, message)
+
+	return err
+}
+
+// WebhookDispatcher sends the pending deliveries to their webhook. Replicas claim the deliveries they send, so none
+// is sent twice at once; a delivery is still sent at least once, and receivers tell repeats apart by X-Webhook-Id.
+type WebhookDispatcher struct {
+	name       string
+	repository RepositoryInterface
+	client     *http.Client
+	tracer     trace.Tracer
+}
+
+// NewWebhookDispatcher gives each webhook up to timeout to answer a delivery.
+func NewWebhookDispatcher(repository RepositoryInterface, timeout time.Duration) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		name:       "webhook-dispatcher",
+		repository: repository,
+		client:     newWebhookClient(timeout, dialPublic),
+		tracer:     otel.GetTracerProvider().Tracer("ltk-code-challenge/webhooks"),
+	}
+}
+
+// newWebhookClient gives the webhooks up to timeout to answer, and checks every address it dials with control. The
+// webhook URLs are chosen by the API clients, so the client goes straight to the webhook, not through a proxy that
+// would dial past control, and does not follow redirects: a 3xx is answered as is, and is a failure.
+func newWebhookClient(timeout time.Duration, control func(network, address string, c syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: control}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// dialPublic refuses to dial the addresses that are not public (see isPublicAddr). It runs once the host name is
+// resolved, so a webhook cannot reach the services next to the server through a name pointing at them.
+func dialPublic(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+
+	if !isPublicAddr(addr) {
+		return fmt.Errorf("address %s is not public", addr)
+	}
+
+	return nil
+}
+
+// isPublicAddr tells whether addr may be the address of a webhook: neither loopback, private, link-local (the cloud
+// metadata endpoints at 169.254.169.254 included), unspecified nor multicast.
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	return !addr.IsLoopback() && !addr.IsPrivate() && !addr.IsLinkLocalUnicast() && !addr.IsLinkLocalMulticast() &&
+		!addr.IsUnspecified() && !addr.IsMulticast()
+}
+
+// Dispatch sends the due deliveries and returns the number of them delivered. A delivery that fails is retried with
+// an exponential backoff until it is dead after WebhookMaxAttempts attempts.
+func (d *WebhookDispatcher) Dispatch(ctx context.Context) (int, error) {
+	loop := claimLoop[PendingDelivery]{
+		component: d.name,
+		item:      "delivery",
+		items:     "deliveries",
+		batch:     WebhookBatch,
+		lease:     WebhookLease,
+		claim:     d.repository.ClaimDeliveries,
+		dispatch: func(ctx context.Context, delivery PendingDelivery, _ time.Time) (int, error) {
+			err := d.deliver(ctx, delivery)
+			if err != nil {
+				return 0, err
+			}
+
+			return 1, nil
+		},
+		id: func(delivery PendingDelivery) string { return delivery.Id },
+	}
+
+	return loop.run(ctx)
+}
+
+// deliver makes an attempt at the delivery and records its outcome. The attempt continues the trace of the request
+// that changed the event.
+func (d *WebhookDispatcher) deliver(ctx context.Context, pending PendingDelivery) error {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(pending.TraceContext))
+
+	ctx, span := d.tracer.Start(ctx, "webhook.deliver",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("webhook.id", pending.WebhookId),
+			attribute.String("webhook.delivery", pending.Id),
+			attribute.String("webhook.event_type", pending.EventType),
+			attribute.Int("webhook.attempt", pending.Attempts+1),
+		),
+	)
+	defer span.End()
+
+	delivery := pending.Delivery
+	now := time.Now()
+
+	statusCode, err := d.send(ctx, pending, now)
+	delivery.Attempts, delivery.LastStatusCode = delivery.Attempts+1, statusCode
+
+	switch {
+	case err == nil:
+		delivery.Status, delivery.NextAttemptAt, delivery.DeliveredAt, delivery.LastError = DeliverySucceeded, nil, &now, ""
+	case delivery.Attempts >= WebhookMaxAttempts:
+		delivery.Status, delivery.NextAttemptAt, delivery.LastError = DeliveryDead, nil, err.Error()
+	default:
+		next := now.Add(WebhookBackoff(delivery.Attempts))
+		delivery.NextAttemptAt, delivery.LastError = &next, err.Error()
+	}
+
+	span.SetAttributes(attribute.String("webhook.status", delivery.Status))
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	// A webhook deleted meanwhile took its deliveries along
+	recordErr := d.repository.RecordDelivery(ctx, &delivery)
+	if errors.Is(recordErr, ErrDeliveryNotFound) {
+		recordErr = nil
+	}
+
+	return errors.Join(err, recordErr)
+}
+
+// send posts the signed delivery and returns the status code answered, any but a 2xx is a failure.
+func (d *WebhookDispatcher) send(ctx context.Context, pending PendingDelivery, now time.Time) (int, error) {
+	body, err := json.Marshal(WebhookPayload{
+		Id:        pending.Id,
+		Type:      pending.EventType,
+		CreatedAt: pending.CreatedAt,
+		Data:      pending.Payload,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode delivery: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pending.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build webhook request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookId, pending.Id)
+	req.Header.Set(HeaderWebhookEvent, pending.EventType)
+	req.Header.Set(HeaderWebhookTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderWebhookSignature, SignWebhook(pending.Secret, now, body))
+
+	// Receivers tracing their requests join the trace of the change
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook answered %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,246 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// The trace context is propagated as it is in production, see resources.Trace
+func init() {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
+
+func TestSignWebhook(t *testing.T) {
+	t.Parallel()
+
+	timestamp := time.Unix(1741000000, 0)
+	body := []byte(`{"id":"uuid-d"}`)
+
+	signature := SignWebhook("0123456789abcdef", timestamp, body)
+
+	assert.Equal(t, "sha256=", signature[:7])
+	assert.Len(t, signature, 7+64)
+	assert.Equal(t, signature, SignWebhook("0123456789abcdef", timestamp, body))
+	assert.NotEqual(t, signature, SignWebhook("fedcba9876543210", timestamp, body))
+	assert.NotEqual(t, signature, SignWebhook("0123456789abcdef", timestamp.Add(time.Second), body))
+	assert.NotEqual(t, signature, SignWebhook("0123456789abcdef", timestamp, []byte(`{"id":"uuid-e"}`)))
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 30*time.Second, WebhookBackoff(1))
+	assert.Equal(t, time.Minute, WebhookBackoff(2))
+	assert.Equal(t, 32*time.Minute, WebhookBackoff(7))
+	assert.Equal(t, WebhookMaxRetryDelay, WebhookBackoff(100))
+}
+
+// newLoopbackDispatcher builds a WebhookDispatcher that may dial the loopback address of the test servers.
+func newLoopbackDispatcher(repository RepositoryInterface) *WebhookDispatcher {
+	dispatcher := NewWebhookDispatcher(repository, time.Second)
+	dispatcher.client = newWebhookClient(time.Second, nil)
+
+	return dispatcher
+}
+
+func TestIsPublicAddr(t *testing.T) {
+	t.Parallel()
+
+	for _, addr := range []string{"93.184.215.14", "2606:2800:21f:cb07:6820:80da:af6b:8b2c"} {
+		assert.True(t, isPublicAddr(netip.MustParseAddr(addr)), addr)
+	}
+
+	for _, addr := range []string{"127.0.0.1", "::1", "10.0.0.1", "172.16.0.1", "192.168.1.1", "fd00::1", "169.254.169.254", "fe80::1", "0.0.0.0", "::", "224.0.0.1", "::ffff:127.0.0.1"} {
+		assert.False(t, isPublicAddr(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestWebhookDispatcher_Dispatch(t *testing.T) {
+	t.Parallel()
+
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	tests := []struct {
+		name       string
+		status     int
+		attempts   int
+		wantStatus string
+		// wantRetry is whether the delivery is attempted again, after WebhookBackoff
+		wantRetry     bool
+		wantDelivered int
+	}{
+		{
+			name:          "delivered",
+			status:        http.StatusNoContent,
+			wantStatus:    DeliverySucceeded,
+			wantDelivered: 1,
+		},
+		{
+			name:       "retried",
+			status:     http.StatusInternalServerError,
+			attempts:   2,
+			wantStatus: DeliveryPending,
+			wantRetry:  true,
+		},
+		{
+			name:       "redirect not followed",
+			status:     http.StatusFound,
+			wantStatus: DeliveryPending,
+			wantRetry:  true,
+		},
+		{
+			name:       "dead after too many attempts",
+			status:     http.StatusGone,
+			attempts:   WebhookMaxAttempts - 1,
+			wantStatus: DeliveryDead,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var header http.Header
+			var payload WebhookPayload
+			var requests int
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+
+				body, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+
+				// Receivers authenticate deliveries by signing them again
+				timestamp, err := strconv.ParseInt(r.Header.Get(HeaderWebhookTimestamp), 10, 64)
+				assert.NoError(t, err)
+				assert.Equal(t, SignWebhook("0123456789abcdef", time.Unix(timestamp, 0), body), r.Header.Get(HeaderWebhookSignature))
+
+				header = r.Header
+				assert.NoError(t, json.Unmarshal(body, &payload))
+				w.Header().Set("Location", "/moved")
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			created := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+			pending := PendingDelivery{
+				Delivery: Delivery{
+					Id: "uuid-d", WebhookId: "uuid-w", EventType: EventDeleted, EventId: "uuid-1", Payload: json.RawMessage(`{"id":"uuid-1"}`),
+					TraceContext: map[string]string{"traceparent": traceparent}, Status: DeliveryPending, Attempts: tt.attempts, CreatedAt: created,
+				},
+				Url:    server.URL,
+				Secret: "0123456789abcdef",
+			}
+
+			repo := new(MockRepository)
+			repo.On("ClaimDeliveries", mock.Anything, mock.Anything, mock.Anything, WebhookBatch).Return([]PendingDelivery{pending}, nil)
+
+			var recorded Delivery
+
+			repo.On("RecordDelivery", mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) { recorded = *args.Get(1).(*Delivery) }).
+				Return(nil)
+
+			start := time.Now()
+
+			delivered, err := newLoopbackDispatcher(repo).Dispatch(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantDelivered, delivered)
+			assert.Equal(t, 1, requests)
+
+			assert.Equal(t, "uuid-d", header.Get(HeaderWebhookId))
+			assert.Equal(t, EventDeleted, header.Get(HeaderWebhookEvent))
+			assert.Equal(t, WebhookPayload{Id: "uuid-d", Type: EventDeleted, CreatedAt: created, Data: json.RawMessage(`{"id":"uuid-1"}`)}, payload)
+
+			// The request continues the trace of the change, from a span of its own
+			assert.Equal(t, traceparent[:36], header.Get("traceparent")[:36])
+
+			assert.Equal(t, tt.wantStatus, recorded.Status)
+			assert.Equal(t, tt.attempts+1, recorded.Attempts)
+			assert.Equal(t, tt.status, recorded.LastStatusCode)
+
+			if tt.wantRetry {
+				require.NotNil(t, recorded.NextAttemptAt)
+				assert.WithinDuration(t, start.Add(WebhookBackoff(tt.attempts+1)), *recorded.NextAttemptAt, 5*time.Second)
+			} else {
+				assert.Nil(t, recorded.NextAttemptAt)
+			}
+
+			if tt.wantStatus == DeliverySucceeded {
+				assert.NotNil(t, recorded.DeliveredAt)
+				assert.Empty(t, recorded.LastError)
+			} else {
+				assert.Nil(t, recorded.DeliveredAt)
+				assert.Contains(t, recorded.LastError, strconv.Itoa(tt.status))
+			}
+
+			repo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("webhook deleted meanwhile", func(t *testing.T) {
+		t.Parallel()
+
+		repo := new(MockRepository)
+		repo.On("ClaimDeliveries", mock.Anything, mock.Anything, mock.Anything, WebhookBatch).
+			Return([]PendingDelivery{{Delivery: Delivery{Id: "uuid-d"}, Url: "http://127.0.0.1:1"}}, nil)
+		repo.On("RecordDelivery", mock.Anything, mock.Anything).Return(ErrDeliveryNotFound)
+
+		delivered, err := NewWebhookDispatcher(repo, time.Second).Dispatch(context.Background())
+		require.NoError(t, err)
+		assert.Zero(t, delivered)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("address not public", func(t *testing.T) {
+		t.Parallel()
+
+		called := false
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}))
+		defer server.Close()
+
+		repo := new(MockRepository)
+		repo.On("ClaimDeliveries", mock.Anything, mock.Anything, mock.Anything, WebhookBatch).
+			Return([]PendingDelivery{{Delivery: Delivery{Id: "uuid-d"}, Url: server.URL}}, nil)
+
+		var recorded Delivery
+
+		repo.On("RecordDelivery", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { recorded = *args.Get(1).(*Delivery) }).
+			Return(nil)
+
+		delivered, err := NewWebhookDispatcher(repo, time.Second).Dispatch(context.Background())
+		require.NoError(t, err)
+		assert.Zero(t, delivered)
+		assert.False(t, called)
+		assert.Contains(t, recorded.LastError, "is not public")
+		assert.NotNil(t, recorded.NextAttemptAt)
+	})
+
+	t.Run("claim failure", func(t *testing.T) {
+		t.Parallel()
+
+		repo := new(MockRepository)
+		repo.On("ClaimDeliveries", mock.Anything, mock.Anything, mock.Anything, WebhookBatch).Return(nil, errors.New("db error"))
+
+		_, err := NewWebhookDispatcher(repo, time.Second).Dispatch(context.Background())
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,371 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// MaxTopics is the most topics a connection subscribes to at once.
+	MaxTopics = 50
+	// WSMaxMessageSize is the largest message read from the clients.
+	WSMaxMessageSize = 4096
+	// WSWriteWait is how long a message may take to be written, clients not reading it meanwhile are disconnected.
+	WSWriteWait = 10 * time.Second
+	// WSPongWait is how long a client may stay silent, it answers the pings sent every WSPingPeriod meanwhile.
+	WSPongWait   = 60 * time.Second
+	WSPingPeriod = WSPongWait * 9 / 10
+	// wsReplies is how many replies to their requests clients may fall behind by before being disconnected.
+	wsReplies = 16
+)
+
+// Actions of the messages clients send, and types of those they receive.
+const (
+	WSSubscribe    = "subscribe"
+	WSUnsubscribe  = "unsubscribe"
+	WSSubscribed   = "subscribed"
+	WSUnsubscribed = "unsubscribed"
+	WSChange       = "change"
+	WSReset        = "reset"
+	WSError        = "error"
+)
+
+// Topics are named after what they match: "event:<id>" the changes of a single event, "window:<from>/<to>" (RFC
+// 3339) those of the events with occurrences possibly within the window, and "title:<prefix>" those of the events
+// whose title starts with the prefix, whatever its case.
+const (
+	topicEvent  = "event:"
+	topicWindow = "window:"
+	topicTitle  = "title:"
+)
+
+// Topic is what the changes a client subscribes to match.
+type Topic struct {
+	EventId     string
+	Window      *TimeWindow
+	TitlePrefix string
+}
+
+// ParseTopic parses the name of a topic.
+func ParseTopic(name string) (Topic, error) {
+	var topic Topic
+
+	switch {
+	case strings.HasPrefix(name, topicEvent):
+		topic.EventId = strings.TrimPrefix(name, topicEvent)
+		if topic.EventId == "" {
+			return topic, errors.New("topic 'event:' is missing the event id")
+		}
+	case strings.HasPrefix(name, topicWindow):
+		from, to, ok := strings.Cut(strings.TrimPrefix(name, topicWindow), "/")
+		if !ok {
+			return topic, errors.New("topic 'window:' must be <from>/<to>")
+		}
+
+		window, err := parseWindow(from, to)
+		if err != nil {
+			return topic, err
+		}
+
+		topic.Window = window
+	case strings.HasPrefix(name, topicTitle):
+		topic.TitlePrefix = strings.TrimPrefix(name, topicTitle)
+		if topic.TitlePrefix == "" || utf8.RuneCountInString(topic.TitlePrefix) > 100 {
+			return topic, errors.New("topic 'title:' must have a prefix of 1 to 100 characters")
+		}
+	default:
+		return topic, fmt.Errorf("unknown topic %q", name)
+	}
+
+	return topic, nil
+}
+
+func parseWindow(from string, to string) (*TimeWindow, error) {
+	start, err := time.Parse(time.RFC3339, from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid window start: %w", err)
+	}
+
+	end, err := time.Parse(time.RFC3339, to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid window end: %w", err)
+	}
+
+	if !start.Before(end) {
+		return nil, errors.New("window start must be before its end")
+	}
+
+	return &TimeWindow{From: start, To: end}, nil
+}
+
+// Matches returns whether the change is of the topic. Occurrences moved into a window do not make their series match.
+func (t Topic) Matches(change Change) bool {
+	switch {
+	case t.EventId != "":
+		return change.EventId == t.EventId
+	case t.Window != nil:
+		return change.StartTime.Before(t.Window.To) && (change.SeriesEnd == nil || change.SeriesEnd.After(t.Window.From))
+	case t.TitlePrefix != "":
+		return strings.HasPrefix(strings.ToLower(change.Title), strings.ToLower(t.TitlePrefix))
+	default:
+		return false
+	}
+}
+
+// WSRequest is a message sent by the clients, to subscribe to a topic or unsubscribe from it.
+type WSRequest struct {
+	Action string `json:"action"`
+	Topic  string `json:"topic"`
+}
+
+// WSMessage is a message sent to the clients: the reply to a request, or a change of the topics it is of. A reset
+// tells them that they may have missed changes, and should fetch what they show again.
+type WSMessage struct {
+	Type   string   `json:"type"`
+	Topic  string   `json:"topic,omitempty"`
+	Topics []string `json:"topics,omitempty"`
+	Change *Change  `json:"change,omitempty"`
+	Error  string   `json:"error,omitempty"`
+}
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// wsSession is a connection of a client to /ws. The read loop handles its requests and the write loop sends the
+// replies and the changes of its topics, it is the only one writing to the connection.
+type wsSession struct {
+	conn    *websocket.Conn
+	sub     *Subscription
+	mu      sync.Mutex // guards topics
+	topics  map[string]Topic
+	replies chan WSMessage
+	done    chan struct{}
+}
+
+func (h *Handlers) ServeWebSocket(gctx *gin.Context) {
+	if h.stream == nil {
+		gctx.AbortWithStatusJSON(http.StatusServiceUnavailable, NewError("change stream unavailable", ErrStreamClosed))
+
+		return
+	}
+
+	sub, err := h.stream.Subscribe(nil)
+	if err != nil {
+		gctx.AbortWithStatusJSON(http.StatusServiceUnavailable, NewError("change stream unavailable", err))
+
+		return
+	}
+	defer h.stream.Unsubscribe(sub)
+
+	// WebSocket: GET /ws, the upgrader answers the requests it cannot upgrade
+	conn, err := upgrader.Upgrade(gctx.Writer, gctx.Request, nil)
+	if err != nil {
+		log.Err(err).Msg("websocket upgrade failed")
+
+		return
+	}
+
+	session := &wsSession{
+		conn:    conn,
+		sub:     sub,
+		topics:  make(map[string]Topic),
+		replies: make(chan WSMessage, wsReplies),
+		done:    make(chan struct{}),
+	}
+
+	ctx := context.WithoutCancel(gctx.Request.Context())
+
+	h.wsMetrics.Opened(ctx)
+	reason := session.run()
+	h.wsMetrics.Closed(ctx, reason)
+}
+
+// run serves the session until the connection closes, and returns why it did.
+func (s *wsSession) run() string {
+	defer s.conn.Close()
+
+	go s.read()
+
+	return s.write()
+}
+
+func (s *wsSession) read() {
+	defer close(s.done)
+
+	s.conn.SetReadLimit(WSMaxMessageSize)
+	_ = s.conn.SetReadDeadline(time.Now().Add(WSPongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(WSPongWait))
+	})
+
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		select {
+		case s.replies <- s.handle(data):
+		default:
+			// Clients not reading their replies are disconnected like those not reading their changes
+			return
+		}
+	}
+}
+
+// handle subscribes to a topic or unsubscribes from it, and returns the reply.
+func (s *wsSession) handle(data []byte) WSMessage {
+	var req WSRequest
+
+	err := json.Unmarshal(data, &req)
+	if err != nil {
+		return WSMessage{Type: WSError, Error: "invalid message: " + err.Error()}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch req.Action {
+	case WSSubscribe:
+		topic, err := ParseTopic(req.Topic)
+		if err != nil {
+			return WSMessage{Type: WSError, Topic: req.Topic, Error: err.Error()}
+		}
+
+		if _, ok := s.topics[req.Topic]; !ok && len(s.topics) >= MaxTopics {
+			return WSMessage{Type: WSError, Topic: req.Topic, Error: fmt.Sprintf("too many topics (%d tops)", MaxTopics)}
+		}
+
+		s.topics[req.Topic] = topic
+
+		return WSMessage{Type: WSSubscribed, Topic: req.Topic}
+	case WSUnsubscribe:
+		delete(s.topics, req.Topic)
+
+		return WSMessage{Type: WSUnsubscribed, Topic: req.Topic}
+	default:
+		return WSMessage{Type: WSError, Topic: req.Topic, Error: fmt.Sprintf("unknown action %q", req.Action)}
+	}
+}
+
+// match returns the topics of the session the change is of, in no particular order.
+func (s *wsSession) match(change Change) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	topics := make([]string, 0)
+
+	for name, topic := range s.topics {
+		if topic.Matches(change) {
+			topics = append(topics, name)
+		}
+	}
+
+	return topics
+}
+
+func (s *wsSession) write() string {
+	ping := time.NewTicker(WSPingPeriod)
+	defer ping.Stop()
+
+	for {
+		var err error
+
+		select {
+		case <-s.done:
+			return "client"
+		case reply := <-s.replies:
+			err = s.send(reply)
+		case change, ok := <-s.sub.Changes():
+			if !ok {
+				return s.close(s.sub.Err())
+			}
+
+			if change.Type == StreamReset {
+				err = s.send(WSMessage{Type: WSReset})
+				break
+			}
+
+			if topics := s.match(change); len(topics) > 0 {
+				err = s.send(WSMessage{Type: WSChange, Topics: topics, Change: &change})
+			}
+		case <-ping.C:
+			err = s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(WSWriteWait))
+		}
+
+		if err != nil {
+			return "error"
+		}
+	}
+}
+
+func (s *wsSession) send(message WSMessage) error {
+	_ = s.conn.SetWriteDeadline(time.Now().Add(WSWriteWait))
+
+	return s.conn.WriteJSON(message)
+}
+
+// close tells the client why the subscription ended, and returns it.
+func (s *wsSession) close(err error) string {
+	code, reason := websocket.CloseGoingAway, "shutdown"
+	if errors.Is(err, ErrSubscriberBehind) {
+		code, reason = websocket.CloseTryAgainLater, "behind"
+	}
+
+	_ = s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, err.Error()), time.Now().Add(WSWriteWait))
+
+	return reason
+}
+
+// WSMetrics counts the connections to /ws: those open, and those closed by why they did (the client left or failed,
+// it fell behind, or the server shut down).
+type WSMetrics struct {
+	active metric.Int64UpDownCounter
+	opened metric.Int64Counter
+	closed metric.Int64Counter
+}
+
+func NewWSMetrics() *WSMetrics {
+	meter := otel.Meter("ltk-code-challenge/ws")
+
+	active, _ := meter.Int64UpDownCounter(
+		"ws.connections.active",
+		metric.WithDescription("WebSocket connections open"),
+	)
+	opened, _ := meter.Int64Counter(
+		"ws.connections.opened",
+		metric.WithDescription("WebSocket connections opened"),
+	)
+	closed, _ := meter.Int64Counter(
+		"ws.connections.closed",
+		metric.WithDescription("WebSocket connections closed, by reason"),
+	)
+
+	return &WSMetrics{active: active, opened: opened, closed: closed}
+}
+
+func (m *WSMetrics) Opened(ctx context.Context) {
+	m.active.Add(ctx, 1)
+	m.opened.Add(ctx, 1)
+}
+
+func (m *WSMetrics) Closed(ctx context.Context, reason string) {
+	m.active.Add(ctx, -1)
+	m.closed.Add(ctx, 1, metric.WithAttributes(attribute.String("ws.close_reason", reason)))
+}
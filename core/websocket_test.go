@@ -0,0 +1,328 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTopic(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		topic   string
+		want    Topic
+		wantErr bool
+	}{
+		{
+			name:  "event",
+			topic: "event:uuid-1",
+			want:  Topic{EventId: "uuid-1"},
+		},
+		{
+			name:  "window",
+			topic: "window:2025-12-22T00:00:00Z/2025-12-29T00:00:00Z",
+			want: Topic{Window: &TimeWindow{
+				From: time.Date(2025, 12, 22, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(2025, 12, 29, 0, 0, 0, 0, time.UTC),
+			}},
+		},
+		{
+			name:  "title",
+			topic: "title:Stand",
+			want:  Topic{TitlePrefix: "Stand"},
+		},
+		{
+			name:    "event without id",
+			topic:   "event:",
+			wantErr: true,
+		},
+		{
+			name:    "window without end",
+			topic:   "window:2025-12-22T00:00:00Z",
+			wantErr: true,
+		},
+		{
+			name:    "window ending before its start",
+			topic:   "window:2025-12-29T00:00:00Z/2025-12-22T00:00:00Z",
+			wantErr: true,
+		},
+		{
+			name:    "window of invalid times",
+			topic:   "window:monday/friday",
+			wantErr: true,
+		},
+		{
+			name:    "title too long",
+			topic:   "title:" + strings.Repeat("a", 101),
+			wantErr: true,
+		},
+		{
+			name:    "unknown topic",
+			topic:   "week:1",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			topic, err := ParseTopic(tt.topic)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, topic)
+		})
+	}
+}
+
+func TestTopic_Matches(t *testing.T) {
+	t.Parallel()
+
+	window := &TimeWindow{
+		From: time.Date(2025, 12, 22, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2025, 12, 29, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name   string
+		topic  Topic
+		change Change
+		want   bool
+	}{
+		{
+			name:   "same event",
+			topic:  Topic{EventId: "uuid-1"},
+			change: Change{EventId: "uuid-1"},
+			want:   true,
+		},
+		{
+			name:   "other event",
+			topic:  Topic{EventId: "uuid-1"},
+			change: Change{EventId: "uuid-2"},
+		},
+		{
+			name:   "event within the window",
+			topic:  Topic{Window: window},
+			change: Change{StartTime: time.Date(2025, 12, 23, 9, 0, 0, 0, time.UTC), SeriesEnd: timePtr(time.Date(2025, 12, 23, 10, 0, 0, 0, time.UTC))},
+			want:   true,
+		},
+		{
+			name:   "series begun before the window",
+			topic:  Topic{Window: window},
+			change: Change{StartTime: time.Date(2025, 12, 1, 9, 0, 0, 0, time.UTC)},
+			want:   true,
+		},
+		{
+			name:   "series ended before the window",
+			topic:  Topic{Window: window},
+			change: Change{StartTime: time.Date(2025, 12, 1, 9, 0, 0, 0, time.UTC), SeriesEnd: timePtr(time.Date(2025, 12, 22, 0, 0, 0, 0, time.UTC))},
+		},
+		{
+			name:   "event after the window",
+			topic:  Topic{Window: window},
+			change: Change{StartTime: time.Date(2025, 12, 29, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:   "title with the prefix, whatever its case",
+			topic:  Topic{TitlePrefix: "stand"},
+			change: Change{Title: "Standup"},
+			want:   true,
+		},
+		{
+			name:   "title without the prefix",
+			topic:  Topic{TitlePrefix: "stand"},
+			change: Change{Title: "Weekly standup"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.topic.Matches(tt.change))
+		})
+	}
+}
+
+// dialWebSocket serves /ws from the stream, and connects to it.
+func dialWebSocket(t *testing.T, stream *ChangeStream) *websocket.Conn {
+	t.Helper()
+
+	router := gin.New()
+	router.GET("/ws", NewHandlers(new(MockRepository), stream).ServeWebSocket)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	return conn
+}
+
+// request sends the request, and returns the reply.
+func request(t *testing.T, conn *websocket.Conn, action string, topic string) WSMessage {
+	t.Helper()
+
+	require.NoError(t, conn.WriteJSON(WSRequest{Action: action, Topic: topic}))
+
+	var reply WSMessage
+	require.NoError(t, conn.ReadJSON(&reply))
+
+	return reply
+}
+
+func TestHandlers_ServeWebSocket(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sends the changes of the topics subscribed to", func(t *testing.T) {
+		t.Parallel()
+
+		stream := NewChangeStream(nil, time.Second)
+		conn := dialWebSocket(t, stream)
+
+		assert.Equal(t, WSMessage{Type: WSSubscribed, Topic: "event:uuid-1"}, request(t, conn, WSSubscribe, "event:uuid-1"))
+		assert.Equal(t, WSMessage{Type: WSSubscribed, Topic: "title:stand"}, request(t, conn, WSSubscribe, "title:stand"))
+
+		stream.publish(Change{Id: 1, Type: EventUpdated, EventId: "uuid-2", Title: "Review"})
+		stream.publish(Change{Id: 2, Type: EventUpdated, EventId: "uuid-1", Title: "Review"})
+
+		var message WSMessage
+		require.NoError(t, conn.ReadJSON(&message))
+		assert.Equal(t, WSChange, message.Type)
+		assert.Equal(t, []string{"event:uuid-1"}, message.Topics)
+		require.NotNil(t, message.Change)
+		assert.Equal(t, int64(2), message.Change.Id)
+
+		assert.Equal(t, WSMessage{Type: WSUnsubscribed, Topic: "event:uuid-1"}, request(t, conn, WSUnsubscribe, "event:uuid-1"))
+
+		stream.publish(Change{Id: 3, Type: EventDeleted, EventId: "uuid-1", Title: "Review"})
+		stream.publish(Change{Id: 4, Type: EventCreated, EventId: "uuid-3", Title: "Standup"})
+
+		require.NoError(t, conn.ReadJSON(&message))
+		assert.Equal(t, []string{"title:stand"}, message.Topics)
+		assert.Equal(t, int64(4), message.Change.Id)
+	})
+
+	t.Run("rejects invalid requests", func(t *testing.T) {
+		t.Parallel()
+
+		conn := dialWebSocket(t, NewChangeStream(nil, time.Second))
+
+		reply := request(t, conn, WSSubscribe, "week:1")
+		assert.Equal(t, WSError, reply.Type)
+		assert.Equal(t, "week:1", reply.Topic)
+
+		reply = request(t, conn, "publish", "event:uuid-1")
+		assert.Equal(t, WSError, reply.Type)
+
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("{")))
+
+		var message WSMessage
+		require.NoError(t, conn.ReadJSON(&message))
+		assert.Equal(t, WSError, message.Type)
+	})
+
+	t.Run("limits the topics", func(t *testing.T) {
+		t.Parallel()
+
+		conn := dialWebSocket(t, NewChangeStream(nil, time.Second))
+
+		for i := range MaxTopics {
+			reply := request(t, conn, WSSubscribe, "event:uuid-"+strings.Repeat("1", i+1))
+			require.Equal(t, WSSubscribed, reply.Type)
+		}
+
+		assert.Equal(t, WSError, request(t, conn, WSSubscribe, "title:stand").Type)
+		// Subscribing again to a topic is harmless
+		assert.Equal(t, WSSubscribed, request(t, conn, WSSubscribe, "event:uuid-1").Type)
+	})
+
+	t.Run("resets the clients that may have missed changes", func(t *testing.T) {
+		t.Parallel()
+
+		stream := NewChangeStream(nil, time.Second)
+		conn := dialWebSocket(t, stream)
+
+		request(t, conn, WSSubscribe, "event:uuid-1")
+
+		stream.lost = true
+		stream.listening()
+
+		var message WSMessage
+		require.NoError(t, conn.ReadJSON(&message))
+		assert.Equal(t, WSMessage{Type: WSReset}, message)
+	})
+
+	t.Run("disconnects the clients too far behind", func(t *testing.T) {
+		t.Parallel()
+
+		stream := NewChangeStream(nil, time.Second)
+		conn := dialWebSocket(t, stream)
+
+		request(t, conn, WSSubscribe, "event:uuid-1")
+
+		// The stream drops the subscribers too far behind, see TestChangeStream_Publish
+		stream.mu.Lock()
+		for sub := range stream.subscribers {
+			stream.drop(sub, ErrSubscriberBehind)
+		}
+		stream.mu.Unlock()
+
+		var err error
+		for err == nil {
+			_, _, err = conn.ReadMessage()
+		}
+
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseTryAgainLater), err)
+	})
+
+	t.Run("closes the connections on shutdown", func(t *testing.T) {
+		t.Parallel()
+
+		stream := NewChangeStream(nil, time.Second)
+		conn := dialWebSocket(t, stream)
+
+		request(t, conn, WSSubscribe, "event:uuid-1")
+		stream.Close()
+
+		_, _, err := conn.ReadMessage()
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), err)
+	})
+
+	t.Run("stream unavailable", func(t *testing.T) {
+		t.Parallel()
+
+		stream := NewChangeStream(nil, time.Second)
+		stream.Close()
+
+		router := gin.New()
+		router.GET("/ws", NewHandlers(new(MockRepository), stream).ServeWebSocket)
+
+		server := httptest.NewServer(router)
+		t.Cleanup(server.Close)
+
+		_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+		require.Error(t, err)
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		_ = resp.Body.Close()
+	})
+}
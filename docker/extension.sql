@@ -1 +1,3 @@
-CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
\ No newline at end of file
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+-- equality on scope within the events_scope_overlap_excl exclusion constraint
+CREATE EXTENSION IF NOT EXISTS btree_gist;
\ No newline at end of file
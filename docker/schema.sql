@@ -0,0 +1,339 @@
+-- calendars group the events of a team; deleting one deletes its events (see DELETE /calendars/:id)
+CREATE TABLE IF NOT EXISTS calendars
+(
+    id          UUID PRIMARY KEY      DEFAULT uuid_generate_v4(),
+    name        VARCHAR(100) NOT NULL,
+    description TEXT         NOT NULL DEFAULT '',
+    -- default time_zone of the events created in the calendar
+    time_zone   TEXT         NOT NULL DEFAULT 'UTC',
+    color       VARCHAR(7)   NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS events
+(
+    id            UUID PRIMARY KEY      DEFAULT uuid_generate_v4(),
+    title         VARCHAR(100) NOT NULL,
+    description   TEXT         NOT NULL DEFAULT '',
+    start_time    TIMESTAMPTZ  NOT NULL,
+    end_time      TIMESTAMPTZ  NOT NULL,
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    -- last change to the event or its occurrences (Last-Modified of the iCalendar export)
+    updated_at    TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    -- optimistic concurrency for PUT/PATCH /events/:id (exposed as the ETag)
+    version       INTEGER      NOT NULL DEFAULT 1,
+    -- soft delete: trashed events are hidden until restored or purged
+    deleted_at    TIMESTAMPTZ,
+    -- IANA time zone the event was scheduled in; recurrences are expanded in it by GET /events/occurrences
+    time_zone     TEXT         NOT NULL DEFAULT 'UTC',
+    -- all-day events span whole days, from midnight to midnight in time_zone
+    all_day       BOOLEAN      NOT NULL DEFAULT FALSE,
+    -- recurrence (RFC 5545)
+    rrule         TEXT         NOT NULL DEFAULT '',
+    exdates       TIMESTAMPTZ[],
+    rdates        TIMESTAMPTZ[],
+    -- end of the last occurrence, NULL when the event recurs forever (see core.SeriesEnd)
+    series_end    TIMESTAMPTZ,
+    -- UID of the VEVENT the event was imported from by POST /events/import, '' otherwise
+    ical_uid      TEXT         NOT NULL DEFAULT '',
+    -- room, team... events sharing a scope cannot overlap, '' opts out
+    scope         VARCHAR(100) NOT NULL DEFAULT '',
+    -- NULL for events outside any calendar
+    calendar_id   UUID REFERENCES calendars (id) ON DELETE CASCADE,
+    -- registrations admitted before the waitlist starts, 0 for no limit
+    capacity      INTEGER      NOT NULL DEFAULT 0 CHECK (capacity >= 0),
+    -- where the event takes place; coordinates are WGS 84 degrees, set together or not at all
+    location_name    VARCHAR(100) NOT NULL DEFAULT '',
+    location_address VARCHAR(255) NOT NULL DEFAULT '',
+    latitude         DOUBLE PRECISION CHECK (latitude BETWEEN -90 AND 90),
+    longitude        DOUBLE PRECISION CHECK (longitude BETWEEN -180 AND 180),
+    -- full-text search for GET /events/search (see core.IndexedSearchLanguage)
+    search_vector TSVECTOR GENERATED ALWAYS AS (
+                      setweight(to_tsvector('english', title), 'A') ||
+                      setweight(to_tsvector('english', description), 'B')
+                      ) STORED,
+    CHECK (start_time <= end_time),
+    CHECK ((latitude IS NULL) = (longitude IS NULL)),
+    CONSTRAINT events_scope_overlap_excl EXCLUDE USING gist (scope WITH =, tstzrange(start_time, end_time) WITH &&)
+        WHERE (scope <> '' AND deleted_at IS NULL)
+);
+
+-- moved, edited or cancelled occurrences of recurring events
+CREATE TABLE IF NOT EXISTS event_overrides
+(
+    event_id      UUID         NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    recurrence_id TIMESTAMPTZ  NOT NULL,
+    title         VARCHAR(100) NOT NULL DEFAULT '',
+    description   TEXT         NOT NULL DEFAULT '',
+    start_time    TIMESTAMPTZ  NOT NULL,
+    end_time      TIMESTAMPTZ  NOT NULL,
+    cancelled     BOOLEAN      NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (event_id, recurrence_id),
+    CHECK (start_time <= end_time)
+);
+
+-- people invited to events and their RSVP
+CREATE TABLE IF NOT EXISTS attendees
+(
+    id           UUID PRIMARY KEY      DEFAULT uuid_generate_v4(),
+    event_id     UUID         NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    email        VARCHAR(254) NOT NULL,
+    name         VARCHAR(100) NOT NULL DEFAULT '',
+    status       VARCHAR(12)  NOT NULL DEFAULT 'needs-action'
+        CHECK (status IN ('needs-action', 'accepted', 'declined', 'tentative')),
+    -- last RSVP, NULL until the attendee answers
+    responded_at TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+-- sign-ups to events with a capacity; those beyond it wait in seq order for a confirmed registrant to cancel
+CREATE TABLE IF NOT EXISTS registrations
+(
+    id         UUID PRIMARY KEY      DEFAULT uuid_generate_v4(),
+    seq        BIGINT GENERATED ALWAYS AS IDENTITY,
+    event_id   UUID         NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    email      VARCHAR(254) NOT NULL,
+    name       VARCHAR(100) NOT NULL DEFAULT '',
+    status     VARCHAR(10)  NOT NULL CHECK (status IN ('confirmed', 'waitlisted')),
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+-- reminders of the occurrences of events, sent through their channel by the reminder dispatcher
+CREATE TABLE IF NOT EXISTS reminders
+(
+    id               UUID PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    event_id         UUID        NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    offset_minutes   INTEGER     NOT NULL CHECK (offset_minutes BETWEEN 0 AND 40320),
+    channel          VARCHAR(10) NOT NULL CHECK (channel IN ('webhook', 'email', 'log')),
+    -- start of the last occurrence reminded of, NULL until the first one
+    reminded_through TIMESTAMPTZ,
+    -- next time the dispatcher looks at the reminder, NULL once the event has no occurrence left
+    due_at           TIMESTAMPTZ          DEFAULT now(),
+    -- lease of the dispatcher sending the reminder, no other replica claims it meanwhile
+    claimed_until    TIMESTAMPTZ,
+    -- bumped along with due_at when the schedule of the event changes (see reschedule_reminders)
+    revision         INTEGER     NOT NULL DEFAULT 1,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- subscriptions to the changes of events, posted to url by the webhook dispatcher
+CREATE TABLE IF NOT EXISTS webhooks
+(
+    id          UUID PRIMARY KEY       DEFAULT uuid_generate_v4(),
+    url         VARCHAR(2048) NOT NULL,
+    -- event.created, event.updated or event.deleted; empty for all of them
+    event_types TEXT[]        NOT NULL DEFAULT '{}',
+    -- HMAC-SHA256 key of the X-Webhook-Signature header
+    secret      TEXT          NOT NULL,
+    created_at  TIMESTAMPTZ   NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ   NOT NULL DEFAULT now()
+);
+
+-- changes of events written along with them, published by the outbox dispatcher; trace_context is the W3C trace
+-- context of the request that made the change
+CREATE TABLE IF NOT EXISTS outbox
+(
+    id              BIGSERIAL PRIMARY KEY,
+    type            VARCHAR(50) NOT NULL,
+    aggregate_id    UUID        NOT NULL,
+    payload         JSONB       NOT NULL,
+    trace_context   JSONB       NOT NULL DEFAULT '{}',
+    -- failed publications, last_error is the error of the latest one
+    attempts        INTEGER     NOT NULL DEFAULT 0,
+    last_error      TEXT        NOT NULL DEFAULT '',
+    -- next time the dispatcher publishes the message, NULL once it is delivered or dead
+    next_attempt_at TIMESTAMPTZ          DEFAULT now(),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    delivered_at    TIMESTAMPTZ,
+    -- set once the message failed OutboxMaxAttempts times, it is no longer published
+    dead_at         TIMESTAMPTZ
+);
+
+-- changes of events sent, or to be sent, to each webhook; event_id has no foreign key as deleted events are delivered too
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id               UUID PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    webhook_id       UUID        NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    -- outbox message the delivery was queued from, it may be published more than once
+    outbox_id        BIGINT,
+    event_type       VARCHAR(20) NOT NULL,
+    event_id         UUID        NOT NULL,
+    payload          JSONB       NOT NULL,
+    -- W3C trace context of the request that changed the event
+    trace_context    JSONB       NOT NULL DEFAULT '{}',
+    status           VARCHAR(10) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'dead')),
+    attempts         INTEGER     NOT NULL DEFAULT 0,
+    -- NULL once the delivery succeeded or is dead
+    next_attempt_at  TIMESTAMPTZ          DEFAULT now(),
+    -- lease of the dispatcher sending the delivery, no other replica claims it meanwhile
+    claimed_until    TIMESTAMPTZ,
+    -- outcome of the last attempt, 0 when no response came back
+    last_status_code INTEGER     NOT NULL DEFAULT 0,
+    last_error       TEXT        NOT NULL DEFAULT '',
+    delivered_at     TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- labels of events, stored once in their normalized form (see core.NormalizeTags)
+CREATE TABLE IF NOT EXISTS tags
+(
+    id   UUID PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    name VARCHAR(50) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS event_tags
+(
+    event_id UUID NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    tag_id   UUID NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (event_id, tag_id)
+);
+
+-- tags of the event representation, by name
+CREATE OR REPLACE FUNCTION event_tag_names(event UUID) RETURNS TEXT[] AS
+$$
+SELECT array_agg(tags.name ORDER BY tags.name)
+FROM event_tags
+         JOIN tags ON tags.id = event_tags.tag_id
+WHERE event_tags.event_id = event
+$$ LANGUAGE sql STABLE;
+
+-- attendees counts of the event representation (see core.AttendeeCounts)
+CREATE OR REPLACE FUNCTION attendee_counts(event UUID) RETURNS JSONB AS
+$$
+SELECT jsonb_build_object(
+               'total', count(*),
+               'accepted', count(*) FILTER (WHERE status = 'accepted'),
+               'declined', count(*) FILTER (WHERE status = 'declined'),
+               'tentative', count(*) FILTER (WHERE status = 'tentative'),
+               'needs_action', count(*) FILTER (WHERE status = 'needs-action')
+           )
+FROM attendees
+WHERE event_id = event
+$$ LANGUAGE sql STABLE;
+
+-- makes the reminders of an event due, so the next dispatch computes their new schedule
+CREATE OR REPLACE FUNCTION reschedule_reminders() RETURNS TRIGGER AS
+$$
+DECLARE
+    event UUID;
+BEGIN
+    IF TG_TABLE_NAME = 'events' THEN
+        event := NEW.id;
+    ELSIF TG_OP = 'DELETE' THEN
+        event := OLD.event_id;
+    ELSE
+        event := NEW.event_id;
+    END IF;
+
+    UPDATE reminders SET due_at = now(), revision = revision + 1 WHERE event_id = event;
+
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS events_reschedule_reminders ON events;
+CREATE TRIGGER events_reschedule_reminders
+    AFTER UPDATE OF start_time, end_time, time_zone, all_day, rrule, exdates, rdates, deleted_at
+    ON events
+    FOR EACH ROW
+EXECUTE FUNCTION reschedule_reminders();
+
+DROP TRIGGER IF EXISTS event_overrides_reschedule_reminders ON event_overrides;
+CREATE TRIGGER event_overrides_reschedule_reminders
+    AFTER INSERT OR UPDATE OR DELETE
+    ON event_overrides
+    FOR EACH ROW
+EXECUTE FUNCTION reschedule_reminders();
+
+-- notifies the changes of events written to the outbox on event_changes, as they commit, for GET /events/stream and
+-- /ws; the event is read as changed, deleted events are still in the trash, so subscribers can match it to their topics
+CREATE OR REPLACE FUNCTION notify_event_change() RETURNS TRIGGER AS
+$$
+DECLARE
+    event events%ROWTYPE;
+BEGIN
+    SELECT * INTO event FROM events WHERE id = NEW.aggregate_id;
+
+    PERFORM pg_notify('event_changes', json_build_object(
+            'id', NEW.id,
+            'type', NEW.type,
+            'event_id', NEW.aggregate_id,
+            'title', event.title,
+            'start_time', event.start_time,
+            'series_end', event.series_end,
+            'created_at', NEW.created_at
+        )::text);
+
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS outbox_notify_event_change ON outbox;
+CREATE TRIGGER outbox_notify_event_change
+    AFTER INSERT
+    ON outbox
+    FOR EACH ROW
+EXECUTE FUNCTION notify_event_change();
+
+-- keyset pagination for GET /events and GET /events/trash
+CREATE INDEX IF NOT EXISTS events_start_time_id_idx ON events (start_time, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS events_trash_start_time_id_idx ON events (start_time, id) WHERE deleted_at IS NOT NULL;
+
+-- keyset pagination for GET /calendars/:id/events, and the cascade of calendar deletions
+CREATE INDEX IF NOT EXISTS events_calendar_id_start_time_id_idx ON events (calendar_id, start_time, id);
+
+-- retention purge of the trash
+CREATE INDEX IF NOT EXISTS events_deleted_at_idx ON events (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- title_prefix filtering for GET /events
+CREATE INDEX IF NOT EXISTS events_lower_title_idx ON events (lower(title) text_pattern_ops);
+
+-- overlaps/ends_before filtering for GET /events
+CREATE INDEX IF NOT EXISTS events_end_time_idx ON events (end_time);
+
+-- near/radius_km filtering for GET /events, by latitude band
+CREATE INDEX IF NOT EXISTS events_latitude_idx ON events (latitude) WHERE latitude IS NOT NULL;
+
+-- tags filtering for GET /events, from the tags to their events (the primary key goes the other way)
+CREATE INDEX IF NOT EXISTS event_tags_tag_id_event_id_idx ON event_tags (tag_id, event_id);
+
+-- full-text search for GET /events/search
+CREATE INDEX IF NOT EXISTS events_search_vector_idx ON events USING gin (search_vector);
+
+-- candidate series for GET /events/occurrences
+CREATE INDEX IF NOT EXISTS events_series_end_idx ON events (series_end) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS event_overrides_start_time_idx ON event_overrides (start_time, end_time) WHERE NOT cancelled;
+
+-- re-importing an iCalendar document skips the events it already created
+CREATE UNIQUE INDEX IF NOT EXISTS events_ical_uid_idx ON events (ical_uid) WHERE ical_uid <> '';
+
+-- an email is invited once per event, whatever its case; also serves attendee_counts
+CREATE UNIQUE INDEX IF NOT EXISTS attendees_event_id_email_idx ON attendees (event_id, lower(email));
+
+-- an email registers once per event, whatever its case
+CREATE UNIQUE INDEX IF NOT EXISTS registrations_event_id_email_idx ON registrations (event_id, lower(email));
+-- seat counts and waitlist order of an event
+CREATE INDEX IF NOT EXISTS registrations_event_id_status_seq_idx ON registrations (event_id, status, seq);
+
+-- a reminder is set once per event, offset and channel
+CREATE UNIQUE INDEX IF NOT EXISTS reminders_event_id_offset_minutes_channel_idx ON reminders (event_id, offset_minutes, channel);
+-- due reminders for the reminder dispatcher
+CREATE INDEX IF NOT EXISTS reminders_due_at_idx ON reminders (due_at) WHERE due_at IS NOT NULL;
+
+-- pending deliveries for the webhook dispatcher
+CREATE INDEX IF NOT EXISTS webhook_deliveries_next_attempt_at_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+-- delivery log of a webhook, latest first
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id_created_at_idx ON webhook_deliveries (webhook_id, created_at DESC);
+-- a message published again queues no delivery twice
+CREATE UNIQUE INDEX IF NOT EXISTS webhook_deliveries_webhook_id_outbox_id_idx ON webhook_deliveries (webhook_id, outbox_id);
+
+-- pending messages for the outbox dispatcher, in order; dead ones are left out
+CREATE INDEX IF NOT EXISTS outbox_pending_id_idx ON outbox (id) WHERE delivered_at IS NULL AND dead_at IS NULL;
+-- retention purge of the outbox
+CREATE INDEX IF NOT EXISTS outbox_delivered_at_idx ON outbox (delivered_at) WHERE delivered_at IS NOT NULL;
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"os"
 	"strconv"
 	"syscall"
 	"time"
@@ -14,12 +15,17 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 
+	eventv1 "ltk-code-challenge/api/event/v1"
 	"ltk-code-challenge/core"
 	"ltk-code-challenge/pkg/resources"
 	"ltk-code-challenge/pkg/servers"
@@ -72,7 +78,11 @@ func main() {
 	defer stopFn(ctx)
 
 	repo := core.NewRepository(pool)
-	handlers := core.NewHandlers(repo)
+	viper.SetDefault("STREAM_HEARTBEAT", 15*time.Second)
+
+	stream := core.NewChangeStream(repo, viper.GetDuration("STREAM_HEARTBEAT"))
+	handlers := core.NewHandlers(repo, stream)
+	graphQL := core.NewGraphQL(repo, stream)
 
 	app := lifecycle.NewApp(
 		lifecycle.WithName(name),
@@ -89,7 +99,55 @@ func main() {
 		router.Use(metricsMiddleware)
 
 		router.POST("/events", handlers.PostEvents)
+		router.POST("/events:action", handlers.PostEventsAction)
+		router.GET("/events", handlers.ListEvents)
+		router.GET("/events.ics", handlers.ExportEvents)
+		router.POST("/events/import", handlers.ImportEvents)
+		router.GET("/events/search", handlers.SearchEvents)
+		router.GET("/events/stream", handlers.StreamEvents)
+		router.GET("/events/trash", handlers.ListTrash)
+		router.GET("/events/occurrences", handlers.ListOccurrences)
 		router.GET("/events/:id", handlers.GetEvents)
+		router.PUT("/events/:id", handlers.PutEvents)
+		router.PATCH("/events/:id", handlers.PatchEvents)
+		router.DELETE("/events/:id", handlers.DeleteEvents)
+		router.POST("/events/:id/restore", handlers.RestoreEvents)
+		router.PUT("/events/:id/occurrences/:recurrence_id", handlers.PutOccurrences)
+		router.DELETE("/events/:id/occurrences/:recurrence_id", handlers.DeleteOccurrences)
+		router.POST("/events/:id/attendees", handlers.PostAttendees)
+		router.GET("/events/:id/attendees", handlers.ListAttendees)
+		router.PUT("/events/:id/attendees/:attendee_id/rsvp", handlers.PutRsvp)
+		router.DELETE("/events/:id/attendees/:attendee_id", handlers.DeleteAttendees)
+		router.POST("/events/:id/reminders", handlers.PostReminders)
+		router.GET("/events/:id/reminders", handlers.ListReminders)
+		router.DELETE("/events/:id/reminders/:reminder_id", handlers.DeleteReminders)
+		router.POST("/events/:id/registrations", handlers.PostRegistrations)
+		router.GET("/events/:id/registrations", handlers.ListRegistrations)
+		router.DELETE("/events/:id/registrations/:registration_id", handlers.DeleteRegistrations)
+		router.POST("/freebusy", handlers.PostFreeBusy)
+		router.GET("/tags", handlers.ListTags)
+		router.POST("/calendars", handlers.PostCalendars)
+		router.GET("/calendars", handlers.ListCalendars)
+		router.GET("/calendars/:id", handlers.GetCalendars)
+		router.PUT("/calendars/:id", handlers.PutCalendars)
+		router.DELETE("/calendars/:id", handlers.DeleteCalendars)
+		router.GET("/calendars/:id/events", handlers.ListCalendarEvents)
+		router.POST("/calendars/:id/events", handlers.PostCalendarEvents)
+		router.POST("/webhooks", handlers.PostWebhooks)
+		router.GET("/webhooks", handlers.ListWebhooks)
+		router.GET("/webhooks/:id", handlers.GetWebhooks)
+		router.PUT("/webhooks/:id", handlers.PutWebhooks)
+		router.DELETE("/webhooks/:id", handlers.DeleteWebhooks)
+		router.GET("/webhooks/:id/deliveries", handlers.ListDeliveries)
+		router.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", handlers.PostRedeliver)
+		router.GET("/ws", handlers.ServeWebSocket)
+		router.POST("/graphql", graphQL.Serve)
+		router.GET("/graphql", graphQL.Serve)
+
+		// GraphiQL is for development only, GIN_MODE=release leaves it out
+		if gin.IsDebugging() {
+			router.GET("/graphiql", graphQL.ServeGraphiQL)
+		}
 
 		httpServer := &http.Server{
 			Addr:              net.JoinHostPort("localhost", "8080"),
@@ -97,7 +155,118 @@ func main() {
 			ReadHeaderTimeout: 60000,
 		}
 
+		// Shutting down waits for the connections to be idle, the streams must end first
+		httpServer.RegisterOnShutdown(stream.Close)
+
 		app.Attach(servers.BuildHttpServer(httpServer))
+		app.Attach("change-stream", stream)
+	}
+	{
+		viper.SetDefault("GRPC_PORT", "9090")
+
+		grpcServer := grpc.NewServer(grpc.StatsHandler(otelgrpc.NewServerHandler()))
+		eventv1.RegisterEventServiceServer(grpcServer, core.NewEventServer(repo, stream))
+
+		// Health checking and reflection, for load balancers and tools such as grpcurl
+		healthServer := health.NewServer()
+		healthServer.SetServingStatus(eventv1.EventService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+		healthpb.RegisterHealthServer(grpcServer, healthServer)
+		reflection.Register(grpcServer)
+
+		app.Attach(servers.BuildGrpcServer(grpcServer, net.JoinHostPort("localhost", viper.GetString("GRPC_PORT"))))
+	}
+	{
+		viper.SetDefault("TRASH_RETENTION", 30*24*time.Hour)
+		viper.SetDefault("TRASH_PURGE_INTERVAL", time.Hour)
+		viper.SetDefault("TRASH_PURGE_TIMEOUT", 10*time.Minute)
+
+		purger := core.NewTrashPurger(repo, viper.GetDuration("TRASH_RETENTION"))
+
+		// Replicas share the database, the advisory lock of each job makes a single one of them run it
+		scheduler := servers.NewScheduler(repo)
+
+		err = scheduler.Register(servers.CronJob{
+			Name:     "trash-purge",
+			Schedule: "@every " + viper.GetDuration("TRASH_PURGE_INTERVAL").String(),
+			Timeout:  viper.GetDuration("TRASH_PURGE_TIMEOUT"),
+			Run: func(ctx context.Context) error {
+				_, err := purger.Purge(ctx)
+				return err
+			},
+		})
+		if err != nil {
+			log.Fatal().Msg(fmt.Sprintf("Unable to schedule trash purge: %v", err))
+		}
+
+		viper.SetDefault("REMINDER_DISPATCH_INTERVAL", 30*time.Second)
+		viper.SetDefault("REMINDER_WEBHOOK_TIMEOUT", 10*time.Second)
+
+		// Reminders on a channel left unconfigured fail and are retried, the log channel always works
+		notifiers := map[string]core.Notifier{core.ChannelLog: core.NewLogNotifier(os.Stdout)}
+
+		if url := viper.GetString("REMINDER_WEBHOOK_URL"); url != "" {
+			notifiers[core.ChannelWebhook] = core.NewWebhookNotifier(url, viper.GetDuration("REMINDER_WEBHOOK_TIMEOUT"))
+		}
+
+		if addr := viper.GetString("SMTP_ADDR"); addr != "" {
+			notifiers[core.ChannelEmail] = core.NewEmailNotifier(addr, viper.GetString("SMTP_FROM"), viper.GetString("SMTP_USERNAME"), viper.GetString("SMTP_PASSWORD"))
+		}
+
+		dispatcher := core.NewReminderDispatcher(repo, notifiers)
+
+		err = scheduler.Register(servers.CronJob{
+			Name:     "reminder-dispatch",
+			Schedule: "@every " + viper.GetDuration("REMINDER_DISPATCH_INTERVAL").String(),
+			Timeout:  core.ReminderLease,
+			Run: func(ctx context.Context) error {
+				_, err := dispatcher.Dispatch(ctx)
+				return err
+			},
+		})
+		if err != nil {
+			log.Fatal().Msg(fmt.Sprintf("Unable to schedule reminders: %v", err))
+		}
+
+		viper.SetDefault("WEBHOOK_DISPATCH_INTERVAL", 10*time.Second)
+		viper.SetDefault("WEBHOOK_TIMEOUT", 10*time.Second)
+
+		webhooks := core.NewWebhookDispatcher(repo, viper.GetDuration("WEBHOOK_TIMEOUT"))
+
+		err = scheduler.Register(servers.CronJob{
+			Name:     "webhook-delivery",
+			Schedule: "@every " + viper.GetDuration("WEBHOOK_DISPATCH_INTERVAL").String(),
+			Timeout:  core.WebhookLease,
+			Run: func(ctx context.Context) error {
+				_, err := webhooks.Dispatch(ctx)
+				return err
+			},
+		})
+		if err != nil {
+			log.Fatal().Msg(fmt.Sprintf("Unable to schedule webhook deliveries: %v", err))
+		}
+
+		viper.SetDefault("OUTBOX_POLL_INTERVAL", time.Second)
+		viper.SetDefault("OUTBOX_RETENTION", 7*24*time.Hour)
+		viper.SetDefault("OUTBOX_PURGE_TIMEOUT", 10*time.Minute)
+
+		// The changes of events reach the webhooks through the outbox, written along with them
+		outbox := core.NewOutboxDispatcher(repo, core.NewWebhookPublisher(repo), viper.GetDuration("OUTBOX_POLL_INTERVAL"))
+
+		err = scheduler.Register(servers.CronJob{
+			Name:     "outbox-purge",
+			Schedule: "@hourly",
+			Timeout:  viper.GetDuration("OUTBOX_PURGE_TIMEOUT"),
+			Run: func(ctx context.Context) error {
+				_, err := outbox.Purge(ctx, viper.GetDuration("OUTBOX_RETENTION"))
+				return err
+			},
+		})
+		if err != nil {
+			log.Fatal().Msg(fmt.Sprintf("Unable to schedule outbox purge: %v", err))
+		}
+
+		app.Attach(servers.BuildCronServer(scheduler))
+		app.Attach("outbox-dispatcher", outbox)
 	}
 
 	if app.Run() != nil {
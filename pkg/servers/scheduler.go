@@ -0,0 +1,218 @@
+package servers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	JobSucceeded   = "succeeded"
+	JobFailed      = "failed"
+	JobOverlapping = "overlapping"
+	JobLocked      = "locked"
+)
+
+// CronJob is a named job run on a cron schedule.
+type CronJob struct {
+	Name string
+	// Schedule is a standard five-field cron expression or a descriptor such as @hourly or @every 15m.
+	Schedule string
+	// Timeout bounds each run, zero means no limit.
+	Timeout time.Duration
+	Run     func(ctx context.Context) error
+}
+
+// JobLocker makes sure a job runs on a single replica at a time. TryLock reports whether the lock of the named job
+// was taken, in which case unlock releases it.
+type JobLocker interface {
+	TryLock(ctx context.Context, name string) (unlock func(), acquired bool, err error)
+}
+
+// Scheduler runs registered jobs on their schedule. A run is skipped while the previous one of the same job is still
+// going on, or while another replica holds the lock of the job.
+type Scheduler struct {
+	name    string
+	cron    *cron.Cron
+	locker  JobLocker
+	tracer  trace.Tracer
+	metrics *CronMetrics
+	ctx     context.Context //nolint:containedctx
+	cancel  context.CancelFunc
+	mu      sync.Mutex
+	jobs    map[string]struct{}
+}
+
+// NewScheduler builds a scheduler taking job locks from locker, a nil locker runs every job on every replica.
+func NewScheduler(locker JobLocker) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Scheduler{
+		name:    "cron-server",
+		cron:    cron.New(),
+		locker:  locker,
+		tracer:  otel.GetTracerProvider().Tracer("ltk-code-challenge/cron"),
+		metrics: NewCronMetrics(),
+		ctx:     ctx,
+		cancel:  cancel,
+		jobs:    make(map[string]struct{}),
+	}
+}
+
+func (s *Scheduler) Register(job CronJob) error {
+	if job.Name == "" {
+		return errors.New("job name is required")
+	}
+
+	if job.Run == nil {
+		return fmt.Errorf("job %s has nothing to run", job.Name)
+	}
+
+	if job.Timeout < 0 {
+		return fmt.Errorf("job %s timeout must not be negative", job.Name)
+	}
+
+	schedule, err := cron.ParseStandard(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("invalid schedule for job %s: %w", job.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.jobs[job.Name]; ok {
+		return fmt.Errorf("job %s is already registered", job.Name)
+	}
+
+	s.jobs[job.Name] = struct{}{}
+
+	var running atomic.Bool
+
+	s.cron.Schedule(schedule, cron.FuncJob(func() { s.run(job, &running) }))
+
+	return nil
+}
+
+func (s *Scheduler) Start() {
+	s.cron.Start()
+}
+
+// Stop stops scheduling jobs, cancels the context of the running ones and waits for them to return.
+func (s *Scheduler) Stop() {
+	done := s.cron.Stop()
+	s.cancel()
+	<-done.Done()
+}
+
+func (s *Scheduler) run(job CronJob, running *atomic.Bool) {
+	start := time.Now()
+
+	ctx, span := s.tracer.Start(s.ctx, "cron."+job.Name,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attribute.String("cron.job", job.Name)),
+	)
+	defer span.End()
+
+	outcome, err := s.execute(ctx, job, running)
+
+	span.SetAttributes(attribute.String("cron.outcome", outcome))
+	s.metrics.Observe(ctx, job.Name, outcome, start)
+
+	logger := log.With().Str("component", s.name).Str("job", job.Name).Str("outcome", outcome).Logger()
+
+	switch outcome {
+	case JobFailed:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		logger.Error().Err(err).Dur("duration", time.Since(start)).Msg("job failed")
+	case JobSucceeded:
+		logger.Info().Dur("duration", time.Since(start)).Msg("job succeeded")
+	default:
+		logger.Info().Msg("job skipped")
+	}
+}
+
+func (s *Scheduler) execute(ctx context.Context, job CronJob, running *atomic.Bool) (string, error) {
+	// The previous run is still going on
+	if !running.CompareAndSwap(false, true) {
+		return JobOverlapping, nil
+	}
+	defer running.Store(false)
+
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+
+	// Another replica is running the job
+	if s.locker != nil {
+		unlock, acquired, err := s.locker.TryLock(ctx, job.Name)
+		if err != nil {
+			return JobFailed, fmt.Errorf("failed to lock job: %w", err)
+		}
+
+		if !acquired {
+			return JobLocked, nil
+		}
+
+		defer unlock()
+	}
+
+	err := job.Run(ctx)
+	if err != nil {
+		return JobFailed, err
+	}
+
+	return JobSucceeded, nil
+}
+
+/*
+
+ */
+
+type CronMetrics struct {
+	runs    metric.Int64Counter
+	latency metric.Float64Histogram
+}
+
+func NewCronMetrics() *CronMetrics {
+	meter := otel.Meter("ltk-code-challenge/cron")
+
+	runs, _ := meter.Int64Counter(
+		"cron.job.runs",
+		metric.WithDescription("Scheduled job runs"),
+	)
+	latency, _ := meter.Float64Histogram(
+		"cron.job.duration.ms",
+		metric.WithDescription("Scheduled job run duration in milliseconds"),
+	)
+
+	return &CronMetrics{runs: runs, latency: latency}
+}
+
+func (m *CronMetrics) Observe(ctx context.Context, job string, outcome string, start time.Time) {
+	attrs := []attribute.KeyValue{
+		attribute.String("cron.job", job),
+		attribute.String("cron.outcome", outcome),
+	}
+
+	m.runs.Add(ctx, 1, metric.WithAttributes(attrs...))
+
+	// Skipped runs did no work worth timing
+	if outcome == JobSucceeded || outcome == JobFailed {
+		m.latency.Record(ctx, float64(time.Since(start).Milliseconds()), metric.WithAttributes(attrs...))
+	}
+}
@@ -0,0 +1,186 @@
+package servers
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubLocker hands out the lock of every job unless held is set, and counts the unlocks.
+type stubLocker struct {
+	held     bool
+	err      error
+	unlocked atomic.Int32
+}
+
+func (l *stubLocker) TryLock(_ context.Context, _ string) (func(), bool, error) {
+	if l.err != nil || l.held {
+		return nil, false, l.err
+	}
+
+	return func() { l.unlocked.Add(1) }, true, nil
+}
+
+func noop(context.Context) error { return nil }
+
+func TestScheduler_Register(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		job    CronJob
+		errMsg string
+	}{
+		{name: "valid", job: CronJob{Name: "purge", Schedule: "@every 1m", Timeout: time.Minute, Run: noop}},
+		{name: "cron expression", job: CronJob{Name: "purge", Schedule: "*/5 * * * *", Run: noop}},
+		{name: "missing name", job: CronJob{Schedule: "@hourly", Run: noop}, errMsg: "job name is required"},
+		{name: "nothing to run", job: CronJob{Name: "purge", Schedule: "@hourly"}, errMsg: "job purge has nothing to run"},
+		{name: "negative timeout", job: CronJob{Name: "purge", Schedule: "@hourly", Timeout: -time.Second, Run: noop}, errMsg: "timeout must not be negative"},
+		{name: "invalid schedule", job: CronJob{Name: "purge", Schedule: "every hour", Run: noop}, errMsg: "invalid schedule for job purge"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := NewScheduler(nil).Register(tt.job)
+			if tt.errMsg != "" {
+				require.ErrorContains(t, err, tt.errMsg)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("duplicate name", func(t *testing.T) {
+		t.Parallel()
+
+		scheduler := NewScheduler(nil)
+		require.NoError(t, scheduler.Register(CronJob{Name: "purge", Schedule: "@hourly", Run: noop}))
+		require.ErrorContains(t, scheduler.Register(CronJob{Name: "purge", Schedule: "@daily", Run: noop}), "job purge is already registered")
+	})
+}
+
+func TestScheduler_execute(t *testing.T) {
+	t.Parallel()
+
+	t.Run("succeeded", func(t *testing.T) {
+		t.Parallel()
+
+		locker := &stubLocker{}
+
+		var running atomic.Bool
+
+		outcome, err := NewScheduler(locker).execute(context.Background(), CronJob{Name: "purge", Run: noop}, &running)
+		require.NoError(t, err)
+		assert.Equal(t, JobSucceeded, outcome)
+		assert.Equal(t, int32(1), locker.unlocked.Load())
+		assert.False(t, running.Load())
+	})
+
+	t.Run("overlapping", func(t *testing.T) {
+		t.Parallel()
+
+		var running atomic.Bool
+		running.Store(true)
+
+		outcome, err := NewScheduler(nil).execute(context.Background(), CronJob{Name: "purge", Run: func(context.Context) error {
+			t.Error("overlapping run")
+			return nil
+		}}, &running)
+		require.NoError(t, err)
+		assert.Equal(t, JobOverlapping, outcome)
+		assert.True(t, running.Load())
+	})
+
+	t.Run("locked by another replica", func(t *testing.T) {
+		t.Parallel()
+
+		var running atomic.Bool
+
+		outcome, err := NewScheduler(&stubLocker{held: true}).execute(context.Background(), CronJob{Name: "purge", Run: func(context.Context) error {
+			t.Error("locked run")
+			return nil
+		}}, &running)
+		require.NoError(t, err)
+		assert.Equal(t, JobLocked, outcome)
+		assert.False(t, running.Load())
+	})
+
+	t.Run("lock failure", func(t *testing.T) {
+		t.Parallel()
+
+		var running atomic.Bool
+
+		outcome, err := NewScheduler(&stubLocker{err: errors.New("db error")}).execute(context.Background(), CronJob{Name: "purge", Run: noop}, &running)
+		require.ErrorContains(t, err, "failed to lock job: db error")
+		assert.Equal(t, JobFailed, outcome)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		t.Parallel()
+
+		locker := &stubLocker{}
+
+		var running atomic.Bool
+
+		outcome, err := NewScheduler(locker).execute(context.Background(), CronJob{Name: "purge", Run: func(context.Context) error {
+			return errors.New("purge error")
+		}}, &running)
+		require.EqualError(t, err, "purge error")
+		assert.Equal(t, JobFailed, outcome)
+		assert.Equal(t, int32(1), locker.unlocked.Load())
+		assert.False(t, running.Load())
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		t.Parallel()
+
+		var running atomic.Bool
+
+		job := CronJob{Name: "purge", Timeout: 10 * time.Millisecond, Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}}
+
+		outcome, err := NewScheduler(nil).execute(context.Background(), job, &running)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, JobFailed, outcome)
+	})
+}
+
+func TestScheduler_Stop(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+
+	var finished atomic.Bool
+
+	scheduler := NewScheduler(nil)
+	require.NoError(t, scheduler.Register(CronJob{Name: "purge", Schedule: "@every 1s", Run: func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+
+		// Work left after the cancellation is waited for
+		time.Sleep(10 * time.Millisecond)
+		finished.Store(true)
+
+		return ctx.Err()
+	}}))
+
+	scheduler.Start()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job not run")
+	}
+
+	scheduler.Stop()
+	assert.True(t, finished.Load())
+}
@@ -0,0 +1,61 @@
+package servers
+
+import (
+	"context"
+
+	"github.com/qmdx00/lifecycle"
+	"github.com/rs/zerolog/log"
+)
+
+type cronServer struct {
+	name     string
+	internal CronServer
+	stop     chan struct{}
+}
+
+func BuildCronServer(server CronServer) (string, Server) {
+	return "cron-server", NewCronServer(server)
+}
+
+func NewCronServer(server CronServer) lifecycle.Server {
+	return &cronServer{
+		name:     "cron-server",
+		internal: server,
+		stop:     make(chan struct{}),
+	}
+}
+
+func (server *cronServer) Run(ctx context.Context) error {
+	log.Info().Str("stage", "startup").Str("component", server.name).Msg("starting up")
+
+	server.internal.Start()
+
+	// The scheduler runs in the background, block like the other servers until it is stopped
+	select {
+	case <-ctx.Done():
+	case <-server.stop:
+	}
+
+	return nil
+}
+
+func (server *cronServer) Stop(ctx context.Context) error {
+	log.Info().Str("stage", "shut down").Str("component", server.name).Msg("stopping")
+	defer log.Info().Str("stage", "shut down").Str("component", server.name).Msg("stopped")
+
+	stopped := make(chan struct{})
+
+	go func() {
+		server.internal.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		close(server.stop)
+		return nil
+	case <-ctx.Done():
+		log.Error().Str("stage", "shut down").Str("component", server.name).Err(ctx.Err()).Msg("failed to stop")
+		return ErrServerFailedToStop(server.name, ctx.Err())
+	}
+}
@@ -0,0 +1,71 @@
+package servers
+
+import (
+	"context"
+	"net"
+
+	"github.com/qmdx00/lifecycle"
+	"github.com/rs/zerolog/log"
+)
+
+type grpcServer struct {
+	name     string
+	addr     string
+	internal GrpcServer
+}
+
+func BuildGrpcServer(server GrpcServer, addr string) (string, Server) {
+	return "grpc-server", NewGrpcServer(server, addr)
+}
+
+func NewGrpcServer(server GrpcServer, addr string) lifecycle.Server {
+	return &grpcServer{
+		name:     "grpc-server",
+		addr:     addr,
+		internal: server,
+	}
+}
+
+func (server *grpcServer) Run(ctx context.Context) error {
+	log.Info().Str("stage", "startup").Str("component", server.name).Msg("starting up")
+
+	var lc net.ListenConfig
+
+	lis, err := lc.Listen(ctx, "tcp", server.addr)
+	if err != nil {
+		log.Error().Str("stage", "startup").Str("component", server.name).Err(err).Msg("failed to listen")
+		return ErrServerFailedToStart(server.name, err)
+	}
+
+	// Serve returns nil once stopped
+	err = server.internal.Serve(lis)
+	if err != nil {
+		log.Error().Str("stage", "startup").Str("component", server.name).Err(err).Msg("failed to serve")
+		return ErrServerFailedToStart(server.name, err)
+	}
+
+	return nil
+}
+
+// Stop waits for the pending RPCs to finish, and cancels those still going on once ctx is done.
+func (server *grpcServer) Stop(ctx context.Context) error {
+	log.Info().Str("stage", "shut down").Str("component", server.name).Msg("stopping")
+	defer log.Info().Str("stage", "shut down").Str("component", server.name).Msg("stopped")
+
+	stopped := make(chan struct{})
+
+	go func() {
+		server.internal.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		server.internal.Stop()
+		log.Error().Str("stage", "shut down").Str("component", server.name).Err(ctx.Err()).Msg("failed to stop")
+
+		return ErrServerFailedToStop(server.name, ctx.Err())
+	}
+}
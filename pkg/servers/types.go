@@ -5,10 +5,16 @@ import (
 	"net/http"
 
 	"github.com/qmdx00/lifecycle"
+	"google.golang.org/grpc"
 )
 
 var (
 	_ Server = (*httpServer)(nil)
+	_ Server = (*cronServer)(nil)
+	_ Server = (*grpcServer)(nil)
+
+	_ CronServer = (*Scheduler)(nil)
+	_ GrpcServer = (*grpc.Server)(nil)
 )
 
 type Server interface {
@@ -23,6 +29,7 @@ type CronServer interface {
 type GrpcServer interface {
 	Serve(lis net.Listener) error
 	GracefulStop()
+	Stop()
 }
 
 //
@@ -44,6 +51,12 @@ type Application interface {
 
 var (
 	_ BuildHttpServerFn = BuildHttpServer
+	_ BuildCronServerFn = BuildCronServer
+	_ BuildGrpcServerFn = BuildGrpcServer
 )
 
 type BuildHttpServerFn func(server *http.Server) (string, Server)
+
+type BuildCronServerFn func(server CronServer) (string, Server)
+
+type BuildGrpcServerFn func(server GrpcServer, addr string) (string, Server)